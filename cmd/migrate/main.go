@@ -0,0 +1,122 @@
+// Command migrate menjalankan migrasi skema database yang di-embed di package migrations.
+// Konfigurasi database dibaca sama seperti server (file, environment, flag).
+package main
+
+import (
+	"backend/config"
+	"backend/internal/migrate"
+	"backend/internal/migrate/repository"
+	"backend/internal/migrate/usecase"
+	"backend/migrations"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+const commands = `Commands:
+  up        Apply all pending migrations
+  down [n]  Revert the last n applied migrations (default 1)
+  redo      Revert and re-apply the last applied migration
+  status    List migrations and whether they are applied
+`
+
+func main() {
+	cfg, args, err := config.Parse(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(os.Stderr, commands)
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if err := cfg.Database.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <command>\n\n%s", os.Args[0], commands)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := config.ConnectDB(ctx, cfg.Database); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer config.DB.Close()
+
+	list, err := migrate.Load(migrations.FS)
+	if err != nil {
+		log.Fatalf("Invalid migrations: %v", err)
+	}
+	migrator := usecase.NewMigrator(repository.NewMigrationRepository(config.DB), list)
+
+	if err := run(ctx, migrator, args); err != nil {
+		config.DB.Close()
+		log.Fatalf("Migration failed: %v", err)
+	}
+}
+
+func run(ctx context.Context, migrator usecase.Migrator, args []string) error {
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied %s\n", m)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("Reverted %s\n", m)
+		}
+		return err
+
+	case "redo":
+		m, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Redone %s\n", m)
+		return nil
+
+	case "status":
+		list, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+		for _, s := range list {
+			appliedAt := ""
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Local().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, s.State, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], commands)
+	}
+}
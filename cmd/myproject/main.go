@@ -2,22 +2,183 @@ package main
 
 import (
 	"backend/config"
+	"backend/internal/migrate"
+	migrateRepository "backend/internal/migrate/repository"
+	migrateUsecase "backend/internal/migrate/usecase"
+	"backend/internal/realtime"
+	"backend/migrations"
+	"backend/pkg/logger"
+	"backend/pkg/server"
+	"backend/pkg/utils"
 	"backend/routes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Database zona waktu untuk jam kerja dan SLA jika image tidak menyediakannya
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	// Koneksi ke database
-	config.ConnectDB()
+	// ctx dibatalkan saat proses menerima SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Muat konfigurasi dari file, environment dan flag; semua kesalahan dilaporkan sekaligus
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	// Semua log, termasuk dari package log milik library, ditulis melalui logger ini
+	slog.SetDefault(logger.New(cfg.Log, os.Stderr))
+	slog.Info("Configuration loaded", "config", cfg.String())
+	utils.SetSecretKey([]byte(cfg.Auth.JWTSecret))
+
+	// Koneksi ke database; ditunggu sampai database siap atau connect timeout habis
+	if err := config.ConnectDB(ctx, cfg.Database); err != nil {
+		fatal("Failed to connect to database", "error", err)
+	}
 	defer config.DB.Close()
 
-	// Setup router Gin
-	router := gin.Default()
+	// Migrasi skema harus selesai sebelum route mendaftarkan jadwal job ke database
+	migrateDatabase(ctx, cfg.Database.AutoMigrate)
+
+	// Worker dan listener hub memakai context sendiri karena baru dihentikan setelah HTTP selesai
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	// Hub real-time menerima event dari semua replika melalui Postgres LISTEN/NOTIFY
+	hub := realtime.NewHub(config.DB, cfg.Database.ConnectionString())
+	go func() {
+		if err := hub.Run(workerCtx); err != nil {
+			slog.Error("Realtime hub stopped", "error", err)
+		}
+	}()
+
+	// Setup router Gin; access log dan recovery dipasang SetupRoutes memakai logger aplikasi
+	router := gin.New()
 
 	// Setup Routes
-	routes.SetupRoutes(router, config.DB)
+	workers := routes.SetupRoutes(router, config.DB, hub, cfg)
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func(worker routes.Worker) {
+			defer wg.Done()
+			worker(workerCtx)
+		}(worker)
+	}
+
+	// Jalankan server; stream real-time ditutup begitu shutdown dimulai agar tidak menahan Shutdown
+	srv, err := server.New(cfg.Server, router)
+	if err != nil {
+		fatal("Failed to configure HTTP server", "error", err)
+	}
+	srv.RegisterOnShutdown(hub.CloseStreams)
+	serverErr := make(chan error, 2)
+	go func() {
+		serverErr <- server.ListenAndServe(srv)
+	}()
+	slog.Info("Listening", "addr", cfg.Server.Addr, "tls", srv.TLSConfig != nil)
+
+	// Metrik Prometheus dilayani listener internal terpisah, bukan router publik
+	var metricsSrv *http.Server
+	if cfg.Metrics.Addr != "" {
+		metricsSrv = server.NewMetrics(cfg.Metrics.Addr)
+		go func() {
+			if err := server.ListenAndServe(metricsSrv); err != nil {
+				serverErr <- fmt.Errorf("metrics listener: %w", err)
+			}
+		}()
+		slog.Info("Serving metrics", "addr", cfg.Metrics.Addr)
+	}
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+	case err := <-serverErr:
+		slog.Error("Server stopped", "error", err)
+		exitCode = 1
+	}
+	// Sinyal kedua menghentikan proses langsung
+	stop()
+
+	// Berhenti berurutan dalam batas shutdown timeout: request HTTP, stream WebSocket, lalu
+	// worker latar belakang (termasuk job yang sedang berjalan). Job yang terpotong deadline
+	// diantrekan ulang oleh replika lain setelah lock-nya kedaluwarsa.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
+	defer cancel()
+
+	slog.Info("Shutting down, draining HTTP requests")
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("HTTP requests still in flight at shutdown deadline", "error", err)
+	}
+	if metricsSrv != nil {
+		metricsSrv.Shutdown(shutdownCtx)
+	}
+	hub.CloseStreams()
+	if err := hub.WaitStreams(shutdownCtx); err != nil {
+		slog.Warn("Realtime streams still open at shutdown deadline", "error", err)
+	}
+
+	slog.Info("Waiting for background workers")
+	stopWorkers()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		slog.Info("Shutdown complete")
+	case <-shutdownCtx.Done():
+		slog.Warn("Background workers still running at shutdown deadline")
+		exitCode = 1
+	}
+
+	if exitCode != 0 {
+		config.DB.Close()
+		os.Exit(exitCode)
+	}
+}
+
+// migrateDatabase menerapkan migrasi tertunda jika auto migrate aktif. Replika lain yang start
+// bersamaan menunggu advisory lock, lalu tidak menemukan migrasi tertunda. Tanpa auto migrate
+// hanya diberi peringatan, karena server mungkin berjalan dengan skema lama.
+func migrateDatabase(ctx context.Context, autoMigrate bool) {
+	list, err := migrate.Load(migrations.FS)
+	if err != nil {
+		fatal("Invalid migrations", "error", err)
+	}
+	migrator := migrateUsecase.NewMigrator(migrateRepository.NewMigrationRepository(config.DB), list)
+
+	if autoMigrate {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			fatal("Database migration failed", "error", err)
+		}
+		for _, m := range applied {
+			slog.Info("Applied migration", "migration", m.String())
+		}
+		return
+	}
+
+	if err := migrator.Check(ctx); errors.Is(err, migrateUsecase.ErrPending) || errors.Is(err, migrateUsecase.ErrChecksumMismatch) {
+		slog.Warn("Database schema is not up to date; run the migrate command or start with -migrate", "error", err)
+	} else if err != nil {
+		slog.Error("Failed to check database migrations", "error", err)
+	}
+}
 
-	// Jalankan server
-	router.Run(":8080")
+// fatal mencatat kesalahan startup lalu menghentikan proses
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
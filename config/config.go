@@ -0,0 +1,443 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config adalah konfigurasi aplikasi. Nilai dimuat berurutan dari default, file konfigurasi
+// (YAML atau TOML), variabel environment lalu flag command line; sumber yang belakangan menimpa
+// yang sebelumnya. Tag env dan flag menentukan nama variabel dan flag untuk setiap field, dan
+// field bertag secret disamarkan saat konfigurasi dicetak.
+type Config struct {
+	Server        ServerConfig   `yaml:"server" toml:"server"`
+	Database      DatabaseConfig `yaml:"database" toml:"database"`
+	Auth          AuthConfig     `yaml:"auth" toml:"auth"`
+	PublicBaseURL string         `yaml:"public_base_url" toml:"public_base_url" env:"PUBLIC_BASE_URL" flag:"public-base-url" usage:"Public URL of this server, used in links and provider callbacks"`
+	Storage       StorageConfig  `yaml:"storage" toml:"storage"`
+	Signing       SigningConfig  `yaml:"signing" toml:"signing"`
+	Twilio        TwilioConfig   `yaml:"twilio" toml:"twilio"`
+	Log           LogConfig      `yaml:"log" toml:"log"`
+	Metrics       MetricsConfig  `yaml:"metrics" toml:"metrics"`
+}
+
+type ServerConfig struct {
+	Addr              string   `yaml:"addr" toml:"addr" env:"SERVER_ADDR" flag:"addr" usage:"HTTP listen address"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" flag:"read-header-timeout" usage:"Maximum time to read request headers"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT" flag:"read-timeout" usage:"Maximum time to read a whole request including the body"`
+	// WriteTimeout tidak membatasi stream real-time karena stream memperpanjang deadline-nya sendiri
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" flag:"write-timeout" usage:"Maximum time to write a response"`
+	IdleTimeout  Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" flag:"idle-timeout" usage:"How long to keep idle keep-alive connections open"`
+	// RequestTimeout adalah deadline context request; query yang melewatinya dibatalkan
+	RequestTimeout Duration `yaml:"request_timeout" toml:"request_timeout" env:"SERVER_REQUEST_TIMEOUT" flag:"request-timeout" usage:"Deadline for handling a request (0 to disable); streams and file transfers are exempt"`
+	// ShutdownTimeout membatasi total waktu menunggu request, stream dan worker saat berhenti
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"Deadline for draining requests and workers on shutdown"`
+	// TLS aktif jika file sertifikat dan kunci diisi; file dibaca ulang otomatis saat berubah
+	TLSCertFile string `yaml:"tls_cert_file" toml:"tls_cert_file" env:"SERVER_TLS_CERT_FILE" flag:"tls-cert" usage:"TLS certificate file (PEM); enables HTTPS"`
+	TLSKeyFile  string `yaml:"tls_key_file" toml:"tls_key_file" env:"SERVER_TLS_KEY_FILE" flag:"tls-key" usage:"TLS private key file (PEM)"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST" flag:"db-host" usage:"PostgreSQL host"`
+	Port     int    `yaml:"port" toml:"port" env:"DB_PORT" flag:"db-port" usage:"PostgreSQL port"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER" flag:"db-user" usage:"PostgreSQL user"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME" flag:"db-name" usage:"PostgreSQL database name"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE" flag:"db-sslmode" usage:"PostgreSQL sslmode"`
+	// AutoMigrate menerapkan migrasi yang tertunda saat server start
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate" env:"DB_AUTO_MIGRATE" flag:"migrate" usage:"Apply pending database migrations on startup"`
+	// Pengaturan pool koneksi; 0 pada MaxOpenConns berarti tanpa batas
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" flag:"db-max-open-conns" usage:"Maximum open database connections (0 for unlimited)"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" flag:"db-max-idle-conns" usage:"Maximum idle database connections"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" flag:"db-conn-max-lifetime" usage:"Maximum lifetime of a database connection (0 to keep forever)"`
+	ConnMaxIdleTime Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" flag:"db-conn-max-idle-time" usage:"Close database connections idle for longer than this (0 to keep)"`
+	// ConnectTimeout adalah batas waktu menunggu database siap saat start sebelum menyerah
+	ConnectTimeout Duration `yaml:"connect_timeout" toml:"connect_timeout" env:"DB_CONNECT_TIMEOUT" flag:"db-connect-timeout" usage:"How long to retry connecting to the database on startup"`
+}
+
+type AuthConfig struct {
+	JWTSecret string `yaml:"jwt_secret" toml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+}
+
+type StorageConfig struct {
+	Driver   string   `yaml:"driver" toml:"driver" env:"STORAGE_DRIVER" flag:"storage-driver" usage:"Attachment storage driver (local or s3)"`
+	LocalDir string   `yaml:"local_dir" toml:"local_dir" env:"STORAGE_LOCAL_DIR" flag:"storage-local-dir" usage:"Directory for the local storage driver"`
+	S3       S3Config `yaml:"s3" toml:"s3"`
+}
+
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint" toml:"endpoint" env:"S3_ENDPOINT" flag:"s3-endpoint" usage:"S3 endpoint URL, empty for AWS"`
+	Region          string `yaml:"region" toml:"region" env:"S3_REGION" flag:"s3-region" usage:"S3 region"`
+	Bucket          string `yaml:"bucket" toml:"bucket" env:"S3_BUCKET" flag:"s3-bucket" usage:"S3 bucket"`
+	AccessKeyID     string `yaml:"access_key_id" toml:"access_key_id" env:"S3_ACCESS_KEY_ID"`
+	SecretAccessKey string `yaml:"secret_access_key" toml:"secret_access_key" env:"S3_SECRET_ACCESS_KEY" secret:"true"`
+	PathStyle       bool   `yaml:"path_style" toml:"path_style" env:"S3_PATH_STYLE" flag:"s3-path-style" usage:"Use path-style S3 URLs"`
+}
+
+// SigningConfig berisi kunci link bertanda tangan. Kunci kosong diganti kunci acak saat start,
+// sehingga link hanya berlaku di satu replika sampai proses dimulai ulang.
+type SigningConfig struct {
+	AttachmentKey string `yaml:"attachment_key" toml:"attachment_key" env:"ATTACHMENT_SIGNING_KEY" secret:"true"`
+	SurveyKey     string `yaml:"survey_key" toml:"survey_key" env:"SURVEY_SIGNING_KEY" secret:"true"`
+}
+
+type TwilioConfig struct {
+	APIBaseURL string `yaml:"api_base_url" toml:"api_base_url" env:"TWILIO_API_BASE_URL" flag:"twilio-api-base-url" usage:"Twilio API base URL override"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"Minimum log level (debug, info, warn or error)"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" flag:"log-format" usage:"Log output format (text or json)"`
+}
+
+// MetricsConfig mengatur listener internal untuk /metrics. Listener ini terpisah dari server
+// publik dan tidak boleh diekspos lewat load balancer.
+type MetricsConfig struct {
+	Addr string `yaml:"addr" toml:"addr" env:"METRICS_ADDR" flag:"metrics-addr" usage:"Internal listen address for Prometheus /metrics (empty to disable)"`
+}
+
+// Duration adalah time.Duration yang ditulis sebagai teks seperti "30s" atau "5m" di file
+// konfigurasi, environment dan flag
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MinJWTSecretLength adalah panjang minimum secret HMAC untuk token JWT
+const MinJWTSecretLength = 32
+
+// redacted menggantikan nilai secret saat konfigurasi dicetak
+const redacted = "[REDACTED]"
+
+// Default mengembalikan konfigurasi bawaan sebelum sumber lain diterapkan
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadHeaderTimeout: Duration(10 * time.Second),
+			ReadTimeout:       Duration(time.Minute),
+			WriteTimeout:      Duration(2 * time.Minute),
+			IdleTimeout:       Duration(2 * time.Minute),
+			RequestTimeout:    Duration(30 * time.Second),
+			ShutdownTimeout:   Duration(30 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Port:            5432,
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+			ConnMaxIdleTime: Duration(5 * time.Minute),
+			ConnectTimeout:  Duration(time.Minute),
+		},
+		Storage: StorageConfig{Driver: "local", LocalDir: "data/attachments"},
+		Log:     LogConfig{Level: "info", Format: "text"},
+		Metrics: MetricsConfig{Addr: "127.0.0.1:9090"},
+	}
+}
+
+// Load memuat konfigurasi dari args (biasanya os.Args[1:]) lalu memvalidasinya. File konfigurasi
+// dipilih dengan flag -config atau CONFIG_FILE; file .env di direktori kerja ikut dibaca bila ada,
+// tanpa menimpa variabel environment yang sudah diset.
+func Load(args []string) (*Config, error) {
+	cfg, _, err := Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Parse memuat konfigurasi seperti Load tanpa validasi dan mengembalikan argumen setelah flag.
+// Dipakai command yang hanya membutuhkan sebagian konfigurasi, misalnya migrate.
+func Parse(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := fs.String("config", "", "Path to a YAML or TOML config file (CONFIG_FILE)")
+	flags := map[string]*flagValue{}
+	walk(reflect.ValueOf(&cfg).Elem(), "", func(f reflect.StructField, v reflect.Value, path string) {
+		if name := f.Tag.Get("flag"); name != "" {
+			flags[name] = &flagValue{isBool: v.Kind() == reflect.Bool}
+			fs.Var(flags[name], name, fmt.Sprintf("%s (%s)", f.Tag.Get("usage"), f.Tag.Get("env")))
+		}
+	})
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		if err := loadFile(&cfg, *configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var errs []error
+	walk(reflect.ValueOf(&cfg).Elem(), "", func(f reflect.StructField, v reflect.Value, path string) {
+		if name := f.Tag.Get("env"); name != "" {
+			if raw, ok := os.LookupEnv(name); ok {
+				if err := setValue(v, raw); err != nil {
+					errs = append(errs, fmt.Errorf("%s: invalid value for %s: %w", path, name, err))
+				}
+			}
+		}
+	})
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	walk(reflect.ValueOf(&cfg).Elem(), "", func(f reflect.StructField, v reflect.Value, path string) {
+		if name := f.Tag.Get("flag"); set[name] {
+			if err := setValue(v, flags[name].raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid value for -%s: %w", path, name, err))
+			}
+		}
+	})
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	return &cfg, fs.Args(), nil
+}
+
+// flagValue menyimpan teks flag apa adanya; nilainya diterapkan setelah file dan environment
+type flagValue struct {
+	raw    string
+	isBool bool
+}
+
+func (f *flagValue) String() string { return f.raw }
+
+func (f *flagValue) Set(s string) error {
+	f.raw = s
+	return nil
+}
+
+// IsBoolFlag membuat flag boolean bisa ditulis tanpa nilai, misalnya -migrate
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
+
+// loadFile membaca file YAML (.yaml, .yml) atau TOML (.toml) di atas nilai yang sudah ada
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		dec := toml.NewDecoder(strings.NewReader(string(data)))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	return nil
+}
+
+// walk memanggil fn untuk setiap field non-struct, dengan path berupa nama kunci YAML
+func walk(v reflect.Value, prefix string, fn func(f reflect.StructField, v reflect.Value, path string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		path := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if f.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), path, fn)
+			continue
+		}
+		fn(f, v.Field(i), path)
+	}
+}
+
+// setValue mengisi field dari teks env atau flag
+func setValue(v reflect.Value, raw string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+	return nil
+}
+
+// source menjelaskan cara mengisi field untuk pesan error validasi
+func source(path, env string) string {
+	return fmt.Sprintf("set %s or %s in the config file", env, path)
+}
+
+// required menambahkan error jika nilai wajib kosong
+func required(errs *[]error, value, path, env string) {
+	if strings.TrimSpace(value) == "" {
+		*errs = append(*errs, fmt.Errorf("%s is required (%s)", path, source(path, env)))
+	}
+}
+
+// Validate memeriksa nilai wajib dan format. Semua masalah dilaporkan sekaligus.
+func (c Config) Validate() error {
+	var errs []error
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("server.addr %q is not a valid listen address: %v", c.Server.Addr, err))
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		errs = append(errs, errors.New("server.tls_cert_file and server.tls_key_file must be set together"))
+	}
+	if c.Server.ReadHeaderTimeout < 0 || c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 ||
+		c.Server.RequestTimeout < 0 {
+		errs = append(errs, errors.New("server timeouts must not be negative"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("server.shutdown_timeout must be positive, got %s", time.Duration(c.Server.ShutdownTimeout)))
+	}
+
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	required(&errs, c.Auth.JWTSecret, "auth.jwt_secret", "JWT_SECRET")
+	if c.Auth.JWTSecret != "" && len(c.Auth.JWTSecret) < MinJWTSecretLength {
+		errs = append(errs, fmt.Errorf("auth.jwt_secret must be at least %d characters", MinJWTSecretLength))
+	}
+
+	if c.PublicBaseURL != "" {
+		u, err := url.Parse(c.PublicBaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("public_base_url %q must be an absolute http(s) URL", c.PublicBaseURL))
+		}
+	}
+
+	switch c.Storage.Driver {
+	case "local":
+		required(&errs, c.Storage.LocalDir, "storage.local_dir", "STORAGE_LOCAL_DIR")
+	case "s3":
+		required(&errs, c.Storage.S3.Region, "storage.s3.region", "S3_REGION")
+		required(&errs, c.Storage.S3.Bucket, "storage.s3.bucket", "S3_BUCKET")
+		required(&errs, c.Storage.S3.AccessKeyID, "storage.s3.access_key_id", "S3_ACCESS_KEY_ID")
+		required(&errs, c.Storage.S3.SecretAccessKey, "storage.s3.secret_access_key", "S3_SECRET_ACCESS_KEY")
+	default:
+		errs = append(errs, fmt.Errorf("storage.driver must be local or s3, got %q", c.Storage.Driver))
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level must be debug, info, warn or error, got %q", c.Log.Level))
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		errs = append(errs, fmt.Errorf("log.format must be text or json, got %q", c.Log.Format))
+	}
+
+	if c.Metrics.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.Metrics.Addr); err != nil {
+			errs = append(errs, fmt.Errorf("metrics.addr %q is not a valid listen address: %v", c.Metrics.Addr, err))
+		} else if c.Metrics.Addr == c.Server.Addr {
+			errs = append(errs, errors.New("metrics.addr must differ from server.addr so /metrics is not served publicly"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate memeriksa konfigurasi database saja
+func (c DatabaseConfig) Validate() error {
+	var errs []error
+	required(&errs, c.Host, "database.host", "DB_HOST")
+	required(&errs, c.User, "database.user", "DB_USER")
+	required(&errs, c.Name, "database.name", "DB_NAME")
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("database.port must be between 1 and 65535, got %d", c.Port))
+	}
+	if c.MaxOpenConns < 0 {
+		errs = append(errs, fmt.Errorf("database.max_open_conns must not be negative, got %d", c.MaxOpenConns))
+	}
+	if c.MaxIdleConns < 0 {
+		errs = append(errs, fmt.Errorf("database.max_idle_conns must not be negative, got %d", c.MaxIdleConns))
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("database.max_idle_conns (%d) must not exceed database.max_open_conns (%d)", c.MaxIdleConns, c.MaxOpenConns))
+	}
+	if c.ConnMaxLifetime < 0 || c.ConnMaxIdleTime < 0 || c.ConnectTimeout < 0 {
+		errs = append(errs, errors.New("database.conn_max_lifetime, conn_max_idle_time and connect_timeout must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Redacted mengembalikan salinan konfigurasi dengan nilai secret disamarkan
+func (c Config) Redacted() Config {
+	walk(reflect.ValueOf(&c).Elem(), "", func(f reflect.StructField, v reflect.Value, path string) {
+		if f.Tag.Get("secret") == "true" && v.String() != "" {
+			v.SetString(redacted)
+		}
+	})
+	return c
+}
+
+// String mencetak konfigurasi sebagai YAML dengan secret disamarkan, sehingga aman untuk log
+func (c Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("<invalid config: %v>", err)
+	}
+	return string(out)
+}
@@ -1,59 +1,90 @@
 package config
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 // DB adalah instance koneksi database global
 var DB *sql.DB
 
-// ConnectDB membuat koneksi ke database PostgreSQL
-func ConnectDB() {
-	// Load konfigurasi dari file .env
-	err := godotenv.Load("../../.env")
+// ConnectDB membuat pool koneksi ke database PostgreSQL dan menunggu sampai database bisa
+// di-ping. Ping diulang dengan backoff eksponensial selama cfg.ConnectTimeout, sehingga server
+// yang start bersamaan dengan Postgres (misalnya di docker compose) tidak langsung mati.
+func ConnectDB(ctx context.Context, cfg DatabaseConfig) error {
+	db, err := sql.Open("postgres", cfg.ConnectionString())
 	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
-		log.Println("Warning: .env file not found, using system environment variables.")
+		return fmt.Errorf("failed to open database: %w", err)
 	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
+	db.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime))
 
-	// Ambil variabel environment
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbUser := os.Getenv("DB_USER")
-	dbPassword := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
-
-	// Buat string koneksi
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		dbHost, dbPort, dbUser, dbPassword, dbName,
-	)
+	if err := waitForDB(ctx, db, time.Duration(cfg.ConnectTimeout)); err != nil {
+		db.Close()
+		return err
+	}
+	DB = db
+	slog.Info("Database connected")
+	return nil
+}
 
-	// Buka koneksi ke database
-	DB, err = sql.Open("postgres", connStr)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+// Batas jeda antar percobaan koneksi saat start
+const (
+	connectMinBackoff = 500 * time.Millisecond
+	connectMaxBackoff = 10 * time.Second
+)
+
+// waitForDB mem-ping database sampai berhasil, timeout habis atau ctx dibatalkan.
+// Timeout 0 berarti hanya satu percobaan.
+func waitForDB(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("failed to ping database: %w", err)
+		}
+		return nil
 	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	// Ping untuk memastikan koneksi berhasil
-	err = DB.Ping()
-	if err != nil {
-		log.Fatalf("Failed to ping database: %v", err)
+	backoff := connectMinBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("database not reachable after %d attempt(s): %w", attempt, err)
+		}
+		slog.Warn("Database not ready, retrying", "attempt", attempt, "retry_in", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not reachable after %d attempt(s): %w", attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, connectMaxBackoff)
 	}
+}
 
-	log.Println("Database connected successfully!")
+// ConnectionString membangun connection string PostgreSQL dari konfigurasi database.
+// Dipakai juga oleh koneksi LISTEN/NOTIFY yang harus dibuka terpisah dari pool DB.
+func (c DatabaseConfig) ConnectionString() string {
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quote(c.Host), c.Port, quote(c.User), quote(c.Password), quote(c.Name), quote(c.SSLMode),
+	)
 }
 
-func debugWorkingDirectory() {
-	dir, err := os.Getwd()
-	if err != nil {
-		log.Fatalf("Error getting current directory: %v", err)
-	}
-	log.Printf("Current working directory: %s", dir)
+// quote meng-escape nilai connection string libpq sehingga spasi atau kutip di password aman
+func quote(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v)
+	return "'" + v + "'"
 }
@@ -0,0 +1,126 @@
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// Pengelompokan laporan
+const (
+	GroupNone    = ""
+	GroupHour    = "hour"
+	GroupDay     = "day"
+	GroupChannel = "channel"
+	GroupTeam    = "team"
+	GroupAgent   = "agent"
+)
+
+// Batas rentang laporan; hourly dibatasi agar deret waktu tetap wajar untuk grafik
+const (
+	DefaultRange   = 7 * 24 * time.Hour
+	MaxHourlyRange = 31 * 24 * time.Hour
+	MaxRange       = 366 * 24 * time.Hour
+)
+
+// Ambang skor survei: CSAT (skala 1-5) puas mulai SatisfiedScore; NPS (skala 0-10) promoter
+// mulai PromoterScore dan detractor sampai DetractorScore
+const (
+	SatisfiedScore = 4
+	PromoterScore  = 9
+	DetractorScore = 6
+)
+
+// Filter membatasi data laporan. TeamID dan AgentID 0 berarti semua.
+type Filter struct {
+	From    time.Time
+	To      time.Time
+	Channel string
+	TeamID  int
+	AgentID int
+}
+
+// Totals adalah jumlah mentah dari tabel rollup yang menjadi dasar Metrics
+type Totals struct {
+	Created              int
+	Resolved             int
+	FirstResponses       int
+	FirstResponseSeconds int64
+	Handled              int
+	HandleSeconds        int64
+	SLAMet               int
+	SLABreached          int
+	CSATResponses        int
+	CSATScoreSum         int64
+	CSATSatisfied        int
+	NPSResponses         int
+	NPSPromoters         int
+	NPSDetractors        int
+}
+
+// Metrics adalah metrik contact centre untuk satu baris laporan. Rasio bernilai null bila
+// tidak ada data pembaginya.
+type Metrics struct {
+	Conversations           int      `json:"conversations"` // Percakapan baru dalam rentang
+	Resolved                int      `json:"resolved"`
+	ResolutionRate          *float64 `json:"resolution_rate"` // Persen; bisa di atas 100 bila banyak backlog lama yang diselesaikan
+	FirstResponses          int      `json:"first_responses"`
+	AvgFirstResponseSeconds *float64 `json:"avg_first_response_seconds"`
+	AvgHandleSeconds        *float64 `json:"avg_handle_seconds"` // Dari assignment pertama sampai percakapan diselesaikan
+	SLAMet                  int      `json:"sla_met"`
+	SLABreached             int      `json:"sla_breached"`
+	SLACompliance           *float64 `json:"sla_compliance"` // Persen timer SLA yang terpenuhi
+	CSATResponses           int      `json:"csat_responses"`
+	CSATAverage             *float64 `json:"csat_average"`
+	CSATScore               *float64 `json:"csat_score"` // Persen responden yang puas (skor >= SatisfiedScore)
+	NPSResponses            int      `json:"nps_responses"`
+	NPS                     *float64 `json:"nps"` // Persen promoter dikurangi persen detractor, -100 sampai 100
+}
+
+func ratio(num, den float64, scale float64) *float64 {
+	if den == 0 {
+		return nil
+	}
+	v := math.Round(num/den*scale*100) / 100
+	return &v
+}
+
+// Metrics menghitung metrik turunan dari jumlah mentah
+func (t Totals) Metrics() Metrics {
+	return Metrics{
+		Conversations:           t.Created,
+		Resolved:                t.Resolved,
+		ResolutionRate:          ratio(float64(t.Resolved), float64(t.Created), 100),
+		FirstResponses:          t.FirstResponses,
+		AvgFirstResponseSeconds: ratio(float64(t.FirstResponseSeconds), float64(t.FirstResponses), 1),
+		AvgHandleSeconds:        ratio(float64(t.HandleSeconds), float64(t.Handled), 1),
+		SLAMet:                  t.SLAMet,
+		SLABreached:             t.SLABreached,
+		SLACompliance:           ratio(float64(t.SLAMet), float64(t.SLAMet+t.SLABreached), 100),
+		CSATResponses:           t.CSATResponses,
+		CSATAverage:             ratio(float64(t.CSATScoreSum), float64(t.CSATResponses), 1),
+		CSATScore:               ratio(float64(t.CSATSatisfied), float64(t.CSATResponses), 100),
+		NPSResponses:            t.NPSResponses,
+		NPS:                     ratio(float64(t.NPSPromoters-t.NPSDetractors), float64(t.NPSResponses), 100),
+	}
+}
+
+// Row adalah satu baris laporan. Kolom pengelompokan yang tidak dipakai dikosongkan;
+// agent_id atau team_id 0 berarti percakapan tanpa agent atau tanpa tim.
+type Row struct {
+	Bucket  *time.Time `json:"bucket,omitempty"`
+	Channel string     `json:"channel,omitempty"`
+	TeamID  *int       `json:"team_id,omitempty"`
+	AgentID *int       `json:"agent_id,omitempty"`
+	Name    string     `json:"name,omitempty"` // Nama agent atau tim
+	Metrics
+}
+
+// Report adalah hasil endpoint analytics
+type Report struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Timezone string    `json:"timezone"`
+	Interval string    `json:"interval,omitempty"`
+	Totals   *Metrics  `json:"totals,omitempty"`
+	Rows     []Row     `json:"rows"`
+}
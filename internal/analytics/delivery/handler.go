@@ -0,0 +1,203 @@
+package delivery
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/analytics"
+	"backend/internal/analytics/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsHandler struct {
+	usecase usecase.AnalyticsUsecase
+}
+
+func NewAnalyticsHandler(uc usecase.AnalyticsUsecase) *AnalyticsHandler {
+	return &AnalyticsHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+	case errors.Is(err, usecase.ErrRangeTooLarge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date range is too large",
+			"max_days": int(analytics.MaxRange.Hours() / 24), "max_hourly_days": int(analytics.MaxHourlyRange.Hours() / 24)})
+	case errors.Is(err, usecase.ErrInvalidInterval):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interval", "intervals": []string{analytics.GroupHour, analytics.GroupDay}})
+	case errors.Is(err, usecase.ErrInvalidGrouping):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid grouping"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// parseFilter membaca from dan to (RFC3339), channel, team_id dan agent_id dari query string
+func parseFilter(c *gin.Context) (analytics.Filter, bool) {
+	f := analytics.Filter{Channel: c.Query("channel")}
+	for name, target := range map[string]*time.Time{"from": &f.From, "to": &f.To} {
+		if v := c.Query(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+				return f, false
+			}
+			*target = t
+		}
+	}
+	for name, target := range map[string]*int{"team_id": &f.TeamID, "agent_id": &f.AgentID} {
+		if v := c.Query(name); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil || id < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+				return f, false
+			}
+			*target = id
+		}
+	}
+	return f, true
+}
+
+// respond mengirim laporan sebagai JSON, atau CSV bila format=csv
+func respond(c *gin.Context, report *analytics.Report, name string) {
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	loc, err := time.LoadLocation(report.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	filename := fmt.Sprintf("%s_%s_%s.csv", name, report.From.In(loc).Format("20060102"), report.To.In(loc).Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"bucket", "channel", "team_id", "agent_id", "name", "conversations", "resolved", "resolution_rate",
+		"first_responses", "avg_first_response_seconds", "avg_handle_seconds", "sla_met", "sla_breached", "sla_compliance",
+		"csat_responses", "csat_average", "csat_score", "nps_responses", "nps"})
+	rows := report.Rows
+	if report.Totals != nil {
+		rows = append([]analytics.Row{{Name: "Total", Metrics: *report.Totals}}, rows...)
+	}
+	for _, row := range rows {
+		bucket := ""
+		if row.Bucket != nil {
+			bucket = row.Bucket.In(loc).Format(time.RFC3339)
+		}
+		w.Write([]string{bucket, row.Channel, optionalInt(row.TeamID), optionalInt(row.AgentID), sanitizeCell(row.Name),
+			strconv.Itoa(row.Conversations), strconv.Itoa(row.Resolved), optionalFloat(row.ResolutionRate),
+			strconv.Itoa(row.FirstResponses), optionalFloat(row.AvgFirstResponseSeconds), optionalFloat(row.AvgHandleSeconds),
+			strconv.Itoa(row.SLAMet), strconv.Itoa(row.SLABreached), optionalFloat(row.SLACompliance),
+			strconv.Itoa(row.CSATResponses), optionalFloat(row.CSATAverage), optionalFloat(row.CSATScore),
+			strconv.Itoa(row.NPSResponses), optionalFloat(row.NPS)})
+	}
+	w.Flush()
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func optionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+// sanitizeCell mencegah nama agent atau tim dieksekusi sebagai formula saat CSV dibuka di spreadsheet
+func sanitizeCell(s string) string {
+	if s != "" && (s[0] == '=' || s[0] == '+' || s[0] == '-' || s[0] == '@' || s[0] == '\t' || s[0] == '\r') {
+		return "'" + s
+	}
+	return s
+}
+
+// GetSummary mengembalikan total metrik dan volume per channel.
+// Query: from, to (RFC3339, default 7 hari terakhir), channel, team_id, agent_id, format (json atau csv).
+func (h *AnalyticsHandler) GetSummary(c *gin.Context) {
+	f, ok := parseFilter(c)
+	if !ok {
+		return
+	}
+	report, err := h.usecase.Summary(c.GetInt("client_id"), f)
+	if err != nil {
+		respondError(c, err, "Failed to fetch analytics summary")
+		return
+	}
+	respond(c, report, "summary")
+}
+
+// GetTimeseries mengembalikan metrik per bucket pada zona waktu client; interval hour atau day
+func (h *AnalyticsHandler) GetTimeseries(c *gin.Context) {
+	f, ok := parseFilter(c)
+	if !ok {
+		return
+	}
+	report, err := h.usecase.Timeseries(c.GetInt("client_id"), f, c.Query("interval"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch analytics timeseries")
+		return
+	}
+	respond(c, report, "timeseries")
+}
+
+// GetAgents mengembalikan metrik per agent; baris agent_id 0 adalah percakapan tanpa agent
+func (h *AnalyticsHandler) GetAgents(c *gin.Context) {
+	h.breakdown(c, analytics.GroupAgent, "agents")
+}
+
+// GetTeams mengembalikan metrik per tim; baris team_id 0 adalah percakapan tanpa tim
+func (h *AnalyticsHandler) GetTeams(c *gin.Context) {
+	h.breakdown(c, analytics.GroupTeam, "teams")
+}
+
+func (h *AnalyticsHandler) GetChannels(c *gin.Context) {
+	h.breakdown(c, analytics.GroupChannel, "channels")
+}
+
+func (h *AnalyticsHandler) breakdown(c *gin.Context, groupBy, name string) {
+	f, ok := parseFilter(c)
+	if !ok {
+		return
+	}
+	report, err := h.usecase.Breakdown(c.GetInt("client_id"), f, groupBy)
+	if err != nil {
+		respondError(c, err, "Failed to fetch analytics")
+		return
+	}
+	respond(c, report, name)
+}
+
+// Rebuild menjadwalkan penghitungan ulang rollup client untuk rentang tertentu, misalnya
+// setelah zona waktu client diganti atau data lama diimpor
+func (h *AnalyticsHandler) Rebuild(c *gin.Context) {
+	var req struct {
+		From time.Time `json:"from" binding:"required"`
+		To   time.Time `json:"to" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	jobID, err := h.usecase.RequestRebuild(c.GetInt("client_id"), req.From, req.To)
+	if err != nil {
+		respondError(c, err, "Failed to schedule analytics rebuild")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"job_id": jobID})
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"backend/internal/analytics"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AnalyticsRepository adalah interface untuk tabel rollup analytics
+type AnalyticsRepository interface {
+	GetTimezone(clientID int) (string, error)
+	// Refresh menghitung ulang bucket rollup di rentang [from, to). clientID 0 berarti semua client.
+	Refresh(from, to time.Time, clientID int) (int64, error)
+	Aggregate(clientID int, f analytics.Filter, groupBy, timezone string) ([]analytics.Row, error)
+}
+
+type analyticsRepo struct {
+	db *sql.DB
+}
+
+func NewAnalyticsRepository(db *sql.DB) AnalyticsRepository {
+	return &analyticsRepo{db: db}
+}
+
+// GetTimezone mengambil zona waktu IANA client, default UTC
+func (r *analyticsRepo) GetTimezone(clientID int) (string, error) {
+	var tz string
+	err := r.db.QueryRow("SELECT timezone FROM clients WHERE client_id = $1", clientID).Scan(&tz)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && tz == "") {
+		return "UTC", nil
+	}
+	return tz, err
+}
+
+// rollupLockKey menyerialkan refresh antar replika agar DELETE dan INSERT tidak bertabrakan
+const rollupLockKey = 41001
+
+// refreshQuery menyusun event dari tabel operasional lalu menjumlahkannya per jam lokal client.
+// Bucket memakai date_trunc dengan zona waktu client agar zona dengan offset setengah jam tetap
+// jatuh tepat pada batas jam lokal. Event diambil sampai satu jam setelah $2 karena bucket yang
+// dimulai sebelum $2 bisa berisi event sesudahnya.
+const refreshQuery = `WITH events AS (
+		-- Percakapan baru, dikaitkan dengan tim dan agent saat ini
+		SELECT cv.client_id, cv.created_at AS at, cv.channel, COALESCE(cv.team_id, 0) AS team_id, COALESCE(cv.assignee_id, 0) AS agent_id,
+			1 AS created, 0 AS resolved, 0 AS first_responses, 0::bigint AS first_response_seconds, 0 AS handled, 0::bigint AS handle_seconds,
+			0 AS sla_met, 0 AS sla_breached, 0 AS csat_responses, 0 AS csat_score, 0 AS csat_satisfied,
+			0 AS nps_responses, 0 AS nps_promoters, 0 AS nps_detractors
+		FROM conversations cv
+		WHERE cv.created_at >= $1 AND cv.created_at < $2::timestamptz + interval '1 hour' AND ($3 = 0 OR cv.client_id = $3)
+		UNION ALL
+		-- Balasan agent pertama; waktu respons dihitung dari percakapan dibuat
+		SELECT cv.client_id, m.created_at, cv.channel, COALESCE(cv.team_id, 0), m.sender_user_id,
+			0, 0, 1, GREATEST(EXTRACT(EPOCH FROM m.created_at - cv.created_at), 0)::bigint, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0
+		FROM messages m JOIN conversations cv ON cv.conversation_id = m.conversation_id
+		WHERE m.direction = 'outbound' AND m.sender_user_id IS NOT NULL
+		  AND m.created_at >= $1 AND m.created_at < $2::timestamptz + interval '1 hour' AND ($3 = 0 OR cv.client_id = $3)
+		  AND NOT EXISTS (
+			SELECT 1 FROM messages p
+			WHERE p.conversation_id = m.conversation_id AND p.direction = 'outbound' AND p.sender_user_id IS NOT NULL
+			  AND (p.created_at, p.message_id) < (m.created_at, m.message_id))
+		UNION ALL
+		-- Penyelesaian; waktu penanganan dari assignment pertama sampai diselesaikan
+		SELECT cv.client_id, cv.resolved_at, cv.channel, COALESCE(cv.team_id, 0), COALESCE(cv.assignee_id, 0),
+			0, 1, 0, 0, CASE WHEN a.first_assigned IS NULL THEN 0 ELSE 1 END,
+			COALESCE(GREATEST(EXTRACT(EPOCH FROM cv.resolved_at - a.first_assigned), 0)::bigint, 0), 0, 0, 0, 0, 0, 0, 0, 0
+		FROM conversations cv
+		LEFT JOIN LATERAL (
+			SELECT MIN(ca.created_at) AS first_assigned FROM conversation_assignments ca
+			WHERE ca.conversation_id = cv.conversation_id AND ca.to_user_id IS NOT NULL
+		) a ON TRUE
+		WHERE cv.resolved_at >= $1 AND cv.resolved_at < $2::timestamptz + interval '1 hour' AND ($3 = 0 OR cv.client_id = $3)
+		UNION ALL
+		-- Timer SLA yang selesai atau terlewat
+		SELECT t.client_id, COALESCE(t.breached_at, t.completed_at), cv.channel, COALESCE(cv.team_id, 0), COALESCE(cv.assignee_id, 0),
+			0, 0, 0, 0, 0, 0,
+			CASE WHEN t.breached_at IS NULL AND t.completed_at <= t.due_at THEN 1 ELSE 0 END,
+			CASE WHEN t.breached_at IS NULL AND t.completed_at <= t.due_at THEN 0 ELSE 1 END, 0, 0, 0, 0, 0, 0
+		FROM sla_timers t JOIN conversations cv ON cv.conversation_id = t.conversation_id
+		WHERE COALESCE(t.breached_at, t.completed_at) >= $1 AND COALESCE(t.breached_at, t.completed_at) < $2::timestamptz + interval '1 hour'
+		  AND ($3 = 0 OR t.client_id = $3)
+		UNION ALL
+		-- Jawaban survei CSAT dan NPS, dikaitkan dengan agent yang menangani percakapan
+		SELECT s.client_id, s.created_at, cv.channel, COALESCE(cv.team_id, 0), COALESCE(s.agent_id, 0),
+			0, 0, 0, 0, 0, 0, 0, 0,
+			CASE WHEN s.survey_type = 'csat' THEN 1 ELSE 0 END,
+			CASE WHEN s.survey_type = 'csat' THEN s.score ELSE 0 END,
+			CASE WHEN s.survey_type = 'csat' AND s.score >= $4 THEN 1 ELSE 0 END,
+			CASE WHEN s.survey_type = 'nps' THEN 1 ELSE 0 END,
+			CASE WHEN s.survey_type = 'nps' AND s.score >= $5 THEN 1 ELSE 0 END,
+			CASE WHEN s.survey_type = 'nps' AND s.score <= $6 THEN 1 ELSE 0 END
+		FROM survey_responses s JOIN conversations cv ON cv.conversation_id = s.conversation_id
+		WHERE s.created_at >= $1 AND s.created_at < $2::timestamptz + interval '1 hour'
+		  AND ($3 = 0 OR s.client_id = $3)
+	), bucketed AS (
+		SELECT e.*, date_trunc('hour', e.at, COALESCE(NULLIF(cl.timezone, ''), 'UTC')) AS bucket
+		FROM events e LEFT JOIN clients cl ON cl.client_id = e.client_id
+	)
+	INSERT INTO analytics_hourly (client_id, bucket, channel, team_id, agent_id,
+		conversations_created, conversations_resolved, first_responses, first_response_seconds, handled, handle_seconds,
+		sla_met, sla_breached, csat_responses, csat_score_sum, csat_satisfied, nps_responses, nps_promoters, nps_detractors)
+	SELECT client_id, bucket, channel, team_id, agent_id,
+		SUM(created), SUM(resolved), SUM(first_responses), SUM(first_response_seconds), SUM(handled), SUM(handle_seconds),
+		SUM(sla_met), SUM(sla_breached), SUM(csat_responses), SUM(csat_score), SUM(csat_satisfied),
+		SUM(nps_responses), SUM(nps_promoters), SUM(nps_detractors)
+	FROM bucketed
+	WHERE bucket >= $1 AND bucket < $2
+	GROUP BY client_id, bucket, channel, team_id, agent_id`
+
+// Refresh menghapus lalu menghitung ulang bucket dalam satu transaksi sehingga pembaca
+// tidak pernah melihat rentang yang setengah terisi
+func (r *analyticsRepo) Refresh(from, to time.Time, clientID int) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", rollupLockKey); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(
+		"DELETE FROM analytics_hourly WHERE bucket >= $1 AND bucket < $2 AND ($3 = 0 OR client_id = $3)",
+		from, to, clientID,
+	); err != nil {
+		return 0, fmt.Errorf("failed to clear analytics rollups: %w", err)
+	}
+	res, err := tx.Exec(refreshQuery, from, to, clientID, analytics.SatisfiedScore, analytics.PromoterScore, analytics.DetractorScore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build analytics rollups: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, tx.Commit()
+}
+
+// grouping adalah ekspresi SQL untuk satu cara pengelompokan; hanya nilai dari map ini
+// yang pernah disisipkan ke query
+type grouping struct {
+	bucket, channel, team, agent, name string
+	join, groupBy                      string
+	timezone                           bool
+}
+
+var groupings = map[string]grouping{
+	analytics.GroupNone: {bucket: "NULL::timestamptz", channel: "''", team: "NULL::int", agent: "NULL::int", name: "''"},
+	analytics.GroupHour: {bucket: "date_trunc('hour', a.bucket, $7)", channel: "''", team: "NULL::int", agent: "NULL::int", name: "''",
+		groupBy: "GROUP BY 1 ORDER BY 1", timezone: true},
+	analytics.GroupDay: {bucket: "date_trunc('day', a.bucket, $7)", channel: "''", team: "NULL::int", agent: "NULL::int", name: "''",
+		groupBy: "GROUP BY 1 ORDER BY 1", timezone: true},
+	analytics.GroupChannel: {bucket: "NULL::timestamptz", channel: "a.channel", team: "NULL::int", agent: "NULL::int", name: "''",
+		groupBy: "GROUP BY a.channel ORDER BY a.channel"},
+	analytics.GroupTeam: {bucket: "NULL::timestamptz", channel: "''", team: "a.team_id", agent: "NULL::int", name: "COALESCE(t.name, '')",
+		join: "LEFT JOIN teams t ON t.team_id = a.team_id", groupBy: "GROUP BY a.team_id, t.name ORDER BY a.team_id"},
+	analytics.GroupAgent: {bucket: "NULL::timestamptz", channel: "''", team: "NULL::int", agent: "a.agent_id", name: "COALESCE(u.username, '')",
+		join: "LEFT JOIN users u ON u.user_id = a.agent_id", groupBy: "GROUP BY a.agent_id, u.username ORDER BY a.agent_id"},
+}
+
+// Aggregate menjumlahkan rollup client sesuai filter dan pengelompokan. Bucket jam dan hari
+// dihitung pada zona waktu yang diberikan.
+func (r *analyticsRepo) Aggregate(clientID int, f analytics.Filter, groupBy, timezone string) ([]analytics.Row, error) {
+	g, ok := groupings[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown analytics grouping %q", groupBy)
+	}
+
+	query := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s,
+		COALESCE(SUM(a.conversations_created), 0), COALESCE(SUM(a.conversations_resolved), 0),
+		COALESCE(SUM(a.first_responses), 0), COALESCE(SUM(a.first_response_seconds), 0),
+		COALESCE(SUM(a.handled), 0), COALESCE(SUM(a.handle_seconds), 0),
+		COALESCE(SUM(a.sla_met), 0), COALESCE(SUM(a.sla_breached), 0),
+		COALESCE(SUM(a.csat_responses), 0), COALESCE(SUM(a.csat_score_sum), 0), COALESCE(SUM(a.csat_satisfied), 0),
+		COALESCE(SUM(a.nps_responses), 0), COALESCE(SUM(a.nps_promoters), 0), COALESCE(SUM(a.nps_detractors), 0)
+		FROM analytics_hourly a %s
+		WHERE a.client_id = $1 AND a.bucket >= $2 AND a.bucket < $3
+		  AND ($4 = '' OR a.channel = $4) AND ($5 = 0 OR a.team_id = $5) AND ($6 = 0 OR a.agent_id = $6)
+		%s`, g.bucket, g.channel, g.team, g.agent, g.name, g.join, g.groupBy)
+	args := []interface{}{clientID, f.From, f.To, f.Channel, f.TeamID, f.AgentID}
+	if g.timezone {
+		args = append(args, timezone)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate analytics: %w", err)
+	}
+	defer rows.Close()
+
+	var list []analytics.Row
+	for rows.Next() {
+		var row analytics.Row
+		var bucket sql.NullTime
+		var team, agent sql.NullInt64
+		var t analytics.Totals
+		if err := rows.Scan(&bucket, &row.Channel, &team, &agent, &row.Name,
+			&t.Created, &t.Resolved, &t.FirstResponses, &t.FirstResponseSeconds, &t.Handled, &t.HandleSeconds,
+			&t.SLAMet, &t.SLABreached, &t.CSATResponses, &t.CSATScoreSum, &t.CSATSatisfied,
+			&t.NPSResponses, &t.NPSPromoters, &t.NPSDetractors); err != nil {
+			return nil, err
+		}
+		if bucket.Valid {
+			b := bucket.Time
+			row.Bucket = &b
+		}
+		if team.Valid {
+			id := int(team.Int64)
+			row.TeamID = &id
+		}
+		if agent.Valid {
+			id := int(agent.Int64)
+			row.AgentID = &id
+		}
+		row.Metrics = t.Metrics()
+		list = append(list, row)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/analytics/repository"
+	"backend/internal/jobs"
+	"backend/pkg/logger"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrInvalidRange    = errors.New("from must be before to")
+	ErrRangeTooLarge   = errors.New("date range is too large")
+	ErrInvalidInterval = errors.New("invalid interval")
+	ErrInvalidGrouping = errors.New("invalid grouping")
+)
+
+// refreshChunk membatasi rentang yang dihitung ulang dalam satu transaksi
+const refreshChunk = 7 * 24 * time.Hour
+
+// JobEnqueuer dipakai untuk menjadwalkan penghitungan ulang rollup di latar belakang
+type JobEnqueuer interface {
+	Enqueue(payload jobs.Payload, opts ...jobs.Option) (int, error)
+}
+
+// RefreshRollups menghitung ulang rollup analytics. Tanpa From dan To, rentang yang dihitung
+// adalah LookbackHours jam terakhir sampai jam berjalan. ClientID 0 berarti semua client.
+type RefreshRollups struct {
+	LookbackHours int        `json:"lookback_hours,omitempty"`
+	ClientID      int        `json:"client_id,omitempty"`
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+}
+
+func (RefreshRollups) JobType() string { return "analytics.rollup" }
+
+// AnalyticsUsecase menyediakan laporan metrik contact centre dari tabel rollup. Data laporan
+// tertinggal paling lama satu jadwal refresh dari data operasional.
+type AnalyticsUsecase interface {
+	Summary(clientID int, f analytics.Filter) (*analytics.Report, error)
+	Timeseries(clientID int, f analytics.Filter, interval string) (*analytics.Report, error)
+	Breakdown(clientID int, f analytics.Filter, groupBy string) (*analytics.Report, error)
+	// RequestRebuild menjadwalkan penghitungan ulang rollup client, misalnya setelah zona waktu diganti
+	RequestRebuild(clientID int, from, to time.Time) (int, error)
+	RefreshRollups(ctx context.Context, payload RefreshRollups) error
+}
+
+type analyticsUsecase struct {
+	repo repository.AnalyticsRepository
+	jobs JobEnqueuer
+}
+
+func NewAnalyticsUsecase(repo repository.AnalyticsRepository, jobs JobEnqueuer) AnalyticsUsecase {
+	return &analyticsUsecase{repo: repo, jobs: jobs}
+}
+
+// normalize mengisi rentang default dan memvalidasi panjang rentang
+func (uc *analyticsUsecase) normalize(f analytics.Filter, max time.Duration) (analytics.Filter, error) {
+	if f.To.IsZero() {
+		f.To = time.Now()
+	}
+	if f.From.IsZero() {
+		f.From = f.To.Add(-analytics.DefaultRange)
+	}
+	if !f.From.Before(f.To) {
+		return f, ErrInvalidRange
+	}
+	if f.To.Sub(f.From) > max {
+		return f, ErrRangeTooLarge
+	}
+	return f, nil
+}
+
+func (uc *analyticsUsecase) location(clientID int) (string, *time.Location, error) {
+	tz, err := uc.repo.GetTimezone(clientID)
+	if err != nil {
+		return "", nil, err
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		slog.Warn("Invalid client timezone, using UTC", "timezone", tz, "client_id", clientID, "error", err)
+		return "UTC", time.UTC, nil
+	}
+	return tz, loc, nil
+}
+
+func (uc *analyticsUsecase) Summary(clientID int, f analytics.Filter) (*analytics.Report, error) {
+	f, err := uc.normalize(f, analytics.MaxRange)
+	if err != nil {
+		return nil, err
+	}
+	tz, _, err := uc.location(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := uc.repo.Aggregate(clientID, f, analytics.GroupNone, tz)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := uc.repo.Aggregate(clientID, f, analytics.GroupChannel, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &analytics.Report{From: f.From, To: f.To, Timezone: tz, Totals: &analytics.Metrics{}, Rows: rows}
+	if len(totals) > 0 {
+		report.Totals = &totals[0].Metrics
+	}
+	if report.Rows == nil {
+		report.Rows = []analytics.Row{}
+	}
+	return report, nil
+}
+
+// Timeseries mengembalikan metrik per jam atau per hari pada zona waktu client. Bucket tanpa
+// data tetap dikembalikan dengan nilai nol agar deret waktu tidak berlubang.
+func (uc *analyticsUsecase) Timeseries(clientID int, f analytics.Filter, interval string) (*analytics.Report, error) {
+	if interval == "" {
+		interval = analytics.GroupDay
+	}
+	var max time.Duration
+	switch interval {
+	case analytics.GroupHour:
+		max = analytics.MaxHourlyRange
+	case analytics.GroupDay:
+		max = analytics.MaxRange
+	default:
+		return nil, ErrInvalidInterval
+	}
+	f, err := uc.normalize(f, max)
+	if err != nil {
+		return nil, err
+	}
+	tz, loc, err := uc.location(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := uc.repo.Aggregate(clientID, f, interval, tz)
+	if err != nil {
+		return nil, err
+	}
+	byBucket := make(map[int64]analytics.Row, len(rows))
+	for _, row := range rows {
+		if row.Bucket != nil {
+			byBucket[row.Bucket.Unix()] = row
+		}
+	}
+
+	series := []analytics.Row{}
+	for b := bucketStart(f.From, interval, loc); b.Before(f.To); b = nextBucket(b, interval) {
+		row, ok := byBucket[b.Unix()]
+		if !ok {
+			row = analytics.Row{Metrics: analytics.Totals{}.Metrics()}
+		}
+		bucket := b
+		row.Bucket = &bucket
+		series = append(series, row)
+	}
+	return &analytics.Report{From: f.From, To: f.To, Timezone: tz, Interval: interval, Rows: series}, nil
+}
+
+// bucketStart membulatkan t ke awal jam atau hari lokal
+func bucketStart(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	if interval == analytics.GroupHour {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// nextBucket maju satu jam nyata atau satu hari kalender, sehingga hari pergantian DST
+// tetap satu bucket
+func nextBucket(t time.Time, interval string) time.Time {
+	if interval == analytics.GroupHour {
+		return t.Add(time.Hour)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+func (uc *analyticsUsecase) Breakdown(clientID int, f analytics.Filter, groupBy string) (*analytics.Report, error) {
+	switch groupBy {
+	case analytics.GroupAgent, analytics.GroupTeam, analytics.GroupChannel:
+	default:
+		return nil, ErrInvalidGrouping
+	}
+	f, err := uc.normalize(f, analytics.MaxRange)
+	if err != nil {
+		return nil, err
+	}
+	tz, _, err := uc.location(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := uc.repo.Aggregate(clientID, f, groupBy, tz)
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = []analytics.Row{}
+	}
+	return &analytics.Report{From: f.From, To: f.To, Timezone: tz, Rows: rows}, nil
+}
+
+func (uc *analyticsUsecase) RequestRebuild(clientID int, from, to time.Time) (int, error) {
+	if !from.Before(to) {
+		return 0, ErrInvalidRange
+	}
+	if to.Sub(from) > analytics.MaxRange {
+		return 0, ErrRangeTooLarge
+	}
+	return uc.jobs.Enqueue(RefreshRollups{ClientID: clientID, From: &from, To: &to}, jobs.ForClient(clientID))
+}
+
+// RefreshRollups menghitung ulang rollup per potongan rentang agar rebuild panjang tidak
+// menahan satu transaksi besar
+func (uc *analyticsUsecase) RefreshRollups(ctx context.Context, p RefreshRollups) error {
+	to := time.Now().Truncate(time.Hour).Add(time.Hour)
+	if p.To != nil {
+		to = *p.To
+	}
+	from := to.Add(-time.Duration(p.LookbackHours) * time.Hour)
+	if p.From != nil {
+		from = *p.From
+	}
+	from = from.Truncate(time.Hour)
+	if !from.Before(to) {
+		return errors.Join(jobs.ErrPermanent, ErrInvalidRange)
+	}
+
+	var total int64
+	for start := from; start.Before(to); start = start.Add(refreshChunk) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := start.Add(refreshChunk)
+		if end.After(to) {
+			end = to
+		}
+		n, err := uc.repo.Refresh(start, end, p.ClientID)
+		if err != nil {
+			return err
+		}
+		total += n
+	}
+	if p.From != nil {
+		logger.From(ctx).Info("Rebuilt analytics rollups", "rows", total, "from", from.Format(time.RFC3339), "to", to.Format(time.RFC3339))
+	}
+	return nil
+}
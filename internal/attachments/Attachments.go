@@ -0,0 +1,113 @@
+package attachments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Batas upload dan kuota
+const (
+	MaxUploadSize      int64 = 25 << 20   // 25 MB per file
+	DefaultQuotaBytes  int64 = 5 << 30    // 5 GB per client jika belum diatur
+	MaxThumbnailPixels       = 40_000_000 // Gambar lebih besar dari ini tidak dibuatkan thumbnail
+	ThumbnailSize            = 320        // Sisi terpanjang thumbnail dalam piksel
+	DefaultURLTTL            = 15 * time.Minute
+)
+
+// Kategori attachment berdasarkan tipe konten hasil deteksi
+const (
+	KindImage    = "image"
+	KindAudio    = "audio"
+	KindVideo    = "video"
+	KindDocument = "document"
+)
+
+// AllowedTypes memetakan tipe MIME yang boleh diunggah ke kategorinya. Tipe selalu
+// dideteksi dari isi file, bukan dari ekstensi atau header Content-Type klien.
+var AllowedTypes = map[string]string{
+	"image/jpeg": KindImage,
+	"image/png":  KindImage,
+	"image/gif":  KindImage,
+	"image/webp": KindImage,
+
+	"audio/ogg":   KindAudio, // Voice note WhatsApp/Telegram (opus)
+	"audio/mpeg":  KindAudio,
+	"audio/mp4":   KindAudio,
+	"audio/x-m4a": KindAudio,
+	"audio/amr":   KindAudio,
+	"audio/wav":   KindAudio,
+	"audio/webm":  KindAudio,
+
+	"video/mp4":  KindVideo,
+	"video/3gpp": KindVideo,
+	"video/webm": KindVideo,
+
+	"application/pdf":    KindDocument,
+	"text/plain":         KindDocument,
+	"text/csv":           KindDocument,
+	"application/msword": KindDocument,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   KindDocument,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         KindDocument,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": KindDocument,
+	"application/vnd.ms-excel":      KindDocument,
+	"application/vnd.ms-powerpoint": KindDocument,
+	"application/zip":               KindDocument,
+}
+
+// Attachment adalah metadata file yang disimpan di storage
+type Attachment struct {
+	ID             int       `json:"id"`
+	ClientID       int       `json:"client_id"`
+	ConversationID *int      `json:"conversation_id,omitempty"`
+	MessageID      *int      `json:"message_id,omitempty"`
+	UploadedBy     *int      `json:"uploaded_by,omitempty"` // Kosong untuk media dari channel
+	FileName       string    `json:"file_name"`
+	ContentType    string    `json:"content_type"`
+	Kind           string    `json:"kind"`
+	Size           int64     `json:"size"`
+	Checksum       string    `json:"checksum"` // SHA-256 hex dari isi file
+	StorageKey     string    `json:"-"`
+	ThumbnailKey   *string   `json:"-"`
+	Width          *int      `json:"width,omitempty"`
+	Height         *int      `json:"height,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Diisi usecase saat attachment dikembalikan ke klien
+	URL          string `json:"url,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
+// Usage adalah pemakaian storage satu client
+type Usage struct {
+	ClientID   int   `json:"client_id"`
+	UsedBytes  int64 `json:"used_bytes"`
+	QuotaBytes int64 `json:"quota_bytes"`
+}
+
+// Variant download yang bisa ditandatangani
+const (
+	VariantOriginal  = "original"
+	VariantThumbnail = "thumbnail"
+)
+
+// Sign menghasilkan signature HMAC-SHA256 untuk URL download yang berlaku sampai expires
+func Sign(key []byte, id int, variant string, expires time.Time) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%d:%s:%d", id, variant, expires.Unix())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify memeriksa signature dan masa berlaku URL download
+func Verify(key []byte, id int, variant, expires, signature string, now time.Time) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > exp {
+		return false
+	}
+	expected := Sign(key, id, variant, time.Unix(exp, 0))
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
@@ -0,0 +1,173 @@
+package delivery
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backend/internal/attachments"
+	"backend/internal/attachments/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentHandler struct {
+	usecase usecase.AttachmentUsecase
+}
+
+func NewAttachmentHandler(uc usecase.AttachmentUsecase) *AttachmentHandler {
+	return &AttachmentHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+	case errors.Is(err, usecase.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+	case errors.Is(err, usecase.ErrEmptyFile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is empty"})
+	case errors.Is(err, usecase.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large", "max_bytes": attachments.MaxUploadSize})
+	case errors.Is(err, usecase.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "File type is not allowed"})
+	case errors.Is(err, usecase.ErrQuotaExceeded):
+		c.JSON(http.StatusInsufficientStorage, gin.H{"error": "Storage quota exceeded"})
+	case errors.Is(err, usecase.ErrInvalidSignature):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Download link is invalid or expired"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// Upload menerima file multipart pada field "file". Percakapan diambil dari parameter path
+// atau field form conversation_id; keduanya opsional.
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	// Overhead multipart diberi ruang agar file tepat di batas ukuran tetap diterima
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, attachments.MaxUploadSize+1<<20)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respondError(c, usecase.ErrTooLarge, "")
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file"})
+		return
+	}
+	defer file.Close()
+
+	upload := usecase.Upload{ClientID: c.GetInt("client_id"), FileName: header.Filename, Body: file}
+	userID := c.GetInt("user_id")
+	upload.UploadedBy = &userID
+
+	convParam := c.Param("id")
+	if convParam == "" {
+		convParam = c.PostForm("conversation_id")
+	}
+	if convParam != "" {
+		convID, err := strconv.Atoi(convParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+			return
+		}
+		upload.ConversationID = &convID
+	}
+
+	a, err := h.usecase.Upload(c.Request.Context(), upload)
+	if err != nil {
+		respondError(c, err, "Failed to upload file")
+		return
+	}
+	c.JSON(http.StatusCreated, a)
+}
+
+func (h *AttachmentHandler) GetAttachment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	a, err := h.usecase.GetAttachment(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch attachment")
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+func (h *AttachmentHandler) GetConversationAttachments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	list, err := h.usecase.GetConversationAttachments(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch attachments")
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	if err := h.usecase.DeleteAttachment(c.Request.Context(), c.GetInt("client_id"), id); err != nil {
+		respondError(c, err, "Failed to delete attachment")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted"})
+}
+
+func (h *AttachmentHandler) GetUsage(c *gin.Context) {
+	u, err := h.usecase.GetUsage(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch storage usage"})
+		return
+	}
+	c.JSON(http.StatusOK, u)
+}
+
+// Download mengirim isi attachment melalui URL bertanda tangan (tanpa JWT). Hanya gambar,
+// audio dan video yang ditampilkan inline; dokumen selalu diunduh sebagai file.
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	variant := c.Query("variant")
+	a, body, err := h.usecase.Open(c.Request.Context(), id, variant, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		respondError(c, err, "Failed to download attachment")
+		return
+	}
+	defer body.Close()
+
+	disposition := "attachment"
+	if a.Kind != attachments.KindDocument {
+		disposition = "inline"
+	}
+	size := a.Size
+	if variant == attachments.VariantThumbnail {
+		size = -1
+	}
+	c.DataFromReader(http.StatusOK, size, a.ContentType, body, map[string]string{
+		"Content-Disposition":     fmt.Sprintf("%s; filename=%q", disposition, strings.ReplaceAll(a.FileName, `\`, "_")),
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": "default-src 'none'; sandbox",
+		"Cache-Control":           "private, max-age=300",
+	})
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"backend/internal/attachments"
+	"database/sql"
+	"errors"
+)
+
+// AttachmentRepository adalah interface untuk metadata attachment dan pemakaian storage client
+type AttachmentRepository interface {
+	// Create menyimpan metadata dan menambah pemakaian storage client dalam satu transaksi.
+	// Nilai false berarti kuota client tidak cukup dan tidak ada yang disimpan.
+	Create(a *attachments.Attachment, defaultQuota int64) (bool, error)
+	GetAttachment(id int) (*attachments.Attachment, error)
+	FetchByConversation(clientID, conversationID int) ([]attachments.Attachment, error)
+	// Delete menghapus metadata milik client, mengurangi pemakaian storage dan mengembalikan
+	// attachment yang dihapus agar objeknya bisa dibersihkan dari storage
+	Delete(clientID, id int) (*attachments.Attachment, error)
+	SetThumbnail(id int, key string, width, height int) error
+	GetUsage(clientID int, defaultQuota int64) (*attachments.Usage, error)
+}
+
+type attachmentRepo struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) AttachmentRepository {
+	return &attachmentRepo{db: db}
+}
+
+const attachmentColumns = `attachment_id, client_id, conversation_id, message_id, uploaded_by, file_name, content_type, kind,
+	size, checksum, storage_key, thumbnail_key, width, height, created_at`
+
+func scanAttachment(row interface{ Scan(...interface{}) error }) (*attachments.Attachment, error) {
+	var a attachments.Attachment
+	var conversationID, messageID, uploadedBy, width, height sql.NullInt64
+	var thumbnailKey sql.NullString
+	err := row.Scan(&a.ID, &a.ClientID, &conversationID, &messageID, &uploadedBy, &a.FileName, &a.ContentType, &a.Kind,
+		&a.Size, &a.Checksum, &a.StorageKey, &thumbnailKey, &width, &height, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	a.ConversationID = nullInt(conversationID)
+	a.MessageID = nullInt(messageID)
+	a.UploadedBy = nullInt(uploadedBy)
+	a.Width = nullInt(width)
+	a.Height = nullInt(height)
+	if thumbnailKey.Valid {
+		a.ThumbnailKey = &thumbnailKey.String
+	}
+	return &a, nil
+}
+
+func nullInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+func (r *attachmentRepo) Create(a *attachments.Attachment, defaultQuota int64) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"INSERT INTO storage_usage (client_id, used_bytes, quota_bytes) VALUES ($1, 0, $2) ON CONFLICT (client_id) DO NOTHING",
+		a.ClientID, defaultQuota,
+	); err != nil {
+		return false, err
+	}
+	// Pengecekan dan penambahan pemakaian dalam satu UPDATE agar upload bersamaan tidak melewati kuota
+	res, err := tx.Exec(
+		"UPDATE storage_usage SET used_bytes = used_bytes + $2 WHERE client_id = $1 AND used_bytes + $2 <= quota_bytes",
+		a.ClientID, a.Size,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	err = tx.QueryRow(
+		`INSERT INTO attachments (client_id, conversation_id, message_id, uploaded_by, file_name, content_type, kind,
+			size, checksum, storage_key, width, height, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
+		 RETURNING attachment_id, created_at`,
+		a.ClientID, a.ConversationID, a.MessageID, a.UploadedBy, a.FileName, a.ContentType, a.Kind,
+		a.Size, a.Checksum, a.StorageKey, a.Width, a.Height,
+	).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *attachmentRepo) GetAttachment(id int) (*attachments.Attachment, error) {
+	return scanAttachment(r.db.QueryRow("SELECT "+attachmentColumns+" FROM attachments WHERE attachment_id = $1", id))
+}
+
+func (r *attachmentRepo) FetchByConversation(clientID, conversationID int) ([]attachments.Attachment, error) {
+	rows, err := r.db.Query(
+		"SELECT "+attachmentColumns+" FROM attachments WHERE client_id = $1 AND conversation_id = $2 ORDER BY created_at, attachment_id",
+		clientID, conversationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []attachments.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *a)
+	}
+	return list, rows.Err()
+}
+
+func (r *attachmentRepo) Delete(clientID, id int) (*attachments.Attachment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	a, err := scanAttachment(tx.QueryRow(
+		"DELETE FROM attachments WHERE attachment_id = $1 AND client_id = $2 RETURNING "+attachmentColumns,
+		id, clientID,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(
+		"UPDATE storage_usage SET used_bytes = GREATEST(used_bytes - $2, 0) WHERE client_id = $1",
+		clientID, a.Size,
+	); err != nil {
+		return nil, err
+	}
+	return a, tx.Commit()
+}
+
+func (r *attachmentRepo) SetThumbnail(id int, key string, width, height int) error {
+	_, err := r.db.Exec(
+		"UPDATE attachments SET thumbnail_key = $1, width = $2, height = $3 WHERE attachment_id = $4",
+		key, width, height, id,
+	)
+	return err
+}
+
+// GetUsage mengembalikan pemakaian storage client; client tanpa baris memakai kuota default
+func (r *attachmentRepo) GetUsage(clientID int, defaultQuota int64) (*attachments.Usage, error) {
+	u := attachments.Usage{ClientID: clientID, QuotaBytes: defaultQuota}
+	err := r.db.QueryRow(
+		"SELECT used_bytes, quota_bytes FROM storage_usage WHERE client_id = $1", clientID,
+	).Scan(&u.UsedBytes, &u.QuotaBytes)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return &u, nil
+}
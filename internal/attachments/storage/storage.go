@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotExist = errors.New("object does not exist")
+
+// Storage adalah interface penyimpanan objek untuk isi attachment (filesystem lokal atau S3)
+type Storage interface {
+	Name() string
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get membuka objek untuk dibaca. ErrNotExist dikembalikan jika key tidak ada.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete menghapus objek; key yang tidak ada tidak dianggap error
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,83 @@
+package attachments
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif" // Decoder gif
+	"image/jpeg"
+	_ "image/png" // Decoder png
+)
+
+var ErrUnsupportedImage = errors.New("image format not supported for thumbnails")
+
+// Dimensions membaca ukuran gambar tanpa men-decode seluruh piksel
+func Dimensions(data []byte) (int, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, ErrUnsupportedImage
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// Thumbnail mengecilkan gambar sehingga sisi terpanjangnya maksimal size piksel dan
+// mengembalikannya sebagai JPEG. Gambar yang lebih kecil dari size tidak diperbesar.
+func Thumbnail(data []byte, size int) ([]byte, error) {
+	w, h, err := Dimensions(data)
+	if err != nil {
+		return nil, err
+	}
+	// Ukuran dicek sebelum decode agar gambar kecil dengan dimensi raksasa tidak menghabiskan memori
+	if w <= 0 || h <= 0 || w*h > MaxThumbnailPixels {
+		return nil, ErrUnsupportedImage
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, max(1, h*size/w)
+		} else {
+			tw, th = max(1, w*size/h), size
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, downscale(src, tw, th), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// downscale mengecilkan gambar dengan rata-rata area (box filter). Piksel transparan
+// digabungkan di atas latar putih karena JPEG tidak mendukung transparansi.
+func downscale(src image.Image, tw, th int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*sh/th, b.Min.Y+max((y+1)*sh/th, y*sh/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*sw/tw, b.Min.X+max((x+1)*sw/tw, x*sw/tw+1)
+
+			var r, g, bl, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					white := 0xffff - uint64(ca)
+					r += uint64(cr) + white
+					g += uint64(cg) + white
+					bl += uint64(cb) + white
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(bl / n >> 8), A: 0xff})
+		}
+	}
+	return dst
+}
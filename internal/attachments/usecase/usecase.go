@@ -0,0 +1,362 @@
+package usecase
+
+import (
+	"backend/internal/attachments"
+	"backend/internal/attachments/repository"
+	"backend/internal/attachments/storage"
+	"backend/internal/conversations"
+	"backend/internal/jobs"
+	"backend/pkg/logger"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+var (
+	ErrNotFound             = errors.New("attachment not found")
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrEmptyFile            = errors.New("file is empty")
+	ErrTooLarge             = errors.New("file exceeds maximum upload size")
+	ErrUnsupportedType      = errors.New("file type is not allowed")
+	ErrQuotaExceeded        = errors.New("storage quota exceeded")
+	ErrInvalidSignature     = errors.New("download link is invalid or expired")
+)
+
+// ConversationService dipakai untuk memastikan percakapan milik client
+type ConversationService interface {
+	GetConversation(clientID, id int) (*conversations.Conversation, error)
+}
+
+// JobEnqueuer dipakai untuk menjadwalkan pembuatan thumbnail di latar belakang
+type JobEnqueuer interface {
+	Enqueue(payload jobs.Payload, opts ...jobs.Option) (int, error)
+}
+
+// Upload adalah file yang diunggah agent atau diterima dari channel
+type Upload struct {
+	ClientID       int
+	ConversationID *int
+	MessageID      *int
+	UploadedBy     *int
+	FileName       string
+	Body           io.Reader
+}
+
+// GenerateThumbnail adalah job pembuatan thumbnail untuk attachment gambar
+type GenerateThumbnail struct {
+	AttachmentID int `json:"attachment_id"`
+}
+
+func (GenerateThumbnail) JobType() string { return "attachments.thumbnail" }
+
+// AttachmentUsecase mengelola upload, download bertanda tangan dan kuota storage
+type AttachmentUsecase interface {
+	Upload(ctx context.Context, u Upload) (*attachments.Attachment, error)
+	GetAttachment(clientID, id int) (*attachments.Attachment, error)
+	GetConversationAttachments(clientID, conversationID int) ([]attachments.Attachment, error)
+	DeleteAttachment(ctx context.Context, clientID, id int) error
+	// Open memverifikasi URL download bertanda tangan lalu membuka isi attachment
+	Open(ctx context.Context, id int, variant, expires, signature string) (*attachments.Attachment, io.ReadCloser, error)
+	GetUsage(clientID int) (*attachments.Usage, error)
+	GenerateThumbnail(ctx context.Context, p GenerateThumbnail) error
+}
+
+type attachmentUsecase struct {
+	repo       repository.AttachmentRepository
+	store      storage.Storage
+	convs      ConversationService
+	jobs       JobEnqueuer
+	signingKey []byte
+	baseURL    string
+	urlTTL     time.Duration
+	now        func() time.Time
+}
+
+// NewAttachmentUsecase membuat usecase attachment. URL download dibentuk dari baseURL
+// (URL publik aplikasi, boleh kosong untuk URL relatif) dan ditandatangani dengan signingKey.
+func NewAttachmentUsecase(repo repository.AttachmentRepository, store storage.Storage, convs ConversationService,
+	jobs JobEnqueuer, signingKey []byte, baseURL string) AttachmentUsecase {
+	return &attachmentUsecase{
+		repo:       repo,
+		store:      store,
+		convs:      convs,
+		jobs:       jobs,
+		signingKey: signingKey,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		urlTTL:     attachments.DefaultURLTTL,
+		now:        time.Now,
+	}
+}
+
+// DetectContentType menentukan tipe MIME dari isi file. Ekstensi dan Content-Type dari klien
+// tidak dipercaya; file yang isinya tidak termasuk AllowedTypes ditolak.
+func DetectContentType(data []byte) (string, string, error) {
+	detected := mimetype.Detect(data)
+	for t, kind := range attachments.AllowedTypes {
+		if detected.Is(t) {
+			return t, kind, nil
+		}
+	}
+	return "", "", fmt.Errorf("%w: %s", ErrUnsupportedType, detected.String())
+}
+
+// sanitizeFileName membuang path dan karakter kontrol dari nama file klien
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	name = strings.ToValidUTF8(name, "")
+	if name == "." || name == "/" || name == "" {
+		return "file"
+	}
+	return name
+}
+
+// storageKey membuat key acak per client sehingga nama file klien tidak pernah menjadi path
+func storageKey(clientID int, now time.Time) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%d/%s/%s", clientID, now.UTC().Format("2006/01"), hex.EncodeToString(b))
+}
+
+func (uc *attachmentUsecase) Upload(ctx context.Context, u Upload) (*attachments.Attachment, error) {
+	if u.ConversationID != nil {
+		if _, err := uc.convs.GetConversation(u.ClientID, *u.ConversationID); err != nil {
+			return nil, ErrConversationNotFound
+		}
+	}
+
+	// Dibaca satu byte melebihi batas untuk mendeteksi file yang terlalu besar
+	data, err := io.ReadAll(io.LimitReader(u.Body, attachments.MaxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyFile
+	}
+	if int64(len(data)) > attachments.MaxUploadSize {
+		return nil, ErrTooLarge
+	}
+	contentType, kind, err := DetectContentType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pengecekan awal agar file tidak diunggah ke storage jika kuota jelas tidak cukup
+	usage, err := uc.repo.GetUsage(u.ClientID, attachments.DefaultQuotaBytes)
+	if err != nil {
+		return nil, err
+	}
+	if usage.UsedBytes+int64(len(data)) > usage.QuotaBytes {
+		return nil, ErrQuotaExceeded
+	}
+
+	sum := sha256.Sum256(data)
+	a := &attachments.Attachment{
+		ClientID:       u.ClientID,
+		ConversationID: u.ConversationID,
+		MessageID:      u.MessageID,
+		UploadedBy:     u.UploadedBy,
+		FileName:       sanitizeFileName(u.FileName),
+		ContentType:    contentType,
+		Kind:           kind,
+		Size:           int64(len(data)),
+		Checksum:       hex.EncodeToString(sum[:]),
+		StorageKey:     storageKey(u.ClientID, uc.now()),
+	}
+	if kind == attachments.KindImage {
+		if w, h, err := attachments.Dimensions(data); err == nil {
+			a.Width, a.Height = &w, &h
+		}
+	}
+
+	if err := uc.store.Put(ctx, a.StorageKey, data, contentType); err != nil {
+		return nil, err
+	}
+	ok, err := uc.repo.Create(a, attachments.DefaultQuotaBytes)
+	if err != nil || !ok {
+		if delErr := uc.store.Delete(context.WithoutCancel(ctx), a.StorageKey); delErr != nil {
+			logger.From(ctx).Error("Failed to remove orphaned attachment object", "storage_key", a.StorageKey, "error", delErr)
+		}
+		if err == nil {
+			err = ErrQuotaExceeded
+		}
+		return nil, err
+	}
+
+	if a.Width != nil {
+		if _, err := uc.jobs.Enqueue(GenerateThumbnail{AttachmentID: a.ID}, jobs.ForClient(a.ClientID),
+			jobs.UniqueKey("attachments.thumbnail:"+strconv.Itoa(a.ID))); err != nil {
+			logger.From(ctx).Error("Failed to enqueue thumbnail", "attachment_id", a.ID, "error", err)
+		}
+	}
+	return uc.withURLs(a), nil
+}
+
+// withURLs mengisi URL download bertanda tangan yang berlaku selama urlTTL
+func (uc *attachmentUsecase) withURLs(a *attachments.Attachment) *attachments.Attachment {
+	expires := uc.now().Add(uc.urlTTL)
+	a.URL = uc.signedURL(a.ID, attachments.VariantOriginal, expires)
+	if a.ThumbnailKey != nil {
+		a.ThumbnailURL = uc.signedURL(a.ID, attachments.VariantThumbnail, expires)
+	}
+	return a
+}
+
+func (uc *attachmentUsecase) signedURL(id int, variant string, expires time.Time) string {
+	q := url.Values{}
+	if variant != attachments.VariantOriginal {
+		q.Set("variant", variant)
+	}
+	q.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	q.Set("signature", attachments.Sign(uc.signingKey, id, variant, expires))
+	return fmt.Sprintf("%s/files/%d?%s", uc.baseURL, id, q.Encode())
+}
+
+func (uc *attachmentUsecase) GetAttachment(clientID, id int) (*attachments.Attachment, error) {
+	a, err := uc.repo.GetAttachment(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && a.ClientID != clientID) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return uc.withURLs(a), nil
+}
+
+func (uc *attachmentUsecase) GetConversationAttachments(clientID, conversationID int) ([]attachments.Attachment, error) {
+	if _, err := uc.convs.GetConversation(clientID, conversationID); err != nil {
+		return nil, ErrConversationNotFound
+	}
+	list, err := uc.repo.FetchByConversation(clientID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		uc.withURLs(&list[i])
+	}
+	return list, nil
+}
+
+// DeleteAttachment menghapus metadata lebih dulu; objek yang gagal dihapus hanya dicatat karena
+// sudah tidak bisa diakses dan tidak lagi dihitung dalam kuota
+func (uc *attachmentUsecase) DeleteAttachment(ctx context.Context, clientID, id int) error {
+	a, err := uc.repo.Delete(clientID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	keys := []string{a.StorageKey}
+	if a.ThumbnailKey != nil {
+		keys = append(keys, *a.ThumbnailKey)
+	}
+	for _, key := range keys {
+		if err := uc.store.Delete(ctx, key); err != nil {
+			logger.From(ctx).Error("Failed to delete attachment object", "storage_key", key, "error", err)
+		}
+	}
+	return nil
+}
+
+func (uc *attachmentUsecase) Open(ctx context.Context, id int, variant, expires, signature string) (*attachments.Attachment, io.ReadCloser, error) {
+	if variant == "" {
+		variant = attachments.VariantOriginal
+	}
+	if !attachments.Verify(uc.signingKey, id, variant, expires, signature, uc.now()) {
+		return nil, nil, ErrInvalidSignature
+	}
+
+	a, err := uc.repo.GetAttachment(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := a.StorageKey
+	if variant == attachments.VariantThumbnail {
+		if a.ThumbnailKey == nil {
+			return nil, nil, ErrNotFound
+		}
+		key = *a.ThumbnailKey
+		a.ContentType = "image/jpeg"
+	}
+	body, err := uc.store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, body, nil
+}
+
+func (uc *attachmentUsecase) GetUsage(clientID int) (*attachments.Usage, error) {
+	return uc.repo.GetUsage(clientID, attachments.DefaultQuotaBytes)
+}
+
+// GenerateThumbnail membuat thumbnail JPEG untuk attachment gambar. Format yang tidak bisa
+// di-decode (misalnya webp) dilewati tanpa error agar job tidak diulang.
+func (uc *attachmentUsecase) GenerateThumbnail(ctx context.Context, p GenerateThumbnail) error {
+	a, err := uc.repo.GetAttachment(p.AttachmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // Attachment sudah dihapus
+	}
+	if err != nil {
+		return err
+	}
+	if a.Kind != attachments.KindImage || a.ThumbnailKey != nil {
+		return nil
+	}
+
+	body, err := uc.store.Get(ctx, a.StorageKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return err
+	}
+
+	thumb, err := attachments.Thumbnail(data, attachments.ThumbnailSize)
+	if errors.Is(err, attachments.ErrUnsupportedImage) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w, h, err := attachments.Dimensions(data)
+	if err != nil {
+		return nil
+	}
+
+	key := a.StorageKey + "_thumb.jpg"
+	if err := uc.store.Put(ctx, key, thumb, "image/jpeg"); err != nil {
+		return err
+	}
+	return uc.repo.SetThumbnail(a.ID, key, w, h)
+}
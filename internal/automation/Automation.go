@@ -0,0 +1,107 @@
+package automation
+
+import "time"
+
+// Event yang bisa memicu rule
+const (
+	EventConversationCreated = "conversation.created"
+	EventMessageReceived     = "message.received" // Pesan inbound dari pelanggan
+	EventStatusChanged       = "conversation.status_changed"
+	EventSLABreached         = "sla.breached"
+)
+
+// Tipe action rule
+const (
+	ActionAssign      = "assign"       // params: user_id, atau kosong untuk routing otomatis
+	ActionAssignTeam  = "assign_team"  // params: team_id
+	ActionTag         = "tag"          // params: tags (menambah tag kontak)
+	ActionSetPriority = "set_priority" // params: priority
+	ActionSendCanned  = "send_canned"  // params: canned_response_id (hanya canned response cakupan client)
+	ActionCallWebhook = "call_webhook" // params: url, secret (dibuat otomatis jika kosong)
+	ActionClose       = "close"        // params: status (resolved atau closed, default closed)
+)
+
+// Status eksekusi rule
+const (
+	ExecutionSuccess = "success"
+	ExecutionFailed  = "failed"
+	ExecutionSkipped = "skipped" // Dilewati oleh proteksi loop
+)
+
+// ValidEvent memeriksa apakah event bisa dipakai sebagai pemicu rule
+func ValidEvent(e string) bool {
+	switch e {
+	case EventConversationCreated, EventMessageReceived, EventStatusChanged, EventSLABreached:
+		return true
+	}
+	return false
+}
+
+// ValidAction memeriksa tipe action
+func ValidAction(t string) bool {
+	switch t {
+	case ActionAssign, ActionAssignTeam, ActionTag, ActionSetPriority, ActionSendCanned, ActionCallWebhook, ActionClose:
+		return true
+	}
+	return false
+}
+
+// Rule adalah otomasi milik client: saat Event terjadi dan Condition bernilai true,
+// semua Actions dijalankan berurutan
+type Rule struct {
+	ID        int       `json:"id"`
+	ClientID  int       `json:"client_id"`
+	Name      string    `json:"name"`
+	Event     string    `json:"event"`
+	Condition string    `json:"condition"` // Ekspresi kondisi; kosong berarti selalu cocok
+	Actions   []Action  `json:"actions"`
+	Position  int       `json:"position"`   // Urutan evaluasi, dari kecil ke besar
+	StopAfter bool      `json:"stop_after"` // Hentikan evaluasi rule berikutnya jika rule ini cocok
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Action adalah satu langkah yang dijalankan rule
+type Action struct {
+	Type   string                 `json:"type"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// Int mengambil parameter angka action
+func (a Action) Int(key string) (int, bool) {
+	switch v := a.Params[key].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	}
+	return 0, false
+}
+
+// String mengambil parameter string action
+func (a Action) String(key string) string {
+	s, _ := a.Params[key].(string)
+	return s
+}
+
+// Execution adalah catatan satu kali rule dijalankan
+type Execution struct {
+	ID             int            `json:"id"`
+	ClientID       int            `json:"client_id"`
+	RuleID         int            `json:"rule_id"`
+	ConversationID int            `json:"conversation_id"`
+	Event          string         `json:"event"`
+	Status         string         `json:"status"`
+	Results        []ActionResult `json:"results"`
+	Error          string         `json:"error,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// ActionResult adalah hasil satu action pada eksekusi
+type ActionResult struct {
+	Type    string `json:"type"`
+	OK      bool   `json:"ok"`
+	Skipped bool   `json:"skipped,omitempty"` // Action tidak mengubah apa pun, misalnya menutup percakapan yang sudah tertutup
+	Error   string `json:"error,omitempty"`
+}
@@ -0,0 +1,158 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/automation"
+	"backend/internal/automation/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AutomationHandler struct {
+	usecase usecase.AutomationUsecase
+}
+
+func NewAutomationHandler(uc usecase.AutomationUsecase) *AutomationHandler {
+	return &AutomationHandler{usecase: uc}
+}
+
+// invalidRule menyusun respons untuk rule yang tidak valid, termasuk posisi kesalahan sintaks kondisi
+func invalidRule(err error) gin.H {
+	body := gin.H{"error": "Invalid automation rule", "details": err.Error()}
+	var syntaxErr *automation.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		body["position"] = syntaxErr.Pos
+	}
+	return body
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Automation rule not found"})
+	case errors.Is(err, usecase.ErrInvalidRule):
+		c.JSON(http.StatusBadRequest, invalidRule(err))
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *AutomationHandler) GetRules(c *gin.Context) {
+	list, err := h.usecase.GetRules(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch automation rules"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *AutomationHandler) GetRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	r, err := h.usecase.GetRule(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch automation rule")
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AutomationHandler) CreateRule(c *gin.Context) {
+	var req automation.Rule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ClientID = c.GetInt("client_id")
+
+	id, err := h.usecase.CreateRule(req)
+	if err != nil {
+		respondError(c, err, "Failed to create automation rule")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Automation rule created", "id": id})
+}
+
+func (h *AutomationHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	var req automation.Rule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ID, req.ClientID = id, c.GetInt("client_id")
+
+	if err := h.usecase.UpdateRule(req); err != nil {
+		respondError(c, err, "Failed to update automation rule")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Automation rule updated", "id": id})
+}
+
+func (h *AutomationHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	if err := h.usecase.DeleteRule(c.GetInt("client_id"), id); err != nil {
+		respondError(c, err, "Failed to delete automation rule")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Automation rule deleted", "id": id})
+}
+
+// ValidateRule memeriksa rule tanpa menyimpannya, untuk umpan balik editor kondisi
+func (h *AutomationHandler) ValidateRule(c *gin.Context) {
+	var req automation.Rule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.usecase.ValidateRule(req); err != nil {
+		body := invalidRule(err)
+		body["valid"] = false
+		c.JSON(http.StatusOK, body)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": true})
+}
+
+// GetExecutions mengembalikan log eksekusi rule, bisa difilter dengan rule_id dan conversation_id
+func (h *AutomationHandler) GetExecutions(c *gin.Context) {
+	var ruleID, conversationID *int
+	for key, dst := range map[string]**int{"rule_id": &ruleID, "conversation_id": &conversationID} {
+		raw := c.Query(key)
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key})
+			return
+		}
+		*dst = &id
+	}
+
+	list, err := h.usecase.GetExecutions(c.GetInt("client_id"), ruleID, conversationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch automation executions"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
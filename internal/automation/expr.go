@@ -0,0 +1,501 @@
+package automation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Bahasa ekspresi kondisi rule, contoh:
+//
+//	conversation.channel == "sms" and (message.body contains "refund" or "vip" in contact.tags)
+//
+// Operator: == != < <= > >= contains startswith endswith matches in, and/or/not
+// (juga && || !), tanda kurung, literal string, angka, true/false/null dan list [..].
+// Field yang tidak ada bernilai null.
+
+// SyntaxError adalah kesalahan parsing ekspresi beserta posisinya
+type SyntaxError struct {
+	Pos int    `json:"pos"`
+	Msg string `json:"message"`
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %d: %s", e.Pos, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// keywordOps adalah operator berupa kata
+var keywordOps = map[string]bool{
+	"and": true, "or": true, "not": true, "contains": true, "startswith": true,
+	"endswith": true, "matches": true, "in": true,
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case r == '[':
+			tokens = append(tokens, token{tokLBracket, "[", i})
+			i++
+		case r == ']':
+			tokens = append(tokens, token{tokRBracket, "]", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case r == '"' || r == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, &SyntaxError{start, "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, token{tokString, sb.String(), start})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			word := string(runes[start:i])
+			if keywordOps[strings.ToLower(word)] {
+				tokens = append(tokens, token{tokOp, strings.ToLower(word), start})
+			} else {
+				tokens = append(tokens, token{tokIdent, word, start})
+			}
+		default:
+			start := i
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+			switch {
+			case two == "==" || two == "!=" || two == "<=" || two == ">=":
+				tokens = append(tokens, token{tokOp, two, start})
+				i += 2
+			case two == "&&":
+				tokens = append(tokens, token{tokOp, "and", start})
+				i += 2
+			case two == "||":
+				tokens = append(tokens, token{tokOp, "or", start})
+				i += 2
+			case r == '<' || r == '>':
+				tokens = append(tokens, token{tokOp, string(r), start})
+				i++
+			case r == '!':
+				tokens = append(tokens, token{tokOp, "not", start})
+				i++
+			default:
+				return nil, &SyntaxError{start, fmt.Sprintf("unexpected character %q", r)}
+			}
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(runes)}), nil
+}
+
+// node adalah simpul AST ekspresi
+type node interface {
+	eval(env map[string]interface{}) interface{}
+}
+
+type literalNode struct{ value interface{} }
+
+type pathNode struct{ path []string }
+
+type listNode struct{ items []node }
+
+type notNode struct{ operand node }
+
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+	re          *regexp.Regexp // Regex yang sudah dikompilasi untuk operator matches dengan literal
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "or" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{"or", left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "and" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{"and", left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.peek().kind == tokOp && p.peek().text == "not" {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand}, nil
+	}
+	return p.parseCompare()
+}
+
+var compareOps = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"contains": true, "startswith": true, "endswith": true, "matches": true, "in": true,
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokOp || !compareOps[t.text] {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &compareNode{op: t.text, left: left, right: right}
+	if t.text == "matches" {
+		lit, ok := right.(*literalNode)
+		pattern, isString := lit.valueString()
+		if !ok || !isString {
+			return nil, &SyntaxError{t.pos, "matches requires a string pattern"}
+		}
+		if n.re, err = regexp.Compile(pattern); err != nil {
+			return nil, &SyntaxError{t.pos, "invalid pattern: " + err.Error()}
+		}
+	}
+	return n, nil
+}
+
+func (l *literalNode) valueString() (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	s, ok := l.value.(string)
+	return s, ok
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return &literalNode{t.text}, nil
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, &SyntaxError{t.pos, "invalid number " + t.text}
+		}
+		return &literalNode{f}, nil
+	case tokIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return &literalNode{true}, nil
+		case "false":
+			return &literalNode{false}, nil
+		case "null", "nil":
+			return &literalNode{nil}, nil
+		}
+		return &pathNode{strings.Split(t.text, ".")}, nil
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, &SyntaxError{t.pos, "missing closing parenthesis"}
+		}
+		return n, nil
+	case tokLBracket:
+		list := &listNode{}
+		if p.peek().kind == tokRBracket {
+			p.next()
+			return list, nil
+		}
+		for {
+			item, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			list.items = append(list.items, item)
+			sep := p.next()
+			if sep.kind == tokRBracket {
+				return list, nil
+			}
+			if sep.kind != tokComma {
+				return nil, &SyntaxError{sep.pos, "expected , or ]"}
+			}
+		}
+	case tokEOF:
+		return nil, &SyntaxError{t.pos, "unexpected end of expression"}
+	}
+	return nil, &SyntaxError{t.pos, fmt.Sprintf("unexpected %q", t.text)}
+}
+
+// Expr adalah ekspresi kondisi yang sudah dikompilasi
+type Expr struct {
+	root node
+}
+
+// Compile mem-parsing ekspresi. Ekspresi kosong selalu bernilai true.
+func Compile(src string) (*Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return &Expr{root: &literalNode{true}}, nil
+	}
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return &Expr{root: root}, nil
+}
+
+// Eval mengevaluasi ekspresi terhadap env dan mengembalikan nilai kebenarannya
+func (e *Expr) Eval(env map[string]interface{}) bool {
+	return truthy(e.root.eval(env))
+}
+
+func (n *literalNode) eval(map[string]interface{}) interface{} { return n.value }
+
+func (n *pathNode) eval(env map[string]interface{}) interface{} {
+	var cur interface{} = env
+	for _, key := range n.path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[key]
+	}
+	return normalize(cur)
+}
+
+func (n *listNode) eval(env map[string]interface{}) interface{} {
+	items := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		items[i] = item.eval(env)
+	}
+	return items
+}
+
+func (n *notNode) eval(env map[string]interface{}) interface{} {
+	return !truthy(n.operand.eval(env))
+}
+
+func (n *logicalNode) eval(env map[string]interface{}) interface{} {
+	left := truthy(n.left.eval(env))
+	if n.op == "and" {
+		return left && truthy(n.right.eval(env))
+	}
+	return left || truthy(n.right.eval(env))
+}
+
+func (n *compareNode) eval(env map[string]interface{}) interface{} {
+	left, right := n.left.eval(env), n.right.eval(env)
+	switch n.op {
+	case "==":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	case "<", "<=", ">", ">=":
+		a, okA := left.(float64)
+		b, okB := right.(float64)
+		if !okA || !okB {
+			return false
+		}
+		switch n.op {
+		case "<":
+			return a < b
+		case "<=":
+			return a <= b
+		case ">":
+			return a > b
+		}
+		return a >= b
+	case "contains":
+		return contains(left, right)
+	case "in":
+		return contains(right, left)
+	case "startswith", "endswith":
+		s, okA := left.(string)
+		sub, okB := right.(string)
+		if !okA || !okB {
+			return false
+		}
+		s, sub = strings.ToLower(s), strings.ToLower(sub)
+		if n.op == "startswith" {
+			return strings.HasPrefix(s, sub)
+		}
+		return strings.HasSuffix(s, sub)
+	case "matches":
+		s, ok := left.(string)
+		return ok && n.re.MatchString(s)
+	}
+	return false
+}
+
+// normalize menyeragamkan tipe nilai env: angka menjadi float64 dan slice menjadi []interface{}
+func normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case *int:
+		if x == nil {
+			return nil
+		}
+		return float64(*x)
+	case []string:
+		items := make([]interface{}, len(x))
+		for i, s := range x {
+			items[i] = s
+		}
+		return items
+	}
+	return v
+}
+
+func truthy(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case string:
+		return x != ""
+	case float64:
+		return x != 0
+	case []interface{}:
+		return len(x) > 0
+	}
+	return true
+}
+
+// equal hanya membandingkan nilai skalar; list dan objek tidak pernah sama
+func equal(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	switch a.(type) {
+	case string, float64, bool:
+		return a == b
+	}
+	return false
+}
+
+// contains memeriksa substring (tanpa membedakan huruf besar/kecil) atau keanggotaan list
+func contains(container, item interface{}) bool {
+	switch c := container.(type) {
+	case string:
+		s, ok := item.(string)
+		return ok && strings.Contains(strings.ToLower(c), strings.ToLower(s))
+	case []interface{}:
+		for _, v := range c {
+			if equal(v, item) {
+				return true
+			}
+			if a, ok := v.(string); ok {
+				if b, ok := item.(string); ok && strings.EqualFold(a, b) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
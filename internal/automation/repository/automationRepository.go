@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"backend/internal/automation"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AutomationRepository adalah interface untuk rule automation dan log eksekusinya
+type AutomationRepository interface {
+	FetchRules(clientID int) ([]automation.Rule, error)
+	FetchActiveRules(clientID int, event string) ([]automation.Rule, error)
+	GetRule(id int) (*automation.Rule, error)
+	CreateRule(r automation.Rule) (int, error)
+	UpdateRule(r automation.Rule) error
+	DeleteRule(id int) error
+	LogExecution(e automation.Execution) error
+	CountExecutions(conversationID int, ruleID *int, since time.Time) (int, error)
+	FetchExecutions(clientID int, ruleID, conversationID *int, limit int) ([]automation.Execution, error)
+}
+
+type automationRepo struct {
+	db *sql.DB
+}
+
+func NewAutomationRepository(db *sql.DB) AutomationRepository {
+	return &automationRepo{db: db}
+}
+
+const ruleColumns = "rule_id, client_id, name, event, condition, actions, position, stop_after, active, created_at, updated_at"
+
+func scanRule(row interface{ Scan(...interface{}) error }) (*automation.Rule, error) {
+	var r automation.Rule
+	var actions []byte
+	err := row.Scan(&r.ID, &r.ClientID, &r.Name, &r.Event, &r.Condition, &actions, &r.Position, &r.StopAfter, &r.Active, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(actions, &r.Actions); err != nil {
+		return nil, fmt.Errorf("invalid actions for rule %d: %w", r.ID, err)
+	}
+	return &r, nil
+}
+
+func scanRules(rows *sql.Rows) ([]automation.Rule, error) {
+	defer rows.Close()
+
+	var list []automation.Rule
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *r)
+	}
+	return list, rows.Err()
+}
+
+func (r *automationRepo) FetchRules(clientID int) ([]automation.Rule, error) {
+	rows, err := r.db.Query("SELECT "+ruleColumns+" FROM automation_rules WHERE client_id = $1 ORDER BY event, position, rule_id", clientID)
+	if err != nil {
+		return nil, err
+	}
+	return scanRules(rows)
+}
+
+// FetchActiveRules mengambil rule aktif untuk satu event sesuai urutan evaluasi
+func (r *automationRepo) FetchActiveRules(clientID int, event string) ([]automation.Rule, error) {
+	rows, err := r.db.Query(
+		"SELECT "+ruleColumns+" FROM automation_rules WHERE client_id = $1 AND event = $2 AND active ORDER BY position, rule_id",
+		clientID, event,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanRules(rows)
+}
+
+func (r *automationRepo) GetRule(id int) (*automation.Rule, error) {
+	return scanRule(r.db.QueryRow("SELECT "+ruleColumns+" FROM automation_rules WHERE rule_id = $1", id))
+}
+
+func (r *automationRepo) CreateRule(rule automation.Rule) (int, error) {
+	actions, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	err = r.db.QueryRow(
+		`INSERT INTO automation_rules (client_id, name, event, condition, actions, position, stop_after, active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()) RETURNING rule_id`,
+		rule.ClientID, rule.Name, rule.Event, rule.Condition, actions, rule.Position, rule.StopAfter, rule.Active,
+	).Scan(&id)
+	return id, err
+}
+
+func (r *automationRepo) UpdateRule(rule automation.Rule) error {
+	actions, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(
+		`UPDATE automation_rules SET name = $1, event = $2, condition = $3, actions = $4, position = $5,
+		 stop_after = $6, active = $7, updated_at = NOW() WHERE rule_id = $8`,
+		rule.Name, rule.Event, rule.Condition, actions, rule.Position, rule.StopAfter, rule.Active, rule.ID,
+	)
+	return err
+}
+
+func (r *automationRepo) DeleteRule(id int) error {
+	_, err := r.db.Exec("DELETE FROM automation_rules WHERE rule_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete automation rule with id %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *automationRepo) LogExecution(e automation.Execution) error {
+	results, err := json.Marshal(e.Results)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(
+		`INSERT INTO automation_executions (client_id, rule_id, conversation_id, event, status, results, error, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())`,
+		e.ClientID, e.RuleID, e.ConversationID, e.Event, e.Status, results, e.Error,
+	)
+	return err
+}
+
+// CountExecutions menghitung eksekusi yang berhasil pada percakapan sejak waktu tertentu,
+// untuk satu rule atau semua rule jika ruleID nil
+func (r *automationRepo) CountExecutions(conversationID int, ruleID *int, since time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM automation_executions
+		 WHERE conversation_id = $1 AND ($2::int IS NULL OR rule_id = $2) AND status <> $3 AND created_at >= $4`,
+		conversationID, ruleID, automation.ExecutionSkipped, since,
+	).Scan(&n)
+	return n, err
+}
+
+func (r *automationRepo) FetchExecutions(clientID int, ruleID, conversationID *int, limit int) ([]automation.Execution, error) {
+	rows, err := r.db.Query(
+		`SELECT execution_id, client_id, rule_id, conversation_id, event, status, results, error, created_at
+		 FROM automation_executions
+		 WHERE client_id = $1 AND ($2::int IS NULL OR rule_id = $2) AND ($3::int IS NULL OR conversation_id = $3)
+		 ORDER BY created_at DESC LIMIT $4`,
+		clientID, ruleID, conversationID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []automation.Execution
+	for rows.Next() {
+		var e automation.Execution
+		var results []byte
+		if err := rows.Scan(&e.ID, &e.ClientID, &e.RuleID, &e.ConversationID, &e.Event, &e.Status, &results, &e.Error, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(results, &e.Results); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,636 @@
+package usecase
+
+import (
+	"backend/internal/automation"
+	"backend/internal/automation/repository"
+	"backend/internal/canned"
+	"backend/internal/contacts"
+	"backend/internal/conversations"
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/routing"
+	"backend/internal/sla"
+	"backend/internal/webhooks"
+	"backend/pkg/safehttp"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("automation rule not found")
+	ErrInvalidRule = errors.New("invalid automation rule")
+)
+
+// Batas proteksi loop: rule yang memicu event yang memicu dirinya sendiri akan berhenti di sini
+const (
+	maxRuleRuns         = 3 // Per rule per percakapan dalam ruleRunWindow
+	ruleRunWindow       = 5 * time.Minute
+	maxConversationRuns = 20 // Semua rule per percakapan dalam conversationRunWindow
+	conversationWindow  = time.Minute
+	minSecretLength     = 16
+	executionPageSize   = 100
+)
+
+// ConversationService membaca dan mengubah percakapan
+type ConversationService interface {
+	GetConversation(clientID, id int) (*conversations.Conversation, error)
+	UpdateStatus(clientID, id int, status string) error
+	SetPriority(clientID, id, priority int) error
+}
+
+// ContactService membaca kontak dan mengubah tag-nya
+type ContactService interface {
+	GetContact(clientID, id int) (*contacts.Contact, error)
+	SetTags(clientID, contactID int, tags []string) error
+}
+
+// Assigner mengassign percakapan ke agent tertentu atau melalui routing otomatis
+type Assigner interface {
+	RouteConversation(conv conversations.Conversation) (*routing.Assignment, error)
+	Assign(clientID, conversationID, toUserID, actorID int, note string) (*routing.Assignment, error)
+}
+
+// TeamQueue memasukkan percakapan ke antrean tim
+type TeamQueue interface {
+	Enqueue(clientID, conversationID, teamID int) error
+}
+
+// CannedSender mengirim canned response ke pelanggan pada percakapan
+type CannedSender interface {
+	Send(ctx context.Context, clientID int, agent canned.Agent, id, conversationID int) (*conversations.Message, error)
+}
+
+type AutomationUsecase interface {
+	GetRules(clientID int) ([]automation.Rule, error)
+	GetRule(clientID, id int) (*automation.Rule, error)
+	CreateRule(r automation.Rule) (int, error)
+	UpdateRule(r automation.Rule) error
+	DeleteRule(clientID, id int) error
+	ValidateRule(r automation.Rule) error
+	GetExecutions(clientID int, ruleID, conversationID *int) ([]automation.Execution, error)
+	HandleEvent(ctx context.Context, e events.Event) error
+	Process(ctx context.Context, e realtime.Event) error
+}
+
+// SourceEvents adalah tipe event outbox yang bisa memicu rule
+var SourceEvents = []string{
+	realtime.EventConversationCreated,
+	realtime.EventConversationStatusChanged,
+	realtime.EventMessageCreated,
+	realtime.EventSLABreached,
+}
+
+type automationUsecase struct {
+	repo          repository.AutomationRepository
+	conversations ConversationService
+	contacts      ContactService
+	assigner      Assigner
+	teams         TeamQueue
+	canned        CannedSender
+	client        *http.Client
+}
+
+func NewAutomationUsecase(repo repository.AutomationRepository, conv ConversationService, contacts ContactService, assigner Assigner, teams TeamQueue, cannedSender CannedSender, client *http.Client) AutomationUsecase {
+	// URL action call_webhook ditentukan tenant, sehingga client default menolak alamat internal
+	if client == nil {
+		client = safehttp.NewClient(10 * time.Second)
+	}
+	return &automationUsecase{
+		repo:          repo,
+		conversations: conv,
+		contacts:      contacts,
+		assigner:      assigner,
+		teams:         teams,
+		canned:        cannedSender,
+		client:        client,
+	}
+}
+
+func (u *automationUsecase) GetRules(clientID int) ([]automation.Rule, error) {
+	return u.repo.FetchRules(clientID)
+}
+
+func (u *automationUsecase) GetRule(clientID, id int) (*automation.Rule, error) {
+	r, err := u.repo.GetRule(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && r.ClientID != clientID) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+// ValidateRule memeriksa event, sintaks kondisi dan parameter setiap action.
+// Kesalahan sintaks kondisi dapat diambil dengan errors.As ke *automation.SyntaxError.
+func (u *automationUsecase) ValidateRule(r automation.Rule) error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+	if !automation.ValidEvent(r.Event) {
+		return fmt.Errorf("%w: unknown event %q", ErrInvalidRule, r.Event)
+	}
+	if _, err := automation.Compile(r.Condition); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRule, err)
+	}
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("%w: at least one action is required", ErrInvalidRule)
+	}
+	for i, a := range r.Actions {
+		if err := validateAction(a); err != nil {
+			return fmt.Errorf("%w: action %d (%s): %s", ErrInvalidRule, i+1, a.Type, err)
+		}
+	}
+	return nil
+}
+
+func validateAction(a automation.Action) error {
+	switch a.Type {
+	case automation.ActionAssign:
+		if _, ok := a.Params["user_id"]; ok {
+			if id, ok := a.Int("user_id"); !ok || id <= 0 {
+				return errors.New("user_id must be a positive number")
+			}
+		}
+	case automation.ActionAssignTeam:
+		if id, ok := a.Int("team_id"); !ok || id <= 0 {
+			return errors.New("team_id is required")
+		}
+	case automation.ActionTag:
+		if len(actionTags(a)) == 0 {
+			return errors.New("tags is required")
+		}
+	case automation.ActionSetPriority:
+		if p, ok := a.Int("priority"); !ok || p < conversations.PriorityNone || p > conversations.PriorityUrgent {
+			return errors.New("priority must be between 0 and 4")
+		}
+	case automation.ActionSendCanned:
+		if id, ok := a.Int("canned_response_id"); !ok || id <= 0 {
+			return errors.New("canned_response_id is required")
+		}
+	case automation.ActionCallWebhook:
+		if _, err := safehttp.CheckURL(a.String("url")); err != nil {
+			return errors.New("url must be an absolute http(s) URL to a public address")
+		}
+		if secret, ok := a.Params["secret"]; ok {
+			if s, _ := secret.(string); len(s) < minSecretLength {
+				return fmt.Errorf("secret must be at least %d characters", minSecretLength)
+			}
+		}
+	case automation.ActionClose:
+		switch a.String("status") {
+		case "", conversations.StatusResolved, conversations.StatusClosed:
+		default:
+			return errors.New("status must be resolved or closed")
+		}
+	default:
+		return errors.New("unknown action type")
+	}
+	return nil
+}
+
+// actionTags mengambil parameter tags sebagai daftar string yang tidak kosong
+func actionTags(a automation.Action) []string {
+	raw, _ := a.Params["tags"].([]interface{})
+	var tags []string
+	for _, v := range raw {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			tags = append(tags, strings.TrimSpace(s))
+		}
+	}
+	return tags
+}
+
+// webhookSecrets melengkapi secret penandatanganan action call_webhook yang belum punya secret.
+// Secret dari rule sebelumnya untuk URL yang sama dipertahankan agar penerima tidak perlu
+// mengganti konfigurasinya setiap kali rule diubah.
+func webhookSecrets(r automation.Rule, previous *automation.Rule) error {
+	existing := map[string]string{}
+	if previous != nil {
+		for _, a := range previous.Actions {
+			if a.Type == automation.ActionCallWebhook && a.String("secret") != "" {
+				existing[a.String("url")] = a.String("secret")
+			}
+		}
+	}
+	for _, a := range r.Actions {
+		if a.Type != automation.ActionCallWebhook || a.String("secret") != "" {
+			continue
+		}
+		secret, ok := existing[a.String("url")]
+		if !ok {
+			b := make([]byte, 32)
+			if _, err := rand.Read(b); err != nil {
+				return err
+			}
+			secret = "whsec_" + hex.EncodeToString(b)
+		}
+		a.Params["secret"] = secret
+	}
+	return nil
+}
+
+func (u *automationUsecase) CreateRule(r automation.Rule) (int, error) {
+	if err := u.ValidateRule(r); err != nil {
+		return 0, err
+	}
+	if err := webhookSecrets(r, nil); err != nil {
+		return 0, err
+	}
+	return u.repo.CreateRule(r)
+}
+
+func (u *automationUsecase) UpdateRule(r automation.Rule) error {
+	previous, err := u.GetRule(r.ClientID, r.ID)
+	if err != nil {
+		return err
+	}
+	if err := u.ValidateRule(r); err != nil {
+		return err
+	}
+	if err := webhookSecrets(r, previous); err != nil {
+		return err
+	}
+	return u.repo.UpdateRule(r)
+}
+
+func (u *automationUsecase) DeleteRule(clientID, id int) error {
+	if _, err := u.GetRule(clientID, id); err != nil {
+		return err
+	}
+	return u.repo.DeleteRule(id)
+}
+
+func (u *automationUsecase) GetExecutions(clientID int, ruleID, conversationID *int) ([]automation.Execution, error) {
+	return u.repo.FetchExecutions(clientID, ruleID, conversationID, executionPageSize)
+}
+
+// HandleEvent adalah subscriber event bus. Event dicatat di outbox bersama perubahan datanya,
+// sehingga rule tetap dijalankan walaupun proses berhenti atau handler gagal; error membuat
+// event dicoba lagi oleh relay.
+func (u *automationUsecase) HandleEvent(ctx context.Context, e events.Event) error {
+	re, err := fromOutbox(e)
+	if err != nil {
+		return err
+	}
+	return u.Process(ctx, re)
+}
+
+// fromOutbox membaca payload event outbox kembali menjadi data bertipe seperti yang
+// dikirim ke hub, sehingga Process bisa memakai keduanya
+func fromOutbox(e events.Event) (realtime.Event, error) {
+	re := realtime.Event{Type: e.Type, ClientID: e.ClientID, CreatedAt: e.OccurredAt}
+	var err error
+	switch e.Type {
+	case realtime.EventConversationCreated, realtime.EventConversationStatusChanged:
+		var conv conversations.Conversation
+		err = json.Unmarshal(e.Payload, &conv)
+		re.Data = &conv
+	case realtime.EventMessageCreated:
+		var m conversations.Message
+		err = json.Unmarshal(e.Payload, &m)
+		re.Data = &m
+	case realtime.EventSLABreached:
+		var t sla.Timer
+		err = json.Unmarshal(e.Payload, &t)
+		re.Data = t
+	}
+	if err != nil {
+		return re, fmt.Errorf("failed to decode %s event %s: %w", e.Type, e.ID, err)
+	}
+	return re, nil
+}
+
+// ruleEvent memetakan event real-time ke event pemicu rule
+func ruleEvent(e realtime.Event) string {
+	switch e.Type {
+	case realtime.EventConversationCreated:
+		return automation.EventConversationCreated
+	case realtime.EventConversationStatusChanged:
+		return automation.EventStatusChanged
+	case realtime.EventSLABreached:
+		return automation.EventSLABreached
+	case realtime.EventMessageCreated:
+		if m := eventMessage(e); m != nil && m.Direction == conversations.DirectionInbound {
+			return automation.EventMessageReceived
+		}
+	}
+	return ""
+}
+
+func eventMessage(e realtime.Event) *conversations.Message {
+	switch m := e.Data.(type) {
+	case *conversations.Message:
+		return m
+	case conversations.Message:
+		return &m
+	}
+	return nil
+}
+
+// eventConversationID mengambil ID percakapan yang menjadi subjek event
+func eventConversationID(e realtime.Event) int {
+	switch d := e.Data.(type) {
+	case *conversations.Conversation:
+		return d.ID
+	case conversations.Conversation:
+		return d.ID
+	case *conversations.Message:
+		return d.ConversationID
+	case conversations.Message:
+		return d.ConversationID
+	case sla.Timer:
+		return d.ConversationID
+	case *sla.Timer:
+		return d.ConversationID
+	}
+	return 0
+}
+
+// run adalah konteks satu evaluasi rule
+type run struct {
+	event   string
+	conv    *conversations.Conversation
+	contact *contacts.Contact
+	message *conversations.Message
+	timer   *sla.Timer
+}
+
+// env membangun data yang bisa dipakai di ekspresi kondisi
+func (r *run) env() map[string]interface{} {
+	env := map[string]interface{}{
+		"event": r.event,
+		"conversation": map[string]interface{}{
+			"id":               r.conv.ID,
+			"channel":          r.conv.Channel,
+			"status":           r.conv.Status,
+			"priority":         r.conv.Priority,
+			"team_id":          r.conv.TeamID,
+			"assignee_id":      r.conv.AssigneeID,
+			"assigned":         r.conv.AssigneeID != nil,
+			"required_skills":  r.conv.RequiredSkills,
+			"external_address": r.conv.ExternalAddress,
+		},
+	}
+	if r.contact != nil {
+		env["contact"] = map[string]interface{}{
+			"id":         r.contact.ID,
+			"name":       r.contact.Name,
+			"tags":       r.contact.Tags,
+			"attributes": r.contact.Attributes,
+		}
+	}
+	if r.message != nil {
+		env["message"] = map[string]interface{}{
+			"body":      r.message.Body,
+			"direction": r.message.Direction,
+			"channel":   r.message.Channel,
+		}
+	}
+	if r.timer != nil {
+		env["sla"] = map[string]interface{}{
+			"metric":    r.timer.Metric,
+			"policy_id": r.timer.PolicyID,
+		}
+	}
+	return env
+}
+
+// Process mengevaluasi rule aktif client untuk satu event secara berurutan
+func (u *automationUsecase) Process(ctx context.Context, e realtime.Event) error {
+	event := ruleEvent(e)
+	convID := eventConversationID(e)
+	if event == "" || convID == 0 {
+		return nil
+	}
+
+	rules, err := u.repo.FetchActiveRules(e.ClientID, event)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+
+	// Data dimuat ulang agar kondisi dievaluasi terhadap keadaan terbaru
+	conv, err := u.conversations.GetConversation(e.ClientID, convID)
+	if err != nil {
+		return err
+	}
+	r := &run{event: event, conv: conv, message: eventMessage(e)}
+	if t, ok := e.Data.(sla.Timer); ok {
+		r.timer = &t
+	}
+	if conv.ContactID != nil {
+		if r.contact, err = u.contacts.GetContact(e.ClientID, *conv.ContactID); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range rules {
+		matched, err := u.apply(ctx, rule, r)
+		if err != nil {
+			return err
+		}
+		if matched && rule.StopAfter {
+			break
+		}
+	}
+	return nil
+}
+
+// apply menjalankan satu rule jika kondisinya cocok dan mencatat eksekusinya
+func (u *automationUsecase) apply(ctx context.Context, rule automation.Rule, r *run) (bool, error) {
+	exec := automation.Execution{ClientID: rule.ClientID, RuleID: rule.ID, ConversationID: r.conv.ID, Event: r.event}
+
+	expr, err := automation.Compile(rule.Condition)
+	if err != nil {
+		exec.Status, exec.Error = automation.ExecutionFailed, err.Error()
+		return false, u.repo.LogExecution(exec)
+	}
+	if !expr.Eval(r.env()) {
+		return false, nil
+	}
+
+	if reason, err := u.loopGuard(rule.ID, r.conv.ID); err != nil {
+		return false, err
+	} else if reason != "" {
+		exec.Status, exec.Error = automation.ExecutionSkipped, reason
+		return true, u.repo.LogExecution(exec)
+	}
+
+	exec.Status = automation.ExecutionSuccess
+	for _, a := range rule.Actions {
+		skipped, err := u.execute(ctx, rule, a, r)
+		res := automation.ActionResult{Type: a.Type, OK: err == nil, Skipped: skipped}
+		if err != nil {
+			res.Error = err.Error()
+			exec.Status = automation.ExecutionFailed
+		}
+		exec.Results = append(exec.Results, res)
+	}
+	return true, u.repo.LogExecution(exec)
+}
+
+// loopGuard mengembalikan alasan jika rule harus dilewati karena terlalu sering berjalan
+func (u *automationUsecase) loopGuard(ruleID, conversationID int) (string, error) {
+	now := time.Now()
+	n, err := u.repo.CountExecutions(conversationID, &ruleID, now.Add(-ruleRunWindow))
+	if err != nil {
+		return "", err
+	}
+	if n >= maxRuleRuns {
+		return fmt.Sprintf("loop protection: rule ran %d times on this conversation in %s", n, ruleRunWindow), nil
+	}
+	n, err = u.repo.CountExecutions(conversationID, nil, now.Add(-conversationWindow))
+	if err != nil {
+		return "", err
+	}
+	if n >= maxConversationRuns {
+		return fmt.Sprintf("loop protection: %d rule executions on this conversation in %s", n, conversationWindow), nil
+	}
+	return "", nil
+}
+
+// execute menjalankan satu action. Nilai true berarti action dilewati karena tidak mengubah apa pun.
+// Keadaan lokal diperbarui agar rule berikutnya melihat hasilnya.
+func (u *automationUsecase) execute(ctx context.Context, rule automation.Rule, a automation.Action, r *run) (bool, error) {
+	conv := r.conv
+	switch a.Type {
+	case automation.ActionAssign:
+		userID, ok := a.Int("user_id")
+		if !ok {
+			if conv.AssigneeID != nil {
+				return true, nil
+			}
+			assignment, err := u.assigner.RouteConversation(*conv)
+			if err != nil || assignment == nil {
+				return assignment == nil && err == nil, err
+			}
+			conv.AssigneeID = &assignment.ToUserID
+			return false, nil
+		}
+		if conv.AssigneeID != nil && *conv.AssigneeID == userID {
+			return true, nil
+		}
+		if _, err := u.assigner.Assign(conv.ClientID, conv.ID, userID, 0, "automation: "+rule.Name); err != nil {
+			return false, err
+		}
+		conv.AssigneeID = &userID
+
+	case automation.ActionAssignTeam:
+		teamID, _ := a.Int("team_id")
+		if conv.TeamID != nil && *conv.TeamID == teamID {
+			return true, nil
+		}
+		if err := u.teams.Enqueue(conv.ClientID, conv.ID, teamID); err != nil {
+			return false, err
+		}
+		conv.TeamID = &teamID
+
+	case automation.ActionTag:
+		if r.contact == nil {
+			return false, errors.New("conversation has no contact")
+		}
+		tags := r.contact.Tags
+		for _, tag := range actionTags(a) {
+			if !hasTag(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		if len(tags) == len(r.contact.Tags) {
+			return true, nil
+		}
+		if err := u.contacts.SetTags(conv.ClientID, r.contact.ID, tags); err != nil {
+			return false, err
+		}
+		r.contact.Tags = tags
+
+	case automation.ActionSetPriority:
+		priority, _ := a.Int("priority")
+		if conv.Priority == priority {
+			return true, nil
+		}
+		if err := u.conversations.SetPriority(conv.ClientID, conv.ID, priority); err != nil {
+			return false, err
+		}
+		conv.Priority = priority
+
+	case automation.ActionSendCanned:
+		id, _ := a.Int("canned_response_id")
+		if _, err := u.canned.Send(ctx, conv.ClientID, canned.Agent{}, id, conv.ID); err != nil {
+			return false, err
+		}
+
+	case automation.ActionCallWebhook:
+		return false, u.callWebhook(ctx, rule, a, r)
+
+	case automation.ActionClose:
+		status := a.String("status")
+		if status == "" {
+			status = conversations.StatusClosed
+		}
+		if conv.Status == status {
+			return true, nil
+		}
+		if err := u.conversations.UpdateStatus(conv.ClientID, conv.ID, status); err != nil {
+			return false, err
+		}
+		conv.Status = status
+
+	default:
+		return false, fmt.Errorf("unknown action type %q", a.Type)
+	}
+	return false, nil
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// callWebhook mengirim konteks rule ke URL eksternal sebagai JSON yang ditandatangani dengan
+// secret action, memakai format header yang sama dengan webhook keluar
+func (u *automationUsecase) callWebhook(ctx context.Context, rule automation.Rule, a automation.Action, r *run) error {
+	secret := a.String("secret")
+	if secret == "" {
+		return errors.New("webhook secret is not configured")
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"rule_id":      rule.ID,
+		"rule_name":    rule.Name,
+		"event":        r.event,
+		"conversation": r.conv,
+		"contact":      r.contact,
+		"message":      r.message,
+		"sla":          r.timer,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.String("url"), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooks.HeaderEvent, r.event)
+	req.Header.Set(webhooks.HeaderSignature, webhooks.SignatureHeader(secret, time.Now(), payload))
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package businesshours
+
+import (
+	"backend/internal/sla"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSchedule dikembalikan jika jadwal, zona waktu atau tanggal libur tidak valid
+var ErrInvalidSchedule = errors.New("invalid business hours")
+
+// dateLayout adalah format tanggal hari libur
+const dateLayout = "2006-01-02"
+
+// weekdays memetakan nama hari pada JSON ke time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// Period adalah jam buka dalam format "HH:MM" waktu lokal; Close boleh "24:00"
+type Period struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// Schedule adalah jam kerja mingguan milik client, atau milik tim jika TeamID diisi
+type Schedule struct {
+	ClientID           int                 `json:"client_id"`
+	TeamID             *int                `json:"team_id"`
+	Timezone           string              `json:"timezone"` // Nama zona waktu IANA, misalnya "Asia/Jakarta"
+	Days               map[string][]Period `json:"days"`     // Kunci: "monday" ... "sunday"; hari tanpa periode berarti tutup
+	AutoReply          bool                `json:"auto_reply"`
+	OutOfOfficeMessage string              `json:"out_of_office_message"`
+	UpdatedAt          *time.Time          `json:"updated_at"`
+}
+
+// DefaultSchedule adalah Senin-Jumat 09:00-17:00, dipakai jika client belum mengatur jam kerja
+func DefaultSchedule(clientID int, timezone string) Schedule {
+	days := make(map[string][]Period)
+	for _, d := range []string{"monday", "tuesday", "wednesday", "thursday", "friday"} {
+		days[d] = []Period{{Open: "09:00", Close: "17:00"}}
+	}
+	return Schedule{ClientID: clientID, Timezone: timezone, Days: days}
+}
+
+// Holiday adalah hari tutup milik client, atau hanya untuk satu tim jika TeamID diisi
+type Holiday struct {
+	ID        int    `json:"id"`
+	ClientID  int    `json:"client_id"`
+	TeamID    *int   `json:"team_id"`
+	Date      string `json:"date"` // Format YYYY-MM-DD
+	Name      string `json:"name"`
+	Recurring bool   `json:"recurring"` // Berulang setiap tahun pada bulan dan tanggal yang sama
+}
+
+// Validate memeriksa tanggal hari libur
+func (h Holiday) Validate() error {
+	if _, err := time.Parse(dateLayout, h.Date); err != nil || strings.TrimSpace(h.Name) == "" {
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+// Matches memeriksa apakah hari libur jatuh pada tanggal lokal day
+func (h Holiday) Matches(day time.Time) bool {
+	date := day.Format(dateLayout)
+	if h.Recurring {
+		return date[5:] == h.Date[5:]
+	}
+	return date == h.Date
+}
+
+// OpenStatus adalah status buka pada suatu waktu
+type OpenStatus struct {
+	Open       bool       `json:"open"`
+	Timezone   string     `json:"timezone"`
+	NextOpenAt *time.Time `json:"next_open_at"` // Diisi jika sedang tutup
+	ClosesAt   *time.Time `json:"closes_at"`    // Diisi jika sedang buka
+}
+
+// parseClock mengubah "HH:MM" menjadi menit sejak tengah malam
+func parseClock(v string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(v, "%d:%d", &h, &m); err != nil || len(v) != 5 {
+		return 0, ErrInvalidSchedule
+	}
+	if h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, ErrInvalidSchedule
+	}
+	return h*60 + m, nil
+}
+
+// Calendar membangun kalender dari jadwal mingguan dan hari libur. Kalender dipakai
+// untuk status buka, balasan di luar jam kerja dan perhitungan SLA.
+func Calendar(s Schedule, holidays []Holiday) (sla.WeeklyCalendar, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil || s.Timezone == "" {
+		return sla.WeeklyCalendar{}, ErrInvalidSchedule
+	}
+
+	days := make(map[time.Weekday][]sla.Shift)
+	for name, periods := range s.Days {
+		weekday, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return sla.WeeklyCalendar{}, ErrInvalidSchedule
+		}
+		for _, p := range periods {
+			start, err := parseClock(p.Open)
+			if err != nil {
+				return sla.WeeklyCalendar{}, err
+			}
+			end, err := parseClock(p.Close)
+			if err != nil {
+				return sla.WeeklyCalendar{}, err
+			}
+			shift := sla.Shift{Start: start, End: end}
+			if !shift.Valid() {
+				return sla.WeeklyCalendar{}, ErrInvalidSchedule
+			}
+			days[weekday] = append(days[weekday], shift)
+		}
+	}
+
+	cal := sla.WeeklyCalendar{Location: loc, Days: days}
+	if len(holidays) > 0 {
+		cal.Closed = func(day time.Time) bool {
+			for _, h := range holidays {
+				if h.Matches(day) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return cal, nil
+}
+
+// StatusAt menghitung apakah kalender buka pada waktu now
+func StatusAt(cal sla.WeeklyCalendar, now time.Time) OpenStatus {
+	status := OpenStatus{Timezone: cal.Location.String()}
+	start, end, ok := cal.NextOpen(now)
+	if !ok {
+		return status
+	}
+	if start.After(now) {
+		next := start.In(cal.Location)
+		status.NextOpenAt = &next
+		return status
+	}
+
+	// Periode yang bersambung (misalnya 24:00 lalu 00:00 keesokan hari) dianggap satu periode buka,
+	// dibatasi satu minggu untuk jadwal 24 jam
+	for i := 0; i < 7; i++ {
+		nextStart, nextEnd, ok := cal.NextOpen(end)
+		if !ok || nextStart.After(end) {
+			break
+		}
+		end = nextEnd
+	}
+	closes := end.In(cal.Location)
+	status.Open, status.ClosesAt = true, &closes
+	return status
+}
@@ -0,0 +1,153 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/businesshours"
+	"backend/internal/businesshours/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BusinessHoursHandler struct {
+	usecase usecase.BusinessHoursUsecase
+}
+
+func NewBusinessHoursHandler(uc usecase.BusinessHoursUsecase) *BusinessHoursHandler {
+	return &BusinessHoursHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, businesshours.ErrInvalidSchedule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid business hours"})
+	case errors.Is(err, usecase.ErrTeamNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+	case errors.Is(err, usecase.ErrHolidayNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Holiday not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// teamQuery membaca query team_id opsional; false jika nilainya tidak valid
+func teamQuery(c *gin.Context) (*int, bool) {
+	v := c.Query("team_id")
+	if v == "" {
+		return nil, true
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return nil, false
+	}
+	return &id, true
+}
+
+// GetSchedule mengembalikan jadwal yang berlaku untuk client atau tim (query team_id)
+func (h *BusinessHoursHandler) GetSchedule(c *gin.Context) {
+	teamID, ok := teamQuery(c)
+	if !ok {
+		return
+	}
+
+	s, err := h.usecase.GetSchedule(c.GetInt("client_id"), teamID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch business hours")
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// SaveSchedule menyimpan jadwal client, atau jadwal tim jika team_id diisi
+func (h *BusinessHoursHandler) SaveSchedule(c *gin.Context) {
+	var req businesshours.Schedule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ClientID = c.GetInt("client_id")
+
+	if err := h.usecase.SaveSchedule(req); err != nil {
+		respondError(c, err, "Failed to save business hours")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Business hours updated"})
+}
+
+func (h *BusinessHoursHandler) DeleteTeamSchedule(c *gin.Context) {
+	teamID, err := strconv.Atoi(c.Param("team_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	if err := h.usecase.DeleteTeamSchedule(c.GetInt("client_id"), teamID); err != nil {
+		respondError(c, err, "Failed to delete business hours")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Team business hours deleted", "team_id": teamID})
+}
+
+// GetStatus mengembalikan apakah client/tim sedang buka beserta waktu buka atau tutup berikutnya
+func (h *BusinessHoursHandler) GetStatus(c *gin.Context) {
+	teamID, ok := teamQuery(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.usecase.Status(c.GetInt("client_id"), teamID, time.Now())
+	if err != nil {
+		respondError(c, err, "Failed to fetch business hours status")
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func (h *BusinessHoursHandler) GetHolidays(c *gin.Context) {
+	teamID, ok := teamQuery(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.usecase.GetHolidays(c.GetInt("client_id"), teamID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch holidays")
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *BusinessHoursHandler) CreateHoliday(c *gin.Context) {
+	var req businesshours.Holiday
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ClientID = c.GetInt("client_id")
+
+	id, err := h.usecase.CreateHoliday(req)
+	if err != nil {
+		respondError(c, err, "Failed to create holiday")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Holiday created", "id": id})
+}
+
+func (h *BusinessHoursHandler) DeleteHoliday(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid holiday ID"})
+		return
+	}
+
+	if err := h.usecase.DeleteHoliday(c.GetInt("client_id"), id); err != nil {
+		respondError(c, err, "Failed to delete holiday")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Holiday deleted", "id": id})
+}
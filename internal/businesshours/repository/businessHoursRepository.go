@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"backend/internal/businesshours"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// BusinessHoursRepository adalah interface untuk jadwal jam kerja dan hari libur
+type BusinessHoursRepository interface {
+	GetSchedule(clientID int, teamID *int) (*businesshours.Schedule, error)
+	SaveSchedule(s businesshours.Schedule) error
+	DeleteSchedule(clientID, teamID int) error
+	FetchHolidays(clientID int, teamID *int) ([]businesshours.Holiday, error)
+	GetHoliday(id int) (*businesshours.Holiday, error)
+	CreateHoliday(h businesshours.Holiday) (int, error)
+	DeleteHoliday(id int) error
+	GetTimezone(clientID int) (string, error)
+	TeamBelongsToClient(teamID, clientID int) (bool, error)
+}
+
+type businessHoursRepo struct {
+	db *sql.DB
+}
+
+func NewBusinessHoursRepository(db *sql.DB) BusinessHoursRepository {
+	return &businessHoursRepo{db: db}
+}
+
+// GetSchedule mengambil jadwal milik client (teamID nil) atau milik tim secara tepat,
+// tanpa fallback ke jadwal client
+func (r *businessHoursRepo) GetSchedule(clientID int, teamID *int) (*businesshours.Schedule, error) {
+	s := businesshours.Schedule{ClientID: clientID, TeamID: teamID}
+	var days []byte
+	err := r.db.QueryRow(
+		`SELECT timezone, days, auto_reply, out_of_office_message, updated_at FROM business_hours
+		 WHERE client_id = $1 AND team_id IS NOT DISTINCT FROM $2`, clientID, teamID,
+	).Scan(&s.Timezone, &days, &s.AutoReply, &s.OutOfOfficeMessage, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(days, &s.Days); err != nil {
+		return nil, fmt.Errorf("invalid business hours for client %d: %w", clientID, err)
+	}
+	return &s, nil
+}
+
+func (r *businessHoursRepo) SaveSchedule(s businesshours.Schedule) error {
+	days, err := json.Marshal(s.Days)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(
+		`INSERT INTO business_hours (client_id, team_id, timezone, days, auto_reply, out_of_office_message, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		 ON CONFLICT (client_id, (COALESCE(team_id, 0))) DO UPDATE SET timezone = EXCLUDED.timezone, days = EXCLUDED.days,
+		 auto_reply = EXCLUDED.auto_reply, out_of_office_message = EXCLUDED.out_of_office_message, updated_at = NOW()`,
+		s.ClientID, s.TeamID, s.Timezone, days, s.AutoReply, s.OutOfOfficeMessage,
+	)
+	return err
+}
+
+func (r *businessHoursRepo) DeleteSchedule(clientID, teamID int) error {
+	_, err := r.db.Exec("DELETE FROM business_hours WHERE client_id = $1 AND team_id = $2", clientID, teamID)
+	return err
+}
+
+// FetchHolidays mengambil hari libur client. Jika teamID diisi, hari libur khusus tim ikut diambil.
+func (r *businessHoursRepo) FetchHolidays(clientID int, teamID *int) ([]businesshours.Holiday, error) {
+	rows, err := r.db.Query(
+		`SELECT holiday_id, client_id, team_id, to_char(date, 'YYYY-MM-DD'), name, recurring FROM holidays
+		 WHERE client_id = $1 AND (team_id IS NULL OR team_id = $2) ORDER BY date`, clientID, teamID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []businesshours.Holiday
+	for rows.Next() {
+		h, err := scanHoliday(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *h)
+	}
+	return list, rows.Err()
+}
+
+func scanHoliday(row interface{ Scan(...interface{}) error }) (*businesshours.Holiday, error) {
+	var h businesshours.Holiday
+	var teamID sql.NullInt64
+	if err := row.Scan(&h.ID, &h.ClientID, &teamID, &h.Date, &h.Name, &h.Recurring); err != nil {
+		return nil, err
+	}
+	if teamID.Valid {
+		id := int(teamID.Int64)
+		h.TeamID = &id
+	}
+	return &h, nil
+}
+
+func (r *businessHoursRepo) GetHoliday(id int) (*businesshours.Holiday, error) {
+	return scanHoliday(r.db.QueryRow(
+		"SELECT holiday_id, client_id, team_id, to_char(date, 'YYYY-MM-DD'), name, recurring FROM holidays WHERE holiday_id = $1", id,
+	))
+}
+
+func (r *businessHoursRepo) CreateHoliday(h businesshours.Holiday) (int, error) {
+	var id int
+	err := r.db.QueryRow(
+		"INSERT INTO holidays (client_id, team_id, date, name, recurring) VALUES ($1, $2, $3, $4, $5) RETURNING holiday_id",
+		h.ClientID, h.TeamID, h.Date, h.Name, h.Recurring,
+	).Scan(&id)
+	return id, err
+}
+
+func (r *businessHoursRepo) DeleteHoliday(id int) error {
+	_, err := r.db.Exec("DELETE FROM holidays WHERE holiday_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete holiday with id %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetTimezone mengambil zona waktu IANA client, default UTC
+func (r *businessHoursRepo) GetTimezone(clientID int) (string, error) {
+	var tz string
+	err := r.db.QueryRow("SELECT timezone FROM clients WHERE client_id = $1", clientID).Scan(&tz)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && tz == "") {
+		return "UTC", nil
+	}
+	return tz, err
+}
+
+func (r *businessHoursRepo) TeamBelongsToClient(teamID, clientID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM teams WHERE team_id = $1 AND client_id = $2)", teamID, clientID).Scan(&exists)
+	return exists, err
+}
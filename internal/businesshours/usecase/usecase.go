@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"backend/internal/businesshours"
+	"backend/internal/businesshours/repository"
+	"backend/internal/sla"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTeamNotFound    = errors.New("team not found")
+	ErrHolidayNotFound = errors.New("holiday not found")
+)
+
+type BusinessHoursUsecase interface {
+	GetSchedule(clientID int, teamID *int) (*businesshours.Schedule, error)
+	SaveSchedule(s businesshours.Schedule) error
+	DeleteTeamSchedule(clientID, teamID int) error
+	GetHolidays(clientID int, teamID *int) ([]businesshours.Holiday, error)
+	CreateHoliday(h businesshours.Holiday) (int, error)
+	DeleteHoliday(clientID, id int) error
+	Status(clientID int, teamID *int, now time.Time) (*businesshours.OpenStatus, error)
+	OutOfOffice(clientID int, teamID *int, at time.Time) (string, bool, error)
+	Calendar(clientID int, teamID *int) (sla.Calendar, error)
+}
+
+type businessHoursUsecase struct {
+	repo repository.BusinessHoursRepository
+}
+
+func NewBusinessHoursUsecase(repo repository.BusinessHoursRepository) BusinessHoursUsecase {
+	return &businessHoursUsecase{repo: repo}
+}
+
+func (u *businessHoursUsecase) checkTeam(clientID int, teamID *int) error {
+	if teamID == nil {
+		return nil
+	}
+	ok, err := u.repo.TeamBelongsToClient(*teamID, clientID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTeamNotFound
+	}
+	return nil
+}
+
+// GetSchedule mengambil jadwal yang berlaku: jadwal tim, lalu jadwal client,
+// lalu jadwal default pada zona waktu client
+func (u *businessHoursUsecase) GetSchedule(clientID int, teamID *int) (*businesshours.Schedule, error) {
+	if err := u.checkTeam(clientID, teamID); err != nil {
+		return nil, err
+	}
+	if teamID != nil {
+		s, err := u.repo.GetSchedule(clientID, teamID)
+		if err == nil {
+			return s, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	s, err := u.repo.GetSchedule(clientID, nil)
+	if errors.Is(err, sql.ErrNoRows) {
+		tz, err := u.repo.GetTimezone(clientID)
+		if err != nil {
+			return nil, err
+		}
+		def := businesshours.DefaultSchedule(clientID, tz)
+		return &def, nil
+	}
+	return s, err
+}
+
+func (u *businessHoursUsecase) SaveSchedule(s businesshours.Schedule) error {
+	if err := u.checkTeam(s.ClientID, s.TeamID); err != nil {
+		return err
+	}
+	if s.Timezone == "" {
+		tz, err := u.repo.GetTimezone(s.ClientID)
+		if err != nil {
+			return err
+		}
+		s.Timezone = tz
+	}
+	if _, err := businesshours.Calendar(s, nil); err != nil {
+		return err
+	}
+	s.OutOfOfficeMessage = strings.TrimSpace(s.OutOfOfficeMessage)
+	if s.AutoReply && s.OutOfOfficeMessage == "" {
+		return businesshours.ErrInvalidSchedule
+	}
+	return u.repo.SaveSchedule(s)
+}
+
+// DeleteTeamSchedule menghapus jadwal khusus tim sehingga tim kembali memakai jadwal client
+func (u *businessHoursUsecase) DeleteTeamSchedule(clientID, teamID int) error {
+	if err := u.checkTeam(clientID, &teamID); err != nil {
+		return err
+	}
+	return u.repo.DeleteSchedule(clientID, teamID)
+}
+
+func (u *businessHoursUsecase) GetHolidays(clientID int, teamID *int) ([]businesshours.Holiday, error) {
+	if err := u.checkTeam(clientID, teamID); err != nil {
+		return nil, err
+	}
+	return u.repo.FetchHolidays(clientID, teamID)
+}
+
+func (u *businessHoursUsecase) CreateHoliday(h businesshours.Holiday) (int, error) {
+	if err := h.Validate(); err != nil {
+		return 0, err
+	}
+	if err := u.checkTeam(h.ClientID, h.TeamID); err != nil {
+		return 0, err
+	}
+	return u.repo.CreateHoliday(h)
+}
+
+func (u *businessHoursUsecase) DeleteHoliday(clientID, id int) error {
+	h, err := u.repo.GetHoliday(id)
+	if err != nil || h.ClientID != clientID {
+		return ErrHolidayNotFound
+	}
+	return u.repo.DeleteHoliday(id)
+}
+
+// calendar membangun kalender jadwal yang berlaku beserta hari libur client dan tim
+func (u *businessHoursUsecase) calendar(clientID int, teamID *int) (*businesshours.Schedule, sla.WeeklyCalendar, error) {
+	s, err := u.GetSchedule(clientID, teamID)
+	if err != nil {
+		return nil, sla.WeeklyCalendar{}, err
+	}
+	holidays, err := u.repo.FetchHolidays(clientID, teamID)
+	if err != nil {
+		return nil, sla.WeeklyCalendar{}, err
+	}
+	cal, err := businesshours.Calendar(*s, holidays)
+	return s, cal, err
+}
+
+// Calendar menyediakan kalender jam kerja untuk SLA; jam SLA berhenti di luar jam kerja dan hari libur
+func (u *businessHoursUsecase) Calendar(clientID int, teamID *int) (sla.Calendar, error) {
+	_, cal, err := u.calendar(clientID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+func (u *businessHoursUsecase) Status(clientID int, teamID *int, now time.Time) (*businesshours.OpenStatus, error) {
+	_, cal, err := u.calendar(clientID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	status := businesshours.StatusAt(cal, now)
+	return &status, nil
+}
+
+// OutOfOffice mengembalikan pesan balasan otomatis jika waktu at di luar jam kerja
+// dan balasan otomatis diaktifkan
+func (u *businessHoursUsecase) OutOfOffice(clientID int, teamID *int, at time.Time) (string, bool, error) {
+	s, cal, err := u.calendar(clientID, teamID)
+	if err != nil || !s.AutoReply {
+		return "", false, err
+	}
+	if businesshours.StatusAt(cal, at).Open {
+		return "", false, nil
+	}
+	return s.OutOfOfficeMessage, true, nil
+}
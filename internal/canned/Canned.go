@@ -0,0 +1,81 @@
+package canned
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Cakupan canned response
+const (
+	ScopeClient = "client" // Terlihat oleh semua agent client
+	ScopeTeam   = "team"   // Terlihat oleh anggota tim
+	ScopeUser   = "user"   // Hanya milik satu agent
+)
+
+// Response adalah balasan tersimpan yang bisa dipakai agent di composer
+type Response struct {
+	ID          int          `json:"id"`
+	ClientID    int          `json:"client_id"`
+	Scope       string       `json:"scope"`
+	TeamID      *int         `json:"team_id"`
+	UserID      *int         `json:"user_id"`
+	Shortcode   string       `json:"shortcode"` // Diketik agent setelah "/", misalnya "/refund"
+	Title       string       `json:"title"`
+	Body        string       `json:"body"` // Boleh berisi variabel seperti {{contact.name}}
+	Category    string       `json:"category"`
+	Attachments []Attachment `json:"attachments"`
+	CreatedBy   int          `json:"created_by"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// Attachment adalah file yang ikut dikirim bersama canned response
+type Attachment struct {
+	URL         string `json:"url"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+}
+
+// Agent adalah user yang memakai canned response, diambil dari klaim JWT
+type Agent struct {
+	UserID   int
+	Username string
+}
+
+// Rendered adalah hasil interpolasi canned response untuk satu percakapan
+type Rendered struct {
+	ResponseID  int          `json:"response_id"`
+	Body        string       `json:"body"`
+	Attachments []Attachment `json:"attachments"`
+	Missing     []string     `json:"missing"` // Variabel tanpa nilai dan tanpa default
+}
+
+// variablePattern mencocokkan {{nama.variabel}} atau {{nama.variabel|default}}
+var variablePattern = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_.]+)\s*(?:\|([^}]*))?\}\}`)
+
+// Render mengganti variabel template dengan nilainya. Variabel tanpa nilai memakai
+// default setelah "|" jika ada, selain itu diganti string kosong dan dilaporkan di missing.
+func Render(body string, vars map[string]string) (string, []string) {
+	missing := []string{}
+	out := variablePattern.ReplaceAllStringFunc(body, func(match string) string {
+		parts := variablePattern.FindStringSubmatch(match)
+		name, fallback := strings.ToLower(parts[1]), strings.TrimSpace(parts[2])
+		if v, ok := vars[name]; ok && v != "" {
+			return v
+		}
+		if fallback != "" {
+			return fallback
+		}
+		missing = append(missing, name)
+		return ""
+	})
+	return out, missing
+}
+
+// ValidShortcode memeriksa shortcode: huruf kecil, angka, "-" atau "_"
+func ValidShortcode(s string) bool {
+	return shortcodePattern.MatchString(s)
+}
+
+var shortcodePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
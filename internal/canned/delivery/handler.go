@@ -0,0 +1,185 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/canned"
+	"backend/internal/canned/repository"
+	"backend/internal/canned/usecase"
+	"backend/pkg/logger"
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CannedHandler struct {
+	usecase usecase.CannedUsecase
+}
+
+func NewCannedHandler(uc usecase.CannedUsecase) *CannedHandler {
+	return &CannedHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Canned response not found"})
+	case errors.Is(err, usecase.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+	case errors.Is(err, usecase.ErrInvalidResponse):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid canned response"})
+	case errors.Is(err, repository.ErrDuplicateShortcode):
+		c.JSON(http.StatusConflict, gin.H{"error": "Shortcode already exists"})
+	case errors.Is(err, usecase.ErrMissingVariables):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrUnsupportedChannel):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Channel does not support replies"})
+	default:
+		logger.From(c.Request.Context()).Error(fallback, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// agentFrom mengambil agent yang login dari klaim JWT
+func agentFrom(c *gin.Context) canned.Agent {
+	if v, ok := c.Get("claims"); ok {
+		if claims, ok := v.(*utils.Claims); ok {
+			return canned.Agent{UserID: claims.UserID, Username: claims.Username}
+		}
+	}
+	return canned.Agent{UserID: c.GetInt("user_id"), Username: c.GetString("username")}
+}
+
+func (h *CannedHandler) GetResponses(c *gin.Context) {
+	list, err := h.usecase.GetResponses(c.GetInt("client_id"), agentFrom(c), c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch canned responses"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// Search mencari canned response berdasarkan shortcode, judul atau isi (query q)
+func (h *CannedHandler) Search(c *gin.Context) {
+	list, err := h.usecase.Search(c.GetInt("client_id"), agentFrom(c), c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search canned responses"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *CannedHandler) GetResponse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid canned response ID"})
+		return
+	}
+
+	r, err := h.usecase.GetResponse(c.GetInt("client_id"), agentFrom(c), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch canned response")
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *CannedHandler) CreateResponse(c *gin.Context) {
+	var req canned.Response
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ClientID = c.GetInt("client_id")
+
+	id, err := h.usecase.CreateResponse(req, agentFrom(c))
+	if err != nil {
+		respondError(c, err, "Failed to create canned response")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Canned response created", "id": id})
+}
+
+func (h *CannedHandler) UpdateResponse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid canned response ID"})
+		return
+	}
+
+	var req canned.Response
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ID, req.ClientID = id, c.GetInt("client_id")
+
+	if err := h.usecase.UpdateResponse(req, agentFrom(c)); err != nil {
+		respondError(c, err, "Failed to update canned response")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Canned response updated", "id": id})
+}
+
+func (h *CannedHandler) DeleteResponse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid canned response ID"})
+		return
+	}
+
+	if err := h.usecase.DeleteResponse(c.GetInt("client_id"), agentFrom(c), id); err != nil {
+		respondError(c, err, "Failed to delete canned response")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Canned response deleted", "id": id})
+}
+
+type conversationRequest struct {
+	ConversationID int `json:"conversation_id" binding:"required"`
+}
+
+// Render mengembalikan isi canned response yang sudah diinterpolasi untuk ditinjau di composer
+func (h *CannedHandler) Render(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid canned response ID"})
+		return
+	}
+	var req conversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "conversation_id is required"})
+		return
+	}
+
+	rendered, err := h.usecase.Render(c.GetInt("client_id"), agentFrom(c), id, req.ConversationID)
+	if err != nil {
+		respondError(c, err, "Failed to render canned response")
+		return
+	}
+	c.JSON(http.StatusOK, rendered)
+}
+
+// Send mengirim canned response ke pelanggan pada percakapan atas nama agent yang login
+func (h *CannedHandler) Send(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid canned response ID"})
+		return
+	}
+	var req conversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "conversation_id is required"})
+		return
+	}
+
+	msg, err := h.usecase.Send(c.Request.Context(), c.GetInt("client_id"), agentFrom(c), id, req.ConversationID)
+	if err != nil {
+		respondError(c, err, "Failed to send canned response")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Canned response sent", "data": msg})
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"backend/internal/canned"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ErrDuplicateShortcode dikembalikan jika shortcode sudah dipakai pada cakupan yang sama
+var ErrDuplicateShortcode = errors.New("shortcode already exists")
+
+// searchLimit membatasi hasil pencarian untuk composer agent
+const searchLimit = 20
+
+// CannedRepository adalah interface untuk repository canned response
+type CannedRepository interface {
+	FetchVisible(clientID, userID int, query, category string) ([]canned.Response, error)
+	GetByID(id int) (*canned.Response, error)
+	Create(r canned.Response) (int, error)
+	Update(r canned.Response) error
+	Delete(id int) error
+	IsTeamMember(teamID, userID int) (bool, error)
+}
+
+type cannedRepo struct {
+	db *sql.DB
+}
+
+func NewCannedRepository(db *sql.DB) CannedRepository {
+	return &cannedRepo{db: db}
+}
+
+const cannedColumns = "canned_response_id, client_id, team_id, user_id, shortcode, title, body, category, attachments, created_by, created_at, updated_at"
+
+func scanResponse(row interface{ Scan(...interface{}) error }) (*canned.Response, error) {
+	var r canned.Response
+	var teamID, userID sql.NullInt64
+	var attachments []byte
+	err := row.Scan(&r.ID, &r.ClientID, &teamID, &userID, &r.Shortcode, &r.Title, &r.Body, &r.Category, &attachments, &r.CreatedBy, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	r.Scope = canned.ScopeClient
+	if teamID.Valid {
+		id := int(teamID.Int64)
+		r.TeamID, r.Scope = &id, canned.ScopeTeam
+	}
+	if userID.Valid {
+		id := int(userID.Int64)
+		r.UserID, r.Scope = &id, canned.ScopeUser
+	}
+	if err := json.Unmarshal(attachments, &r.Attachments); err != nil {
+		return nil, fmt.Errorf("invalid attachments for canned response %d: %w", r.ID, err)
+	}
+	return &r, nil
+}
+
+// FetchVisible mengambil canned response yang boleh dipakai user: milik client, milik tim
+// tempat user menjadi anggota, dan milik user sendiri. Jika query diisi, hasil dicari dari
+// shortcode, judul dan isi dengan prefix shortcode di urutan teratas.
+func (r *cannedRepo) FetchVisible(clientID, userID int, query, category string) ([]canned.Response, error) {
+	q := "SELECT " + cannedColumns + ` FROM canned_responses
+		WHERE client_id = $1
+		AND ((team_id IS NULL AND user_id IS NULL) OR user_id = $2
+			OR team_id IN (SELECT team_id FROM team_members WHERE user_id = $2))
+		AND ($3 = '' OR category = $3)`
+	args := []interface{}{clientID, userID, category}
+
+	if query = strings.TrimSpace(query); query != "" {
+		pattern := "%" + escapeLike(query) + "%"
+		q += ` AND (shortcode ILIKE $4 OR title ILIKE $5 OR body ILIKE $5)
+			ORDER BY (shortcode ILIKE $4) DESC, user_id IS NULL, team_id IS NULL, shortcode LIMIT ` + fmt.Sprint(searchLimit)
+		args = append(args, escapeLike(strings.TrimPrefix(query, "/"))+"%", pattern)
+	} else {
+		q += " ORDER BY category, shortcode"
+	}
+
+	rows, err := r.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []canned.Response
+	for rows.Next() {
+		resp, err := scanResponse(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *resp)
+	}
+	return list, rows.Err()
+}
+
+// escapeLike meng-escape karakter wildcard LIKE dari input pencarian
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *cannedRepo) GetByID(id int) (*canned.Response, error) {
+	return scanResponse(r.db.QueryRow("SELECT "+cannedColumns+" FROM canned_responses WHERE canned_response_id = $1", id))
+}
+
+func (r *cannedRepo) Create(c canned.Response) (int, error) {
+	attachments, err := json.Marshal(c.Attachments)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	err = r.db.QueryRow(
+		`INSERT INTO canned_responses (client_id, team_id, user_id, shortcode, title, body, category, attachments, created_by, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW()) RETURNING canned_response_id`,
+		c.ClientID, c.TeamID, c.UserID, c.Shortcode, c.Title, c.Body, c.Category, attachments, c.CreatedBy,
+	).Scan(&id)
+	return id, uniqueError(err)
+}
+
+func (r *cannedRepo) Update(c canned.Response) error {
+	attachments, err := json.Marshal(c.Attachments)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(
+		`UPDATE canned_responses SET shortcode = $1, title = $2, body = $3, category = $4, attachments = $5, updated_at = NOW()
+		 WHERE canned_response_id = $6`,
+		c.Shortcode, c.Title, c.Body, c.Category, attachments, c.ID,
+	)
+	return uniqueError(err)
+}
+
+// uniqueError mengubah pelanggaran unique index shortcode menjadi ErrDuplicateShortcode
+func uniqueError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateShortcode
+	}
+	return err
+}
+
+func (r *cannedRepo) Delete(id int) error {
+	_, err := r.db.Exec("DELETE FROM canned_responses WHERE canned_response_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete canned response with id %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *cannedRepo) IsTeamMember(teamID, userID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM team_members WHERE team_id = $1 AND user_id = $2)", teamID, userID).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"backend/internal/canned"
+	"backend/internal/canned/repository"
+	"backend/internal/contacts"
+	"backend/internal/conversations"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotFound             = errors.New("canned response not found")
+	ErrInvalidResponse      = errors.New("invalid canned response")
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrUnsupportedChannel   = errors.New("channel does not support replies")
+	ErrMissingVariables     = errors.New("canned response has unresolved variables")
+)
+
+// ConversationReader mengambil percakapan milik client
+type ConversationReader interface {
+	GetConversation(clientID, id int) (*conversations.Conversation, error)
+}
+
+// ContactReader mengambil kontak milik client beserta identitasnya
+type ContactReader interface {
+	GetContact(clientID, id int) (*contacts.Contact, error)
+}
+
+// ReplySender mengirim balasan ke pelanggan pada percakapan di satu channel
+type ReplySender interface {
+	Reply(ctx context.Context, conv conversations.Conversation, senderUserID *int, body string) (*conversations.Message, error)
+}
+
+type CannedUsecase interface {
+	GetResponses(clientID int, agent canned.Agent, category string) ([]canned.Response, error)
+	Search(clientID int, agent canned.Agent, query string) ([]canned.Response, error)
+	GetResponse(clientID int, agent canned.Agent, id int) (*canned.Response, error)
+	CreateResponse(r canned.Response, agent canned.Agent) (int, error)
+	UpdateResponse(r canned.Response, agent canned.Agent) error
+	DeleteResponse(clientID int, agent canned.Agent, id int) error
+	Render(clientID int, agent canned.Agent, id, conversationID int) (*canned.Rendered, error)
+	Send(ctx context.Context, clientID int, agent canned.Agent, id, conversationID int) (*conversations.Message, error)
+}
+
+type cannedUsecase struct {
+	repo          repository.CannedRepository
+	conversations ConversationReader
+	contacts      ContactReader
+	senders       map[string]ReplySender
+}
+
+// NewCannedUsecase membuat usecase canned response. senders dipetakan berdasarkan channel percakapan.
+func NewCannedUsecase(repo repository.CannedRepository, conv ConversationReader, contacts ContactReader, senders map[string]ReplySender) CannedUsecase {
+	return &cannedUsecase{repo: repo, conversations: conv, contacts: contacts, senders: senders}
+}
+
+func (u *cannedUsecase) GetResponses(clientID int, agent canned.Agent, category string) ([]canned.Response, error) {
+	return u.repo.FetchVisible(clientID, agent.UserID, "", category)
+}
+
+// Search mencari canned response untuk composer; awalan "/" pada query diabaikan
+func (u *cannedUsecase) Search(clientID int, agent canned.Agent, query string) ([]canned.Response, error) {
+	return u.repo.FetchVisible(clientID, agent.UserID, query, "")
+}
+
+// GetResponse mengambil canned response yang boleh dipakai agent
+func (u *cannedUsecase) GetResponse(clientID int, agent canned.Agent, id int) (*canned.Response, error) {
+	r, err := u.repo.GetByID(id)
+	if err != nil || r.ClientID != clientID {
+		return nil, ErrNotFound
+	}
+	ok, err := u.canAccess(*r, agent.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return r, nil
+}
+
+// canAccess memeriksa cakupan: milik client terbuka untuk semua, milik tim hanya
+// untuk anggota, milik user hanya untuk pemiliknya
+func (u *cannedUsecase) canAccess(r canned.Response, userID int) (bool, error) {
+	switch {
+	case r.UserID != nil:
+		return *r.UserID == userID, nil
+	case r.TeamID != nil:
+		return u.repo.IsTeamMember(*r.TeamID, userID)
+	}
+	return true, nil
+}
+
+// normalize memvalidasi input dan menentukan pemilik sesuai cakupan
+func (u *cannedUsecase) normalize(r *canned.Response, agent canned.Agent) error {
+	r.Shortcode = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(r.Shortcode), "/"))
+	r.Title, r.Category = strings.TrimSpace(r.Title), strings.TrimSpace(r.Category)
+	if !canned.ValidShortcode(r.Shortcode) || r.Title == "" || strings.TrimSpace(r.Body) == "" {
+		return ErrInvalidResponse
+	}
+	for _, a := range r.Attachments {
+		if a.URL == "" {
+			return ErrInvalidResponse
+		}
+	}
+	if r.Attachments == nil {
+		r.Attachments = []canned.Attachment{}
+	}
+
+	switch r.Scope {
+	case canned.ScopeClient, "":
+		r.Scope, r.TeamID, r.UserID = canned.ScopeClient, nil, nil
+	case canned.ScopeTeam:
+		if r.TeamID == nil {
+			return ErrInvalidResponse
+		}
+		member, err := u.repo.IsTeamMember(*r.TeamID, agent.UserID)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return ErrInvalidResponse
+		}
+		r.UserID = nil
+	case canned.ScopeUser:
+		r.TeamID, r.UserID = nil, &agent.UserID
+	default:
+		return ErrInvalidResponse
+	}
+	return nil
+}
+
+func (u *cannedUsecase) CreateResponse(r canned.Response, agent canned.Agent) (int, error) {
+	if err := u.normalize(&r, agent); err != nil {
+		return 0, err
+	}
+	r.CreatedBy = agent.UserID
+	return u.repo.Create(r)
+}
+
+// UpdateResponse mengubah isi canned response; cakupan tidak bisa diubah
+func (u *cannedUsecase) UpdateResponse(r canned.Response, agent canned.Agent) error {
+	existing, err := u.GetResponse(r.ClientID, agent, r.ID)
+	if err != nil {
+		return err
+	}
+	r.Scope, r.TeamID = existing.Scope, existing.TeamID
+	if err := u.normalize(&r, agent); err != nil {
+		return err
+	}
+	return u.repo.Update(r)
+}
+
+func (u *cannedUsecase) DeleteResponse(clientID int, agent canned.Agent, id int) error {
+	if _, err := u.GetResponse(clientID, agent, id); err != nil {
+		return err
+	}
+	return u.repo.Delete(id)
+}
+
+// Render mengisi variabel canned response dengan data kontak percakapan dan agent yang login
+func (u *cannedUsecase) Render(clientID int, agent canned.Agent, id, conversationID int) (*canned.Rendered, error) {
+	r, err := u.GetResponse(clientID, agent, id)
+	if err != nil {
+		return nil, err
+	}
+	conv, err := u.conversations.GetConversation(clientID, conversationID)
+	if err != nil {
+		return nil, ErrConversationNotFound
+	}
+	vars, err := u.variables(*conv, agent)
+	if err != nil {
+		return nil, err
+	}
+
+	body, missing := canned.Render(r.Body, vars)
+	return &canned.Rendered{ResponseID: r.ID, Body: body, Attachments: r.Attachments, Missing: missing}, nil
+}
+
+// Send merender lalu mengirim canned response ke pelanggan. Agent kosong (UserID 0)
+// berarti pesan dikirim oleh sistem.
+func (u *cannedUsecase) Send(ctx context.Context, clientID int, agent canned.Agent, id, conversationID int) (*conversations.Message, error) {
+	rendered, err := u.Render(clientID, agent, id, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rendered.Missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingVariables, strings.Join(rendered.Missing, ", "))
+	}
+	conv, err := u.conversations.GetConversation(clientID, conversationID)
+	if err != nil {
+		return nil, ErrConversationNotFound
+	}
+	sender, ok := u.senders[conv.Channel]
+	if !ok {
+		return nil, ErrUnsupportedChannel
+	}
+
+	var senderUserID *int
+	if agent.UserID != 0 {
+		senderUserID = &agent.UserID
+	}
+	return sender.Reply(ctx, *conv, senderUserID, rendered.Body)
+}
+
+// variables membangun nilai variabel template dari percakapan, kontak dan agent
+func (u *cannedUsecase) variables(conv conversations.Conversation, agent canned.Agent) (map[string]string, error) {
+	vars := map[string]string{
+		"conversation.id":      strconv.Itoa(conv.ID),
+		"conversation.channel": conv.Channel,
+		"agent.username":       agent.Username,
+	}
+	if agent.UserID != 0 {
+		vars["agent.id"] = strconv.Itoa(agent.UserID)
+	}
+	if conv.ContactID == nil {
+		return vars, nil
+	}
+
+	contact, err := u.contacts.GetContact(conv.ClientID, *conv.ContactID)
+	if err != nil {
+		return nil, err
+	}
+	vars["contact.id"] = strconv.Itoa(contact.ID)
+	vars["contact.name"] = contact.Name
+	if fields := strings.Fields(contact.Name); len(fields) > 0 {
+		vars["contact.first_name"] = fields[0]
+	}
+	for _, i := range contact.Identities {
+		// Identitas pertama per tipe dipakai, misalnya {{contact.phone}} atau {{contact.email}}
+		if _, ok := vars["contact."+i.Type]; !ok {
+			vars["contact."+i.Type] = i.Value
+		}
+	}
+	for key, v := range contact.Attributes {
+		vars["contact.attributes."+strings.ToLower(key)] = fmt.Sprint(v)
+	}
+	return vars, nil
+}
@@ -0,0 +1,75 @@
+package contacts
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Tipe identitas channel yang bisa dihubungkan ke kontak
+const (
+	IdentityPhone    = "phone"
+	IdentityWhatsApp = "whatsapp"
+	IdentityEmail    = "email"
+	IdentityPSID     = "psid" // Page-scoped ID Facebook Messenger
+	IdentityTelegram = "telegram_id"
+	IdentityWebChat  = "webchat"
+)
+
+// Contact adalah profil pelanggan milik satu client
+type Contact struct {
+	ID         int                    `json:"id"`
+	ClientID   int                    `json:"client_id"`
+	Name       string                 `json:"name"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Tags       []string               `json:"tags"`
+	Identities []Identity             `json:"identities,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+// Identity adalah alamat kontak pada sebuah channel (nomor telepon, email, PSID, dll)
+type Identity struct {
+	ID        int       `json:"id"`
+	ContactID int       `json:"contact_id"`
+	ClientID  int       `json:"client_id"`
+	Type      string    `json:"type"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DuplicateSuggestion adalah pasangan kontak yang kemungkinan orang yang sama
+type DuplicateSuggestion struct {
+	ContactID   int    `json:"contact_id"`
+	DuplicateID int    `json:"duplicate_id"`
+	MatchType   string `json:"match_type"` // phone atau email
+	MatchValue  string `json:"match_value"`
+}
+
+// ValidIdentityType memeriksa apakah tipe identitas dikenal
+func ValidIdentityType(t string) bool {
+	switch t {
+	case IdentityPhone, IdentityWhatsApp, IdentityEmail, IdentityPSID, IdentityTelegram, IdentityWebChat:
+		return true
+	}
+	return false
+}
+
+// NormalizeIdentity menyeragamkan nilai identitas agar bisa dicocokkan:
+// nomor telepon hanya menyisakan digit dan awalan '+', email menjadi huruf kecil.
+func NormalizeIdentity(identityType, value string) string {
+	value = strings.TrimSpace(value)
+	switch identityType {
+	case IdentityPhone, IdentityWhatsApp:
+		var b strings.Builder
+		for i, r := range value {
+			if unicode.IsDigit(r) || (r == '+' && i == 0) {
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	case IdentityEmail:
+		return strings.ToLower(value)
+	}
+	return value
+}
@@ -0,0 +1,209 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/contacts"
+	"backend/internal/contacts/repository"
+	"backend/internal/contacts/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ContactHandler struct {
+	usecase usecase.ContactUsecase
+}
+
+func NewContactHandler(uc usecase.ContactUsecase) *ContactHandler {
+	return &ContactHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+	case errors.Is(err, usecase.ErrInvalidIdentity):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identity"})
+	case errors.Is(err, repository.ErrIdentityTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Identity already belongs to another contact"})
+	case errors.Is(err, usecase.ErrCannotMergeSelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge a contact into itself"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *ContactHandler) GetContacts(c *gin.Context) {
+	list, err := h.usecase.GetContacts(c.GetInt("client_id"), c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contacts"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *ContactHandler) GetContact(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	contact, err := h.usecase.GetContact(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch contact")
+		return
+	}
+	c.JSON(http.StatusOK, contact)
+}
+
+func (h *ContactHandler) CreateContact(c *gin.Context) {
+	var req contacts.Contact
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ClientID = c.GetInt("client_id")
+
+	id, err := h.usecase.CreateContact(req)
+	if err != nil {
+		respondError(c, err, "Failed to create contact")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Contact created", "id": id})
+}
+
+func (h *ContactHandler) UpdateContact(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var req contacts.Contact
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ID, req.ClientID = id, c.GetInt("client_id")
+
+	if err := h.usecase.UpdateContact(req); err != nil {
+		respondError(c, err, "Failed to update contact")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Contact updated", "id": id})
+}
+
+func (h *ContactHandler) DeleteContact(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	if err := h.usecase.DeleteContact(c.GetInt("client_id"), id); err != nil {
+		respondError(c, err, "Failed to delete contact")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Contact deleted", "id": id})
+}
+
+func (h *ContactHandler) AddIdentity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var req contacts.Identity
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ContactID = id
+
+	identityID, err := h.usecase.AddIdentity(c.GetInt("client_id"), req)
+	if err != nil {
+		respondError(c, err, "Failed to add identity")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Identity added", "id": identityID})
+}
+
+func (h *ContactHandler) RemoveIdentity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+	identityID, err := strconv.Atoi(c.Param("identity_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identity ID"})
+		return
+	}
+
+	if err := h.usecase.RemoveIdentity(c.GetInt("client_id"), id, identityID); err != nil {
+		respondError(c, err, "Failed to remove identity")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Identity removed", "id": identityID})
+}
+
+// SetTags mengganti seluruh tag kontak
+func (h *ContactHandler) SetTags(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.usecase.SetTags(c.GetInt("client_id"), id, req.Tags); err != nil {
+		respondError(c, err, "Failed to update tags")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tags updated", "id": id})
+}
+
+func (h *ContactHandler) GetDuplicates(c *gin.Context) {
+	list, err := h.usecase.GetDuplicateSuggestions(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch duplicate suggestions"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// Merge menggabungkan kontak merge_id ke kontak pada URL
+func (h *ContactHandler) Merge(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var req struct {
+		MergeID int `json:"merge_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.MergeID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "merge_id is required"})
+		return
+	}
+
+	contact, err := h.usecase.MergeContacts(c.GetInt("client_id"), id, req.MergeID, c.GetInt("user_id"))
+	if err != nil {
+		respondError(c, err, "Failed to merge contacts")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Contacts merged", "contact": contact})
+}
@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"backend/internal/contacts"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ErrIdentityTaken dikembalikan jika identitas yang sama sudah dimiliki kontak lain pada client
+var ErrIdentityTaken = errors.New("identity already belongs to another contact")
+
+// ContactRepository adalah interface untuk repository Contact
+type ContactRepository interface {
+	FetchByClient(clientID int, search string) ([]contacts.Contact, error)
+	GetByID(id int) (*contacts.Contact, error)
+	FindByIdentity(clientID int, identityType, value string) (*contacts.Contact, error)
+	Create(c contacts.Contact) (int, error)
+	CreateForIdentity(clientID int, identityType, value string) (int, error)
+	Update(c contacts.Contact) error
+	Delete(id int) error
+	FetchIdentities(contactID int) ([]contacts.Identity, error)
+	AddIdentity(i contacts.Identity) (int, error)
+	RemoveIdentity(contactID, identityID int) error
+	SetTags(contactID int, tags []string) error
+	FindDuplicates(clientID int) ([]contacts.DuplicateSuggestion, error)
+	Merge(primaryID, secondaryID, mergedBy int) error
+}
+
+type contactRepo struct {
+	db *sql.DB
+}
+
+func NewContactRepository(db *sql.DB) ContactRepository {
+	return &contactRepo{db: db}
+}
+
+// contactSelect mengambil kontak beserta tag-nya dalam satu query
+const contactSelect = `SELECT c.contact_id, c.client_id, c.name, c.attributes, c.created_at, c.updated_at,
+	COALESCE(array_agg(t.tag ORDER BY t.tag) FILTER (WHERE t.tag IS NOT NULL), '{}')
+	FROM contacts c LEFT JOIN contact_tags t ON t.contact_id = c.contact_id `
+
+func scanContact(row interface{ Scan(...interface{}) error }) (*contacts.Contact, error) {
+	var c contacts.Contact
+	var attributes []byte
+	err := row.Scan(&c.ID, &c.ClientID, &c.Name, &attributes, &c.CreatedAt, &c.UpdatedAt, pq.Array(&c.Tags))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(attributes, &c.Attributes); err != nil {
+		return nil, fmt.Errorf("invalid attributes for contact %d: %w", c.ID, err)
+	}
+	return &c, nil
+}
+
+// FetchByClient mengambil kontak milik client, dengan pencarian opsional pada nama dan identitas
+func (r *contactRepo) FetchByClient(clientID int, search string) ([]contacts.Contact, error) {
+	rows, err := r.db.Query(contactSelect+`WHERE c.client_id = $1 AND ($2 = '' OR c.name ILIKE '%' || $2 || '%'
+		OR EXISTS (SELECT 1 FROM contact_identities i WHERE i.contact_id = c.contact_id AND i.value ILIKE '%' || $2 || '%'))
+		GROUP BY c.contact_id ORDER BY c.updated_at DESC`, clientID, search)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []contacts.Contact
+	for rows.Next() {
+		c, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *c)
+	}
+	return list, rows.Err()
+}
+
+func (r *contactRepo) GetByID(id int) (*contacts.Contact, error) {
+	return scanContact(r.db.QueryRow(contactSelect+"WHERE c.contact_id = $1 GROUP BY c.contact_id", id))
+}
+
+// FindByIdentity mencari kontak terlama yang memiliki identitas tertentu
+func (r *contactRepo) FindByIdentity(clientID int, identityType, value string) (*contacts.Contact, error) {
+	return scanContact(r.db.QueryRow(contactSelect+`WHERE c.contact_id = (
+		SELECT contact_id FROM contact_identities WHERE client_id = $1 AND type = $2 AND value = $3 ORDER BY contact_id LIMIT 1
+	) GROUP BY c.contact_id`, clientID, identityType, value))
+}
+
+// Create menyimpan kontak beserta identitas dan tag-nya dalam satu transaksi, sehingga kegagalan
+// identitas tidak meninggalkan kontak setengah jadi. Identitas harus sudah dinormalisasi.
+func (r *contactRepo) Create(c contacts.Contact) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := insertContact(tx, c)
+	if err != nil {
+		return 0, err
+	}
+	for _, i := range c.Identities {
+		_, err := tx.Exec(
+			"INSERT INTO contact_identities (contact_id, client_id, type, value) VALUES ($1, $2, $3, $4)",
+			id, c.ClientID, i.Type, i.Value,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to add identity: %w", uniqueError(err))
+		}
+	}
+	if len(c.Tags) > 0 {
+		_, err := tx.Exec(
+			"INSERT INTO contact_tags (contact_id, tag) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING",
+			id, pq.Array(c.Tags),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to set tags: %w", err)
+		}
+	}
+	return id, tx.Commit()
+}
+
+// CreateForIdentity membuat kontak baru untuk identitas yang belum dikenal. Jika permintaan lain
+// sudah membuat kontak untuk identitas yang sama, transaksi ini dibatalkan dan kontak tersebut
+// yang dikembalikan, sehingga pesan bersamaan dari nomor baru tidak menghasilkan kontak ganda.
+func (r *contactRepo) CreateForIdentity(clientID int, identityType, value string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := insertContact(tx, contacts.Contact{ClientID: clientID, Attributes: map[string]interface{}{}})
+	if err != nil {
+		return 0, err
+	}
+	// Insert yang bentrok menunggu transaksi pemilik identitas selesai, sehingga select
+	// berikutnya sudah melihat kontak yang di-commit transaksi tersebut
+	var identityID int
+	err = tx.QueryRow(
+		`INSERT INTO contact_identities (contact_id, client_id, type, value) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (client_id, type, value) DO NOTHING RETURNING identity_id`,
+		id, clientID, identityType, value,
+	).Scan(&identityID)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		err = r.db.QueryRow(
+			"SELECT contact_id FROM contact_identities WHERE client_id = $1 AND type = $2 AND value = $3",
+			clientID, identityType, value,
+		).Scan(&id)
+		return id, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to add identity: %w", err)
+	}
+	return id, tx.Commit()
+}
+
+func insertContact(tx *sql.Tx, c contacts.Contact) (int, error) {
+	attributes, err := json.Marshal(c.Attributes)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	err = tx.QueryRow(
+		"INSERT INTO contacts (client_id, name, attributes) VALUES ($1, $2, $3) RETURNING contact_id",
+		c.ClientID, c.Name, attributes,
+	).Scan(&id)
+	return id, err
+}
+
+// uniqueError mengubah pelanggaran unique index identitas menjadi ErrIdentityTaken
+func uniqueError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrIdentityTaken
+	}
+	return err
+}
+
+func (r *contactRepo) Update(c contacts.Contact) error {
+	attributes, err := json.Marshal(c.Attributes)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(
+		"UPDATE contacts SET name = $1, attributes = $2, updated_at = NOW() WHERE contact_id = $3",
+		c.Name, attributes, c.ID,
+	)
+	return err
+}
+
+func (r *contactRepo) Delete(id int) error {
+	_, err := r.db.Exec("DELETE FROM contacts WHERE contact_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete contact with id %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *contactRepo) FetchIdentities(contactID int) ([]contacts.Identity, error) {
+	rows, err := r.db.Query(
+		"SELECT identity_id, contact_id, client_id, type, value, created_at FROM contact_identities WHERE contact_id = $1 ORDER BY identity_id",
+		contactID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []contacts.Identity
+	for rows.Next() {
+		var i contacts.Identity
+		if err := rows.Scan(&i.ID, &i.ContactID, &i.ClientID, &i.Type, &i.Value, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, i)
+	}
+	return list, rows.Err()
+}
+
+func (r *contactRepo) AddIdentity(i contacts.Identity) (int, error) {
+	var id int
+	err := r.db.QueryRow(
+		"INSERT INTO contact_identities (contact_id, client_id, type, value) VALUES ($1, $2, $3, $4) RETURNING identity_id",
+		i.ContactID, i.ClientID, i.Type, i.Value,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add identity: %w", uniqueError(err))
+	}
+	return id, touch(r.db, i.ContactID)
+}
+
+func (r *contactRepo) RemoveIdentity(contactID, identityID int) error {
+	_, err := r.db.Exec("DELETE FROM contact_identities WHERE identity_id = $1 AND contact_id = $2", identityID, contactID)
+	if err != nil {
+		return err
+	}
+	return touch(r.db, contactID)
+}
+
+// touch memperbarui updated_at kontak saat identitas atau tag berubah, agar indeks pencarian
+// kontak ikut diperbarui
+func touch(exec interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}, contactID int) error {
+	_, err := exec.Exec("UPDATE contacts SET updated_at = NOW() WHERE contact_id = $1", contactID)
+	return err
+}
+
+// SetTags mengganti seluruh tag kontak
+func (r *contactRepo) SetTags(contactID int, tags []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM contact_tags WHERE contact_id = $1", contactID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO contact_tags (contact_id, tag) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING",
+		contactID, pq.Array(tags),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set tags: %w", err)
+	}
+	if err := touch(tx, contactID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindDuplicates mencari pasangan kontak yang memiliki nomor telepon atau email yang sama.
+// Nomor WhatsApp dan nomor telepon biasa dianggap identitas yang sama.
+func (r *contactRepo) FindDuplicates(clientID int) ([]contacts.DuplicateSuggestion, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT a.contact_id, b.contact_id,
+		CASE WHEN a.type = 'email' THEN 'email' ELSE 'phone' END, a.value
+		FROM contact_identities a
+		JOIN contact_identities b ON b.client_id = a.client_id AND b.value = a.value AND b.contact_id > a.contact_id
+			AND (b.type = a.type OR (a.type IN ('phone', 'whatsapp') AND b.type IN ('phone', 'whatsapp')))
+		WHERE a.client_id = $1 AND a.type IN ('phone', 'whatsapp', 'email')
+		ORDER BY a.contact_id, b.contact_id`, clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []contacts.DuplicateSuggestion
+	for rows.Next() {
+		var d contacts.DuplicateSuggestion
+		if err := rows.Scan(&d.ContactID, &d.DuplicateID, &d.MatchType, &d.MatchValue); err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// Merge menggabungkan kontak secondary ke primary: identitas, tag, atribut, riwayat
+// percakapan dan status opt-out SMS dipindahkan, lalu kontak secondary dihapus dalam satu transaksi.
+// Atribut milik primary menang jika ada key yang sama.
+func (r *contactRepo) Merge(primaryID, secondaryID, mergedBy int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	steps := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`DELETE FROM contact_identities s USING contact_identities p
+			WHERE s.contact_id = $2 AND p.contact_id = $1 AND s.type = p.type AND s.value = p.value`, []interface{}{primaryID, secondaryID}},
+		{"UPDATE contact_identities SET contact_id = $1 WHERE contact_id = $2", []interface{}{primaryID, secondaryID}},
+		{"INSERT INTO contact_tags (contact_id, tag) SELECT $1, tag FROM contact_tags WHERE contact_id = $2 ON CONFLICT DO NOTHING", []interface{}{primaryID, secondaryID}},
+		{`UPDATE contacts p SET attributes = s.attributes || p.attributes, name = COALESCE(NULLIF(p.name, ''), s.name), updated_at = NOW()
+			FROM contacts s WHERE p.contact_id = $1 AND s.contact_id = $2`, []interface{}{primaryID, secondaryID}},
+		{"UPDATE conversations SET contact_id = $1 WHERE contact_id = $2", []interface{}{primaryID, secondaryID}},
+		{`INSERT INTO contact_merges (client_id, primary_contact_id, merged_contact_id, merged_by)
+			SELECT client_id, $1, $2, $3 FROM contacts WHERE contact_id = $1`, []interface{}{primaryID, secondaryID, mergedBy}},
+		{"UPDATE sms_opt_outs SET contact_id = $1 WHERE contact_id = $2", []interface{}{primaryID, secondaryID}},
+		{"DELETE FROM contacts WHERE contact_id = $1", []interface{}{secondaryID}},
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(step.query, step.args...); err != nil {
+			return fmt.Errorf("failed to merge contact %d into %d: %w", secondaryID, primaryID, err)
+		}
+	}
+	return tx.Commit()
+}
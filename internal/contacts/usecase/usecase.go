@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"backend/internal/contacts"
+	"backend/internal/contacts/repository"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrNotFound            = errors.New("contact not found")
+	ErrInvalidIdentity     = errors.New("invalid identity")
+	ErrCannotMergeSelf     = errors.New("cannot merge a contact into itself")
+	ErrIdentityNotResolved = errors.New("identity could not be resolved")
+)
+
+type ContactUsecase interface {
+	GetContacts(clientID int, search string) ([]contacts.Contact, error)
+	GetContact(clientID, id int) (*contacts.Contact, error)
+	CreateContact(c contacts.Contact) (int, error)
+	UpdateContact(c contacts.Contact) error
+	DeleteContact(clientID, id int) error
+	AddIdentity(clientID int, i contacts.Identity) (int, error)
+	RemoveIdentity(clientID, contactID, identityID int) error
+	SetTags(clientID, contactID int, tags []string) error
+	GetDuplicateSuggestions(clientID int) ([]contacts.DuplicateSuggestion, error)
+	MergeContacts(clientID, primaryID, secondaryID, userID int) (*contacts.Contact, error)
+	ResolveIdentity(clientID int, identityType, value string) (int, error)
+}
+
+type contactUsecase struct {
+	repo repository.ContactRepository
+}
+
+func NewContactUsecase(repo repository.ContactRepository) ContactUsecase {
+	return &contactUsecase{repo: repo}
+}
+
+func (u *contactUsecase) GetContacts(clientID int, search string) ([]contacts.Contact, error) {
+	return u.repo.FetchByClient(clientID, strings.TrimSpace(search))
+}
+
+// GetContact mengambil kontak beserta identitasnya dan memastikan milik client yang sama
+func (u *contactUsecase) GetContact(clientID, id int) (*contacts.Contact, error) {
+	c, err := u.repo.GetByID(id)
+	if err != nil || c.ClientID != clientID {
+		return nil, ErrNotFound
+	}
+	c.Identities, err = u.repo.FetchIdentities(id)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// CreateContact memvalidasi identitas lebih dulu lalu menyimpan kontak, identitas dan tag-nya
+// sekaligus, sehingga identitas yang tidak valid tidak meninggalkan kontak tanpa identitas
+func (u *contactUsecase) CreateContact(c contacts.Contact) (int, error) {
+	if c.Attributes == nil {
+		c.Attributes = map[string]interface{}{}
+	}
+	for n, i := range c.Identities {
+		if !contacts.ValidIdentityType(i.Type) {
+			return 0, ErrInvalidIdentity
+		}
+		c.Identities[n].Value = contacts.NormalizeIdentity(i.Type, i.Value)
+		if c.Identities[n].Value == "" {
+			return 0, ErrInvalidIdentity
+		}
+	}
+	return u.repo.Create(c)
+}
+
+func (u *contactUsecase) UpdateContact(c contacts.Contact) error {
+	if _, err := u.GetContact(c.ClientID, c.ID); err != nil {
+		return err
+	}
+	if c.Attributes == nil {
+		c.Attributes = map[string]interface{}{}
+	}
+	return u.repo.Update(c)
+}
+
+func (u *contactUsecase) DeleteContact(clientID, id int) error {
+	if _, err := u.GetContact(clientID, id); err != nil {
+		return err
+	}
+	return u.repo.Delete(id)
+}
+
+// AddIdentity menghubungkan identitas channel ke kontak setelah dinormalisasi
+func (u *contactUsecase) AddIdentity(clientID int, i contacts.Identity) (int, error) {
+	if !contacts.ValidIdentityType(i.Type) {
+		return 0, ErrInvalidIdentity
+	}
+	i.Value = contacts.NormalizeIdentity(i.Type, i.Value)
+	if i.Value == "" {
+		return 0, ErrInvalidIdentity
+	}
+	if _, err := u.GetContact(clientID, i.ContactID); err != nil {
+		return 0, err
+	}
+	i.ClientID = clientID
+	return u.repo.AddIdentity(i)
+}
+
+func (u *contactUsecase) RemoveIdentity(clientID, contactID, identityID int) error {
+	if _, err := u.GetContact(clientID, contactID); err != nil {
+		return err
+	}
+	return u.repo.RemoveIdentity(contactID, identityID)
+}
+
+func (u *contactUsecase) SetTags(clientID, contactID int, tags []string) error {
+	if _, err := u.GetContact(clientID, contactID); err != nil {
+		return err
+	}
+	cleaned := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			cleaned = append(cleaned, t)
+		}
+	}
+	return u.repo.SetTags(contactID, cleaned)
+}
+
+func (u *contactUsecase) GetDuplicateSuggestions(clientID int) ([]contacts.DuplicateSuggestion, error) {
+	return u.repo.FindDuplicates(clientID)
+}
+
+// MergeContacts menggabungkan kontak secondary ke primary dan mengembalikan hasil gabungan
+func (u *contactUsecase) MergeContacts(clientID, primaryID, secondaryID, userID int) (*contacts.Contact, error) {
+	if primaryID == secondaryID {
+		return nil, ErrCannotMergeSelf
+	}
+	if _, err := u.GetContact(clientID, primaryID); err != nil {
+		return nil, err
+	}
+	if _, err := u.GetContact(clientID, secondaryID); err != nil {
+		return nil, err
+	}
+	if err := u.repo.Merge(primaryID, secondaryID, userID); err != nil {
+		return nil, err
+	}
+	return u.GetContact(clientID, primaryID)
+}
+
+// ResolveIdentity mengembalikan ID kontak pemilik identitas dan membuat kontak baru
+// jika identitas tersebut belum pernah terlihat. Dipakai saat pesan masuk dari channel.
+func (u *contactUsecase) ResolveIdentity(clientID int, identityType, value string) (int, error) {
+	value = contacts.NormalizeIdentity(identityType, value)
+	if value == "" {
+		return 0, ErrIdentityNotResolved
+	}
+
+	c, err := u.repo.FindByIdentity(clientID, identityType, value)
+	if err == nil {
+		return c.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	return u.repo.CreateForIdentity(clientID, identityType, value)
+}
@@ -0,0 +1,69 @@
+package conversations
+
+import (
+	"backend/internal/sla"
+	"errors"
+	"time"
+)
+
+// ErrDuplicateMessage berarti pesan inbound dengan external ID yang sama sudah tersimpan,
+// biasanya karena provider mengirim ulang webhook yang sama
+var ErrDuplicateMessage = errors.New("inbound message already recorded")
+
+// Channel yang didukung untuk percakapan
+const (
+	ChannelSMS = "sms"
+)
+
+// Status percakapan
+const (
+	StatusOpen     = "open"
+	StatusResolved = "resolved"
+	StatusClosed   = "closed"
+)
+
+// Prioritas percakapan; antrean mendahulukan prioritas yang lebih tinggi
+const (
+	PriorityNone   = 0
+	PriorityLow    = 1
+	PriorityMedium = 2
+	PriorityHigh   = 3
+	PriorityUrgent = 4
+)
+
+// Arah pesan
+const (
+	DirectionInbound  = "inbound"
+	DirectionOutbound = "outbound"
+)
+
+// Conversation adalah percakapan antara pelanggan dan client pada satu channel
+type Conversation struct {
+	ID              int         `json:"id"`
+	ClientID        int         `json:"client_id"`
+	ContactID       *int        `json:"contact_id"`
+	Channel         string      `json:"channel"`
+	ExternalAddress string      `json:"external_address"` // Alamat pelanggan di channel (nomor telepon, email, dll)
+	Status          string      `json:"status"`
+	TeamID          *int        `json:"team_id"`         // Antrean tim tempat percakapan menunggu
+	AssigneeID      *int        `json:"assignee_id"`     // Agent yang sedang menangani percakapan
+	RequiredSkills  []string    `json:"required_skills"` // Skill yang dibutuhkan untuk routing berbasis skill
+	Priority        int         `json:"priority"`
+	SLA             []sla.Timer `json:"sla,omitempty"` // Status timer SLA, diisi pada endpoint percakapan
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+}
+
+// Message adalah satu pesan di dalam percakapan
+type Message struct {
+	ID             int       `json:"id"`
+	ConversationID int       `json:"conversation_id"`
+	ClientID       int       `json:"client_id"`
+	Direction      string    `json:"direction"`
+	Channel        string    `json:"channel"`
+	Body           string    `json:"body"`
+	ExternalID     string    `json:"external_id"` // ID pesan dari provider channel
+	Status         string    `json:"status"`
+	SenderUserID   *int      `json:"sender_user_id,omitempty"` // Agent pengirim untuk pesan outbound
+	CreatedAt      time.Time `json:"created_at"`
+}
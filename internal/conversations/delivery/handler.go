@@ -0,0 +1,143 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/conversations/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConversationHandler struct {
+	usecase usecase.ConversationUsecase
+}
+
+func NewConversationHandler(uc usecase.ConversationUsecase) *ConversationHandler {
+	return &ConversationHandler{usecase: uc}
+}
+
+// GetConversations mengembalikan daftar percakapan milik client pengguna yang login
+func (h *ConversationHandler) GetConversations(c *gin.Context) {
+	list, err := h.usecase.GetConversations(c.GetInt("client_id"), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversations"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GetContactConversations mengembalikan riwayat percakapan seorang kontak
+func (h *ConversationHandler) GetContactConversations(c *gin.Context) {
+	contactID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	list, err := h.usecase.GetContactConversations(c.GetInt("client_id"), contactID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversations"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *ConversationHandler) GetConversation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	conv, err := h.usecase.GetConversation(c.GetInt("client_id"), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	}
+	c.JSON(http.StatusOK, conv)
+}
+
+func (h *ConversationHandler) GetMessages(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messages, err := h.usecase.GetMessages(c.GetInt("client_id"), id)
+	if errors.Is(err, usecase.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
+		return
+	}
+	c.JSON(http.StatusOK, messages)
+}
+
+// UpdateStatus mengubah status percakapan (open, resolved, closed)
+func (h *ConversationHandler) UpdateStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	err = h.usecase.UpdateStatus(c.GetInt("client_id"), id, req.Status)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	case errors.Is(err, usecase.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update conversation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation updated", "id": id, "status": req.Status})
+}
+
+// SetPriority mengubah prioritas percakapan (0 sampai 4)
+func (h *ConversationHandler) SetPriority(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req struct {
+		Priority int `json:"priority"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	err = h.usecase.SetPriority(c.GetInt("client_id"), id, req.Priority)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidPriority):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid priority"})
+		return
+	case errors.Is(err, usecase.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update conversation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation updated", "id": id, "priority": req.Priority})
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"backend/internal/conversations"
+	"backend/internal/events"
+	outbox "backend/internal/events/repository"
+	"backend/pkg/txn"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ConversationRepository adalah interface untuk repository Conversation dan Message
+type ConversationRepository interface {
+	FetchByClient(clientID int, status string) ([]conversations.Conversation, error)
+	FetchByContact(contactID int) ([]conversations.Conversation, error)
+	GetByID(id int) (*conversations.Conversation, error)
+	FindOpenByAddress(clientID int, channel, address string) (*conversations.Conversation, error)
+	Create(c conversations.Conversation) (int, error)
+	UpdateStatus(id int, status string) error
+	UpdatePriority(id, priority int) error
+	FetchMessages(conversationID int) ([]conversations.Message, error)
+	CreateMessage(m conversations.Message) (int, error)
+	UpdateMessageStatus(clientID int, channel, externalID, status string) error
+	// InTx menjalankan fn dalam satu transaksi. repo yang diberikan ke fn memakai transaksi
+	// tersebut, begitu juga publisher, sehingga event hanya tercatat di outbox jika perubahan
+	// percakapan berhasil di-commit.
+	InTx(fn func(repo ConversationRepository, publisher events.Publisher) error) error
+}
+
+type conversationRepo struct {
+	db  *sql.DB
+	tx  txn.Runner
+	ctx context.Context // Membawa transaksi dari InTx
+}
+
+func NewConversationRepository(db *sql.DB) ConversationRepository {
+	return &conversationRepo{db: db, tx: txn.NewRunner(db), ctx: context.Background()}
+}
+
+// q mengembalikan transaksi InTx yang sedang berjalan, atau pool koneksi
+func (r *conversationRepo) q() txn.Querier {
+	return txn.From(r.ctx, r.db)
+}
+
+func (r *conversationRepo) InTx(fn func(repo ConversationRepository, publisher events.Publisher) error) error {
+	return r.tx.Do(r.ctx, func(ctx context.Context) error {
+		repo := &conversationRepo{db: r.db, tx: r.tx, ctx: ctx}
+		return fn(repo, outbox.NewOutbox(repo.q()))
+	})
+}
+
+const conversationColumns = "conversation_id, client_id, contact_id, channel, external_address, status, team_id, assignee_id, required_skills, priority, created_at, updated_at"
+
+func scanConversation(row interface{ Scan(...interface{}) error }) (*conversations.Conversation, error) {
+	var c conversations.Conversation
+	var contactID, teamID, assigneeID sql.NullInt64
+	err := row.Scan(&c.ID, &c.ClientID, &contactID, &c.Channel, &c.ExternalAddress, &c.Status,
+		&teamID, &assigneeID, pq.Array(&c.RequiredSkills), &c.Priority, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if contactID.Valid {
+		id := int(contactID.Int64)
+		c.ContactID = &id
+	}
+	if teamID.Valid {
+		id := int(teamID.Int64)
+		c.TeamID = &id
+	}
+	if assigneeID.Valid {
+		id := int(assigneeID.Int64)
+		c.AssigneeID = &id
+	}
+	return &c, nil
+}
+
+func scanConversations(rows *sql.Rows) ([]conversations.Conversation, error) {
+	defer rows.Close()
+
+	var list []conversations.Conversation
+	for rows.Next() {
+		c, err := scanConversation(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *c)
+	}
+	return list, rows.Err()
+}
+
+// FetchByClient mengambil percakapan milik client, opsional difilter berdasarkan status
+func (r *conversationRepo) FetchByClient(clientID int, status string) ([]conversations.Conversation, error) {
+	rows, err := r.q().Query(
+		"SELECT "+conversationColumns+" FROM conversations WHERE client_id = $1 AND ($2 = '' OR status = $2) ORDER BY updated_at DESC",
+		clientID, status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanConversations(rows)
+}
+
+// FetchByContact mengambil riwayat percakapan seorang kontak di semua channel
+func (r *conversationRepo) FetchByContact(contactID int) ([]conversations.Conversation, error) {
+	rows, err := r.q().Query("SELECT "+conversationColumns+" FROM conversations WHERE contact_id = $1 ORDER BY created_at DESC", contactID)
+	if err != nil {
+		return nil, err
+	}
+	return scanConversations(rows)
+}
+
+func (r *conversationRepo) GetByID(id int) (*conversations.Conversation, error) {
+	return scanConversation(r.q().QueryRow("SELECT "+conversationColumns+" FROM conversations WHERE conversation_id = $1", id))
+}
+
+// FindOpenByAddress mencari percakapan yang masih terbuka untuk alamat pelanggan di suatu channel
+func (r *conversationRepo) FindOpenByAddress(clientID int, channel, address string) (*conversations.Conversation, error) {
+	return scanConversation(r.q().QueryRow(
+		"SELECT "+conversationColumns+" FROM conversations WHERE client_id = $1 AND channel = $2 AND external_address = $3 AND status = $4 ORDER BY created_at DESC LIMIT 1",
+		clientID, channel, address, conversations.StatusOpen,
+	))
+}
+
+func (r *conversationRepo) Create(c conversations.Conversation) (int, error) {
+	var id int
+	err := r.q().QueryRow(
+		"INSERT INTO conversations (client_id, contact_id, channel, external_address, status) VALUES ($1, $2, $3, $4, $5) RETURNING conversation_id",
+		c.ClientID, c.ContactID, c.Channel, c.ExternalAddress, c.Status,
+	).Scan(&id)
+	return id, err
+}
+
+// UpdateStatus juga mencatat resolved_at saat percakapan pertama kali diselesaikan dan
+// mengosongkannya kembali bila percakapan dibuka ulang
+func (r *conversationRepo) UpdateStatus(id int, status string) error {
+	_, err := r.q().Exec(`UPDATE conversations SET status = $1, updated_at = NOW(),
+		resolved_at = CASE WHEN $1 IN ('resolved', 'closed') THEN COALESCE(resolved_at, NOW()) ELSE NULL END
+		WHERE conversation_id = $2`, status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *conversationRepo) UpdatePriority(id, priority int) error {
+	_, err := r.q().Exec("UPDATE conversations SET priority = $1, updated_at = NOW() WHERE conversation_id = $2", priority, id)
+	if err != nil {
+		return fmt.Errorf("failed to update priority of conversation %d: %w", id, err)
+	}
+	return nil
+}
+
+// FetchMessages mengambil semua pesan dalam percakapan, urut dari yang terlama
+func (r *conversationRepo) FetchMessages(conversationID int) ([]conversations.Message, error) {
+	rows, err := r.q().Query(
+		"SELECT message_id, conversation_id, client_id, direction, channel, body, external_id, status, sender_user_id, created_at FROM messages WHERE conversation_id = $1 ORDER BY created_at",
+		conversationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []conversations.Message
+	for rows.Next() {
+		var m conversations.Message
+		var sender sql.NullInt64
+		err := rows.Scan(&m.ID, &m.ConversationID, &m.ClientID, &m.Direction, &m.Channel, &m.Body, &m.ExternalID, &m.Status, &sender, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if sender.Valid {
+			id := int(sender.Int64)
+			m.SenderUserID = &id
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+// CreateMessage menyimpan pesan baru dan memperbarui waktu aktivitas percakapan. Pesan inbound
+// dengan external ID yang sudah tersimpan tidak disimpan lagi dan menghasilkan ErrDuplicateMessage.
+func (r *conversationRepo) CreateMessage(m conversations.Message) (int, error) {
+	var id int
+	err := r.q().QueryRow(
+		`INSERT INTO messages (conversation_id, client_id, direction, channel, body, external_id, status, sender_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (client_id, channel, external_id) WHERE direction = 'inbound' AND external_id <> '' DO NOTHING
+		 RETURNING message_id`,
+		m.ConversationID, m.ClientID, m.Direction, m.Channel, m.Body, m.ExternalID, m.Status, m.SenderUserID,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, conversations.ErrDuplicateMessage
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create message: %w", err)
+	}
+	_, err = r.q().Exec("UPDATE conversations SET updated_at = NOW() WHERE conversation_id = $1", m.ConversationID)
+	return id, err
+}
+
+// UpdateMessageStatus memperbarui status pengiriman pesan client berdasarkan ID dari provider
+func (r *conversationRepo) UpdateMessageStatus(clientID int, channel, externalID, status string) error {
+	_, err := r.q().Exec(
+		"UPDATE messages SET status = $1 WHERE client_id = $2 AND channel = $3 AND external_id = $4",
+		status, clientID, channel, externalID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update message status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package usecase
+
+import (
+	"backend/internal/contacts"
+	"backend/internal/conversations"
+	"backend/internal/conversations/repository"
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/routing"
+	"backend/internal/sla"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	ErrNotFound        = errors.New("conversation not found")
+	ErrInvalidStatus   = errors.New("invalid conversation status")
+	ErrInvalidPriority = errors.New("invalid conversation priority")
+)
+
+// ContactResolver mencari atau membuat kontak berdasarkan identitas channel
+type ContactResolver interface {
+	ResolveIdentity(clientID int, identityType, value string) (int, error)
+}
+
+// ConversationRouter mengassign percakapan ke agent
+type ConversationRouter interface {
+	RouteConversation(conv conversations.Conversation) (*routing.Assignment, error)
+	AssignPending(clientID int) (int, error)
+	Assign(clientID, conversationID, toUserID, actorID int, note string) (*routing.Assignment, error)
+}
+
+// SLATracker memperbarui timer SLA berdasarkan aktivitas percakapan
+type SLATracker interface {
+	MessageAdded(conv conversations.Conversation, m conversations.Message, created bool)
+	StatusChanged(conv conversations.Conversation)
+	Timers(conversationIDs []int) (map[int][]sla.Timer, error)
+}
+
+// messagesTotal menghitung pesan yang tersimpan per channel dan arah (inbound/outbound)
+var messagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "messages_total",
+	Help: "Messages stored by channel and direction.",
+}, []string{"channel", "direction"})
+
+// identityTypes memetakan channel ke tipe identitas kontak untuk alamat pelanggan
+var identityTypes = map[string]string{
+	conversations.ChannelSMS: contacts.IdentityPhone,
+}
+
+type ConversationUsecase interface {
+	GetConversations(clientID int, status string) ([]conversations.Conversation, error)
+	GetContactConversations(clientID, contactID int) ([]conversations.Conversation, error)
+	GetConversation(clientID, id int) (*conversations.Conversation, error)
+	GetMessages(clientID, conversationID int) ([]conversations.Message, error)
+	UpdateStatus(clientID, id int, status string) error
+	SetPriority(clientID, id, priority int) error
+	AppendMessage(address string, m conversations.Message) (*conversations.Conversation, *conversations.Message, bool, error)
+	RecordMessage(m conversations.Message) (*conversations.Message, error)
+	UpdateMessageStatus(clientID int, channel, externalID, status string) error
+}
+
+type conversationUsecase struct {
+	repo      repository.ConversationRepository
+	contacts  ContactResolver
+	router    ConversationRouter
+	sla       SLATracker
+	publisher realtime.Publisher
+}
+
+func NewConversationUsecase(repo repository.ConversationRepository, contacts ContactResolver, router ConversationRouter, tracker SLATracker, publisher realtime.Publisher) ConversationUsecase {
+	return &conversationUsecase{repo: repo, contacts: contacts, router: router, sla: tracker, publisher: publisher}
+}
+
+// publish mengirim event real-time ke agent; kegagalan hanya dicatat karena data dan event
+// outbox-nya sudah tersimpan
+func (u *conversationUsecase) publish(eventType string, clientID int, data interface{}) {
+	err := u.publisher.Publish(realtime.Event{Type: eventType, ClientID: clientID, Data: data})
+	if err != nil {
+		slog.Error("Failed to publish conversation event", "event_type", eventType, "client_id", clientID, "error", err)
+	}
+}
+
+// withSLA melengkapi percakapan dengan status timer SLA
+func (u *conversationUsecase) withSLA(list []conversations.Conversation) ([]conversations.Conversation, error) {
+	ids := make([]int, len(list))
+	for i, conv := range list {
+		ids[i] = conv.ID
+	}
+	timers, err := u.sla.Timers(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].SLA = timers[list[i].ID]
+	}
+	return list, nil
+}
+
+func (u *conversationUsecase) GetConversations(clientID int, status string) ([]conversations.Conversation, error) {
+	list, err := u.repo.FetchByClient(clientID, status)
+	if err != nil {
+		return nil, err
+	}
+	return u.withSLA(list)
+}
+
+// GetContactConversations mengambil riwayat percakapan kontak milik client
+func (u *conversationUsecase) GetContactConversations(clientID, contactID int) ([]conversations.Conversation, error) {
+	list, err := u.repo.FetchByContact(contactID)
+	if err != nil {
+		return nil, err
+	}
+	owned := list[:0]
+	for _, conv := range list {
+		if conv.ClientID == clientID {
+			owned = append(owned, conv)
+		}
+	}
+	return u.withSLA(owned)
+}
+
+// GetConversation mengambil percakapan dan memastikan percakapan milik client yang sama
+func (u *conversationUsecase) GetConversation(clientID, id int) (*conversations.Conversation, error) {
+	conv, err := u.repo.GetByID(id)
+	if err != nil || conv.ClientID != clientID {
+		return nil, ErrNotFound
+	}
+	list, err := u.withSLA([]conversations.Conversation{*conv})
+	if err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+func (u *conversationUsecase) GetMessages(clientID, conversationID int) ([]conversations.Message, error) {
+	if _, err := u.GetConversation(clientID, conversationID); err != nil {
+		return nil, err
+	}
+	return u.repo.FetchMessages(conversationID)
+}
+
+func (u *conversationUsecase) UpdateStatus(clientID, id int, status string) error {
+	switch status {
+	case conversations.StatusOpen, conversations.StatusResolved, conversations.StatusClosed:
+	default:
+		return ErrInvalidStatus
+	}
+	conv, err := u.GetConversation(clientID, id)
+	if err != nil {
+		return err
+	}
+	conv.Status = status
+	err = u.repo.InTx(func(repo repository.ConversationRepository, publisher events.Publisher) error {
+		if err := repo.UpdateStatus(id, status); err != nil {
+			return err
+		}
+		return publisher.Publish(clientID, realtime.Payload{Type: realtime.EventConversationStatusChanged, Data: conv})
+	})
+	if err != nil {
+		return err
+	}
+
+	u.sla.StatusChanged(*conv)
+	u.publish(realtime.EventConversationStatusChanged, clientID, conv)
+
+	// Percakapan yang selesai membebaskan kapasitas agent untuk antrean
+	if status != conversations.StatusOpen && conv.AssigneeID != nil {
+		if _, err := u.router.AssignPending(clientID); err != nil {
+			slog.Error("Failed to assign pending conversations", "client_id", clientID, "error", err)
+		}
+	}
+	return nil
+}
+
+// SetPriority mengubah prioritas percakapan; antrean mendahulukan prioritas yang lebih tinggi
+func (u *conversationUsecase) SetPriority(clientID, id, priority int) error {
+	if priority < conversations.PriorityNone || priority > conversations.PriorityUrgent {
+		return ErrInvalidPriority
+	}
+	if _, err := u.GetConversation(clientID, id); err != nil {
+		return err
+	}
+	return u.repo.UpdatePriority(id, priority)
+}
+
+// AppendMessage menyimpan pesan ke percakapan terbuka milik alamat pelanggan dan
+// membuat percakapan baru jika belum ada. Nilai bool bernilai true jika percakapan baru dibuat.
+func (u *conversationUsecase) AppendMessage(address string, m conversations.Message) (*conversations.Conversation, *conversations.Message, bool, error) {
+	created := false
+	conv, err := u.repo.FindOpenByAddress(m.ClientID, m.Channel, address)
+	if errors.Is(err, sql.ErrNoRows) {
+		conv = &conversations.Conversation{
+			ClientID:        m.ClientID,
+			Channel:         m.Channel,
+			ExternalAddress: address,
+			Status:          conversations.StatusOpen,
+		}
+		if identityType, ok := identityTypes[m.Channel]; ok {
+			contactID, err := u.contacts.ResolveIdentity(m.ClientID, identityType, address)
+			if err != nil {
+				// Percakapan tetap dibuat; kontak bisa dihubungkan belakangan
+				slog.Error("Failed to resolve contact", "channel", m.Channel, "address", address, "error", err)
+			} else {
+				conv.ContactID = &contactID
+			}
+		}
+		created = true
+	} else if err != nil {
+		return nil, nil, false, err
+	}
+
+	// Percakapan baru, pesan dan event-nya disimpan bersama agar subscriber outbox tidak
+	// pernah melewatkan pesan yang sudah tersimpan
+	err = u.repo.InTx(func(repo repository.ConversationRepository, publisher events.Publisher) error {
+		var err error
+		if created {
+			if conv.ID, err = repo.Create(*conv); err != nil {
+				return err
+			}
+			if err := publisher.Publish(conv.ClientID, realtime.Payload{Type: realtime.EventConversationCreated, Data: conv}); err != nil {
+				return err
+			}
+		}
+		m.ConversationID = conv.ID
+		if m.ID, err = repo.CreateMessage(m); err != nil {
+			return err
+		}
+		return publisher.Publish(m.ClientID, realtime.Payload{Type: realtime.EventMessageCreated, Data: m})
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	messagesTotal.WithLabelValues(m.Channel, m.Direction).Inc()
+
+	u.sla.MessageAdded(*conv, m, created)
+	if created {
+		u.publish(realtime.EventConversationCreated, conv.ClientID, conv)
+		u.assignNew(conv, m)
+	}
+	u.publish(realtime.EventMessageCreated, m.ClientID, m)
+	return conv, &m, created, nil
+}
+
+// RecordMessage menyimpan pesan ke percakapan m.ConversationID apa pun statusnya, tanpa membuat
+// percakapan baru atau menyentuh SLA dan routing. Dipakai untuk pesan sistem seperti survei.
+func (u *conversationUsecase) RecordMessage(m conversations.Message) (*conversations.Message, error) {
+	err := u.repo.InTx(func(repo repository.ConversationRepository, publisher events.Publisher) error {
+		var err error
+		if m.ID, err = repo.CreateMessage(m); err != nil {
+			return err
+		}
+		return publisher.Publish(m.ClientID, realtime.Payload{Type: realtime.EventMessageCreated, Data: m})
+	})
+	if err != nil {
+		return nil, err
+	}
+	messagesTotal.WithLabelValues(m.Channel, m.Direction).Inc()
+	u.publish(realtime.EventMessageCreated, m.ClientID, m)
+	return &m, nil
+}
+
+// assignNew mengassign percakapan baru: percakapan yang dimulai agent (outbound) langsung
+// menjadi milik agent tersebut, sedangkan percakapan dari pelanggan masuk ke routing engine.
+func (u *conversationUsecase) assignNew(conv *conversations.Conversation, m conversations.Message) {
+	var err error
+	if m.Direction == conversations.DirectionOutbound && m.SenderUserID != nil {
+		_, err = u.router.Assign(conv.ClientID, conv.ID, *m.SenderUserID, *m.SenderUserID, "")
+	} else {
+		var a *routing.Assignment
+		a, err = u.router.RouteConversation(*conv)
+		if a != nil {
+			conv.AssigneeID = &a.ToUserID
+		}
+	}
+	if err != nil {
+		slog.Error("Failed to assign conversation", "conversation_id", conv.ID, "error", err)
+	}
+}
+
+func (u *conversationUsecase) UpdateMessageStatus(clientID int, channel, externalID, status string) error {
+	return u.repo.UpdateMessageStatus(clientID, channel, externalID, status)
+}
@@ -0,0 +1,90 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Status event di outbox
+const (
+	StatusPending   = "pending"
+	StatusPublished = "published" // Semua subscriber sudah memproses event
+	StatusFailed    = "failed"    // Berhenti dicoba setelah MaxAttempts
+)
+
+// Batas percobaan relay per event
+const (
+	MaxAttempts = 10
+	baseBackoff = 5 * time.Second
+	maxBackoff  = 30 * time.Minute
+)
+
+// ErrLeaseLost berarti lease event sudah habis atau diambil relay lain, sehingga hasil
+// pemrosesan tidak dicatat oleh relay ini
+var ErrLeaseLost = errors.New("outbox event is no longer claimed by this relay")
+
+// Payload adalah event domain bertipe, misalnya users.Created
+type Payload interface {
+	EventType() string
+}
+
+// Event adalah amplop event domain yang disimpan di outbox. ID dipakai subscriber
+// sebagai idempotency key karena event bisa dikirim lebih dari sekali.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	ClientID   int             `json:"client_id"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Attempts   int             `json:"attempts"`
+	Handled    []string        `json:"-"` // Subscriber yang sudah memproses event ini
+}
+
+// Decode membaca payload event ke tipe bertipe
+func (e Event) Decode(dst Payload) error {
+	return json.Unmarshal(e.Payload, dst)
+}
+
+// New membungkus payload bertipe menjadi event dengan ID unik
+func New(clientID int, p Payload) (Event, error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return Event{}, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:         hex.EncodeToString(id),
+		Type:       p.EventType(),
+		ClientID:   clientID,
+		Payload:    payload,
+		OccurredAt: time.Now(),
+	}, nil
+}
+
+// Publisher mencatat event domain. Implementasi outbox menulis ke tabel outbox di dalam
+// transaksi pemanggil, sehingga event hanya terkirim jika transaksi berhasil di-commit.
+type Publisher interface {
+	Publish(clientID int, p Payload) error
+}
+
+// Handler memproses event untuk satu subscriber. Error membuat event dicoba lagi nanti.
+type Handler func(ctx context.Context, e Event) error
+
+// Backoff mengembalikan jeda sebelum relay mencoba lagi setelah attempts kali gagal
+func Backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
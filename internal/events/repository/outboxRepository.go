@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"backend/internal/events"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Execer adalah koneksi atau transaksi yang bisa menjalankan perintah SQL
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+type outbox struct {
+	tx Execer
+}
+
+// NewOutbox membuat Publisher yang menulis event ke tabel outbox melalui tx.
+// Pakai transaksi yang sama dengan perubahan data agar keduanya atomik.
+func NewOutbox(tx Execer) events.Publisher {
+	return &outbox{tx: tx}
+}
+
+func (o *outbox) Publish(clientID int, p events.Payload) error {
+	e, err := events.New(clientID, p)
+	if err != nil {
+		return err
+	}
+	_, err = o.tx.Exec(
+		`INSERT INTO outbox_events (event_id, client_id, event_type, payload, status, attempts, next_attempt_at, occurred_at)
+		 VALUES ($1, $2, $3, $4, $5, 0, NOW(), $6)`,
+		e.ID, e.ClientID, e.Type, []byte(e.Payload), events.StatusPending, e.OccurredAt,
+	)
+	return err
+}
+
+// OutboxRepository adalah interface untuk relay event dari outbox
+type OutboxRepository interface {
+	ClaimDue(limit int, lease time.Duration, owner string) ([]events.Event, error)
+	MarkHandled(eventID, subscriber, owner string) error
+	MarkPublished(eventID, owner string) error
+	Reschedule(eventID string, attempts int, next time.Time, lastError string, failed bool, owner string) error
+	Prune(olderThan time.Duration) (int, error)
+}
+
+type outboxRepo struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepo{db: db}
+}
+
+// ClaimDue mengambil event yang belum selesai dan menguncinya atas nama owner selama lease,
+// beserta daftar subscriber yang sudah memprosesnya
+func (r *outboxRepo) ClaimDue(limit int, lease time.Duration, owner string) ([]events.Event, error) {
+	rows, err := r.db.Query(`UPDATE outbox_events o SET locked_until = NOW() + make_interval(secs => $2), locked_by = $4
+		WHERE o.event_id IN (
+			SELECT event_id FROM outbox_events
+			WHERE status = $3 AND next_attempt_at <= NOW() AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY occurred_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING o.event_id, o.client_id, o.event_type, o.payload, o.occurred_at, o.attempts,
+		COALESCE((SELECT array_agg(h.subscriber) FROM outbox_handled h WHERE h.event_id = o.event_id), '{}')`,
+		limit, lease.Seconds(), events.StatusPending, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []events.Event
+	for rows.Next() {
+		var e events.Event
+		var payload []byte
+		err := rows.Scan(&e.ID, &e.ClientID, &e.Type, &payload, &e.OccurredAt, &e.Attempts, pq.Array(&e.Handled))
+		if err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// leaseHeld mengubah hasil perintah yang dijaga lease menjadi ErrLeaseLost jika tidak ada baris
+// yang berubah
+func leaseHeld(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return events.ErrLeaseLost
+	}
+	return nil
+}
+
+// MarkHandled mencatat bahwa subscriber sudah memproses event, sehingga tidak dikirim ulang
+// kepadanya. Catatan hanya ditulis selama owner masih memegang lease event.
+func (r *outboxRepo) MarkHandled(eventID, subscriber, owner string) error {
+	return leaseHeld(r.db.Exec(
+		`INSERT INTO outbox_handled (event_id, subscriber, handled_at)
+		 SELECT event_id, $2, NOW() FROM outbox_events WHERE event_id = $1 AND locked_by = $3 AND locked_until > NOW()
+		 ON CONFLICT DO NOTHING`,
+		eventID, subscriber, owner,
+	))
+}
+
+func (r *outboxRepo) MarkPublished(eventID, owner string) error {
+	return leaseHeld(r.db.Exec(
+		`UPDATE outbox_events SET status = $1, published_at = NOW(), locked_until = NULL, locked_by = NULL
+		 WHERE event_id = $2 AND locked_by = $3 AND locked_until > NOW()`,
+		events.StatusPublished, eventID, owner,
+	))
+}
+
+// Reschedule menjadwalkan ulang event yang gagal diproses sebagian subscriber
+func (r *outboxRepo) Reschedule(eventID string, attempts int, next time.Time, lastError string, failed bool, owner string) error {
+	status := events.StatusPending
+	if failed {
+		status = events.StatusFailed
+	}
+	return leaseHeld(r.db.Exec(
+		`UPDATE outbox_events SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, locked_until = NULL, locked_by = NULL
+		 WHERE event_id = $5 AND locked_by = $6 AND locked_until > NOW()`,
+		status, attempts, next, lastError, eventID, owner,
+	))
+}
+
+// Prune menghapus event yang sudah terkirim lebih lama dari batas. Catatan subscriber di
+// outbox_handled ikut terhapus melalui ON DELETE CASCADE.
+func (r *outboxRepo) Prune(olderThan time.Duration) (int, error) {
+	res, err := r.db.Exec(
+		"DELETE FROM outbox_events WHERE status = $1 AND published_at < NOW() - make_interval(secs => $2)",
+		events.StatusPublished, olderThan.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"backend/internal/events"
+	"backend/internal/events/repository"
+	"backend/pkg/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	relayBatch     = 50
+	handlerTimeout = 30 * time.Second
+	// Event diklaim satu per satu dan subscriber dipanggil berurutan, sehingga lease satu event
+	// harus cukup untuk semua subscriber yang mencapai handlerTimeout
+	relayLeaseMargin = time.Minute
+)
+
+// PruneEvents menghapus event outbox yang sudah terkirim lebih lama dari RetentionHours
+type PruneEvents struct {
+	RetentionHours int `json:"retention_hours"`
+}
+
+func (PruneEvents) JobType() string { return "events.prune" }
+
+// EventBus mengirim event dari outbox ke subscriber di dalam proses secara at-least-once
+type EventBus interface {
+	Subscribe(name string, handler events.Handler, types ...string)
+	RelayPending(ctx context.Context) (int, error)
+	RunRelay(ctx context.Context, interval time.Duration)
+	PruneEvents(ctx context.Context, payload PruneEvents) error
+}
+
+type subscription struct {
+	name    string
+	types   map[string]bool // Kosong berarti semua tipe event
+	handler events.Handler
+}
+
+func (s subscription) wants(eventType string) bool {
+	return len(s.types) == 0 || s.types[eventType]
+}
+
+type eventBus struct {
+	repo repository.OutboxRepository
+	mu   sync.RWMutex
+	subs []subscription
+}
+
+func NewEventBus(repo repository.OutboxRepository) EventBus {
+	return &eventBus{repo: repo}
+}
+
+// Subscribe mendaftarkan handler dengan nama unik. Nama dipakai untuk mencatat event mana yang
+// sudah diproses, jadi jangan diganti setelah ada event di outbox.
+func (b *eventBus) Subscribe(name string, handler events.Handler, types ...string) {
+	s := subscription{name: name, types: make(map[string]bool, len(types)), handler: handler}
+	for _, t := range types {
+		s.types[t] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, s)
+}
+
+// RelayPending memproses sampai satu batch event yang jatuh tempo dan mengembalikan jumlah
+// event yang selesai dikirim ke semua subscriber. Event diklaim satu per satu agar lease tidak
+// habis selagi event lain di batch yang sama diproses.
+func (b *eventBus) RelayPending(ctx context.Context) (int, error) {
+	// Token klaim unik per batch; hasil hanya dicatat selama token ini masih memegang lease
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return 0, err
+	}
+	owner := hex.EncodeToString(token)
+
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+	lease := time.Duration(len(subs))*handlerTimeout + relayLeaseMargin
+
+	published := 0
+	for i := 0; i < relayBatch && ctx.Err() == nil; i++ {
+		due, err := b.repo.ClaimDue(1, lease, owner)
+		if err != nil {
+			return published, err
+		}
+		if len(due) == 0 {
+			break
+		}
+		e := due[0]
+		err = b.relay(ctx, e, subs, owner)
+		switch {
+		case errors.Is(err, events.ErrLeaseLost):
+			logger.From(ctx).Warn("Outbox event lease lost; result discarded", "event_id", e.ID, "event_type", e.Type)
+		case err != nil:
+			logger.From(ctx).Error("Failed to relay event", "event_id", e.ID, "event_type", e.Type, "error", err)
+		default:
+			published++
+		}
+	}
+	return published, nil
+}
+
+// relay mengirim satu event ke subscriber yang belum memprosesnya. Setiap handler dibatasi
+// handlerTimeout agar event selesai sebelum lease habis.
+func (b *eventBus) relay(ctx context.Context, e events.Event, subs []subscription, owner string) error {
+	handled := make(map[string]bool, len(e.Handled))
+	for _, name := range e.Handled {
+		handled[name] = true
+	}
+
+	var failures []string
+	for _, s := range subs {
+		if handled[s.name] || !s.wants(e.Type) {
+			continue
+		}
+		if err := b.handle(ctx, s, e); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", s.name, err))
+			continue
+		}
+		if err := b.repo.MarkHandled(e.ID, s.name, owner); err != nil {
+			return err
+		}
+	}
+
+	if len(failures) == 0 {
+		return b.repo.MarkPublished(e.ID, owner)
+	}
+
+	attempts := e.Attempts + 1
+	lastError := strings.Join(failures, "; ")
+	if err := b.repo.Reschedule(e.ID, attempts, time.Now().Add(events.Backoff(attempts)), lastError, attempts >= events.MaxAttempts, owner); err != nil {
+		return err
+	}
+	return fmt.Errorf("%s", lastError)
+}
+
+func (b *eventBus) handle(ctx context.Context, s subscription, e events.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, handlerTimeout)
+	defer cancel()
+	return s.handler(ctx, e)
+}
+
+// PruneEvents menghapus event yang sudah terkirim ke semua subscriber beserta catatan subscriber-nya
+func (b *eventBus) PruneEvents(ctx context.Context, p PruneEvents) error {
+	n, err := b.repo.Prune(time.Duration(p.RetentionHours) * time.Hour)
+	if err == nil && n > 0 {
+		logger.From(ctx).Info("Pruned published outbox events", "count", n)
+	}
+	return err
+}
+
+// RunRelay memproses outbox setiap interval sampai ctx dibatalkan
+func (b *eventBus) RunRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Batch penuh berarti masih ada antrean, lanjutkan tanpa menunggu tick berikutnya
+			for {
+				n, err := b.RelayPending(ctx)
+				if err != nil {
+					logger.From(ctx).Error("Failed to relay outbox events", "error", err)
+				}
+				if err != nil || n < relayBatch || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
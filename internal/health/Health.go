@@ -0,0 +1,69 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check memeriksa satu dependency yang dibutuhkan untuk melayani request, misalnya database
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// CheckResult hanya menampilkan status di respons probe. Error dan durasi berisi detail
+// internal (alamat host, pesan driver), jadi hanya dicatat ke log server.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"-"`
+	Duration string `json:"-"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// OK bernilai true jika semua check berhasil
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Run menjalankan semua check secara paralel dengan batas waktu bersama. Check yang melewati
+// batas waktu dianggap gagal dengan error context deadline exceeded.
+func Run(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Run(ctx)
+			result := CheckResult{Status: StatusOK, Duration: time.Since(start).Round(time.Millisecond).String()}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}(check)
+	}
+	wg.Wait()
+	return report
+}
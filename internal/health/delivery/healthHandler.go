@@ -0,0 +1,45 @@
+package delivery
+
+import (
+	"net/http"
+	"time"
+
+	"backend/internal/health"
+	"backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout membatasi lama semua check readiness agar probe tidak menggantung
+const readinessTimeout = 3 * time.Second
+
+type HealthHandler struct {
+	checks []health.Check
+}
+
+// NewHealthHandler membuat handler probe dengan check yang harus lolos agar replika siap
+func NewHealthHandler(checks ...health.Check) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Liveness hanya menandakan proses masih melayani HTTP. Dependency sengaja tidak diperiksa agar
+// replika tidak di-restart saat database sedang tidak tersedia.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, health.Report{Status: health.StatusOK})
+}
+
+// Readiness memeriksa database dan migrasi; 503 membuat load balancer berhenti mengirim traffic
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	ctx := c.Request.Context()
+	report := health.Run(ctx, h.checks, readinessTimeout)
+	for name, result := range report.Checks {
+		if result.Status != health.StatusOK {
+			logger.From(ctx).Error("Readiness check failed", "check", name, "error", result.Error, "duration", result.Duration)
+		}
+	}
+	if !report.OK() {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
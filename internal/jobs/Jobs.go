@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Status job
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed" // Gagal setelah MaxAttempts percobaan; bisa di-retry manual
+)
+
+const (
+	DefaultMaxAttempts = 5
+	baseBackoff        = 10 * time.Second
+	maxBackoff         = time.Hour
+)
+
+// ErrPermanent menandai error yang tidak perlu dicoba lagi; job langsung gagal
+var ErrPermanent = errors.New("permanent job failure")
+
+// ErrLostOwnership berarti job sudah tidak dipegang worker ini (lock kedaluwarsa dan job diambil
+// ulang), sehingga hasil percobaannya dibuang
+var ErrLostOwnership = errors.New("job is no longer owned by this worker")
+
+// Job adalah satu unit kerja asinkron di antrean
+type Job struct {
+	ID          int             `json:"id"`
+	ClientID    *int            `json:"client_id"` // Kosong untuk job sistem
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	UniqueKey   *string         `json:"unique_key,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	LockedBy    string          `json:"locked_by,omitempty"`
+	LockedAt    *time.Time      `json:"locked_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Depth adalah jumlah job per tipe dan status yang belum selesai (queued, running atau failed)
+type Depth struct {
+	Type   string
+	Status string
+	Count  int
+}
+
+// Payload adalah data job bertipe; JobType menentukan handler yang menjalankannya
+type Payload interface {
+	JobType() string
+}
+
+// Handler menjalankan job. Error membuat job dicoba lagi dengan backoff, kecuali
+// error yang membungkus ErrPermanent.
+type Handler func(ctx context.Context, j Job) error
+
+// Typed membuat Handler yang membaca payload job ke tipe T terlebih dahulu
+func Typed[T Payload](fn func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, j Job) error {
+		var payload T
+		if err := json.Unmarshal(j.Payload, &payload); err != nil {
+			return errors.Join(ErrPermanent, err)
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// Option mengatur job saat dimasukkan ke antrean
+type Option func(j *Job)
+
+// At menjadwalkan job untuk dijalankan pada waktu tertentu
+func At(t time.Time) Option {
+	return func(j *Job) { j.RunAt = t }
+}
+
+// After menunda job selama d
+func After(d time.Duration) Option {
+	return func(j *Job) { j.RunAt = time.Now().Add(d) }
+}
+
+// MaxAttempts mengganti batas percobaan job
+func MaxAttempts(n int) Option {
+	return func(j *Job) { j.MaxAttempts = n }
+}
+
+// UniqueKey mencegah job lain dengan kunci yang sama masuk antrean selama job ini
+// masih menunggu atau berjalan
+func UniqueKey(key string) Option {
+	return func(j *Job) { j.UniqueKey = &key }
+}
+
+// ForClient mengaitkan job dengan client agar terlihat di endpoint admin client tersebut
+func ForClient(clientID int) Option {
+	return func(j *Job) { j.ClientID = &clientID }
+}
+
+// Backoff mengembalikan jeda sebelum percobaan berikutnya setelah attempts kali gagal
+func Backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,145 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule adalah jadwal cron lima kolom: menit jam tanggal bulan hari-minggu.
+// Mendukung *, daftar (1,15), rentang (1-5), langkah (*/10, 0-30/5) serta
+// @hourly, @daily, @weekly dan @monthly.
+type Schedule struct {
+	spec   string
+	minute [60]bool
+	hour   [24]bool
+	dom    [32]bool
+	month  [13]bool
+	dow    [7]bool
+	anyDom bool
+	anyDow bool
+}
+
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// ParseSchedule membaca ekspresi cron
+func ParseSchedule(spec string) (*Schedule, error) {
+	expr := strings.TrimSpace(spec)
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	s := &Schedule{spec: spec, anyDom: strings.HasPrefix(fields[2], "*"), anyDow: strings.HasPrefix(fields[4], "*")}
+	var dow [8]bool
+	targets := []struct {
+		set      []bool
+		min, max int
+	}{
+		{s.minute[:], 0, 59},
+		{s.hour[:], 0, 23},
+		{s.dom[:], 1, 31},
+		{s.month[:], 1, 12},
+		{dow[:], 0, 7}, // 0 dan 7 sama-sama Minggu
+	}
+	for i, f := range fields {
+		if err := parseField(f, targets[i].set, targets[i].min, targets[i].max); err != nil {
+			return nil, fmt.Errorf("cron %q: field %d: %w", spec, i+1, err)
+		}
+	}
+	copy(s.dow[:], dow[:7])
+	s.dow[0] = s.dow[0] || dow[7]
+	return s, nil
+}
+
+func parseField(field string, set []bool, min, max int) error {
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid step %q", part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return fmt.Errorf("invalid value %q", part)
+				}
+			} else if step > 1 {
+				hi = max // "5/15" berarti mulai 5 sampai akhir rentang
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return fmt.Errorf("value %q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+func (s *Schedule) String() string { return s.spec }
+
+// dayMatches mengikuti aturan cron: jika tanggal dan hari-minggu sama-sama dibatasi,
+// cukup salah satu yang cocok
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom, dow := s.dom[t.Day()], s.dow[t.Weekday()]
+	switch {
+	case s.anyDom && s.anyDow:
+		return true
+	case s.anyDom:
+		return dow
+	case s.anyDow:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next mengembalikan waktu terjadwal pertama setelah t, dalam zona waktu t.
+// Nilai nol berarti jadwal tidak pernah terjadi (misalnya 30 Februari).
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !s.month[t.Month()] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.hour[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !s.minute[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
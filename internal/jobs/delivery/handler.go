@@ -0,0 +1,71 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/jobs/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobHandler struct {
+	usecase usecase.JobQueue
+}
+
+func NewJobHandler(uc usecase.JobQueue) *JobHandler {
+	return &JobHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+	case errors.Is(err, usecase.ErrNotFailed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Only failed jobs can be retried"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// GetJobs mengembalikan job milik client, bisa difilter dengan status dan type
+func (h *JobHandler) GetJobs(c *gin.Context) {
+	list, err := h.usecase.GetJobs(c.GetInt("client_id"), c.Query("status"), c.Query("type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch jobs"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *JobHandler) GetJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	j, err := h.usecase.GetJob(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch job")
+		return
+	}
+	c.JSON(http.StatusOK, j)
+}
+
+// Retry memasukkan kembali job yang gagal ke antrean
+func (h *JobHandler) Retry(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	if err := h.usecase.Retry(c.GetInt("client_id"), id); err != nil {
+		respondError(c, err, "Failed to retry job")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Job queued for retry", "id": id})
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"backend/internal/jobs"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// JobRepository adalah interface untuk antrean job dan jadwal cron
+type JobRepository interface {
+	Enqueue(j jobs.Job) (int, bool, error)
+	Claim(types []string, workerID string) (*jobs.Job, error)
+	Complete(id int, workerID string) error
+	Fail(id int, workerID, lastError string, retryAt *time.Time) error
+	RequeueStale(lockTimeout time.Duration) (int, error)
+	Prune(olderThan time.Duration) (int, error)
+	FetchJobs(clientID int, status, jobType string, limit int) ([]jobs.Job, error)
+	GetJob(id int) (*jobs.Job, error)
+	Retry(id int) (bool, error)
+	SaveSchedule(name, spec string, next time.Time) error
+	FireSchedule(name string, now, next time.Time, j jobs.Job) (bool, error)
+	Depths(ctx context.Context) ([]jobs.Depth, error)
+}
+
+type jobRepo struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) JobRepository {
+	return &jobRepo{db: db}
+}
+
+const jobColumns = `job_id, client_id, job_type, payload, status, attempts, max_attempts, run_at, unique_key,
+	last_error, locked_by, locked_at, created_at, finished_at`
+
+func scanJob(row interface{ Scan(...interface{}) error }) (*jobs.Job, error) {
+	var j jobs.Job
+	var clientID sql.NullInt64
+	var payload []byte
+	var uniqueKey, lockedBy sql.NullString
+	var lockedAt, finishedAt sql.NullTime
+	err := row.Scan(&j.ID, &clientID, &j.Type, &payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.RunAt, &uniqueKey,
+		&j.LastError, &lockedBy, &lockedAt, &j.CreatedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	j.Payload = payload
+	if clientID.Valid {
+		id := int(clientID.Int64)
+		j.ClientID = &id
+	}
+	if uniqueKey.Valid {
+		j.UniqueKey = &uniqueKey.String
+	}
+	j.LockedBy = lockedBy.String
+	if lockedAt.Valid {
+		j.LockedAt = &lockedAt.Time
+	}
+	if finishedAt.Valid {
+		j.FinishedAt = &finishedAt.Time
+	}
+	return &j, nil
+}
+
+// queryer adalah bagian dari *sql.DB dan *sql.Tx yang dipakai untuk memasukkan job
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// enqueue memasukkan job. Jika job lain dengan unique key yang sama masih menunggu atau
+// berjalan, ID job tersebut dikembalikan dengan nilai created false.
+func enqueue(q queryer, j jobs.Job) (int, bool, error) {
+	var id int
+	err := q.QueryRow(
+		`INSERT INTO jobs (client_id, job_type, payload, status, attempts, max_attempts, run_at, unique_key, last_error, created_at)
+		 VALUES ($1, $2, $3, $4, 0, $5, $6, $7, '', NOW())
+		 ON CONFLICT (unique_key) WHERE status IN ('queued', 'running') DO NOTHING
+		 RETURNING job_id`,
+		j.ClientID, j.Type, []byte(j.Payload), jobs.StatusQueued, j.MaxAttempts, j.RunAt, j.UniqueKey,
+	).Scan(&id)
+	if err == nil {
+		return id, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) || j.UniqueKey == nil {
+		return 0, false, err
+	}
+
+	err = q.QueryRow(
+		"SELECT job_id FROM jobs WHERE unique_key = $1 AND status IN ('queued', 'running')", *j.UniqueKey,
+	).Scan(&id)
+	return id, false, err
+}
+
+func (r *jobRepo) Enqueue(j jobs.Job) (int, bool, error) {
+	return enqueue(r.db, j)
+}
+
+// Claim mengambil satu job yang jatuh tempo dengan tipe yang didukung worker. SKIP LOCKED
+// membuat beberapa worker dan replika bisa mengambil job bersamaan tanpa saling menunggu.
+func (r *jobRepo) Claim(types []string, workerID string) (*jobs.Job, error) {
+	j, err := scanJob(r.db.QueryRow(`UPDATE jobs SET status = $3, attempts = attempts + 1, locked_by = $2, locked_at = NOW()
+		WHERE job_id = (
+			SELECT job_id FROM jobs WHERE status = $4 AND run_at <= NOW() AND job_type = ANY($1)
+			ORDER BY run_at, job_id LIMIT 1 FOR UPDATE SKIP LOCKED)
+		RETURNING `+jobColumns,
+		pq.Array(types), workerID, jobs.StatusRunning, jobs.StatusQueued))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return j, err
+}
+
+// Complete menandai job selesai. Hanya berlaku selama job masih running dan dikunci workerID;
+// jika tidak, job sudah diambil alih dan ErrLostOwnership dikembalikan.
+func (r *jobRepo) Complete(id int, workerID string) error {
+	return owned(r.db.Exec(
+		`UPDATE jobs SET status = $1, finished_at = NOW(), locked_by = NULL, locked_at = NULL
+		 WHERE job_id = $2 AND locked_by = $3 AND status = $4`,
+		jobs.StatusSucceeded, id, workerID, jobs.StatusRunning,
+	))
+}
+
+// Fail mencatat kegagalan job. retryAt yang diisi menjadwalkan ulang job; nil berarti job gagal permanen.
+// Seperti Complete, hanya worker yang masih memegang job yang boleh mengubahnya.
+func (r *jobRepo) Fail(id int, workerID, lastError string, retryAt *time.Time) error {
+	if retryAt != nil {
+		return owned(r.db.Exec(
+			`UPDATE jobs SET status = $1, run_at = $2, last_error = $3, locked_by = NULL, locked_at = NULL
+			 WHERE job_id = $4 AND locked_by = $5 AND status = $6`,
+			jobs.StatusQueued, *retryAt, lastError, id, workerID, jobs.StatusRunning,
+		))
+	}
+	return owned(r.db.Exec(
+		`UPDATE jobs SET status = $1, last_error = $2, finished_at = NOW(), locked_by = NULL, locked_at = NULL
+		 WHERE job_id = $3 AND locked_by = $4 AND status = $5`,
+		jobs.StatusFailed, lastError, id, workerID, jobs.StatusRunning,
+	))
+}
+
+// owned mengubah update yang tidak mengenai baris mana pun menjadi ErrLostOwnership
+func owned(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return jobs.ErrLostOwnership
+	}
+	return nil
+}
+
+// RequeueStale mengembalikan job yang terkunci terlalu lama (worker mati) ke antrean. Job yang
+// sudah memakai semua jatah percobaan ditandai gagal, karena Claim sudah menghitung percobaan
+// yang terputus itu.
+func (r *jobRepo) RequeueStale(lockTimeout time.Duration) (int, error) {
+	res, err := r.db.Exec(
+		`UPDATE jobs SET status = CASE WHEN attempts >= max_attempts THEN $1 ELSE $2 END,
+		 run_at = CASE WHEN attempts >= max_attempts THEN run_at ELSE NOW() END,
+		 finished_at = CASE WHEN attempts >= max_attempts THEN NOW() END,
+		 last_error = 'worker lost: lock expired', locked_by = NULL, locked_at = NULL
+		 WHERE status = $3 AND locked_at < NOW() - make_interval(secs => $4)`,
+		jobs.StatusFailed, jobs.StatusQueued, jobs.StatusRunning, lockTimeout.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// Prune menghapus job yang sudah berhasil lebih lama dari batas
+func (r *jobRepo) Prune(olderThan time.Duration) (int, error) {
+	res, err := r.db.Exec(
+		"DELETE FROM jobs WHERE status = $1 AND finished_at < NOW() - make_interval(secs => $2)",
+		jobs.StatusSucceeded, olderThan.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (r *jobRepo) FetchJobs(clientID int, status, jobType string, limit int) ([]jobs.Job, error) {
+	rows, err := r.db.Query(
+		`SELECT `+jobColumns+` FROM jobs WHERE client_id = $1 AND ($2 = '' OR status = $2) AND ($3 = '' OR job_type = $3)
+		 ORDER BY created_at DESC LIMIT $4`,
+		clientID, status, jobType, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []jobs.Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *j)
+	}
+	return list, rows.Err()
+}
+
+func (r *jobRepo) GetJob(id int) (*jobs.Job, error) {
+	return scanJob(r.db.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE job_id = $1", id))
+}
+
+// Retry memasukkan kembali job yang gagal ke antrean dengan jatah percobaan penuh.
+// Nilai false berarti job tidak dalam status gagal.
+func (r *jobRepo) Retry(id int) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE jobs SET status = $1, attempts = 0, run_at = NOW(), finished_at = NULL WHERE job_id = $2 AND status = $3`,
+		jobs.StatusQueued, id, jobs.StatusFailed,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SaveSchedule mendaftarkan jadwal cron. Jadwal yang spec-nya berubah dihitung ulang dari next.
+func (r *jobRepo) SaveSchedule(name, spec string, next time.Time) error {
+	_, err := r.db.Exec(
+		`INSERT INTO job_schedules (name, spec, next_run_at) VALUES ($1, $2, $3)
+		 ON CONFLICT (name) DO UPDATE SET spec = EXCLUDED.spec,
+		 next_run_at = CASE WHEN job_schedules.spec <> EXCLUDED.spec THEN EXCLUDED.next_run_at ELSE job_schedules.next_run_at END`,
+		name, spec, next,
+	)
+	return err
+}
+
+// FireSchedule mengklaim slot jadwal yang jatuh tempo dan memasukkan job-nya dalam satu transaksi,
+// sehingga hanya satu replika yang menjalankan setiap slot
+func (r *jobRepo) FireSchedule(name string, now, next time.Time, j jobs.Job) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"UPDATE job_schedules SET next_run_at = $1, last_run_at = $2 WHERE name = $3 AND next_run_at <= $2",
+		next, now, name,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if _, _, err := enqueue(tx, j); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Depths menghitung job yang menunggu, berjalan atau gagal per tipe. Job yang berhasil tidak
+// dihitung karena dihapus secara berkala.
+func (r *jobRepo) Depths(ctx context.Context) ([]jobs.Depth, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT job_type, status, COUNT(*) FROM jobs WHERE status IN ($1, $2, $3)
+		 GROUP BY job_type, status ORDER BY job_type, status`,
+		jobs.StatusQueued, jobs.StatusRunning, jobs.StatusFailed,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []jobs.Depth
+	for rows.Next() {
+		var d jobs.Depth
+		if err := rows.Scan(&d.Type, &d.Status, &d.Count); err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,340 @@
+package usecase
+
+import (
+	"backend/internal/jobs"
+	"backend/internal/jobs/repository"
+	"backend/pkg/logger"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ErrNotFound       = errors.New("job not found")
+	ErrNotFailed      = errors.New("only failed jobs can be retried")
+	ErrUnknownJobType = errors.New("no handler registered for job type")
+)
+
+const (
+	pollInterval    = time.Second
+	jobTimeout      = 5 * time.Minute
+	lockTimeout     = 10 * time.Minute // Harus lebih lama dari jobTimeout
+	maintenanceTick = 15 * time.Second
+	jobPageSize     = 100
+	depthTimeout    = 5 * time.Second
+)
+
+// JobQueue menjalankan job asinkron dari antrean Postgres
+type JobQueue interface {
+	Register(jobType string, handler jobs.Handler)
+	RegisterCron(name, spec string, payload jobs.Payload, opts ...jobs.Option) error
+	Enqueue(payload jobs.Payload, opts ...jobs.Option) (int, error)
+	GetJobs(clientID int, status, jobType string) ([]jobs.Job, error)
+	GetJob(clientID, id int) (*jobs.Job, error)
+	Retry(clientID, id int) error
+	ProcessNext(ctx context.Context) (bool, error)
+	Run(ctx context.Context, concurrency int)
+	// Depths mengembalikan jumlah job yang belum selesai per tipe dan status
+	Depths(ctx context.Context) ([]jobs.Depth, error)
+}
+
+type cronEntry struct {
+	name     string
+	schedule *jobs.Schedule
+	job      jobs.Job
+}
+
+type jobQueue struct {
+	repo     repository.JobRepository
+	workerID string
+
+	mu       sync.RWMutex
+	handlers map[string]jobs.Handler
+	crons    []cronEntry
+}
+
+// PruneJobs menghapus job yang sudah berhasil lebih lama dari RetentionHours
+type PruneJobs struct {
+	RetentionHours int `json:"retention_hours"`
+}
+
+func (PruneJobs) JobType() string { return "jobs.prune" }
+
+func NewJobQueue(repo repository.JobRepository) JobQueue {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	q := &jobQueue{
+		repo:     repo,
+		workerID: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix)),
+		handlers: make(map[string]jobs.Handler),
+	}
+	q.Register(PruneJobs{}.JobType(), jobs.Typed(func(ctx context.Context, p PruneJobs) error {
+		n, err := repo.Prune(time.Duration(p.RetentionHours) * time.Hour)
+		if err == nil && n > 0 {
+			logger.From(ctx).Info("Pruned finished jobs", "count", n)
+		}
+		return err
+	}))
+	return q
+}
+
+// Register memasang handler untuk satu tipe job. Worker hanya mengambil job yang punya handler.
+func (q *jobQueue) Register(jobType string, handler jobs.Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// newJob menyusun job dari payload bertipe dan opsi
+func newJob(payload jobs.Payload, opts ...jobs.Option) (jobs.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	j := jobs.Job{Type: payload.JobType(), Payload: data, MaxAttempts: jobs.DefaultMaxAttempts, RunAt: time.Now()}
+	for _, opt := range opts {
+		opt(&j)
+	}
+	if j.MaxAttempts < 1 {
+		j.MaxAttempts = 1
+	}
+	return j, nil
+}
+
+// RegisterCron menjadwalkan job berulang. Setiap slot jadwal hanya dijalankan sekali di
+// seluruh replika; job dari slot yang sama dideduplikasi dengan unique key nama jadwal.
+func (q *jobQueue) RegisterCron(name, spec string, payload jobs.Payload, opts ...jobs.Option) error {
+	schedule, err := jobs.ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	j, err := newJob(payload, append(opts, jobs.UniqueKey("cron:"+name))...)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.crons = append(q.crons, cronEntry{name: name, schedule: schedule, job: j})
+	return nil
+}
+
+// Enqueue memasukkan job ke antrean. Jika unique key sudah dipakai job yang masih aktif,
+// ID job tersebut yang dikembalikan.
+func (q *jobQueue) Enqueue(payload jobs.Payload, opts ...jobs.Option) (int, error) {
+	j, err := newJob(payload, opts...)
+	if err != nil {
+		return 0, err
+	}
+	id, _, err := q.repo.Enqueue(j)
+	return id, err
+}
+
+func (q *jobQueue) GetJobs(clientID int, status, jobType string) ([]jobs.Job, error) {
+	return q.repo.FetchJobs(clientID, status, jobType, jobPageSize)
+}
+
+// GetJob mengembalikan job milik client; job sistem tidak terlihat dari endpoint client
+func (q *jobQueue) GetJob(clientID, id int) (*jobs.Job, error) {
+	j, err := q.repo.GetJob(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && (j.ClientID == nil || *j.ClientID != clientID)) {
+		return nil, ErrNotFound
+	}
+	return j, err
+}
+
+func (q *jobQueue) Retry(clientID, id int) error {
+	if _, err := q.GetJob(clientID, id); err != nil {
+		return err
+	}
+	ok, err := q.repo.Retry(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFailed
+	}
+	return nil
+}
+
+func (q *jobQueue) Depths(ctx context.Context) ([]jobs.Depth, error) {
+	return q.repo.Depths(ctx)
+}
+
+// depthCollector mengekspos kedalaman antrean job sebagai gauge jobs_queue_depth
+type depthCollector struct {
+	queue JobQueue
+	desc  *prometheus.Desc
+}
+
+// DepthCollector membuat collector kedalaman antrean yang dihitung ulang pada setiap scrape.
+// Semua replika melaporkan nilai yang sama karena antrean disimpan di database.
+func DepthCollector(q JobQueue) prometheus.Collector {
+	return &depthCollector{
+		queue: q,
+		desc: prometheus.NewDesc("jobs_queue_depth", "Unfinished background jobs by type and status.",
+			[]string{"type", "status"}, nil),
+	}
+}
+
+func (c *depthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect tidak menerima ctx dari scrape, sehingga query dibatasi depthTimeout agar scrape
+// tidak menggantung saat database lambat
+func (c *depthCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), depthTimeout)
+	defer cancel()
+	depths, err := c.queue.Depths(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for _, d := range depths {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(d.Count), d.Type, d.Status)
+	}
+}
+
+func (q *jobQueue) types() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	types := make([]string, 0, len(q.handlers))
+	for t := range q.handlers {
+		types = append(types, t)
+	}
+	return types
+}
+
+// ProcessNext mengambil dan menjalankan satu job. Nilai false berarti antrean kosong.
+// jobs.ErrLostOwnership berarti job sudah diambil alih sebelum hasilnya tercatat.
+func (q *jobQueue) ProcessNext(ctx context.Context) (bool, error) {
+	j, err := q.repo.Claim(q.types(), q.workerID)
+	if err != nil || j == nil {
+		return false, err
+	}
+
+	q.mu.RLock()
+	handler := q.handlers[j.Type]
+	q.mu.RUnlock()
+
+	// Log dari handler job mencantumkan ID dan tipe job
+	l := logger.From(ctx).With("job_id", j.ID, "job_type", j.Type)
+	ctx = logger.WithContext(ctx, l)
+
+	// Job yang sedang berjalan dibiarkan selesai saat shutdown, dibatasi jobTimeout
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobTimeout)
+	defer cancel()
+
+	err = runHandler(jobCtx, handler, *j)
+	if err == nil {
+		err = q.repo.Complete(j.ID, q.workerID)
+	} else {
+		l.Warn("Job attempt failed", "attempt", j.Attempts, "max_attempts", j.MaxAttempts, "error", err)
+		if errors.Is(err, jobs.ErrPermanent) || j.Attempts >= j.MaxAttempts {
+			err = q.repo.Fail(j.ID, q.workerID, err.Error(), nil)
+		} else {
+			retryAt := time.Now().Add(jobs.Backoff(j.Attempts))
+			err = q.repo.Fail(j.ID, q.workerID, err.Error(), &retryAt)
+		}
+	}
+	// Lock kedaluwarsa saat job berjalan terlalu lama; job sudah diantrekan ulang oleh RequeueStale
+	// dan mungkin sedang dijalankan worker lain, jadi statusnya tidak boleh ditimpa
+	if errors.Is(err, jobs.ErrLostOwnership) {
+		l.Warn("Job lock lost before finishing; result discarded")
+	}
+	return true, err
+}
+
+// runHandler menjalankan handler dan mengubah panic menjadi error
+func runHandler(ctx context.Context, handler jobs.Handler, j jobs.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	if handler == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownJobType, j.Type)
+	}
+	return handler(ctx, j)
+}
+
+// Run menjalankan concurrency worker, penjadwal cron dan pemulihan job yang macet sampai
+// ctx dibatalkan. Run baru kembali setelah semua job yang sedang berjalan selesai.
+func (q *jobQueue) Run(ctx context.Context, concurrency int) {
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+
+	q.mu.RLock()
+	crons := q.crons
+	q.mu.RUnlock()
+	for _, c := range crons {
+		if err := q.repo.SaveSchedule(c.name, c.schedule.String(), c.schedule.Next(time.Now())); err != nil {
+			logger.From(ctx).Error("Failed to register cron schedule", "schedule", c.name, "error", err)
+		}
+	}
+
+	ticker := time.NewTicker(maintenanceTick)
+	defer ticker.Stop()
+	q.tick(crons)
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+			q.tick(crons)
+		}
+	}
+}
+
+// work mengambil job terus-menerus; saat antrean kosong menunggu pollInterval
+func (q *jobQueue) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		processed, err := q.ProcessNext(ctx)
+		if err != nil && !errors.Is(err, jobs.ErrLostOwnership) {
+			logger.From(ctx).Error("Failed to process job", "error", err)
+		}
+		if processed && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// tick menjalankan jadwal cron yang jatuh tempo dan memulihkan job yang terkunci terlalu lama
+func (q *jobQueue) tick(crons []cronEntry) {
+	now := time.Now()
+	for _, c := range crons {
+		j := c.job
+		j.RunAt = now
+		if _, err := q.repo.FireSchedule(c.name, now, c.schedule.Next(now), j); err != nil {
+			slog.Error("Failed to fire cron schedule", "schedule", c.name, "error", err)
+		}
+	}
+	if n, err := q.repo.RequeueStale(lockTimeout); err != nil {
+		slog.Error("Failed to requeue stale jobs", "error", err)
+	} else if n > 0 {
+		slog.Info("Recovered stale jobs", "count", n)
+	}
+}
@@ -0,0 +1,110 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Status migrasi
+const (
+	StatePending  = "pending"
+	StateApplied  = "applied"
+	StateModified = "modified" // File up berubah setelah migrasi diterapkan
+	StateMissing  = "missing"  // Tercatat di database tetapi tidak ada di binary ini, misalnya dari versi yang lebih baru
+)
+
+// Migration adalah satu versi skema dari file <versi>_<nama>.up.sql dan .down.sql
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string // Kosong jika migrasi tidak bisa dibatalkan
+	Checksum string // SHA-256 dari SQL up
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Applied adalah migrasi yang tercatat di tabel schema_migrations
+type Applied struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Status adalah keadaan satu versi untuk perintah status
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Checksum menghitung checksum SQL migrasi
+func Checksum(sql string) string {
+	sum := sha256.Sum256([]byte(sql))
+	return hex.EncodeToString(sum[:])
+}
+
+// Load membaca migrasi dari direktori root fsys, diurutkan dari versi terkecil. File lain
+// diabaikan; versi ganda, nama yang berbeda antara up dan down, atau down tanpa up ditolak.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Clean(entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			if m.Up != "" {
+				return nil, fmt.Errorf("duplicate up migration for version %d", version)
+			}
+			m.Up = string(content)
+			m.Checksum = Checksum(m.Up)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %s has no up file", m)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
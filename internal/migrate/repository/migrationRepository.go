@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"backend/internal/migrate"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// migrationLockKey adalah advisory lock agar hanya satu replika yang menjalankan migrasi
+const migrationLockKey = 44001
+
+type MigrationRepository interface {
+	// Lock mengembalikan repository yang terikat ke koneksi pemegang lock; semua langkah migrasi
+	// harus dijalankan melaluinya
+	Lock(ctx context.Context) (locked MigrationRepository, unlock func(), err error)
+	EnsureTable(ctx context.Context) error
+	FetchApplied(ctx context.Context) ([]migrate.Applied, error)
+	Apply(ctx context.Context, m migrate.Migration) error
+	Revert(ctx context.Context, m migrate.Migration) error
+}
+
+// querier adalah *sql.DB atau *sql.Conn
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type migrationRepo struct {
+	db   *sql.DB
+	conn querier // Koneksi pemegang lock; sama dengan db sebelum Lock
+}
+
+func NewMigrationRepository(db *sql.DB) MigrationRepository {
+	return &migrationRepo{db: db, conn: db}
+}
+
+// Lock menunggu advisory lock migrasi. Lock level sesi sehingga koneksinya dipegang sampai
+// unlock dipanggil. Migrasi berjalan di koneksi yang sama melalui repository yang dikembalikan,
+// sehingga tidak menunggu koneksi kedua saat pool hanya berisi satu koneksi.
+func (r *migrationRepo) Lock(ctx context.Context) (MigrationRepository, func(), error) {
+	if r.conn != querier(r.db) {
+		return nil, nil, errors.New("migration lock is already held")
+	}
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	return &migrationRepo{db: r.db, conn: conn}, func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			slog.Error("Failed to release migration lock", "error", err)
+		}
+		conn.Close()
+	}, nil
+}
+
+func (r *migrationRepo) EnsureTable(ctx context.Context) error {
+	_, err := r.conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		checksum   TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func (r *migrationRepo) FetchApplied(ctx context.Context) ([]migrate.Applied, error) {
+	rows, err := r.conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []migrate.Applied
+	for rows.Next() {
+		var a migrate.Applied
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// Apply menjalankan SQL up dan mencatat versinya dalam satu transaksi
+func (r *migrationRepo) Apply(ctx context.Context, m migrate.Migration) error {
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+		return fmt.Errorf("migration %s failed: %w", m, err)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, NOW())",
+		m.Version, m.Name, m.Checksum)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", m, err)
+	}
+	return tx.Commit()
+}
+
+// Revert menjalankan SQL down dan menghapus catatan versinya dalam satu transaksi
+func (r *migrationRepo) Revert(ctx context.Context, m migrate.Migration) error {
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.Down); err != nil {
+		return fmt.Errorf("rollback of migration %s failed: %w", m, err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", m.Version); err != nil {
+		return fmt.Errorf("failed to remove migration record %s: %w", m, err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"backend/internal/migrate"
+	"backend/internal/migrate/repository"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrChecksumMismatch = errors.New("applied migrations were modified")
+	ErrIrreversible     = errors.New("migration has no down file")
+	ErrNothingToRevert  = errors.New("no applied migrations to revert")
+	ErrInvalidSteps     = errors.New("steps must be at least 1")
+	ErrPending          = errors.New("migrations are pending")
+)
+
+// Migrator menjalankan migrasi skema. Semua perintah memegang advisory lock migrasi sehingga
+// replika lain yang ikut start menunggu sampai migrasi selesai.
+type Migrator interface {
+	Up(ctx context.Context) ([]migrate.Migration, error)
+	Down(ctx context.Context, steps int) ([]migrate.Migration, error)
+	Redo(ctx context.Context) (*migrate.Migration, error)
+	Status(ctx context.Context) ([]migrate.Status, error)
+	Check(ctx context.Context) error
+}
+
+type migrator struct {
+	repo       repository.MigrationRepository
+	migrations []migrate.Migration
+}
+
+// NewMigrator membuat Migrator untuk daftar migrasi hasil migrate.Load
+func NewMigrator(repo repository.MigrationRepository, migrations []migrate.Migration) Migrator {
+	return &migrator{repo: repo, migrations: migrations}
+}
+
+// begin mengambil lock, menyiapkan tabel schema_migrations dan membaca versi yang sudah diterapkan.
+// Langkah berikutnya harus memakai repository yang dikembalikan, yang terikat ke koneksi pemegang lock.
+func (m *migrator) begin(ctx context.Context) (repository.MigrationRepository, func(), map[int64]migrate.Applied, error) {
+	repo, unlock, err := m.repo.Lock(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := repo.EnsureTable(ctx); err != nil {
+		unlock()
+		return nil, nil, nil, err
+	}
+	applied, err := fetchApplied(ctx, repo)
+	if err != nil {
+		unlock()
+		return nil, nil, nil, err
+	}
+	return repo, unlock, applied, nil
+}
+
+func fetchApplied(ctx context.Context, repo repository.MigrationRepository) (map[int64]migrate.Applied, error) {
+	list, err := repo.FetchApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]migrate.Applied, len(list))
+	for _, a := range list {
+		applied[a.Version] = a
+	}
+	return applied, nil
+}
+
+// verify menolak berjalan jika file migrasi yang sudah diterapkan diubah, karena database
+// tidak lagi sesuai dengan isi file tersebut
+func (m *migrator) verify(applied map[int64]migrate.Applied) error {
+	var modified []string
+	for _, mig := range m.migrations {
+		if a, ok := applied[mig.Version]; ok && a.Checksum != mig.Checksum {
+			modified = append(modified, mig.String())
+		}
+	}
+	if len(modified) > 0 {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, strings.Join(modified, ", "))
+	}
+	return nil
+}
+
+// Up menerapkan semua migrasi yang belum diterapkan, berurutan dari versi terkecil. Versi di
+// database yang tidak dikenal binary ini (dari rilis yang lebih baru) dibiarkan.
+func (m *migrator) Up(ctx context.Context) ([]migrate.Migration, error) {
+	repo, unlock, applied, err := m.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := m.verify(applied); err != nil {
+		return nil, err
+	}
+
+	var done []migrate.Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := repo.Apply(ctx, mig); err != nil {
+			return done, err
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down membatalkan steps migrasi terakhir yang sudah diterapkan, dari versi terbesar
+func (m *migrator) Down(ctx context.Context, steps int) ([]migrate.Migration, error) {
+	if steps < 1 {
+		return nil, ErrInvalidSteps
+	}
+	repo, unlock, applied, err := m.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	targets, err := m.lastApplied(applied, steps)
+	if err != nil {
+		return nil, err
+	}
+	var done []migrate.Migration
+	for _, mig := range targets {
+		if err := repo.Revert(ctx, mig); err != nil {
+			return done, err
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Redo membatalkan lalu menerapkan ulang migrasi terakhir, berguna saat mengembangkan migrasi baru
+func (m *migrator) Redo(ctx context.Context) (*migrate.Migration, error) {
+	repo, unlock, applied, err := m.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	targets, err := m.lastApplied(applied, 1)
+	if err != nil {
+		return nil, err
+	}
+	mig := targets[0]
+	if err := repo.Revert(ctx, mig); err != nil {
+		return nil, err
+	}
+	if err := repo.Apply(ctx, mig); err != nil {
+		return nil, err
+	}
+	return &mig, nil
+}
+
+// lastApplied memilih steps migrasi terakhir untuk dibatalkan. Versi terbaru di database
+// harus dikenal binary ini dan tidak berubah, karena SQL down-nya diambil dari file.
+func (m *migrator) lastApplied(applied map[int64]migrate.Applied, steps int) ([]migrate.Migration, error) {
+	if err := m.verify(applied); err != nil {
+		return nil, err
+	}
+	known := make(map[int64]migrate.Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	if len(versions) == 0 {
+		return nil, ErrNothingToRevert
+	}
+	if steps > len(versions) {
+		steps = len(versions)
+	}
+
+	var targets []migrate.Migration
+	for _, v := range versions[:steps] {
+		mig, ok := known[v]
+		if !ok {
+			return nil, fmt.Errorf("migration %04d_%s is not known to this binary", v, applied[v].Name)
+		}
+		if strings.TrimSpace(mig.Down) == "" {
+			return nil, fmt.Errorf("%w: %s", ErrIrreversible, mig)
+		}
+		targets = append(targets, mig)
+	}
+	return targets, nil
+}
+
+// Status mengembalikan keadaan semua migrasi yang dikenal binary maupun yang tercatat di database
+func (m *migrator) Status(ctx context.Context) ([]migrate.Status, error) {
+	_, unlock, applied, err := m.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var list []migrate.Status
+	seen := map[int64]bool{}
+	for _, mig := range m.migrations {
+		s := migrate.Status{Version: mig.Version, Name: mig.Name, State: migrate.StatePending}
+		if a, ok := applied[mig.Version]; ok {
+			at := a.AppliedAt
+			s.AppliedAt = &at
+			s.State = migrate.StateApplied
+			if a.Checksum != mig.Checksum {
+				s.State = migrate.StateModified
+			}
+		}
+		seen[mig.Version] = true
+		list = append(list, s)
+	}
+	for v, a := range applied {
+		if !seen[v] {
+			at := a.AppliedAt
+			list = append(list, migrate.Status{Version: v, Name: a.Name, State: migrate.StateMissing, AppliedAt: &at})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Check memastikan semua migrasi yang dikenal binary sudah diterapkan tanpa perubahan. Check tidak
+// mengambil lock maupun membuat tabel, sehingga aman dipanggil berkala oleh readiness probe.
+func (m *migrator) Check(ctx context.Context) error {
+	applied, err := fetchApplied(ctx, m.repo)
+	if err != nil {
+		return err
+	}
+	if err := m.verify(applied); err != nil {
+		return err
+	}
+	var pending []string
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig.String())
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPending, strings.Join(pending, ", "))
+	}
+	return nil
+}
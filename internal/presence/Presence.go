@@ -0,0 +1,68 @@
+package presence
+
+import "time"
+
+// Status agent
+const (
+	StatusOnline  = "online"
+	StatusAway    = "away"
+	StatusBusy    = "busy"
+	StatusOffline = "offline"
+)
+
+// Alasan perubahan status otomatis
+const (
+	ReasonIdle         = "idle"
+	ReasonDisconnected = "disconnected"
+)
+
+// DefaultIdleTimeoutSeconds adalah batas tanpa aktivitas sebelum agent otomatis menjadi away
+const DefaultIdleTimeoutSeconds = 600
+
+// Presence adalah status terkini seorang agent
+type Presence struct {
+	UserID         int        `json:"user_id"`
+	ClientID       int        `json:"client_id"`
+	Username       string     `json:"username"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason,omitempty"` // Alasan break atau alasan perubahan otomatis
+	Auto           bool       `json:"auto"`             // true jika status diubah otomatis oleh sistem
+	Connections    int        `json:"connections"`      // Jumlah koneksi real-time yang aktif
+	LastActivityAt *time.Time `json:"last_activity_at"`
+	ChangedAt      *time.Time `json:"changed_at"`
+}
+
+// StatusChange adalah satu periode status pada riwayat untuk pelaporan
+type StatusChange struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	ClientID  int        `json:"client_id"`
+	Status    string     `json:"status"`
+	Reason    string     `json:"reason,omitempty"`
+	Auto      bool       `json:"auto"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+}
+
+// Settings adalah pengaturan presence milik client
+type Settings struct {
+	ClientID           int      `json:"client_id"`
+	IdleTimeoutSeconds int      `json:"idle_timeout_seconds"`
+	BreakReasons       []string `json:"break_reasons"` // Alasan break yang boleh dipilih agent, misalnya "lunch"
+}
+
+// IdleCandidate adalah agent online yang harus diubah menjadi away
+type IdleCandidate struct {
+	UserID   int
+	ClientID int
+	Reason   string
+}
+
+// ValidStatus memeriksa apakah status dikenal
+func ValidStatus(s string) bool {
+	switch s {
+	case StatusOnline, StatusAway, StatusBusy, StatusOffline:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/presence"
+	"backend/internal/presence/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultHistoryRange adalah rentang riwayat status jika from/to tidak diisi
+const defaultHistoryRange = 24 * time.Hour
+
+type PresenceHandler struct {
+	usecase usecase.PresenceUsecase
+}
+
+func NewPresenceHandler(uc usecase.PresenceUsecase) *PresenceHandler {
+	return &PresenceHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrAgentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
+	case errors.Is(err, usecase.ErrInvalidStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+	case errors.Is(err, usecase.ErrInvalidReason):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid break reason"})
+	case errors.Is(err, usecase.ErrInvalidSettings):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid presence settings"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+type statusRequest struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// SetMyStatus mengubah status user yang login
+func (h *PresenceHandler) SetMyStatus(c *gin.Context) {
+	h.setStatus(c, c.GetInt("user_id"))
+}
+
+// SetAgentStatus mengubah status agent lain, misalnya oleh supervisor
+func (h *PresenceHandler) SetAgentStatus(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	h.setStatus(c, userID)
+}
+
+func (h *PresenceHandler) setStatus(c *gin.Context, userID int) {
+	var req statusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	p, err := h.usecase.SetStatus(c.GetInt("client_id"), userID, req.Status, req.Reason)
+	if err != nil {
+		respondError(c, err, "Failed to update status")
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// GetPresence mengembalikan status live semua agent client
+func (h *PresenceHandler) GetPresence(c *gin.Context) {
+	list, err := h.usecase.GetPresence(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch presence"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GetTeamPresence mengembalikan status live anggota satu tim
+func (h *PresenceHandler) GetTeamPresence(c *gin.Context) {
+	teamID, err := strconv.Atoi(c.Param("team_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	list, err := h.usecase.GetTeamPresence(c.GetInt("client_id"), teamID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch presence"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GetHistory mengembalikan riwayat status untuk pelaporan.
+// Query: from dan to (RFC3339, default 24 jam terakhir), user_id (opsional).
+func (h *PresenceHandler) GetHistory(c *gin.Context) {
+	to := time.Now()
+	from := to.Add(-defaultHistoryRange)
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to"})
+			return
+		}
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	var userID *int
+	if v := c.Query("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		userID = &id
+	}
+
+	list, err := h.usecase.GetHistory(c.GetInt("client_id"), userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status history"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *PresenceHandler) GetSettings(c *gin.Context) {
+	s, err := h.usecase.GetSettings(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch presence settings"})
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+func (h *PresenceHandler) UpdateSettings(c *gin.Context) {
+	var s presence.Settings
+	if err := c.ShouldBindJSON(&s); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	s.ClientID = c.GetInt("client_id")
+
+	if err := h.usecase.UpdateSettings(s); err != nil {
+		respondError(c, err, "Failed to update presence settings")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Presence settings updated"})
+}
+
+// RecordActivity dipanggil client SSE (yang tidak bisa mengirim frame) saat agent aktif
+func (h *PresenceHandler) RecordActivity(c *gin.Context) {
+	if err := h.usecase.RecordActivity(c.GetInt("client_id"), c.GetInt("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record activity"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"backend/internal/events"
+	outbox "backend/internal/events/repository"
+	"backend/internal/presence"
+	"backend/pkg/txn"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// connectionTTL adalah batas heartbeat sebelum koneksi dianggap mati (misalnya replika crash)
+	connectionTTL = "90 seconds"
+	// disconnectGrace memberi waktu agent menyambung ulang (misalnya reload halaman) sebelum dianggap terputus
+	disconnectGrace = "60 seconds"
+)
+
+// PresenceRepository adalah interface untuk status, koneksi real-time dan riwayat status agent
+type PresenceRepository interface {
+	GetSettings(clientID int) (*presence.Settings, error)
+	SaveSettings(s presence.Settings) error
+	Get(userID int) (*presence.Presence, error)
+	FetchByClient(clientID int, teamID *int) ([]presence.Presence, error)
+	SetStatus(clientID, userID int, status, reason string, auto bool) error
+	AddConnection(connectionID string, clientID, userID int) error
+	MarkDisconnected(connectionID string) error
+	TouchConnections(connectionIDs []string) error
+	DeleteStaleConnections() error
+	RecordActivity(userID int) error
+	FindIdle() ([]presence.IdleCandidate, error)
+	FetchHistory(clientID int, userID *int, from, to time.Time) ([]presence.StatusChange, error)
+	// InTx menjalankan fn dalam satu transaksi bersama publisher outbox, sehingga event presence
+	// hanya tercatat jika perubahan status berhasil di-commit
+	InTx(fn func(repo PresenceRepository, publisher events.Publisher) error) error
+}
+
+type presenceRepo struct {
+	db  *sql.DB
+	tx  txn.Runner
+	ctx context.Context // Membawa transaksi dari InTx
+}
+
+func NewPresenceRepository(db *sql.DB) PresenceRepository {
+	return &presenceRepo{db: db, tx: txn.NewRunner(db), ctx: context.Background()}
+}
+
+// q mengembalikan transaksi InTx yang sedang berjalan, atau pool koneksi
+func (r *presenceRepo) q() txn.Querier {
+	return txn.From(r.ctx, r.db)
+}
+
+func (r *presenceRepo) InTx(fn func(repo PresenceRepository, publisher events.Publisher) error) error {
+	return r.tx.Do(r.ctx, func(ctx context.Context) error {
+		repo := &presenceRepo{db: r.db, tx: r.tx, ctx: ctx}
+		return fn(repo, outbox.NewOutbox(repo.q()))
+	})
+}
+
+// GetSettings mengambil pengaturan presence client, atau default jika belum ada
+func (r *presenceRepo) GetSettings(clientID int) (*presence.Settings, error) {
+	s := presence.Settings{ClientID: clientID, IdleTimeoutSeconds: presence.DefaultIdleTimeoutSeconds, BreakReasons: []string{}}
+	err := r.q().QueryRow(
+		"SELECT idle_timeout_seconds, break_reasons FROM presence_settings WHERE client_id = $1", clientID,
+	).Scan(&s.IdleTimeoutSeconds, pq.Array(&s.BreakReasons))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *presenceRepo) SaveSettings(s presence.Settings) error {
+	_, err := r.q().Exec(
+		`INSERT INTO presence_settings (client_id, idle_timeout_seconds, break_reasons) VALUES ($1, $2, $3)
+		 ON CONFLICT (client_id) DO UPDATE SET idle_timeout_seconds = EXCLUDED.idle_timeout_seconds, break_reasons = EXCLUDED.break_reasons`,
+		s.ClientID, s.IdleTimeoutSeconds, pq.Array(s.BreakReasons),
+	)
+	return err
+}
+
+// presenceSelect mengambil user beserta status dan jumlah koneksi yang masih hidup.
+// User tanpa baris agent_presence dianggap offline.
+const presenceSelect = `SELECT u.user_id, u.client_id, u.username, COALESCE(p.status, 'offline'), COALESCE(p.reason, ''),
+	COALESCE(p.auto, false), p.last_activity_at, p.changed_at,
+	(SELECT COUNT(*) FROM presence_connections c WHERE c.user_id = u.user_id AND ` + liveConnection + `)
+	FROM users u LEFT JOIN agent_presence p ON p.user_id = u.user_id `
+
+// liveConnection adalah kondisi SQL untuk koneksi yang masih dianggap hidup
+const liveConnection = `c.last_seen_at > NOW() - interval '` + connectionTTL + `'
+	AND (c.disconnected_at IS NULL OR c.disconnected_at > NOW() - interval '` + disconnectGrace + `')`
+
+func scanPresence(row interface{ Scan(...interface{}) error }) (*presence.Presence, error) {
+	var p presence.Presence
+	var lastActivity, changed sql.NullTime
+	err := row.Scan(&p.UserID, &p.ClientID, &p.Username, &p.Status, &p.Reason, &p.Auto, &lastActivity, &changed, &p.Connections)
+	if err != nil {
+		return nil, err
+	}
+	if lastActivity.Valid {
+		p.LastActivityAt = &lastActivity.Time
+	}
+	if changed.Valid {
+		p.ChangedAt = &changed.Time
+	}
+	return &p, nil
+}
+
+func (r *presenceRepo) Get(userID int) (*presence.Presence, error) {
+	return scanPresence(r.q().QueryRow(presenceSelect+"WHERE u.user_id = $1", userID))
+}
+
+// FetchByClient mengambil presence semua agent client, atau hanya anggota tim jika teamID diisi
+func (r *presenceRepo) FetchByClient(clientID int, teamID *int) ([]presence.Presence, error) {
+	rows, err := r.q().Query(presenceSelect+`WHERE u.client_id = $1
+		AND ($2::int IS NULL OR EXISTS(SELECT 1 FROM team_members tm WHERE tm.user_id = u.user_id AND tm.team_id = $2))
+		ORDER BY u.username`, clientID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []presence.Presence
+	for rows.Next() {
+		p, err := scanPresence(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *p)
+	}
+	return list, rows.Err()
+}
+
+// SetStatus mengubah status agent dan menutup periode status sebelumnya pada riwayat
+func (r *presenceRepo) SetStatus(clientID, userID int, status, reason string, auto bool) error {
+	return r.tx.Do(r.ctx, func(ctx context.Context) error {
+		tx := txn.From(ctx, r.db)
+		_, err := tx.Exec(
+			`INSERT INTO agent_presence (user_id, client_id, status, reason, auto, changed_at, last_activity_at)
+			 VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+			 ON CONFLICT (user_id) DO UPDATE SET status = EXCLUDED.status, reason = EXCLUDED.reason, auto = EXCLUDED.auto, changed_at = NOW(),
+			 last_activity_at = CASE WHEN EXCLUDED.auto THEN agent_presence.last_activity_at ELSE NOW() END`,
+			userID, clientID, status, reason, auto,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to set status for user %d: %w", userID, err)
+		}
+
+		if _, err := tx.Exec("UPDATE agent_status_history SET ended_at = NOW() WHERE user_id = $1 AND ended_at IS NULL", userID); err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			"INSERT INTO agent_status_history (user_id, client_id, status, reason, auto, started_at) VALUES ($1, $2, $3, $4, $5, NOW())",
+			userID, clientID, status, reason, auto,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record status history: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *presenceRepo) AddConnection(connectionID string, clientID, userID int) error {
+	_, err := r.q().Exec(
+		"INSERT INTO presence_connections (connection_id, user_id, client_id, connected_at, last_seen_at) VALUES ($1, $2, $3, NOW(), NOW())",
+		connectionID, userID, clientID,
+	)
+	return err
+}
+
+func (r *presenceRepo) MarkDisconnected(connectionID string) error {
+	_, err := r.q().Exec("UPDATE presence_connections SET disconnected_at = NOW() WHERE connection_id = $1", connectionID)
+	return err
+}
+
+// TouchConnections memperbarui heartbeat koneksi yang masih terbuka di replika ini
+func (r *presenceRepo) TouchConnections(connectionIDs []string) error {
+	if len(connectionIDs) == 0 {
+		return nil
+	}
+	_, err := r.q().Exec("UPDATE presence_connections SET last_seen_at = NOW() WHERE connection_id = ANY($1)", pq.Array(connectionIDs))
+	return err
+}
+
+// DeleteStaleConnections menghapus koneksi yang sudah lama terputus atau tidak mengirim heartbeat
+func (r *presenceRepo) DeleteStaleConnections() error {
+	_, err := r.q().Exec(`DELETE FROM presence_connections
+		WHERE last_seen_at < NOW() - interval '5 minutes' OR disconnected_at < NOW() - interval '5 minutes'`)
+	return err
+}
+
+func (r *presenceRepo) RecordActivity(userID int) error {
+	_, err := r.q().Exec("UPDATE agent_presence SET last_activity_at = NOW() WHERE user_id = $1", userID)
+	return err
+}
+
+// FindIdle mencari agent online yang tidak memiliki koneksi hidup atau tidak aktif
+// melebihi batas idle client
+func (r *presenceRepo) FindIdle() ([]presence.IdleCandidate, error) {
+	rows, err := r.q().Query(`SELECT p.user_id, p.client_id,
+		CASE WHEN live.user_id IS NULL THEN 'disconnected' ELSE 'idle' END
+		FROM agent_presence p
+		LEFT JOIN presence_settings s ON s.client_id = p.client_id
+		LEFT JOIN (SELECT DISTINCT c.user_id FROM presence_connections c WHERE `+liveConnection+`) live ON live.user_id = p.user_id
+		WHERE p.status = 'online' AND (live.user_id IS NULL
+			OR p.last_activity_at < NOW() - make_interval(secs => COALESCE(s.idle_timeout_seconds, $1)))`,
+		presence.DefaultIdleTimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []presence.IdleCandidate
+	for rows.Next() {
+		var c presence.IdleCandidate
+		if err := rows.Scan(&c.UserID, &c.ClientID, &c.Reason); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// FetchHistory mengambil periode status yang bersinggungan dengan rentang waktu
+func (r *presenceRepo) FetchHistory(clientID int, userID *int, from, to time.Time) ([]presence.StatusChange, error) {
+	rows, err := r.q().Query(`SELECT history_id, user_id, client_id, status, reason, auto, started_at, ended_at
+		FROM agent_status_history
+		WHERE client_id = $1 AND ($2::int IS NULL OR user_id = $2)
+		AND started_at < $4 AND (ended_at IS NULL OR ended_at > $3)
+		ORDER BY started_at`, clientID, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []presence.StatusChange
+	for rows.Next() {
+		var h presence.StatusChange
+		var ended sql.NullTime
+		if err := rows.Scan(&h.ID, &h.UserID, &h.ClientID, &h.Status, &h.Reason, &h.Auto, &h.StartedAt, &ended); err != nil {
+			return nil, err
+		}
+		if ended.Valid {
+			h.EndedAt = &ended.Time
+		}
+		list = append(list, h)
+	}
+	return list, rows.Err()
+}
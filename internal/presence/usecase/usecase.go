@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"backend/internal/events"
+	"backend/internal/presence"
+	"backend/internal/presence/repository"
+	"backend/internal/realtime"
+	"backend/pkg/logger"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAgentNotFound   = errors.New("agent not found")
+	ErrInvalidStatus   = errors.New("invalid status")
+	ErrInvalidReason   = errors.New("invalid break reason")
+	ErrInvalidSettings = errors.New("invalid presence settings")
+)
+
+// AgentRouter mengassign antrean percakapan client ke agent yang tersedia
+type AgentRouter interface {
+	AssignPending(clientID int) (int, error)
+}
+
+// ConnectionLister mengembalikan ID koneksi real-time yang terbuka di replika ini
+type ConnectionLister interface {
+	ConnectionIDs() []string
+}
+
+type PresenceUsecase interface {
+	realtime.ConnectionObserver
+	GetSettings(clientID int) (*presence.Settings, error)
+	UpdateSettings(s presence.Settings) error
+	GetPresence(clientID int) ([]presence.Presence, error)
+	GetTeamPresence(clientID, teamID int) ([]presence.Presence, error)
+	SetStatus(clientID, userID int, status, reason string) (*presence.Presence, error)
+	RecordActivity(clientID, userID int) error
+	GetHistory(clientID int, userID *int, from, to time.Time) ([]presence.StatusChange, error)
+	ProcessIdle() (int, error)
+	RunSweeper(ctx context.Context, connections ConnectionLister, interval time.Duration)
+}
+
+type presenceUsecase struct {
+	repo      repository.PresenceRepository
+	router    AgentRouter
+	publisher realtime.Publisher
+}
+
+func NewPresenceUsecase(repo repository.PresenceRepository, router AgentRouter, publisher realtime.Publisher) PresenceUsecase {
+	return &presenceUsecase{repo: repo, router: router, publisher: publisher}
+}
+
+func (u *presenceUsecase) GetSettings(clientID int) (*presence.Settings, error) {
+	return u.repo.GetSettings(clientID)
+}
+
+func (u *presenceUsecase) UpdateSettings(s presence.Settings) error {
+	if s.IdleTimeoutSeconds < 60 {
+		return ErrInvalidSettings
+	}
+	reasons := []string{}
+	for _, r := range s.BreakReasons {
+		if r = strings.TrimSpace(r); r != "" {
+			reasons = append(reasons, r)
+		}
+	}
+	s.BreakReasons = reasons
+	return u.repo.SaveSettings(s)
+}
+
+func (u *presenceUsecase) GetPresence(clientID int) ([]presence.Presence, error) {
+	return u.repo.FetchByClient(clientID, nil)
+}
+
+func (u *presenceUsecase) GetTeamPresence(clientID, teamID int) ([]presence.Presence, error) {
+	return u.repo.FetchByClient(clientID, &teamID)
+}
+
+// SetStatus mengubah status agent secara manual, oleh agent sendiri atau supervisor.
+// Alasan break hanya boleh untuk status away atau busy dan harus terdaftar di pengaturan client.
+func (u *presenceUsecase) SetStatus(clientID, userID int, status, reason string) (*presence.Presence, error) {
+	if !presence.ValidStatus(status) {
+		return nil, ErrInvalidStatus
+	}
+	reason = strings.TrimSpace(reason)
+	if reason != "" {
+		if status != presence.StatusAway && status != presence.StatusBusy {
+			return nil, ErrInvalidReason
+		}
+		settings, err := u.repo.GetSettings(clientID)
+		if err != nil {
+			return nil, err
+		}
+		if len(settings.BreakReasons) > 0 && !contains(settings.BreakReasons, reason) {
+			return nil, ErrInvalidReason
+		}
+	}
+
+	current, err := u.repo.Get(userID)
+	if err != nil || current.ClientID != clientID {
+		return nil, ErrAgentNotFound
+	}
+	return u.changeStatus(clientID, userID, status, reason, false)
+}
+
+func (u *presenceUsecase) changeStatus(clientID, userID int, status, reason string, auto bool) (*presence.Presence, error) {
+	var p *presence.Presence
+	err := u.repo.InTx(func(repo repository.PresenceRepository, publisher events.Publisher) error {
+		if err := repo.SetStatus(clientID, userID, status, reason, auto); err != nil {
+			return err
+		}
+		var err error
+		if p, err = repo.Get(userID); err != nil {
+			return err
+		}
+		return publisher.Publish(clientID, realtime.Payload{Type: realtime.EventPresenceUpdated, Data: p})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.publisher.Publish(realtime.Event{Type: realtime.EventPresenceUpdated, ClientID: clientID, Data: p})
+	if err != nil {
+		slog.Error("Failed to publish presence", "user_id", userID, "error", err)
+	}
+	if status == presence.StatusOnline {
+		// Agent yang kembali online bisa langsung menerima antrean percakapan
+		if _, err := u.router.AssignPending(clientID); err != nil {
+			slog.Error("Failed to assign pending conversations", "client_id", clientID, "error", err)
+		}
+	}
+	return p, nil
+}
+
+// RecordActivity mencatat aktivitas agent. Agent yang otomatis away karena idle
+// atau terputus dikembalikan menjadi online.
+func (u *presenceUsecase) RecordActivity(clientID, userID int) error {
+	if err := u.repo.RecordActivity(userID); err != nil {
+		return err
+	}
+	return u.restore(clientID, userID)
+}
+
+func (u *presenceUsecase) restore(clientID, userID int) error {
+	p, err := u.repo.Get(userID)
+	if err != nil {
+		return err
+	}
+	if p.Status == presence.StatusAway && p.Auto {
+		_, err = u.changeStatus(clientID, userID, presence.StatusOnline, "", false)
+	}
+	return err
+}
+
+func (u *presenceUsecase) GetHistory(clientID int, userID *int, from, to time.Time) ([]presence.StatusChange, error) {
+	return u.repo.FetchHistory(clientID, userID, from, to)
+}
+
+// Connected mencatat koneksi real-time baru milik agent
+func (u *presenceUsecase) Connected(s *realtime.Subscriber) {
+	if err := u.repo.AddConnection(s.ID, s.ClientID, s.UserID); err != nil {
+		slog.Error("Failed to record connection", "user_id", s.UserID, "error", err)
+		return
+	}
+	if err := u.RecordActivity(s.ClientID, s.UserID); err != nil {
+		slog.Error("Failed to record activity", "user_id", s.UserID, "error", err)
+	}
+}
+
+// Disconnected menandai koneksi terputus. Agent baru dianggap away oleh sweeper
+// setelah masa tenggang, sehingga reload halaman tidak mengubah status.
+func (u *presenceUsecase) Disconnected(s *realtime.Subscriber) {
+	if err := u.repo.MarkDisconnected(s.ID); err != nil {
+		slog.Error("Failed to record disconnect", "user_id", s.UserID, "error", err)
+	}
+}
+
+func (u *presenceUsecase) Activity(s *realtime.Subscriber) {
+	if err := u.RecordActivity(s.ClientID, s.UserID); err != nil {
+		slog.Error("Failed to record activity", "user_id", s.UserID, "error", err)
+	}
+}
+
+// ProcessIdle mengubah agent online yang idle atau tidak terhubung menjadi away
+func (u *presenceUsecase) ProcessIdle() (int, error) {
+	idle, err := u.repo.FindIdle()
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range idle {
+		if _, err := u.changeStatus(c.ClientID, c.UserID, presence.StatusAway, c.Reason, true); err != nil {
+			return 0, err
+		}
+	}
+	return len(idle), nil
+}
+
+// RunSweeper memperbarui heartbeat koneksi replika ini dan memproses agent idle
+// secara berkala sampai ctx dibatalkan
+func (u *presenceUsecase) RunSweeper(ctx context.Context, connections ConnectionLister, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := u.repo.TouchConnections(connections.ConnectionIDs()); err != nil {
+				logger.From(ctx).Error("Failed to refresh presence connections", "error", err)
+			}
+			if err := u.repo.DeleteStaleConnections(); err != nil {
+				logger.From(ctx).Error("Failed to delete stale presence connections", "error", err)
+			}
+			if _, err := u.ProcessIdle(); err != nil {
+				logger.From(ctx).Error("Failed to process idle agents", "error", err)
+			}
+		}
+	}
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package realtime
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Tipe event yang dikirim ke agent melalui stream real-time
+const (
+	EventMessageCreated            = "message.created"
+	EventConversationCreated       = "conversation.created"
+	EventConversationAssigned      = "conversation.assigned"
+	EventConversationEnqueued      = "conversation.enqueued"
+	EventConversationStatusChanged = "conversation.status_changed"
+	EventPresenceUpdated           = "presence.updated"
+	EventSLAWarning                = "sla.warning"
+	EventSLABreached               = "sla.breached"
+	// EventResync dikirim setelah koneksi LISTEN terputus; client sebaiknya memuat ulang data
+	EventResync = "stream.resync"
+)
+
+// Event adalah pesan yang di-fan-out ke semua agent pada satu client (tenant)
+type Event struct {
+	Type      string      `json:"type"`
+	ClientID  int         `json:"client_id"`
+	UserID    int         `json:"user_id,omitempty"` // Jika diisi, event hanya dikirim ke user tersebut
+	Data      interface{} `json:"data,omitempty"`
+	Truncated bool        `json:"truncated,omitempty"` // Data dibuang karena melebihi batas payload NOTIFY
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Payload membungkus data event real-time sebagai events.Payload agar bisa dicatat di outbox
+// dalam transaksi yang sama dengan perubahan datanya. Hub hanya mengirim event ke agent secara
+// best-effort; subscriber yang tidak boleh kehilangan event (webhook, automation) membacanya
+// dari outbox. Hanya Data yang disimpan sebagai payload.
+type Payload struct {
+	Type string
+	Data interface{}
+}
+
+func (p Payload) EventType() string {
+	return p.Type
+}
+
+func (p Payload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Data)
+}
+
+// Publisher adalah interface untuk mengirim event real-time
+type Publisher interface {
+	Publish(e Event) error
+}
+
+// ConnectionObserver menerima pemberitahuan siklus hidup koneksi stream,
+// misalnya untuk melacak presence agent
+type ConnectionObserver interface {
+	Connected(s *Subscriber)
+	Disconnected(s *Subscriber)
+	Activity(s *Subscriber)
+}
@@ -0,0 +1,150 @@
+package delivery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = 50 * time.Second
+	sseKeepAlive   = 25 * time.Second
+	maxClientFrame = 1024
+)
+
+// Autentikasi memakai token JWT (bukan cookie), sehingga origin lain tidak bisa
+// membuka stream atas nama agent tanpa memiliki token-nya.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+type RealtimeHandler struct {
+	hub      *realtime.Hub
+	observer realtime.ConnectionObserver
+}
+
+// NewRealtimeHandler membuat handler stream. observer boleh nil.
+func NewRealtimeHandler(hub *realtime.Hub, observer realtime.ConnectionObserver) *RealtimeHandler {
+	return &RealtimeHandler{hub: hub, observer: observer}
+}
+
+// clientFrame adalah pesan yang dikirim agent melalui WebSocket
+type clientFrame struct {
+	Type string `json:"type"`
+}
+
+const frameActivity = "activity"
+
+// Stream membuka stream event untuk agent yang login. Request dengan header
+// Upgrade dilayani sebagai WebSocket, selain itu sebagai Server-Sent Events.
+func (h *RealtimeHandler) Stream(c *gin.Context) {
+	sub := h.hub.Subscribe(c.GetInt("client_id"), c.GetInt("user_id"))
+	defer h.hub.Unsubscribe(sub)
+	if h.observer != nil {
+		h.observer.Connected(sub)
+		defer h.observer.Disconnected(sub)
+	}
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.serveWebSocket(c, sub)
+		return
+	}
+	h.serveSSE(c, sub)
+}
+
+func (h *RealtimeHandler) serveWebSocket(c *gin.Context, sub *realtime.Subscriber) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrader sudah menulis respon error ke client
+		return
+	}
+	defer conn.Close()
+
+	// Baca frame dari client untuk mendeteksi koneksi putus, menerima pong dan sinyal aktivitas agent
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadLimit(maxClientFrame)
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pongWait))
+		})
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var f clientFrame
+			if json.Unmarshal(data, &f) == nil && f.Type == frameActivity && h.observer != nil {
+				h.observer.Activity(sub)
+			}
+			conn.SetReadDeadline(time.Now().Add(pongWait))
+		}
+	}()
+
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case e, ok := <-sub.Events:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "stream closed"))
+				return
+			}
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *RealtimeHandler) serveSSE(c *gin.Context, sub *realtime.Subscriber) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Matikan buffering di reverse proxy nginx
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	// WriteTimeout server berlaku untuk seluruh request, sehingga stream panjang memperpanjang
+	// deadline-nya sendiri sebelum setiap tulisan seperti pada WebSocket
+	rc := http.NewResponseController(c.Writer)
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case e, ok := <-sub.Events:
+			if !ok {
+				return false
+			}
+			rc.SetWriteDeadline(time.Now().Add(writeWait))
+			c.SSEvent(e.Type, e)
+			return true
+		case <-ticker.C:
+			rc.SetWriteDeadline(time.Now().Add(writeWait))
+			// Komentar SSE sebagai heartbeat agar proxy tidak menutup koneksi idle
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
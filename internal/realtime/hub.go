@@ -0,0 +1,283 @@
+package realtime
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// NotifyChannel adalah channel Postgres LISTEN/NOTIFY yang dipakai semua replika
+	NotifyChannel = "omchannel_events"
+	// maxNotifyPayload sedikit di bawah batas 8000 byte payload NOTIFY
+	maxNotifyPayload = 7900
+	subscriberBuffer = 64
+)
+
+// Subscriber adalah satu koneksi stream milik agent
+type Subscriber struct {
+	ID       string // ID unik koneksi, dipakai untuk melacak presence agent
+	ClientID int
+	UserID   int
+	Events   chan Event
+}
+
+// Hub menyimpan subscriber lokal dan menyalurkan event dari Postgres NOTIFY,
+// sehingga event yang dipublikasikan di replika mana pun sampai ke semua agent.
+type Hub struct {
+	db      *sql.DB
+	connStr string
+
+	mu        sync.RWMutex
+	subs      map[int]map[*Subscriber]struct{}
+	observers []func(Event)
+
+	// open berisi subscriber yang handler-nya belum memanggil Unsubscribe; dipakai untuk
+	// menunggu stream selesai saat shutdown
+	open      map[*Subscriber]struct{}
+	closed    bool
+	drained   chan struct{}
+	drainOnce sync.Once
+}
+
+// NewHub membuat hub real-time. Jika db nil, event hanya disalurkan secara lokal.
+func NewHub(db *sql.DB, connStr string) *Hub {
+	return &Hub{
+		db:      db,
+		connStr: connStr,
+		subs:    make(map[int]map[*Subscriber]struct{}),
+		open:    make(map[*Subscriber]struct{}),
+		drained: make(chan struct{}),
+	}
+}
+
+// Subscribe mendaftarkan koneksi stream baru untuk user pada client tertentu
+func (h *Hub) Subscribe(clientID, userID int) *Subscriber {
+	s := &Subscriber{ID: newSubscriberID(), ClientID: clientID, UserID: userID, Events: make(chan Event, subscriberBuffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.open[s] = struct{}{}
+	if h.closed {
+		// Hub sedang shutdown; stream langsung berakhir dan client menyambung ke replika lain
+		close(s.Events)
+		return s
+	}
+	if h.subs[clientID] == nil {
+		h.subs[clientID] = make(map[*Subscriber]struct{})
+	}
+	h.subs[clientID][s] = struct{}{}
+	return s
+}
+
+func newSubscriberID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ConnectionIDs mengembalikan ID semua subscriber yang terhubung ke replika ini
+func (h *Hub) ConnectionIDs() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var ids []string
+	for _, tenant := range h.subs {
+		for s := range tenant {
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids
+}
+
+// Unsubscribe melepas subscriber dan menutup channel event-nya
+func (h *Hub) Unsubscribe(s *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(s)
+	delete(h.open, s)
+	h.checkDrained()
+}
+
+// CloseStreams menutup channel event semua subscriber lokal dan menolak subscriber baru.
+// Dipanggil saat shutdown sehingga stream WebSocket mengirim close frame dan stream SSE
+// berakhir, lalu client menyambung ulang ke replika lain.
+func (h *Hub) CloseStreams() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for _, tenant := range h.subs {
+		for s := range tenant {
+			h.remove(s)
+		}
+	}
+	h.checkDrained()
+}
+
+// WaitStreams menunggu semua handler stream memanggil Unsubscribe setelah CloseStreams.
+// Koneksi WebSocket sudah di-hijack sehingga tidak ditunggu oleh http.Server.Shutdown.
+func (h *Hub) WaitStreams(ctx context.Context) error {
+	select {
+	case <-h.drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// checkDrained harus dipanggil dengan h.mu terkunci
+func (h *Hub) checkDrained() {
+	if h.closed && len(h.open) == 0 {
+		h.drainOnce.Do(func() { close(h.drained) })
+	}
+}
+
+// remove harus dipanggil dengan h.mu terkunci
+func (h *Hub) remove(s *Subscriber) {
+	tenant := h.subs[s.ClientID]
+	if _, ok := tenant[s]; !ok {
+		return
+	}
+	delete(tenant, s)
+	close(s.Events)
+	if len(tenant) == 0 {
+		delete(h.subs, s.ClientID)
+	}
+}
+
+// Observe mendaftarkan fungsi yang menerima setiap event yang dipublikasikan dari replika
+// ini, lengkap dengan data sebelum dipotong. Fungsi dipanggil sinkron dan tidak boleh blocking.
+func (h *Hub) Observe(fn func(Event)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.observers = append(h.observers, fn)
+}
+
+// Publish mengirim event ke semua replika melalui pg_notify
+func (h *Hub) Publish(e Event) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	h.mu.RLock()
+	observers := h.observers
+	h.mu.RUnlock()
+	for _, fn := range observers {
+		fn(e)
+	}
+	if h.db == nil {
+		h.dispatch(e)
+		return nil
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if len(payload) > maxNotifyPayload {
+		// Kirim event tanpa data; client memuat ulang data melalui REST API
+		e.Data, e.Truncated = nil, true
+		if payload, err = json.Marshal(e); err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+
+	_, err = h.db.Exec("SELECT pg_notify($1, $2)", NotifyChannel, string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+// Run mendengarkan NotifyChannel sampai ctx dibatalkan
+func (h *Hub) Run(ctx context.Context) error {
+	listener := pq.NewListener(h.connStr, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("Realtime listener error", "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(NotifyChannel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", NotifyChannel, err)
+	}
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.NotificationChannel():
+			if n == nil {
+				// Koneksi tersambung ulang; event selama terputus mungkin hilang
+				h.broadcast(Event{Type: EventResync, CreatedAt: time.Now()})
+				continue
+			}
+			var e Event
+			if err := json.Unmarshal([]byte(n.Extra), &e); err != nil {
+				slog.Error("Invalid realtime event payload", "error", err)
+				continue
+			}
+			h.dispatch(e)
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
+
+// dispatch mengirim event ke subscriber lokal milik client event tersebut
+func (h *Hub) dispatch(e Event) {
+	h.deliver(e, false)
+}
+
+// broadcast mengirim event ke semua subscriber lokal di semua client
+func (h *Hub) broadcast(e Event) {
+	h.deliver(e, true)
+}
+
+// deliver mengirim event tanpa blocking. Subscriber yang buffer-nya penuh diputus
+// agar tidak memperlambat yang lain; client akan menyambung ulang dan memuat ulang data.
+func (h *Hub) deliver(e Event, allClients bool) {
+	var slow []*Subscriber
+
+	h.mu.RLock()
+	tenants := []map[*Subscriber]struct{}{h.subs[e.ClientID]}
+	if allClients {
+		tenants = tenants[:0]
+		for _, tenant := range h.subs {
+			tenants = append(tenants, tenant)
+		}
+	}
+	for _, tenant := range tenants {
+		for s := range tenant {
+			if e.UserID != 0 && e.UserID != s.UserID {
+				continue
+			}
+			select {
+			case s.Events <- e:
+			default:
+				slow = append(slow, s)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(slow) > 0 {
+		h.mu.Lock()
+		for _, s := range slow {
+			h.remove(s)
+		}
+		h.mu.Unlock()
+	}
+}
@@ -0,0 +1,175 @@
+package routing
+
+import (
+	"sort"
+	"time"
+)
+
+// Strategi routing yang bisa dipilih per client
+const (
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastBusy   = "least_busy"
+	StrategySkillsBased = "skills_based"
+	StrategySticky      = "sticky"
+)
+
+// Alasan perpindahan assignment pada riwayat
+const (
+	ReasonAuto     = "auto"
+	ReasonManual   = "manual"
+	ReasonTransfer = "transfer"
+)
+
+// Settings adalah konfigurasi routing milik satu client
+type Settings struct {
+	ClientID             int    `json:"client_id"`
+	Strategy             string `json:"strategy"`
+	FallbackStrategy     string `json:"fallback_strategy"` // Dipakai strategi sticky jika agent terakhir tidak tersedia
+	AutoAssign           bool   `json:"auto_assign"`
+	DefaultMaxConcurrent int    `json:"default_max_concurrent"`
+}
+
+// DefaultSettings adalah konfigurasi routing untuk client yang belum mengatur apa pun
+func DefaultSettings(clientID int) Settings {
+	return Settings{
+		ClientID:             clientID,
+		Strategy:             StrategyRoundRobin,
+		FallbackStrategy:     StrategyLeastBusy,
+		AutoAssign:           true,
+		DefaultMaxConcurrent: 5,
+	}
+}
+
+// ValidStrategy memeriksa apakah nama strategi dikenal
+func ValidStrategy(s string) bool {
+	switch s {
+	case StrategyRoundRobin, StrategyLeastBusy, StrategySkillsBased, StrategySticky:
+		return true
+	}
+	return false
+}
+
+// Agent adalah user yang bisa menerima percakapan beserta kapasitasnya saat ini
+type Agent struct {
+	UserID         int            `json:"user_id"`
+	Username       string         `json:"username"`
+	Available      bool           `json:"available"`
+	MaxConcurrent  int            `json:"max_concurrent"`
+	ActiveCount    int            `json:"active_count"`
+	LastAssignedAt *time.Time     `json:"last_assigned_at"`
+	Skills         map[string]int `json:"skills"` // Nama skill -> tingkat kemahiran
+}
+
+// HasCapacity bernilai true jika agent tersedia dan belum mencapai batas percakapan
+func (a Agent) HasCapacity() bool {
+	return a.Available && a.ActiveCount < a.MaxConcurrent
+}
+
+// Assignment adalah satu baris riwayat assignment percakapan
+type Assignment struct {
+	ID             int       `json:"id"`
+	ConversationID int       `json:"conversation_id"`
+	ClientID       int       `json:"client_id"`
+	FromUserID     *int      `json:"from_user_id"`
+	ToUserID       int       `json:"to_user_id"`
+	AssignedBy     *int      `json:"assigned_by"` // nil jika diassign otomatis oleh sistem
+	Reason         string    `json:"reason"`
+	Strategy       string    `json:"strategy,omitempty"`
+	Note           string    `json:"note,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Request adalah kebutuhan percakapan yang akan di-routing
+type Request struct {
+	RequiredSkills []string
+	LastAgentID    int // Agent terakhir yang menangani kontak, untuk strategi sticky
+}
+
+// SelectAgent memilih agent untuk sebuah percakapan sesuai strategi client.
+// Hanya agent yang tersedia dan masih memiliki kapasitas yang dipertimbangkan;
+// nil berarti percakapan harus menunggu di antrean.
+func SelectAgent(settings Settings, agents []Agent, req Request) *Agent {
+	candidates := make([]Agent, 0, len(agents))
+	for _, a := range agents {
+		if a.HasCapacity() {
+			candidates = append(candidates, a)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	switch settings.Strategy {
+	case StrategySticky:
+		for _, a := range candidates {
+			if req.LastAgentID != 0 && a.UserID == req.LastAgentID {
+				return &a
+			}
+		}
+		fallback := settings
+		fallback.Strategy = settings.FallbackStrategy
+		if fallback.Strategy == StrategySticky || !ValidStrategy(fallback.Strategy) {
+			fallback.Strategy = StrategyLeastBusy
+		}
+		return SelectAgent(fallback, candidates, req)
+	case StrategySkillsBased:
+		return selectBySkills(candidates, req.RequiredSkills)
+	case StrategyLeastBusy:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].ActiveCount != candidates[j].ActiveCount {
+				return candidates[i].ActiveCount < candidates[j].ActiveCount
+			}
+			return assignedBefore(candidates[i], candidates[j])
+		})
+	default:
+		// Round-robin: agent yang paling lama tidak menerima percakapan mendapat giliran
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return assignedBefore(candidates[i], candidates[j])
+		})
+	}
+	return &candidates[0]
+}
+
+// selectBySkills memilih agent yang memiliki semua skill yang dibutuhkan dengan total
+// kemahiran tertinggi; jika sama, agent dengan beban paling ringan yang dipilih.
+func selectBySkills(candidates []Agent, required []string) *Agent {
+	var best *Agent
+	bestScore := -1
+	for i := range candidates {
+		a := candidates[i]
+		score, qualified := 0, true
+		for _, skill := range required {
+			level := a.Skills[skill]
+			if level <= 0 {
+				qualified = false
+				break
+			}
+			score += level
+		}
+		if !qualified {
+			continue
+		}
+		if best == nil || score > bestScore ||
+			(score == bestScore && (a.ActiveCount < best.ActiveCount ||
+				(a.ActiveCount == best.ActiveCount && assignedBefore(a, *best)))) {
+			best, bestScore = &candidates[i], score
+		}
+	}
+	return best
+}
+
+// assignedBefore bernilai true jika a terakhir menerima percakapan lebih dulu dari b.
+// Agent yang belum pernah menerima percakapan selalu didahulukan.
+func assignedBefore(a, b Agent) bool {
+	switch {
+	case a.LastAssignedAt == nil && b.LastAssignedAt == nil:
+		return a.UserID < b.UserID
+	case a.LastAssignedAt == nil:
+		return true
+	case b.LastAssignedAt == nil:
+		return false
+	case a.LastAssignedAt.Equal(*b.LastAssignedAt):
+		return a.UserID < b.UserID
+	}
+	return a.LastAssignedAt.Before(*b.LastAssignedAt)
+}
@@ -0,0 +1,192 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/routing"
+	"backend/internal/routing/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoutingHandler struct {
+	usecase usecase.RoutingUsecase
+}
+
+func NewRoutingHandler(uc usecase.RoutingUsecase) *RoutingHandler {
+	return &RoutingHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+	case errors.Is(err, usecase.ErrAgentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
+	case errors.Is(err, usecase.ErrAgentUnavailable):
+		c.JSON(http.StatusConflict, gin.H{"error": "Agent is not available"})
+	case errors.Is(err, usecase.ErrNotAssignee):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the current assignee can transfer the conversation"})
+	case errors.Is(err, usecase.ErrInvalidSettings):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid routing settings"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *RoutingHandler) GetSettings(c *gin.Context) {
+	settings, err := h.usecase.GetSettings(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch routing settings"})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *RoutingHandler) UpdateSettings(c *gin.Context) {
+	var req routing.Settings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ClientID = c.GetInt("client_id")
+
+	if err := h.usecase.UpdateSettings(req); err != nil {
+		respondError(c, err, "Failed to update routing settings")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Routing settings updated"})
+}
+
+// GetAgents mengembalikan kapasitas dan beban semua agent client
+func (h *RoutingHandler) GetAgents(c *gin.Context) {
+	agents, err := h.usecase.GetAgents(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch agents"})
+		return
+	}
+	c.JSON(http.StatusOK, agents)
+}
+
+type agentRequest struct {
+	MaxConcurrent int `json:"max_concurrent"`
+}
+
+func (h *RoutingHandler) UpdateAgent(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req agentRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.MaxConcurrent < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.usecase.UpdateAgent(c.GetInt("client_id"), userID, req.MaxConcurrent); err != nil {
+		respondError(c, err, "Failed to update agent")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Agent updated", "user_id": userID})
+}
+
+func (h *RoutingHandler) SetAgentSkills(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req struct {
+		Skills map[string]int `json:"skills"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.usecase.SetAgentSkills(c.GetInt("client_id"), userID, req.Skills); err != nil {
+		respondError(c, err, "Failed to update skills")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Skills updated", "user_id": userID})
+}
+
+type assignRequest struct {
+	UserID int    `json:"user_id"`
+	Note   string `json:"note"`
+}
+
+// Assign menetapkan agent percakapan secara manual
+func (h *RoutingHandler) Assign(c *gin.Context) {
+	h.reassign(c, h.usecase.Assign)
+}
+
+// Transfer memindahkan percakapan milik agent yang login ke agent lain
+func (h *RoutingHandler) Transfer(c *gin.Context) {
+	h.reassign(c, h.usecase.Transfer)
+}
+
+func (h *RoutingHandler) reassign(c *gin.Context, fn func(clientID, conversationID, toUserID, actorID int, note string) (*routing.Assignment, error)) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req assignRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+
+	assignment, err := fn(c.GetInt("client_id"), id, req.UserID, c.GetInt("user_id"), req.Note)
+	if err != nil {
+		respondError(c, err, "Failed to assign conversation")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation assigned", "assignment": assignment})
+}
+
+func (h *RoutingHandler) SetRequiredSkills(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req struct {
+		Skills []string `json:"skills"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.usecase.SetRequiredSkills(c.GetInt("client_id"), id, req.Skills); err != nil {
+		respondError(c, err, "Failed to update required skills")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Required skills updated", "id": id})
+}
+
+// GetAssignments mengembalikan riwayat assignment percakapan
+func (h *RoutingHandler) GetAssignments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	list, err := h.usecase.GetAssignments(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch assignment history")
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
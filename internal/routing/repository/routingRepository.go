@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"backend/internal/events"
+	outbox "backend/internal/events/repository"
+	"backend/internal/routing"
+	"backend/pkg/txn"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ErrAlreadyAssigned dikembalikan jika percakapan sudah diassign oleh proses lain
+var ErrAlreadyAssigned = errors.New("conversation already assigned")
+
+// Target adalah data percakapan yang dibutuhkan untuk routing
+type Target struct {
+	ID             int
+	ClientID       int
+	ContactID      *int
+	TeamID         *int // Jika diisi, hanya anggota tim yang dipertimbangkan
+	AssigneeID     *int
+	Status         string
+	RequiredSkills []string
+}
+
+// RoutingRepository adalah interface untuk konfigurasi routing, kapasitas agent dan riwayat assignment
+type RoutingRepository interface {
+	GetSettings(clientID int) (*routing.Settings, error)
+	SaveSettings(s routing.Settings) error
+	FetchAgents(clientID int) ([]routing.Agent, error)
+	SaveAgentSettings(userID int, maxConcurrent int) error
+	SetAgentSkills(userID int, skills map[string]int) error
+	UserBelongsToClient(userID, clientID int) (bool, error)
+	GetTarget(conversationID int) (*Target, error)
+	FetchPendingTargets(clientID int) ([]Target, error)
+	LastAgentForContact(contactID int) (int, error)
+	AutoAssign(t Target, strategy string, choose func([]routing.Agent) *routing.Agent) (*routing.Assignment, error)
+	Reassign(a routing.Assignment) (*routing.Assignment, error)
+	SetRequiredSkills(conversationID int, skills []string) error
+	FetchAssignments(conversationID int) ([]routing.Assignment, error)
+	// InTx menjalankan fn dalam satu transaksi bersama publisher outbox; AutoAssign dan
+	// Reassign di dalamnya memakai savepoint pada transaksi tersebut
+	InTx(fn func(repo RoutingRepository, publisher events.Publisher) error) error
+}
+
+type routingRepo struct {
+	db  *sql.DB
+	tx  txn.Runner
+	ctx context.Context // Membawa transaksi dari InTx
+}
+
+func NewRoutingRepository(db *sql.DB) RoutingRepository {
+	return &routingRepo{db: db, tx: txn.NewRunner(db), ctx: context.Background()}
+}
+
+// q mengembalikan transaksi InTx yang sedang berjalan, atau pool koneksi
+func (r *routingRepo) q() txn.Querier {
+	return txn.From(r.ctx, r.db)
+}
+
+func (r *routingRepo) InTx(fn func(repo RoutingRepository, publisher events.Publisher) error) error {
+	return r.tx.Do(r.ctx, func(ctx context.Context) error {
+		repo := &routingRepo{db: r.db, tx: r.tx, ctx: ctx}
+		return fn(repo, outbox.NewOutbox(repo.q()))
+	})
+}
+
+// queryer dipenuhi oleh *sql.DB dan *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// GetSettings mengambil konfigurasi routing client, atau konfigurasi default jika belum ada
+func (r *routingRepo) GetSettings(clientID int) (*routing.Settings, error) {
+	s := routing.DefaultSettings(clientID)
+	err := r.q().QueryRow(
+		"SELECT strategy, fallback_strategy, auto_assign, default_max_concurrent FROM routing_settings WHERE client_id = $1", clientID,
+	).Scan(&s.Strategy, &s.FallbackStrategy, &s.AutoAssign, &s.DefaultMaxConcurrent)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *routingRepo) SaveSettings(s routing.Settings) error {
+	_, err := r.q().Exec(
+		`INSERT INTO routing_settings (client_id, strategy, fallback_strategy, auto_assign, default_max_concurrent)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (client_id) DO UPDATE SET strategy = EXCLUDED.strategy, fallback_strategy = EXCLUDED.fallback_strategy,
+		 auto_assign = EXCLUDED.auto_assign, default_max_concurrent = EXCLUDED.default_max_concurrent`,
+		s.ClientID, s.Strategy, s.FallbackStrategy, s.AutoAssign, s.DefaultMaxConcurrent,
+	)
+	return err
+}
+
+func (r *routingRepo) FetchAgents(clientID int) ([]routing.Agent, error) {
+	return fetchAgents(r.q(), clientID, nil)
+}
+
+// fetchAgents mengambil user milik client beserta kapasitas, beban dan skill-nya.
+// Agent hanya tersedia jika status presence-nya online.
+// Jika teamID diisi, hanya anggota tim tersebut yang diambil.
+func fetchAgents(q queryer, clientID int, teamID *int) ([]routing.Agent, error) {
+	rows, err := q.Query(`SELECT u.user_id, u.username, COALESCE(p.status = 'online', false),
+		COALESCE(s.max_concurrent, COALESCE((SELECT default_max_concurrent FROM routing_settings WHERE client_id = $1), $2)),
+		(SELECT COUNT(*) FROM conversations c WHERE c.assignee_id = u.user_id AND c.status = 'open'),
+		s.last_assigned_at
+		FROM users u LEFT JOIN agent_settings s ON s.user_id = u.user_id
+		LEFT JOIN agent_presence p ON p.user_id = u.user_id
+		WHERE u.client_id = $1
+		AND ($3::int IS NULL OR EXISTS(SELECT 1 FROM team_members tm WHERE tm.user_id = u.user_id AND tm.team_id = $3))
+		ORDER BY u.user_id`, clientID, routing.DefaultSettings(clientID).DefaultMaxConcurrent, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	var agents []routing.Agent
+	index := make(map[int]int)
+	for rows.Next() {
+		var a routing.Agent
+		var lastAssigned sql.NullTime
+		if err := rows.Scan(&a.UserID, &a.Username, &a.Available, &a.MaxConcurrent, &a.ActiveCount, &lastAssigned); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if lastAssigned.Valid {
+			a.LastAssignedAt = &lastAssigned.Time
+		}
+		a.Skills = make(map[string]int)
+		index[a.UserID] = len(agents)
+		agents = append(agents, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	skillRows, err := q.Query(
+		"SELECT k.user_id, k.skill, k.proficiency FROM agent_skills k JOIN users u ON u.user_id = k.user_id WHERE u.client_id = $1",
+		clientID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer skillRows.Close()
+	for skillRows.Next() {
+		var userID, proficiency int
+		var skill string
+		if err := skillRows.Scan(&userID, &skill, &proficiency); err != nil {
+			return nil, err
+		}
+		if i, ok := index[userID]; ok {
+			agents[i].Skills[skill] = proficiency
+		}
+	}
+	return agents, skillRows.Err()
+}
+
+// SaveAgentSettings menyimpan batas percakapan bersamaan seorang agent
+func (r *routingRepo) SaveAgentSettings(userID int, maxConcurrent int) error {
+	// max_concurrent 0 disimpan sebagai NULL agar mengikuti default client
+	_, err := r.q().Exec(
+		`INSERT INTO agent_settings (user_id, max_concurrent) VALUES ($1, NULLIF($2, 0))
+		 ON CONFLICT (user_id) DO UPDATE SET max_concurrent = EXCLUDED.max_concurrent`,
+		userID, maxConcurrent,
+	)
+	return err
+}
+
+// SetAgentSkills mengganti seluruh skill agent
+func (r *routingRepo) SetAgentSkills(userID int, skills map[string]int) error {
+	return r.tx.Do(r.ctx, func(ctx context.Context) error {
+		tx := txn.From(ctx, r.db)
+		if _, err := tx.Exec("DELETE FROM agent_skills WHERE user_id = $1", userID); err != nil {
+			return err
+		}
+		for skill, proficiency := range skills {
+			_, err := tx.Exec("INSERT INTO agent_skills (user_id, skill, proficiency) VALUES ($1, $2, $3)", userID, skill, proficiency)
+			if err != nil {
+				return fmt.Errorf("failed to save skill %s: %w", skill, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *routingRepo) UserBelongsToClient(userID, clientID int) (bool, error) {
+	var exists bool
+	err := r.q().QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE user_id = $1 AND client_id = $2)", userID, clientID).Scan(&exists)
+	return exists, err
+}
+
+const targetSelect = "SELECT c.conversation_id, c.client_id, c.contact_id, c.team_id, c.assignee_id, c.status, c.required_skills FROM conversations c "
+
+func scanTarget(row interface{ Scan(...interface{}) error }) (*Target, error) {
+	var t Target
+	var contactID, teamID, assigneeID sql.NullInt64
+	if err := row.Scan(&t.ID, &t.ClientID, &contactID, &teamID, &assigneeID, &t.Status, pq.Array(&t.RequiredSkills)); err != nil {
+		return nil, err
+	}
+	t.ContactID = nullableInt(contactID)
+	t.TeamID = nullableInt(teamID)
+	t.AssigneeID = nullableInt(assigneeID)
+	return &t, nil
+}
+
+func (r *routingRepo) GetTarget(conversationID int) (*Target, error) {
+	return scanTarget(r.q().QueryRow(targetSelect+"WHERE c.conversation_id = $1", conversationID))
+}
+
+// FetchPendingTargets mengambil antrean percakapan terbuka tanpa agent, dimulai dari
+// tim dengan prioritas tertinggi, prioritas percakapan, lalu yang paling lama menunggu
+func (r *routingRepo) FetchPendingTargets(clientID int) ([]Target, error) {
+	rows, err := r.q().Query(targetSelect+`LEFT JOIN teams t ON t.team_id = c.team_id
+		WHERE c.client_id = $1 AND c.status = 'open' AND c.assignee_id IS NULL
+		ORDER BY COALESCE(t.priority, 0) DESC, c.priority DESC, COALESCE(c.enqueued_at, c.created_at)`, clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Target
+	for rows.Next() {
+		t, err := scanTarget(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *t)
+	}
+	return list, rows.Err()
+}
+
+// LastAgentForContact mengembalikan agent yang terakhir menangani percakapan kontak, atau 0
+func (r *routingRepo) LastAgentForContact(contactID int) (int, error) {
+	var userID int
+	err := r.q().QueryRow(
+		"SELECT assignee_id FROM conversations WHERE contact_id = $1 AND assignee_id IS NOT NULL ORDER BY updated_at DESC LIMIT 1",
+		contactID,
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return userID, err
+}
+
+// AutoAssign memilih dan menetapkan agent dalam satu transaksi. Advisory lock per client
+// memastikan replika lain tidak memakai kapasitas agent yang sama secara bersamaan.
+func (r *routingRepo) AutoAssign(t Target, strategy string, choose func([]routing.Agent) *routing.Agent) (*routing.Assignment, error) {
+	var result *routing.Assignment
+	err := r.tx.Do(r.ctx, func(ctx context.Context) error {
+		result = nil
+		tx := txn.From(ctx, r.db)
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1, $2)", advisoryLockRouting, t.ClientID); err != nil {
+			return fmt.Errorf("failed to lock routing for client %d: %w", t.ClientID, err)
+		}
+
+		agents, err := fetchAgents(tx, t.ClientID, t.TeamID)
+		if err != nil {
+			return err
+		}
+		agent := choose(agents)
+		if agent == nil {
+			return nil
+		}
+
+		res, err := tx.Exec(
+			"UPDATE conversations SET assignee_id = $1, updated_at = NOW() WHERE conversation_id = $2 AND assignee_id IS NULL",
+			agent.UserID, t.ID,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrAlreadyAssigned
+		}
+
+		a := routing.Assignment{ConversationID: t.ID, ClientID: t.ClientID, ToUserID: agent.UserID, Reason: routing.ReasonAuto, Strategy: strategy}
+		if err := insertAssignment(tx, &a); err != nil {
+			return err
+		}
+		result = &a
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Reassign memindahkan percakapan ke agent lain dan mencatat agent sebelumnya di riwayat
+func (r *routingRepo) Reassign(a routing.Assignment) (*routing.Assignment, error) {
+	err := r.tx.Do(r.ctx, func(ctx context.Context) error {
+		tx := txn.From(ctx, r.db)
+		var previous sql.NullInt64
+		err := tx.QueryRow("SELECT assignee_id FROM conversations WHERE conversation_id = $1 FOR UPDATE", a.ConversationID).Scan(&previous)
+		if err != nil {
+			return err
+		}
+		a.FromUserID = nullableInt(previous)
+
+		_, err = tx.Exec("UPDATE conversations SET assignee_id = $1, updated_at = NOW() WHERE conversation_id = $2", a.ToUserID, a.ConversationID)
+		if err != nil {
+			return err
+		}
+		return insertAssignment(tx, &a)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// advisoryLockRouting adalah namespace advisory lock untuk routing percakapan
+const advisoryLockRouting = 29
+
+func insertAssignment(tx txn.Querier, a *routing.Assignment) error {
+	err := tx.QueryRow(
+		`INSERT INTO conversation_assignments (conversation_id, client_id, from_user_id, to_user_id, assigned_by, reason, strategy, note)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING assignment_id, created_at`,
+		a.ConversationID, a.ClientID, a.FromUserID, a.ToUserID, a.AssignedBy, a.Reason, a.Strategy, a.Note,
+	).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record assignment: %w", err)
+	}
+	_, err = tx.Exec(
+		`INSERT INTO agent_settings (user_id, last_assigned_at) VALUES ($1, $2)
+		 ON CONFLICT (user_id) DO UPDATE SET last_assigned_at = EXCLUDED.last_assigned_at`,
+		a.ToUserID, a.CreatedAt,
+	)
+	return err
+}
+
+func (r *routingRepo) SetRequiredSkills(conversationID int, skills []string) error {
+	_, err := r.q().Exec("UPDATE conversations SET required_skills = $1 WHERE conversation_id = $2", pq.Array(skills), conversationID)
+	return err
+}
+
+func (r *routingRepo) FetchAssignments(conversationID int) ([]routing.Assignment, error) {
+	rows, err := r.q().Query(
+		`SELECT assignment_id, conversation_id, client_id, from_user_id, to_user_id, assigned_by, reason, strategy, note, created_at
+		 FROM conversation_assignments WHERE conversation_id = $1 ORDER BY created_at`,
+		conversationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []routing.Assignment
+	for rows.Next() {
+		var a routing.Assignment
+		var from, by sql.NullInt64
+		err := rows.Scan(&a.ID, &a.ConversationID, &a.ClientID, &from, &a.ToUserID, &by, &a.Reason, &a.Strategy, &a.Note, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		a.FromUserID = nullableInt(from)
+		a.AssignedBy = nullableInt(by)
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+func nullableInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	id := int(v.Int64)
+	return &id
+}
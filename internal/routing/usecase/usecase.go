@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"backend/internal/conversations"
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/routing"
+	"backend/internal/routing/repository"
+	"errors"
+	"log/slog"
+)
+
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrAgentNotFound        = errors.New("agent not found")
+	ErrAgentUnavailable     = errors.New("agent is not available")
+	ErrNotAssignee          = errors.New("only the current assignee can transfer the conversation")
+	ErrInvalidSettings      = errors.New("invalid routing settings")
+)
+
+type RoutingUsecase interface {
+	GetSettings(clientID int) (*routing.Settings, error)
+	UpdateSettings(s routing.Settings) error
+	GetAgents(clientID int) ([]routing.Agent, error)
+	UpdateAgent(clientID, userID int, maxConcurrent int) error
+	SetAgentSkills(clientID, userID int, skills map[string]int) error
+	RouteConversation(conv conversations.Conversation) (*routing.Assignment, error)
+	AssignPending(clientID int) (int, error)
+	Assign(clientID, conversationID, toUserID, actorID int, note string) (*routing.Assignment, error)
+	Transfer(clientID, conversationID, toUserID, actorID int, note string) (*routing.Assignment, error)
+	SetRequiredSkills(clientID, conversationID int, skills []string) error
+	GetAssignments(clientID, conversationID int) ([]routing.Assignment, error)
+}
+
+type routingUsecase struct {
+	repo      repository.RoutingRepository
+	publisher realtime.Publisher
+}
+
+func NewRoutingUsecase(repo repository.RoutingRepository, publisher realtime.Publisher) RoutingUsecase {
+	return &routingUsecase{repo: repo, publisher: publisher}
+}
+
+func (u *routingUsecase) GetSettings(clientID int) (*routing.Settings, error) {
+	return u.repo.GetSettings(clientID)
+}
+
+func (u *routingUsecase) UpdateSettings(s routing.Settings) error {
+	if !routing.ValidStrategy(s.Strategy) || s.DefaultMaxConcurrent < 1 {
+		return ErrInvalidSettings
+	}
+	if s.FallbackStrategy == "" {
+		s.FallbackStrategy = routing.StrategyLeastBusy
+	}
+	if !routing.ValidStrategy(s.FallbackStrategy) || s.FallbackStrategy == routing.StrategySticky {
+		return ErrInvalidSettings
+	}
+	return u.repo.SaveSettings(s)
+}
+
+func (u *routingUsecase) GetAgents(clientID int) ([]routing.Agent, error) {
+	return u.repo.FetchAgents(clientID)
+}
+
+// UpdateAgent mengubah kapasitas agent. Ketersediaan diatur melalui status presence.
+// Kapasitas bisa bertambah, sehingga antrean percakapan client langsung dicoba diassign ulang.
+func (u *routingUsecase) UpdateAgent(clientID, userID int, maxConcurrent int) error {
+	if err := u.checkAgent(clientID, userID); err != nil {
+		return err
+	}
+	if err := u.repo.SaveAgentSettings(userID, maxConcurrent); err != nil {
+		return err
+	}
+	if _, err := u.AssignPending(clientID); err != nil {
+		slog.Error("Failed to assign pending conversations", "client_id", clientID, "error", err)
+	}
+	return nil
+}
+
+func (u *routingUsecase) SetAgentSkills(clientID, userID int, skills map[string]int) error {
+	if err := u.checkAgent(clientID, userID); err != nil {
+		return err
+	}
+	return u.repo.SetAgentSkills(userID, skills)
+}
+
+func (u *routingUsecase) checkAgent(clientID, userID int) error {
+	ok, err := u.repo.UserBelongsToClient(userID, clientID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAgentNotFound
+	}
+	return nil
+}
+
+// RouteConversation mengassign percakapan baru ke agent sesuai strategi client.
+// Nilai nil tanpa error berarti percakapan tetap di antrean.
+func (u *routingUsecase) RouteConversation(conv conversations.Conversation) (*routing.Assignment, error) {
+	settings, err := u.repo.GetSettings(conv.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.AutoAssign {
+		return nil, nil
+	}
+	return u.route(*settings, repository.Target{
+		ID:             conv.ID,
+		ClientID:       conv.ClientID,
+		ContactID:      conv.ContactID,
+		TeamID:         conv.TeamID,
+		RequiredSkills: conv.RequiredSkills,
+	})
+}
+
+// AssignPending mencoba mengassign antrean percakapan client dari yang terlama,
+// berhenti saat tidak ada lagi agent dengan kapasitas tersisa.
+func (u *routingUsecase) AssignPending(clientID int) (int, error) {
+	settings, err := u.repo.GetSettings(clientID)
+	if err != nil || !settings.AutoAssign {
+		return 0, err
+	}
+	pending, err := u.repo.FetchPendingTargets(clientID)
+	if err != nil {
+		return 0, err
+	}
+
+	assigned := 0
+	for _, t := range pending {
+		a, err := u.route(*settings, t)
+		if errors.Is(err, repository.ErrAlreadyAssigned) {
+			continue
+		}
+		if err != nil {
+			return assigned, err
+		}
+		if a == nil && t.TeamID == nil && len(t.RequiredSkills) == 0 {
+			// Tidak ada agent tersisa; percakapan berikutnya juga tidak akan mendapat agent
+			break
+		}
+		if a != nil {
+			assigned++
+		}
+	}
+	return assigned, nil
+}
+
+func (u *routingUsecase) route(settings routing.Settings, t repository.Target) (*routing.Assignment, error) {
+	req := routing.Request{RequiredSkills: t.RequiredSkills}
+	if settings.Strategy == routing.StrategySticky && t.ContactID != nil {
+		lastAgent, err := u.repo.LastAgentForContact(*t.ContactID)
+		if err != nil {
+			return nil, err
+		}
+		req.LastAgentID = lastAgent
+	}
+
+	var a *routing.Assignment
+	err := u.repo.InTx(func(repo repository.RoutingRepository, publisher events.Publisher) error {
+		var err error
+		a, err = repo.AutoAssign(t, settings.Strategy, func(agents []routing.Agent) *routing.Agent {
+			return routing.SelectAgent(settings, agents, req)
+		})
+		if err != nil || a == nil {
+			return err
+		}
+		return recordAssignment(publisher, *a)
+	})
+	if err != nil || a == nil {
+		return nil, err
+	}
+	u.publishAssignment(*a)
+	return a, nil
+}
+
+// Assign menetapkan agent secara manual (misalnya oleh supervisor).
+// actorID 0 berarti assignment dilakukan sistem, misalnya oleh automation.
+func (u *routingUsecase) Assign(clientID, conversationID, toUserID, actorID int, note string) (*routing.Assignment, error) {
+	if _, err := u.getTarget(clientID, conversationID); err != nil {
+		return nil, err
+	}
+	if err := u.checkAgent(clientID, toUserID); err != nil {
+		return nil, err
+	}
+	a := routing.Assignment{
+		ConversationID: conversationID, ClientID: clientID, ToUserID: toUserID,
+		Reason: routing.ReasonManual, Note: note,
+	}
+	if actorID != 0 {
+		a.AssignedBy = &actorID
+	}
+	return u.reassign(a)
+}
+
+// Transfer memindahkan percakapan dari agent yang sedang menangani ke agent lain yang tersedia
+func (u *routingUsecase) Transfer(clientID, conversationID, toUserID, actorID int, note string) (*routing.Assignment, error) {
+	t, err := u.getTarget(clientID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if t.AssigneeID == nil || *t.AssigneeID != actorID {
+		return nil, ErrNotAssignee
+	}
+
+	agents, err := u.repo.FetchAgents(clientID)
+	if err != nil {
+		return nil, err
+	}
+	var target *routing.Agent
+	for i := range agents {
+		if agents[i].UserID == toUserID {
+			target = &agents[i]
+		}
+	}
+	if target == nil {
+		return nil, ErrAgentNotFound
+	}
+	if !target.Available {
+		return nil, ErrAgentUnavailable
+	}
+
+	return u.reassign(routing.Assignment{
+		ConversationID: conversationID, ClientID: clientID, ToUserID: toUserID,
+		AssignedBy: &actorID, Reason: routing.ReasonTransfer, Note: note,
+	})
+}
+
+func (u *routingUsecase) reassign(a routing.Assignment) (*routing.Assignment, error) {
+	var result *routing.Assignment
+	err := u.repo.InTx(func(repo repository.RoutingRepository, publisher events.Publisher) error {
+		var err error
+		if result, err = repo.Reassign(a); err != nil {
+			return err
+		}
+		return recordAssignment(publisher, *result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.publishAssignment(*result)
+	return result, nil
+}
+
+func (u *routingUsecase) SetRequiredSkills(clientID, conversationID int, skills []string) error {
+	if _, err := u.getTarget(clientID, conversationID); err != nil {
+		return err
+	}
+	return u.repo.SetRequiredSkills(conversationID, skills)
+}
+
+func (u *routingUsecase) GetAssignments(clientID, conversationID int) ([]routing.Assignment, error) {
+	if _, err := u.getTarget(clientID, conversationID); err != nil {
+		return nil, err
+	}
+	return u.repo.FetchAssignments(conversationID)
+}
+
+func (u *routingUsecase) getTarget(clientID, conversationID int) (*repository.Target, error) {
+	t, err := u.repo.GetTarget(conversationID)
+	if err != nil || t.ClientID != clientID {
+		return nil, ErrConversationNotFound
+	}
+	return t, nil
+}
+
+// recordAssignment mencatat event assignment ke outbox di dalam transaksi assignment
+func recordAssignment(publisher events.Publisher, a routing.Assignment) error {
+	return publisher.Publish(a.ClientID, realtime.Payload{Type: realtime.EventConversationAssigned, Data: a})
+}
+
+// publishAssignment memberi tahu semua agent client agar antrean dan inbox diperbarui
+func (u *routingUsecase) publishAssignment(a routing.Assignment) {
+	err := u.publisher.Publish(realtime.Event{Type: realtime.EventConversationAssigned, ClientID: a.ClientID, Data: a})
+	if err != nil {
+		slog.Error("Failed to publish assignment", "conversation_id", a.ConversationID, "error", err)
+	}
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"time"
+)
+
+// Cakupan pencarian
+const (
+	ScopeConversations = "conversations" // Percakapan yang pesannya atau kontaknya cocok
+	ScopeMessages      = "messages"
+	ScopeContacts      = "contacts"
+)
+
+// Batas paginasi
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// DefaultLanguage dipakai client yang belum memilih bahasa; konfigurasi simple tidak melakukan stemming
+const DefaultLanguage = "simple"
+
+// Languages adalah konfigurasi text search bawaan Postgres yang bisa dipilih client
+var Languages = []string{
+	"simple", "arabic", "danish", "dutch", "english", "finnish", "french", "german", "hungarian",
+	"indonesian", "italian", "norwegian", "portuguese", "romanian", "russian", "spanish", "swedish", "turkish",
+}
+
+// ValidLanguage memeriksa apakah bahasa termasuk Languages
+func ValidLanguage(lang string) bool {
+	for _, l := range Languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// Query adalah permintaan pencarian. Rentang tanggal berlaku pada waktu dibuatnya entitas
+// yang dikembalikan; filter channel, status dan agent tidak berlaku untuk cakupan kontak.
+type Query struct {
+	Text    string
+	Scope   string
+	Channel string
+	Status  string
+	AgentID *int
+	From    *time.Time
+	To      *time.Time
+	Page    int
+	PerPage int
+}
+
+// Offset mengembalikan jumlah baris yang dilewati untuk halaman saat ini
+func (q Query) Offset() int {
+	return (q.Page - 1) * q.PerPage
+}
+
+// Hit adalah satu hasil pencarian
+type Hit struct {
+	Type           string    `json:"type"` // conversation, message atau contact
+	ID             int       `json:"id"`
+	ConversationID *int      `json:"conversation_id,omitempty"`
+	MessageID      *int      `json:"message_id,omitempty"` // Pesan paling relevan untuk hasil percakapan
+	ContactID      *int      `json:"contact_id,omitempty"`
+	ContactName    string    `json:"contact_name,omitempty"`
+	Channel        string    `json:"channel,omitempty"`
+	Status         string    `json:"status,omitempty"`
+	AssigneeID     *int      `json:"assignee_id,omitempty"`
+	Highlight      string    `json:"highlight"` // Potongan teks yang sudah di-escape, kata yang cocok dibungkus <mark>
+	Rank           float64   `json:"rank"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Page adalah satu halaman hasil pencarian
+type Page struct {
+	Results []Hit `json:"results"`
+	Total   int   `json:"total"`
+	Page    int   `json:"page"`
+	PerPage int   `json:"per_page"`
+}
+
+// Penanda awal dan akhir kata yang cocok pada hasil ts_headline. Karakter private-use ini
+// dibuang dari teks sebelum ts_headline sehingga isi pesan tidak bisa memalsukan penanda.
+const (
+	MarkStart = "\ue000"
+	MarkStop  = "\ue001"
+)
+
+// Highlight meng-escape hasil ts_headline sebagai HTML lalu mengganti penanda dengan <mark>
+func Highlight(raw string) string {
+	s := html.EscapeString(raw)
+	s = strings.ReplaceAll(s, MarkStart, "<mark>")
+	return strings.ReplaceAll(s, MarkStop, "</mark>")
+}
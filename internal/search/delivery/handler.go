@@ -0,0 +1,114 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/search"
+	"backend/internal/search/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	usecase usecase.SearchUsecase
+}
+
+func NewSearchHandler(uc usecase.SearchUsecase) *SearchHandler {
+	return &SearchHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrEmptyQuery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+	case errors.Is(err, usecase.ErrInvalidScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search scope",
+			"scopes": []string{search.ScopeConversations, search.ScopeMessages, search.ScopeContacts}})
+	case errors.Is(err, usecase.ErrUnsupportedLanguage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported search language", "languages": search.Languages})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// Search menjalankan pencarian full-text.
+// Query: q (wajib, mendukung "frasa", OR dan -kata), scope (conversations, messages, contacts),
+// channel, status, agent_id, from dan to (RFC3339), page, per_page.
+func (h *SearchHandler) Search(c *gin.Context) {
+	q := search.Query{
+		Text:    c.Query("q"),
+		Scope:   c.Query("scope"),
+		Channel: c.Query("channel"),
+		Status:  c.Query("status"),
+	}
+
+	if v := c.Query("agent_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+			return
+		}
+		q.AgentID = &id
+	}
+	for name, target := range map[string]**time.Time{"from": &q.From, "to": &q.To} {
+		if v := c.Query(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+				return
+			}
+			*target = &t
+		}
+	}
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+	for name, target := range map[string]*int{"page": &q.Page, "per_page": &q.PerPage} {
+		if v := c.Query(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+				return
+			}
+			*target = n
+		}
+	}
+
+	page, err := h.usecase.Search(c.GetInt("client_id"), q)
+	if err != nil {
+		respondError(c, err, "Failed to search")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *SearchHandler) GetSettings(c *gin.Context) {
+	language, err := h.usecase.GetLanguage(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch search settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"language": language, "languages": search.Languages})
+}
+
+// UpdateSettings mengganti bahasa pencarian; pesan dan kontak client diindeks ulang di latar belakang
+func (h *SearchHandler) UpdateSettings(c *gin.Context) {
+	var req struct {
+		Language string `json:"language" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.usecase.SetLanguage(c.GetInt("client_id"), req.Language); err != nil {
+		respondError(c, err, "Failed to update search settings")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"language": req.Language})
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"backend/internal/search"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// SearchRepository adalah interface untuk pencarian full-text dan pemeliharaan indeksnya
+type SearchRepository interface {
+	GetLanguage(clientID int) (string, error)
+	// SetLanguage mengganti bahasa pencarian client dan menandai semua dokumennya untuk diindeks ulang
+	SetLanguage(clientID int, language string) error
+	SearchConversations(clientID int, language string, q search.Query) ([]search.Hit, int, error)
+	SearchMessages(clientID int, language string, q search.Query) ([]search.Hit, int, error)
+	SearchContacts(clientID int, language string, q search.Query) ([]search.Hit, int, error)
+	// IndexMessages mengisi search_vector pesan yang belum diindeks, paling banyak limit baris
+	IndexMessages(limit int) (int, error)
+	// IndexMessage mengisi search_vector satu pesan
+	IndexMessage(messageID int) error
+	// IndexContacts mengindeks ulang kontak yang berubah sejak terakhir diindeks, paling banyak limit baris
+	IndexContacts(limit int) (int, error)
+}
+
+type searchRepo struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) SearchRepository {
+	return &searchRepo{db: db}
+}
+
+// headlineOptions mengatur potongan teks hasil ts_headline
+var headlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \"",
+	search.MarkStart, search.MarkStop)
+
+// markers dibuang dari teks sebelum ts_headline; lihat search.MarkStart
+const markers = search.MarkStart + search.MarkStop
+
+// clientLanguage adalah ekspresi konfigurasi text search milik client sebuah baris
+const clientLanguage = `COALESCE((SELECT search_language FROM clients WHERE client_id = %s), 'simple')::regconfig`
+
+func (r *searchRepo) GetLanguage(clientID int) (string, error) {
+	var lang sql.NullString
+	err := r.db.QueryRow("SELECT search_language FROM clients WHERE client_id = $1", clientID).Scan(&lang)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !lang.Valid) {
+		return search.DefaultLanguage, nil
+	}
+	return lang.String, err
+}
+
+func (r *searchRepo) SetLanguage(clientID int, language string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO clients (client_id, search_language) VALUES ($1, $2)
+		 ON CONFLICT (client_id) DO UPDATE SET search_language = EXCLUDED.search_language`,
+		clientID, language,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE messages SET search_vector = NULL WHERE client_id = $1", clientID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE contacts SET search_indexed_at = NULL WHERE client_id = $1", clientID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// scanHits membaca hasil pencarian beserta total hasil dari COUNT(*) OVER ()
+func scanHits(rows *sql.Rows, hitType string) ([]search.Hit, int, error) {
+	defer rows.Close()
+
+	var list []search.Hit
+	total := 0
+	for rows.Next() {
+		h := search.Hit{Type: hitType}
+		var conversationID, messageID, contactID, assigneeID sql.NullInt64
+		var headline string
+		err := rows.Scan(&h.ID, &conversationID, &messageID, &contactID, &h.ContactName, &h.Channel, &h.Status,
+			&assigneeID, &headline, &h.Rank, &total, &h.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		h.ConversationID = nullInt(conversationID)
+		h.MessageID = nullInt(messageID)
+		h.ContactID = nullInt(contactID)
+		h.AssigneeID = nullInt(assigneeID)
+		h.Highlight = search.Highlight(headline)
+		list = append(list, h)
+	}
+	return list, total, rows.Err()
+}
+
+func nullInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+// SearchConversations mencari percakapan yang salah satu pesannya atau kontaknya cocok.
+// Potongan teks diambil dari pesan paling relevan, atau dari nama kontak jika hanya kontak yang cocok.
+func (r *searchRepo) SearchConversations(clientID int, language string, q search.Query) ([]search.Hit, int, error) {
+	rows, err := r.db.Query(`WITH q AS (SELECT websearch_to_tsquery($2::regconfig, $3) AS query),
+		msg AS (
+			SELECT DISTINCT ON (m.conversation_id) m.conversation_id, m.message_id, ts_rank_cd(m.search_vector, q.query) AS rank
+			FROM messages m CROSS JOIN q
+			WHERE m.client_id = $1 AND m.search_vector @@ q.query
+			ORDER BY m.conversation_id, rank DESC, m.message_id DESC),
+		hits AS (
+			SELECT cv.conversation_id, msg.message_id,
+				COALESCE(msg.rank, 0) + COALESCE(ts_rank_cd(ct.search_vector, q.query), 0) AS rank, COUNT(*) OVER () AS total
+			FROM conversations cv CROSS JOIN q
+			LEFT JOIN msg ON msg.conversation_id = cv.conversation_id
+			LEFT JOIN contacts ct ON ct.contact_id = cv.contact_id AND ct.client_id = $1 AND ct.search_vector @@ q.query
+			WHERE cv.client_id = $1 AND (msg.message_id IS NOT NULL OR ct.contact_id IS NOT NULL)
+				AND ($4 = '' OR cv.channel = $4) AND ($5 = '' OR cv.status = $5) AND ($6::int IS NULL OR cv.assignee_id = $6)
+				AND ($7::timestamptz IS NULL OR cv.created_at >= $7) AND ($8::timestamptz IS NULL OR cv.created_at < $8)
+			ORDER BY rank DESC, cv.updated_at DESC, cv.conversation_id DESC
+			LIMIT $9 OFFSET $10)
+		SELECT cv.conversation_id, cv.conversation_id, hits.message_id, cv.contact_id, COALESCE(ct.name, ''), cv.channel, cv.status,
+			cv.assignee_id, ts_headline($2::regconfig, translate(COALESCE(m.body, ct.name, ''), $12, ''), q.query, $11),
+			hits.rank, hits.total, cv.created_at
+		FROM hits JOIN conversations cv ON cv.conversation_id = hits.conversation_id
+		LEFT JOIN messages m ON m.message_id = hits.message_id
+		LEFT JOIN contacts ct ON ct.contact_id = cv.contact_id
+		CROSS JOIN q
+		ORDER BY hits.rank DESC, cv.updated_at DESC, cv.conversation_id DESC`,
+		clientID, language, q.Text, q.Channel, q.Status, q.AgentID, q.From, q.To, q.PerPage, q.Offset(), headlineOptions, markers,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return scanHits(rows, "conversation")
+}
+
+func (r *searchRepo) SearchMessages(clientID int, language string, q search.Query) ([]search.Hit, int, error) {
+	rows, err := r.db.Query(`WITH q AS (SELECT websearch_to_tsquery($2::regconfig, $3) AS query),
+		hits AS (
+			SELECT m.message_id, ts_rank_cd(m.search_vector, q.query) AS rank, COUNT(*) OVER () AS total
+			FROM messages m JOIN conversations cv ON cv.conversation_id = m.conversation_id CROSS JOIN q
+			WHERE m.client_id = $1 AND cv.client_id = $1 AND m.search_vector @@ q.query
+				AND ($4 = '' OR m.channel = $4) AND ($5 = '' OR cv.status = $5) AND ($6::int IS NULL OR cv.assignee_id = $6)
+				AND ($7::timestamptz IS NULL OR m.created_at >= $7) AND ($8::timestamptz IS NULL OR m.created_at < $8)
+			ORDER BY rank DESC, m.created_at DESC, m.message_id DESC
+			LIMIT $9 OFFSET $10)
+		SELECT m.message_id, m.conversation_id, m.message_id, cv.contact_id, COALESCE(ct.name, ''), m.channel, cv.status,
+			cv.assignee_id, ts_headline($2::regconfig, translate(COALESCE(m.body, ''), $12, ''), q.query, $11),
+			hits.rank, hits.total, m.created_at
+		FROM hits JOIN messages m ON m.message_id = hits.message_id
+		JOIN conversations cv ON cv.conversation_id = m.conversation_id
+		LEFT JOIN contacts ct ON ct.contact_id = cv.contact_id
+		CROSS JOIN q
+		ORDER BY hits.rank DESC, m.created_at DESC, m.message_id DESC`,
+		clientID, language, q.Text, q.Channel, q.Status, q.AgentID, q.From, q.To, q.PerPage, q.Offset(), headlineOptions, markers,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return scanHits(rows, "message")
+}
+
+// SearchContacts mencari kontak berdasarkan nama, identitas (telepon, email, dll) dan tag
+func (r *searchRepo) SearchContacts(clientID int, language string, q search.Query) ([]search.Hit, int, error) {
+	rows, err := r.db.Query(`WITH q AS (SELECT websearch_to_tsquery($2::regconfig, $3) AS query),
+		hits AS (
+			SELECT ct.contact_id, ts_rank_cd(ct.search_vector, q.query) AS rank, COUNT(*) OVER () AS total
+			FROM contacts ct CROSS JOIN q
+			WHERE ct.client_id = $1 AND ct.search_vector @@ q.query
+				AND ($4::timestamptz IS NULL OR ct.created_at >= $4) AND ($5::timestamptz IS NULL OR ct.created_at < $5)
+			ORDER BY rank DESC, ct.contact_id DESC
+			LIMIT $6 OFFSET $7)
+		SELECT ct.contact_id, NULL::int, NULL::int, ct.contact_id, COALESCE(ct.name, ''), '', '', NULL::int,
+			ts_headline($2::regconfig, translate(concat_ws(' · ', ct.name,
+				(SELECT string_agg(value, ', ' ORDER BY identity_id) FROM contact_identities WHERE contact_id = ct.contact_id),
+				(SELECT string_agg(tag, ', ' ORDER BY tag) FROM contact_tags WHERE contact_id = ct.contact_id)), $9, ''), q.query, $8),
+			hits.rank, hits.total, ct.created_at
+		FROM hits JOIN contacts ct ON ct.contact_id = hits.contact_id
+		CROSS JOIN q
+		ORDER BY hits.rank DESC, ct.contact_id DESC`,
+		clientID, language, q.Text, q.From, q.To, q.PerPage, q.Offset(), headlineOptions, markers,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return scanHits(rows, "contact")
+}
+
+func (r *searchRepo) IndexMessages(limit int) (int, error) {
+	res, err := r.db.Exec(`UPDATE messages m SET search_vector = to_tsvector(`+fmt.Sprintf(clientLanguage, "m.client_id")+`, COALESCE(m.body, ''))
+		WHERE m.message_id IN (
+			SELECT message_id FROM messages WHERE search_vector IS NULL ORDER BY message_id LIMIT $1 FOR UPDATE SKIP LOCKED)`,
+		limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (r *searchRepo) IndexMessage(messageID int) error {
+	_, err := r.db.Exec(`UPDATE messages m SET search_vector = to_tsvector(`+fmt.Sprintf(clientLanguage, "m.client_id")+`, COALESCE(m.body, ''))
+		WHERE m.message_id = $1`,
+		messageID,
+	)
+	return err
+}
+
+// IndexContacts membangun vektor kontak: nama berbobot A, identitas berbobot B dan tag berbobot C.
+// Identitas juga diindeks tanpa tanda baca sehingga nomor telepon bisa dicari dengan digitnya saja
+// dan email dengan bagian namanya.
+func (r *searchRepo) IndexContacts(limit int) (int, error) {
+	lang := fmt.Sprintf(clientLanguage, "c.client_id")
+	res, err := r.db.Exec(`UPDATE contacts c SET search_indexed_at = NOW(), search_vector =
+			setweight(to_tsvector(`+lang+`, COALESCE(c.name, '')), 'A') ||
+			setweight(to_tsvector(`+lang+`, COALESCE((
+				SELECT string_agg(value || ' ' || regexp_replace(value, '[^[:alnum:]]+', ' ', 'g'), ' ')
+				FROM contact_identities WHERE contact_id = c.contact_id), '')), 'B') ||
+			setweight(to_tsvector(`+lang+`, COALESCE((
+				SELECT string_agg(tag, ' ') FROM contact_tags WHERE contact_id = c.contact_id), '')), 'C')
+		WHERE c.contact_id IN (
+			SELECT contact_id FROM contacts WHERE search_indexed_at IS NULL OR search_indexed_at < updated_at
+			ORDER BY contact_id LIMIT $1 FOR UPDATE SKIP LOCKED)`,
+		limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"backend/internal/conversations"
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/search"
+	"backend/internal/search/repository"
+	"backend/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	ErrEmptyQuery          = errors.New("search query is empty")
+	ErrInvalidScope        = errors.New("invalid search scope")
+	ErrUnsupportedLanguage = errors.New("unsupported search language")
+)
+
+const (
+	maxQueryLength = 256
+	indexBatchSize = 500
+)
+
+// SearchUsecase menyediakan pencarian full-text yang dibatasi per client
+type SearchUsecase interface {
+	Search(clientID int, q search.Query) (*search.Page, error)
+	GetLanguage(clientID int) (string, error)
+	SetLanguage(clientID int, language string) error
+	// IndexPending mengindeks pesan dan kontak yang belum atau perlu diindeks ulang
+	IndexPending(ctx context.Context) (int, error)
+	// HandleEvent adalah subscriber event bus yang langsung mengindeks pesan baru
+	HandleEvent(ctx context.Context, e events.Event) error
+	RunIndexer(ctx context.Context, interval time.Duration)
+}
+
+type searchUsecase struct {
+	repo repository.SearchRepository
+}
+
+func NewSearchUsecase(repo repository.SearchRepository) SearchUsecase {
+	return &searchUsecase{repo: repo}
+}
+
+// normalizeQuery merapikan teks pencarian. Nomor telepon yang diketik dengan spasi atau tanda
+// baca ("+62 812-3456") diubah menjadi digitnya saja agar cocok dengan identitas yang diindeks.
+func normalizeQuery(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) > maxQueryLength {
+		text = strings.ToValidUTF8(text[:maxQueryLength], "")
+	}
+
+	digits, phoneLike := 0, true
+	for _, r := range text {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case r == '+' || r == '-' || r == '(' || r == ')' || r == '.' || r == ' ':
+		default:
+			phoneLike = false
+		}
+	}
+	if phoneLike && digits >= 6 {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, text)
+	}
+	return text
+}
+
+func (uc *searchUsecase) Search(clientID int, q search.Query) (*search.Page, error) {
+	q.Text = normalizeQuery(q.Text)
+	if q.Text == "" {
+		return nil, ErrEmptyQuery
+	}
+	if q.Scope == "" {
+		q.Scope = search.ScopeConversations
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PerPage < 1 {
+		q.PerPage = search.DefaultPerPage
+	}
+	if q.PerPage > search.MaxPerPage {
+		q.PerPage = search.MaxPerPage
+	}
+
+	language, err := uc.repo.GetLanguage(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []search.Hit
+	var total int
+	switch q.Scope {
+	case search.ScopeConversations:
+		hits, total, err = uc.repo.SearchConversations(clientID, language, q)
+	case search.ScopeMessages:
+		hits, total, err = uc.repo.SearchMessages(clientID, language, q)
+	case search.ScopeContacts:
+		hits, total, err = uc.repo.SearchContacts(clientID, language, q)
+	default:
+		return nil, ErrInvalidScope
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hits == nil {
+		hits = []search.Hit{}
+	}
+	return &search.Page{Results: hits, Total: total, Page: q.Page, PerPage: q.PerPage}, nil
+}
+
+func (uc *searchUsecase) GetLanguage(clientID int) (string, error) {
+	return uc.repo.GetLanguage(clientID)
+}
+
+// SetLanguage mengganti bahasa pencarian. Dokumen client diindeks ulang oleh indexer, sehingga
+// hasil pencarian belum lengkap sampai proses itu selesai.
+func (uc *searchUsecase) SetLanguage(clientID int, language string) error {
+	if !search.ValidLanguage(language) {
+		return ErrUnsupportedLanguage
+	}
+	current, err := uc.repo.GetLanguage(clientID)
+	if err != nil {
+		return err
+	}
+	if current == language {
+		return nil
+	}
+	return uc.repo.SetLanguage(clientID, language)
+}
+
+// IndexPending mengindeks per batch sampai tidak ada lagi yang tertunda atau ctx dibatalkan
+func (uc *searchUsecase) IndexPending(ctx context.Context) (int, error) {
+	total := 0
+	for _, index := range []func(int) (int, error){uc.repo.IndexMessages, uc.repo.IndexContacts} {
+		for ctx.Err() == nil {
+			n, err := index(indexBatchSize)
+			total += n
+			if err != nil {
+				return total, err
+			}
+			if n < indexBatchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+// HandleEvent mengindeks pesan dari event message.created agar langsung bisa dicari. Indexer
+// berkala tetap berjalan untuk pesan yang belum terindeks, misalnya setelah bahasa diganti.
+func (uc *searchUsecase) HandleEvent(ctx context.Context, e events.Event) error {
+	if e.Type != realtime.EventMessageCreated {
+		return nil
+	}
+	var m conversations.Message
+	if err := json.Unmarshal(e.Payload, &m); err != nil {
+		return err
+	}
+	return uc.repo.IndexMessage(m.ID)
+}
+
+func (uc *searchUsecase) RunIndexer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := uc.IndexPending(ctx); err != nil {
+				logger.From(ctx).Error("Failed to update search index", "error", err)
+			}
+		}
+	}
+}
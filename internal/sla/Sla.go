@@ -0,0 +1,145 @@
+package sla
+
+import "time"
+
+// Metrik SLA yang diukur pada percakapan
+const (
+	MetricFirstResponse = "first_response" // Balasan agent pertama sejak percakapan dibuat
+	MetricNextResponse  = "next_response"  // Balasan agent berikutnya setelah pelanggan menulis lagi
+	MetricResolution    = "resolution"     // Percakapan diselesaikan
+)
+
+// Status timer SLA
+const (
+	StateRunning  = "running"
+	StateWarning  = "warning"  // Mendekati batas waktu
+	StateBreached = "breached" // Melewati batas waktu dan belum terpenuhi
+	StateMet      = "met"
+	StateMissed   = "missed" // Terpenuhi setelah batas waktu
+)
+
+// Policy adalah kebijakan SLA milik client. Kebijakan dengan prioritas tertinggi
+// yang kondisinya cocok dipasang ke percakapan baru.
+type Policy struct {
+	ID                   int        `json:"id"`
+	ClientID             int        `json:"client_id"`
+	Name                 string     `json:"name"`
+	Priority             int        `json:"priority"`
+	Conditions           Conditions `json:"conditions"`
+	FirstResponseSeconds int        `json:"first_response_seconds"` // 0 berarti tanpa target
+	NextResponseSeconds  int        `json:"next_response_seconds"`
+	ResolutionSeconds    int        `json:"resolution_seconds"`
+	BusinessHours        bool       `json:"business_hours"`  // Hitung waktu hanya pada jam kerja client
+	WarningPercent       int        `json:"warning_percent"` // Persentase target sebelum near-breach, 0 untuk menonaktifkan
+	Active               bool       `json:"active"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// Target mengembalikan durasi target untuk metrik tertentu
+func (p Policy) Target(metric string) time.Duration {
+	switch metric {
+	case MetricFirstResponse:
+		return time.Duration(p.FirstResponseSeconds) * time.Second
+	case MetricNextResponse:
+		return time.Duration(p.NextResponseSeconds) * time.Second
+	case MetricResolution:
+		return time.Duration(p.ResolutionSeconds) * time.Second
+	}
+	return 0
+}
+
+// Conditions menentukan percakapan yang tercakup kebijakan. Daftar kosong berarti semua.
+type Conditions struct {
+	Channels []string `json:"channels"`
+	TeamIDs  []int    `json:"team_ids"`
+	Tags     []string `json:"tags"` // Cocok jika kontak memiliki salah satu tag
+}
+
+// Subject adalah atribut percakapan yang dicocokkan dengan kondisi kebijakan
+type Subject struct {
+	Channel string
+	TeamID  *int
+	Tags    []string
+}
+
+// Matches memeriksa apakah semua kondisi terpenuhi oleh subject
+func (c Conditions) Matches(s Subject) bool {
+	if len(c.Channels) > 0 && !containsString(c.Channels, s.Channel) {
+		return false
+	}
+	if len(c.TeamIDs) > 0 {
+		found := false
+		for _, id := range c.TeamIDs {
+			if s.TeamID != nil && *s.TeamID == id {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(c.Tags) > 0 {
+		found := false
+		for _, tag := range s.Tags {
+			if containsString(c.Tags, tag) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectPolicy memilih kebijakan aktif pertama yang cocok. policies harus
+// sudah terurut dari prioritas tertinggi.
+func SelectPolicy(policies []Policy, s Subject) *Policy {
+	for i := range policies {
+		if policies[i].Active && policies[i].Conditions.Matches(s) {
+			return &policies[i]
+		}
+	}
+	return nil
+}
+
+// Timer adalah satu metrik SLA yang berjalan pada percakapan
+type Timer struct {
+	ConversationID int        `json:"conversation_id"`
+	ClientID       int        `json:"client_id"`
+	PolicyID       int        `json:"policy_id"`
+	Metric         string     `json:"metric"`
+	StartedAt      time.Time  `json:"started_at"`
+	WarnAt         *time.Time `json:"warn_at"`
+	DueAt          time.Time  `json:"due_at"`
+	CompletedAt    *time.Time `json:"completed_at"`
+	WarnedAt       *time.Time `json:"warned_at"`
+	BreachedAt     *time.Time `json:"breached_at"`
+	State          string     `json:"state"`
+}
+
+// StateAt menghitung status timer pada waktu tertentu
+func (t Timer) StateAt(now time.Time) string {
+	if t.CompletedAt != nil {
+		if t.CompletedAt.After(t.DueAt) {
+			return StateMissed
+		}
+		return StateMet
+	}
+	if !now.Before(t.DueAt) {
+		return StateBreached
+	}
+	if t.WarnAt != nil && !now.Before(*t.WarnAt) {
+		return StateWarning
+	}
+	return StateRunning
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
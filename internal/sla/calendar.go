@@ -0,0 +1,87 @@
+package sla
+
+import (
+	"sort"
+	"time"
+)
+
+// searchDays adalah batas hari yang diperiksa saat mencari jam buka berikutnya
+const searchDays = 400
+
+// Calendar menentukan kapan jam SLA berjalan
+type Calendar interface {
+	// NextOpen mengembalikan periode buka yang berisi t, atau periode buka
+	// pertama setelah t. ok bernilai false jika tidak ada jam buka.
+	NextOpen(t time.Time) (start, end time.Time, ok bool)
+}
+
+// AlwaysOpen adalah kalender 24/7
+type AlwaysOpen struct{}
+
+func (AlwaysOpen) NextOpen(t time.Time) (time.Time, time.Time, bool) {
+	return t, t.AddDate(100, 0, 0), true
+}
+
+// Shift adalah jam buka dalam satu hari, dalam menit sejak tengah malam waktu lokal
+type Shift struct {
+	Start int `json:"start"`
+	End   int `json:"end"` // Maksimal 1440 (tengah malam berikutnya)
+}
+
+// Valid memeriksa rentang shift
+func (s Shift) Valid() bool {
+	return s.Start >= 0 && s.End <= 24*60 && s.Start < s.End
+}
+
+// WeeklyCalendar adalah jam kerja mingguan pada zona waktu tertentu. Jam dihitung
+// dari waktu lokal setiap hari, sehingga pergantian DST tidak menggeser jam buka.
+type WeeklyCalendar struct {
+	Location *time.Location
+	Days     map[time.Weekday][]Shift
+	// Closed menandai hari tutup di luar jadwal mingguan (misalnya hari libur); boleh nil.
+	// day adalah tengah malam waktu lokal.
+	Closed func(day time.Time) bool
+}
+
+func (c WeeklyCalendar) NextOpen(t time.Time) (time.Time, time.Time, bool) {
+	local := t.In(c.Location)
+	y, m, d := local.Date()
+	for i := 0; i < searchDays; i++ {
+		day := time.Date(y, m, d+i, 0, 0, 0, 0, c.Location)
+		if c.Closed != nil && c.Closed(day) {
+			continue
+		}
+		shifts := append([]Shift(nil), c.Days[day.Weekday()]...)
+		sort.Slice(shifts, func(a, b int) bool { return shifts[a].Start < shifts[b].Start })
+		for _, s := range shifts {
+			start := time.Date(y, m, d+i, 0, s.Start, 0, 0, c.Location)
+			end := time.Date(y, m, d+i, 0, s.End, 0, 0, c.Location)
+			if end.After(t) {
+				return start, end, true
+			}
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// AddBusinessTime menambahkan durasi d ke start dengan hanya menghitung jam buka kalender
+func AddBusinessTime(cal Calendar, start time.Time, d time.Duration) time.Time {
+	t := start
+	for d > 0 {
+		open, end, ok := cal.NextOpen(t)
+		if !ok {
+			// Kalender tanpa jam buka; gunakan waktu biasa agar SLA tetap memiliki batas
+			return t.Add(d)
+		}
+		if open.Before(t) {
+			open = t
+		}
+		available := end.Sub(open)
+		if d <= available {
+			return open.Add(d)
+		}
+		d -= available
+		t = end
+	}
+	return t
+}
@@ -0,0 +1,107 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/sla"
+	"backend/internal/sla/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SLAHandler struct {
+	usecase usecase.SLAUsecase
+}
+
+func NewSLAHandler(uc usecase.SLAUsecase) *SLAHandler {
+	return &SLAHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "SLA policy not found"})
+	case errors.Is(err, usecase.ErrInvalidPolicy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid SLA policy"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *SLAHandler) GetPolicies(c *gin.Context) {
+	list, err := h.usecase.GetPolicies(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch SLA policies"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *SLAHandler) GetPolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid policy ID"})
+		return
+	}
+
+	p, err := h.usecase.GetPolicy(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch SLA policy")
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *SLAHandler) CreatePolicy(c *gin.Context) {
+	var req sla.Policy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ClientID = c.GetInt("client_id")
+
+	id, err := h.usecase.CreatePolicy(req)
+	if err != nil {
+		respondError(c, err, "Failed to create SLA policy")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "SLA policy created", "id": id})
+}
+
+func (h *SLAHandler) UpdatePolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid policy ID"})
+		return
+	}
+
+	var req sla.Policy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ID, req.ClientID = id, c.GetInt("client_id")
+
+	if err := h.usecase.UpdatePolicy(req); err != nil {
+		respondError(c, err, "Failed to update SLA policy")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "SLA policy updated", "id": id})
+}
+
+func (h *SLAHandler) DeletePolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid policy ID"})
+		return
+	}
+
+	if err := h.usecase.DeletePolicy(c.GetInt("client_id"), id); err != nil {
+		respondError(c, err, "Failed to delete SLA policy")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "SLA policy deleted", "id": id})
+}
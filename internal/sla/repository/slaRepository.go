@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"backend/internal/events"
+	outbox "backend/internal/events/repository"
+	"backend/internal/sla"
+	"backend/pkg/txn"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SLARepository adalah interface untuk kebijakan SLA dan timer SLA percakapan
+type SLARepository interface {
+	FetchPolicies(clientID int) ([]sla.Policy, error)
+	GetPolicy(id int) (*sla.Policy, error)
+	CreatePolicy(p sla.Policy) (int, error)
+	UpdatePolicy(p sla.Policy) error
+	DeletePolicy(id int) error
+	GetConversationPolicy(conversationID int) (*sla.Policy, error)
+	FetchContactTags(contactID int) ([]string, error)
+	StartTimer(t sla.Timer, restart bool) error
+	CompleteTimers(conversationID int, metrics []string) error
+	FetchTimers(conversationIDs []int) ([]sla.Timer, error)
+	FlagWarnings() ([]sla.Timer, error)
+	FlagBreaches() ([]sla.Timer, error)
+	// InTx menjalankan fn dalam satu transaksi bersama publisher outbox, sehingga event SLA
+	// hanya tercatat jika timer berhasil ditandai
+	InTx(fn func(repo SLARepository, publisher events.Publisher) error) error
+}
+
+type slaRepo struct {
+	db  *sql.DB
+	tx  txn.Runner
+	ctx context.Context // Membawa transaksi dari InTx
+}
+
+func NewSLARepository(db *sql.DB) SLARepository {
+	return &slaRepo{db: db, tx: txn.NewRunner(db), ctx: context.Background()}
+}
+
+// q mengembalikan transaksi InTx yang sedang berjalan, atau pool koneksi
+func (r *slaRepo) q() txn.Querier {
+	return txn.From(r.ctx, r.db)
+}
+
+func (r *slaRepo) InTx(fn func(repo SLARepository, publisher events.Publisher) error) error {
+	return r.tx.Do(r.ctx, func(ctx context.Context) error {
+		repo := &slaRepo{db: r.db, tx: r.tx, ctx: ctx}
+		return fn(repo, outbox.NewOutbox(repo.q()))
+	})
+}
+
+const policyColumns = `policy_id, client_id, name, priority, channels, team_ids, tags,
+	first_response_seconds, next_response_seconds, resolution_seconds, business_hours, warning_percent, active, created_at`
+
+func scanPolicy(row interface{ Scan(...interface{}) error }) (*sla.Policy, error) {
+	var p sla.Policy
+	var teamIDs pq.Int64Array
+	err := row.Scan(&p.ID, &p.ClientID, &p.Name, &p.Priority, pq.Array(&p.Conditions.Channels), &teamIDs, pq.Array(&p.Conditions.Tags),
+		&p.FirstResponseSeconds, &p.NextResponseSeconds, &p.ResolutionSeconds, &p.BusinessHours, &p.WarningPercent, &p.Active, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range teamIDs {
+		p.Conditions.TeamIDs = append(p.Conditions.TeamIDs, int(id))
+	}
+	return &p, nil
+}
+
+func intArray(ids []int) pq.Int64Array {
+	arr := pq.Int64Array{}
+	for _, id := range ids {
+		arr = append(arr, int64(id))
+	}
+	return arr
+}
+
+// FetchPolicies mengambil kebijakan client terurut dari prioritas tertinggi
+func (r *slaRepo) FetchPolicies(clientID int) ([]sla.Policy, error) {
+	rows, err := r.q().Query("SELECT "+policyColumns+" FROM sla_policies WHERE client_id = $1 ORDER BY priority DESC, policy_id", clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []sla.Policy
+	for rows.Next() {
+		p, err := scanPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *p)
+	}
+	return list, rows.Err()
+}
+
+func (r *slaRepo) GetPolicy(id int) (*sla.Policy, error) {
+	return scanPolicy(r.q().QueryRow("SELECT "+policyColumns+" FROM sla_policies WHERE policy_id = $1", id))
+}
+
+func (r *slaRepo) CreatePolicy(p sla.Policy) (int, error) {
+	var id int
+	err := r.q().QueryRow(
+		`INSERT INTO sla_policies (client_id, name, priority, channels, team_ids, tags,
+		 first_response_seconds, next_response_seconds, resolution_seconds, business_hours, warning_percent, active)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING policy_id`,
+		p.ClientID, p.Name, p.Priority, pq.Array(p.Conditions.Channels), intArray(p.Conditions.TeamIDs), pq.Array(p.Conditions.Tags),
+		p.FirstResponseSeconds, p.NextResponseSeconds, p.ResolutionSeconds, p.BusinessHours, p.WarningPercent, p.Active,
+	).Scan(&id)
+	return id, err
+}
+
+// UpdatePolicy mengubah kebijakan; timer yang sudah berjalan tetap memakai batas waktu lama
+func (r *slaRepo) UpdatePolicy(p sla.Policy) error {
+	_, err := r.q().Exec(
+		`UPDATE sla_policies SET name = $1, priority = $2, channels = $3, team_ids = $4, tags = $5,
+		 first_response_seconds = $6, next_response_seconds = $7, resolution_seconds = $8,
+		 business_hours = $9, warning_percent = $10, active = $11 WHERE policy_id = $12`,
+		p.Name, p.Priority, pq.Array(p.Conditions.Channels), intArray(p.Conditions.TeamIDs), pq.Array(p.Conditions.Tags),
+		p.FirstResponseSeconds, p.NextResponseSeconds, p.ResolutionSeconds, p.BusinessHours, p.WarningPercent, p.Active, p.ID,
+	)
+	return err
+}
+
+func (r *slaRepo) DeletePolicy(id int) error {
+	_, err := r.q().Exec("DELETE FROM sla_policies WHERE policy_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete SLA policy with id %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetConversationPolicy mengambil kebijakan yang terpasang pada percakapan
+func (r *slaRepo) GetConversationPolicy(conversationID int) (*sla.Policy, error) {
+	return scanPolicy(r.q().QueryRow(
+		"SELECT "+policyColumns+" FROM sla_policies WHERE policy_id = (SELECT policy_id FROM sla_timers WHERE conversation_id = $1 LIMIT 1)",
+		conversationID,
+	))
+}
+
+func (r *slaRepo) FetchContactTags(contactID int) ([]string, error) {
+	var tags []string
+	err := r.q().QueryRow(
+		"SELECT COALESCE(array_agg(tag), '{}') FROM contact_tags WHERE contact_id = $1", contactID,
+	).Scan(pq.Array(&tags))
+	return tags, err
+}
+
+// StartTimer membuat timer metrik pada percakapan. Jika restart bernilai true, timer
+// yang sudah selesai dimulai ulang (untuk next response); timer yang masih berjalan tidak diubah.
+func (r *slaRepo) StartTimer(t sla.Timer, restart bool) error {
+	_, err := r.q().Exec(
+		`INSERT INTO sla_timers (conversation_id, client_id, policy_id, metric, started_at, warn_at, due_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 ON CONFLICT (conversation_id, metric) DO UPDATE SET policy_id = EXCLUDED.policy_id, started_at = EXCLUDED.started_at,
+		 warn_at = EXCLUDED.warn_at, due_at = EXCLUDED.due_at, completed_at = NULL, warned_at = NULL, breached_at = NULL
+		 WHERE $8 AND sla_timers.completed_at IS NOT NULL`,
+		t.ConversationID, t.ClientID, t.PolicyID, t.Metric, t.StartedAt, t.WarnAt, t.DueAt, restart,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start %s timer for conversation %d: %w", t.Metric, t.ConversationID, err)
+	}
+	return nil
+}
+
+// CompleteTimers menandai timer metrik yang masih berjalan sebagai terpenuhi
+func (r *slaRepo) CompleteTimers(conversationID int, metrics []string) error {
+	_, err := r.q().Exec(
+		"UPDATE sla_timers SET completed_at = NOW() WHERE conversation_id = $1 AND metric = ANY($2) AND completed_at IS NULL",
+		conversationID, pq.Array(metrics),
+	)
+	return err
+}
+
+const timerColumns = "conversation_id, client_id, policy_id, metric, started_at, warn_at, due_at, completed_at, warned_at, breached_at"
+
+func scanTimers(rows *sql.Rows) ([]sla.Timer, error) {
+	defer rows.Close()
+
+	now := time.Now()
+	var list []sla.Timer
+	for rows.Next() {
+		var t sla.Timer
+		var warnAt, completed, warned, breached sql.NullTime
+		err := rows.Scan(&t.ConversationID, &t.ClientID, &t.PolicyID, &t.Metric, &t.StartedAt, &warnAt, &t.DueAt, &completed, &warned, &breached)
+		if err != nil {
+			return nil, err
+		}
+		t.WarnAt, t.CompletedAt = nullTime(warnAt), nullTime(completed)
+		t.WarnedAt, t.BreachedAt = nullTime(warned), nullTime(breached)
+		t.State = t.StateAt(now)
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func (r *slaRepo) FetchTimers(conversationIDs []int) ([]sla.Timer, error) {
+	rows, err := r.q().Query(
+		"SELECT "+timerColumns+" FROM sla_timers WHERE conversation_id = ANY($1) ORDER BY conversation_id, due_at",
+		intArray(conversationIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTimers(rows)
+}
+
+// FlagWarnings menandai timer yang mencapai batas near-breach, masing-masing hanya sekali
+func (r *slaRepo) FlagWarnings() ([]sla.Timer, error) {
+	rows, err := r.q().Query(`UPDATE sla_timers SET warned_at = NOW()
+		WHERE completed_at IS NULL AND warned_at IS NULL AND breached_at IS NULL AND warn_at <= NOW() AND due_at > NOW()
+		RETURNING ` + timerColumns)
+	if err != nil {
+		return nil, err
+	}
+	return scanTimers(rows)
+}
+
+// FlagBreaches menandai timer yang melewati batas waktu, masing-masing hanya sekali
+func (r *slaRepo) FlagBreaches() ([]sla.Timer, error) {
+	rows, err := r.q().Query(`UPDATE sla_timers SET breached_at = NOW()
+		WHERE completed_at IS NULL AND breached_at IS NULL AND due_at <= NOW()
+		RETURNING ` + timerColumns)
+	if err != nil {
+		return nil, err
+	}
+	return scanTimers(rows)
+}
@@ -0,0 +1,292 @@
+package usecase
+
+import (
+	"backend/internal/conversations"
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/sla"
+	"backend/internal/sla/repository"
+	"backend/pkg/logger"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("SLA policy not found")
+	ErrInvalidPolicy = errors.New("invalid SLA policy")
+)
+
+// CalendarSource menyediakan kalender jam kerja untuk perhitungan SLA
+type CalendarSource interface {
+	Calendar(clientID int, teamID *int) (sla.Calendar, error)
+}
+
+type SLAUsecase interface {
+	GetPolicies(clientID int) ([]sla.Policy, error)
+	GetPolicy(clientID, id int) (*sla.Policy, error)
+	CreatePolicy(p sla.Policy) (int, error)
+	UpdatePolicy(p sla.Policy) error
+	DeletePolicy(clientID, id int) error
+	MessageAdded(conv conversations.Conversation, m conversations.Message, created bool)
+	StatusChanged(conv conversations.Conversation)
+	Timers(conversationIDs []int) (map[int][]sla.Timer, error)
+	ProcessTimers() (int, error)
+	RunScheduler(ctx context.Context, interval time.Duration)
+}
+
+type slaUsecase struct {
+	repo      repository.SLARepository
+	calendars CalendarSource
+	publisher realtime.Publisher
+}
+
+func NewSLAUsecase(repo repository.SLARepository, calendars CalendarSource, publisher realtime.Publisher) SLAUsecase {
+	return &slaUsecase{repo: repo, calendars: calendars, publisher: publisher}
+}
+
+func (u *slaUsecase) GetPolicies(clientID int) ([]sla.Policy, error) {
+	return u.repo.FetchPolicies(clientID)
+}
+
+// GetPolicy mengambil kebijakan dan memastikan kebijakan milik client yang sama
+func (u *slaUsecase) GetPolicy(clientID, id int) (*sla.Policy, error) {
+	p, err := u.repo.GetPolicy(id)
+	if err != nil || p.ClientID != clientID {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
+
+func validate(p sla.Policy) error {
+	if strings.TrimSpace(p.Name) == "" || p.WarningPercent < 0 || p.WarningPercent >= 100 {
+		return ErrInvalidPolicy
+	}
+	if p.FirstResponseSeconds < 0 || p.NextResponseSeconds < 0 || p.ResolutionSeconds < 0 {
+		return ErrInvalidPolicy
+	}
+	if p.FirstResponseSeconds == 0 && p.NextResponseSeconds == 0 && p.ResolutionSeconds == 0 {
+		return ErrInvalidPolicy
+	}
+	return nil
+}
+
+func (u *slaUsecase) CreatePolicy(p sla.Policy) (int, error) {
+	if err := validate(p); err != nil {
+		return 0, err
+	}
+	return u.repo.CreatePolicy(p)
+}
+
+func (u *slaUsecase) UpdatePolicy(p sla.Policy) error {
+	if err := validate(p); err != nil {
+		return err
+	}
+	if _, err := u.GetPolicy(p.ClientID, p.ID); err != nil {
+		return err
+	}
+	return u.repo.UpdatePolicy(p)
+}
+
+func (u *slaUsecase) DeletePolicy(clientID, id int) error {
+	if _, err := u.GetPolicy(clientID, id); err != nil {
+		return err
+	}
+	return u.repo.DeletePolicy(id)
+}
+
+// MessageAdded memperbarui timer SLA setelah pesan disimpan. Percakapan baru mendapat
+// kebijakan yang cocok; balasan agent menyelesaikan timer respon, dan pesan pelanggan
+// setelah balasan agent memulai timer next response. Pesan otomatis (tanpa agent
+// pengirim, misalnya balasan di luar jam kerja) tidak dihitung sebagai respon.
+func (u *slaUsecase) MessageAdded(conv conversations.Conversation, m conversations.Message, created bool) {
+	var err error
+	switch {
+	case created:
+		err = u.attach(conv, m)
+	case m.Direction == conversations.DirectionOutbound:
+		if m.SenderUserID == nil {
+			return
+		}
+		err = u.repo.CompleteTimers(conv.ID, []string{sla.MetricFirstResponse, sla.MetricNextResponse})
+	default:
+		err = u.startNextResponse(conv, m.CreatedAt)
+	}
+	if err != nil {
+		slog.Error("Failed to update SLA timers", "conversation_id", conv.ID, "error", err)
+	}
+}
+
+// attach memasang kebijakan dengan prioritas tertinggi yang cocok ke percakapan baru
+func (u *slaUsecase) attach(conv conversations.Conversation, m conversations.Message) error {
+	policies, err := u.repo.FetchPolicies(conv.ClientID)
+	if err != nil || len(policies) == 0 {
+		return err
+	}
+	subject := sla.Subject{Channel: conv.Channel, TeamID: conv.TeamID}
+	if conv.ContactID != nil {
+		if subject.Tags, err = u.repo.FetchContactTags(*conv.ContactID); err != nil {
+			return err
+		}
+	}
+	p := sla.SelectPolicy(policies, subject)
+	if p == nil {
+		return nil
+	}
+
+	metrics := []string{sla.MetricResolution}
+	if m.Direction == conversations.DirectionInbound {
+		metrics = append(metrics, sla.MetricFirstResponse)
+	}
+	for _, metric := range metrics {
+		if err := u.start(*p, conv, metric, m.CreatedAt, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startNextResponse memulai timer next response jika first response sudah terpenuhi
+func (u *slaUsecase) startNextResponse(conv conversations.Conversation, at time.Time) error {
+	p, err := u.repo.GetConversationPolicy(conv.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil || p.NextResponseSeconds == 0 {
+		return err
+	}
+
+	timers, err := u.repo.FetchTimers([]int{conv.ID})
+	if err != nil {
+		return err
+	}
+	for _, t := range timers {
+		if t.Metric == sla.MetricFirstResponse && t.CompletedAt == nil {
+			// Pelanggan masih menunggu balasan pertama
+			return nil
+		}
+	}
+	return u.start(*p, conv, sla.MetricNextResponse, at, true)
+}
+
+func (u *slaUsecase) start(p sla.Policy, conv conversations.Conversation, metric string, at time.Time, restart bool) error {
+	target := p.Target(metric)
+	if target == 0 {
+		return nil
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	var cal sla.Calendar = sla.AlwaysOpen{}
+	if p.BusinessHours {
+		var err error
+		if cal, err = u.calendars.Calendar(conv.ClientID, conv.TeamID); err != nil {
+			return err
+		}
+	}
+
+	t := sla.Timer{
+		ConversationID: conv.ID,
+		ClientID:       conv.ClientID,
+		PolicyID:       p.ID,
+		Metric:         metric,
+		StartedAt:      at,
+		DueAt:          sla.AddBusinessTime(cal, at, target),
+	}
+	if p.WarningPercent > 0 {
+		warnAt := sla.AddBusinessTime(cal, at, target*time.Duration(p.WarningPercent)/100)
+		t.WarnAt = &warnAt
+	}
+	return u.repo.StartTimer(t, restart)
+}
+
+// StatusChanged menyelesaikan semua timer saat percakapan diselesaikan atau ditutup
+func (u *slaUsecase) StatusChanged(conv conversations.Conversation) {
+	if conv.Status == conversations.StatusOpen {
+		return
+	}
+	metrics := []string{sla.MetricFirstResponse, sla.MetricNextResponse, sla.MetricResolution}
+	if err := u.repo.CompleteTimers(conv.ID, metrics); err != nil {
+		slog.Error("Failed to complete SLA timers", "conversation_id", conv.ID, "error", err)
+	}
+}
+
+// Timers mengambil timer SLA beberapa percakapan, dikelompokkan per percakapan
+func (u *slaUsecase) Timers(conversationIDs []int) (map[int][]sla.Timer, error) {
+	result := make(map[int][]sla.Timer)
+	if len(conversationIDs) == 0 {
+		return result, nil
+	}
+	timers, err := u.repo.FetchTimers(conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range timers {
+		result[t.ConversationID] = append(result[t.ConversationID], t)
+	}
+	return result, nil
+}
+
+// ProcessTimers menandai timer yang mendekati atau melewati batas waktu dan
+// mengirim event ke agent client. Setiap timer hanya dilaporkan sekali per kondisi.
+func (u *slaUsecase) ProcessTimers() (int, error) {
+	var warnings, breaches []sla.Timer
+	err := u.repo.InTx(func(repo repository.SLARepository, publisher events.Publisher) error {
+		var err error
+		if warnings, err = repo.FlagWarnings(); err != nil {
+			return err
+		}
+		if breaches, err = repo.FlagBreaches(); err != nil {
+			return err
+		}
+		for _, t := range warnings {
+			if err := publisher.Publish(t.ClientID, realtime.Payload{Type: realtime.EventSLAWarning, Data: t}); err != nil {
+				return err
+			}
+		}
+		for _, t := range breaches {
+			if err := publisher.Publish(t.ClientID, realtime.Payload{Type: realtime.EventSLABreached, Data: t}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, t := range warnings {
+		u.publish(realtime.EventSLAWarning, t)
+	}
+	for _, t := range breaches {
+		u.publish(realtime.EventSLABreached, t)
+	}
+	return len(warnings) + len(breaches), nil
+}
+
+func (u *slaUsecase) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.ProcessTimers(); err != nil {
+				logger.From(ctx).Error("Failed to process SLA timers", "error", err)
+			}
+		}
+	}
+}
+
+func (u *slaUsecase) publish(eventType string, t sla.Timer) {
+	err := u.publisher.Publish(realtime.Event{Type: eventType, ClientID: t.ClientID, Data: t})
+	if err != nil {
+		slog.Error("Failed to publish SLA event", "event_type", eventType, "conversation_id", t.ConversationID, "error", err)
+	}
+}
@@ -0,0 +1,78 @@
+package sms
+
+import (
+	"strings"
+	"time"
+)
+
+// Account adalah konfigurasi provider SMS milik satu client
+type Account struct {
+	ClientID        int     `json:"client_id"`
+	Provider        string  `json:"provider"`
+	AccountSID      string  `json:"account_sid"`
+	AuthToken       string  `json:"-"`
+	FromNumber      string  `json:"from_number"`
+	PricePerSegment float64 `json:"price_per_segment"`
+}
+
+// InboundMessage adalah SMS masuk yang sudah diparsing dari webhook provider
+type InboundMessage struct {
+	ExternalID string `json:"external_id"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Body       string `json:"body"`
+	NumMedia   int    `json:"num_media"`
+}
+
+// OutboundMessage adalah SMS yang akan dikirim melalui provider
+type OutboundMessage struct {
+	From           string `json:"from"`
+	To             string `json:"to"`
+	Body           string `json:"body"`
+	StatusCallback string `json:"status_callback,omitempty"`
+}
+
+// SendResult adalah hasil pengiriman SMS dari provider
+type SendResult struct {
+	ExternalID string `json:"external_id"`
+	Status     string `json:"status"`
+}
+
+// StatusUpdate adalah callback status pengiriman dari provider
+type StatusUpdate struct {
+	ExternalID string `json:"external_id"`
+	Status     string `json:"status"`
+	ErrorCode  string `json:"error_code,omitempty"`
+}
+
+// OptOut adalah status berlangganan SMS sebuah nomor pada satu client
+type OptOut struct {
+	ClientID  int       `json:"client_id"`
+	Phone     string    `json:"phone"` // Format E.164 hasil contacts.NormalizeIdentity
+	ContactID *int      `json:"contact_id"`
+	OptedOut  bool      `json:"opted_out"`
+	Keyword   string    `json:"keyword"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Keyword standar opt-out dan opt-in (mengikuti perilaku carrier/Twilio)
+var (
+	stopKeywords  = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT"}
+	startKeywords = []string{"START", "YES", "UNSTOP"}
+)
+
+// ParseKeyword mengembalikan keyword opt-out/opt-in jika seluruh isi pesan adalah keyword tersebut
+func ParseKeyword(body string) (keyword string, optOut bool, ok bool) {
+	word := strings.ToUpper(strings.TrimSpace(body))
+	for _, k := range stopKeywords {
+		if word == k {
+			return k, true, true
+		}
+	}
+	for _, k := range startKeywords {
+		if word == k {
+			return k, false, true
+		}
+	}
+	return "", false, false
+}
@@ -0,0 +1,165 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backend/internal/sms/usecase"
+	"backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// emptyTwiML adalah respon kosong yang diterima oleh provider kompatibel Twilio
+const emptyTwiML = `<?xml version="1.0" encoding="UTF-8"?><Response></Response>`
+
+type SmsHandler struct {
+	usecase       usecase.SmsUsecase
+	publicBaseURL string
+}
+
+// NewSmsHandler membuat handler SMS. publicBaseURL adalah URL publik server
+// (misalnya https://api.example.com) yang dipakai provider untuk memanggil webhook;
+// jika kosong, URL diturunkan dari request.
+func NewSmsHandler(uc usecase.SmsUsecase, publicBaseURL string) *SmsHandler {
+	return &SmsHandler{usecase: uc, publicBaseURL: strings.TrimRight(publicBaseURL, "/")}
+}
+
+// requestURL membangun ulang URL lengkap yang dipanggil provider untuk validasi signature
+func (h *SmsHandler) requestURL(c *gin.Context) string {
+	base := h.publicBaseURL
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+			scheme = proto
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + c.Request.URL.RequestURI()
+}
+
+// Inbound menerima SMS masuk dari webhook provider
+func (h *SmsHandler) Inbound(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("client_id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "invalid client id")
+		return
+	}
+
+	p, account, err := h.usecase.ProviderFor(clientID, c.Param("provider"))
+	if err != nil {
+		c.String(http.StatusNotFound, "sms provider not configured")
+		return
+	}
+	if err := p.ValidateRequest(*account, c.Request, h.requestURL(c)); err != nil {
+		c.String(http.StatusForbidden, "invalid signature")
+		return
+	}
+
+	msg, err := p.ParseInbound(c.Request)
+	if err != nil {
+		c.String(http.StatusBadRequest, "invalid payload")
+		return
+	}
+
+	base := strings.TrimSuffix(h.requestURL(c), c.Request.URL.RequestURI())
+	if err := h.usecase.HandleInbound(c.Request.Context(), clientID, *msg, base); err != nil {
+		logger.From(c.Request.Context()).Error("Failed to handle inbound sms", "external_id", msg.ExternalID, "error", err)
+		c.String(http.StatusInternalServerError, "failed to process message")
+		return
+	}
+	c.Data(http.StatusOK, "text/xml; charset=utf-8", []byte(emptyTwiML))
+}
+
+// Status menerima callback status pengiriman SMS outbound
+func (h *SmsHandler) Status(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("client_id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "invalid client id")
+		return
+	}
+
+	p, account, err := h.usecase.ProviderFor(clientID, c.Param("provider"))
+	if err != nil {
+		c.String(http.StatusNotFound, "sms provider not configured")
+		return
+	}
+	if err := p.ValidateRequest(*account, c.Request, h.requestURL(c)); err != nil {
+		c.String(http.StatusForbidden, "invalid signature")
+		return
+	}
+
+	update, err := p.ParseStatus(c.Request)
+	if err != nil {
+		c.String(http.StatusBadRequest, "invalid payload")
+		return
+	}
+
+	if err := h.usecase.HandleStatus(clientID, *update); err != nil {
+		logger.From(c.Request.Context()).Error("Failed to update sms status", "external_id", update.ExternalID, "error", err)
+		c.String(http.StatusInternalServerError, "failed to process status")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Send mengirim SMS outbound atas nama agent yang login
+func (h *SmsHandler) Send(c *gin.Context) {
+	var req struct {
+		To   string `json:"to"`
+		Body string `json:"body"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.To == "" || req.Body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	base := strings.TrimSuffix(h.requestURL(c), c.Request.URL.RequestURI())
+	msg, info, err := h.usecase.Send(c.Request.Context(), c.GetInt("client_id"), c.GetInt("user_id"), req.To, req.Body, base)
+	switch {
+	case errors.Is(err, usecase.ErrOptedOut):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Recipient has opted out"})
+		return
+	case errors.Is(err, usecase.ErrNoSmsAccount):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "SMS account not configured"})
+		return
+	case err != nil:
+		logger.From(c.Request.Context()).Error("Failed to send sms", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send SMS"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "SMS sent", "data": msg, "segments": info})
+}
+
+// Estimate menghitung jumlah segmen dan perkiraan biaya sebuah pesan
+func (h *SmsHandler) Estimate(c *gin.Context) {
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	info, cost, err := h.usecase.Estimate(c.GetInt("client_id"), req.Body)
+	if err != nil && !errors.Is(err, usecase.ErrNoSmsAccount) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate cost"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"segments": info, "estimated_cost": cost})
+}
+
+func (h *SmsHandler) GetOptOuts(c *gin.Context) {
+	list, err := h.usecase.GetOptOuts(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch opt-outs"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"backend/internal/sms"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Provider adalah interface untuk gateway SMS (Twilio atau layanan yang kompatibel)
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, account sms.Account, msg sms.OutboundMessage) (*sms.SendResult, error)
+	// ValidateRequest memverifikasi bahwa webhook benar-benar dikirim oleh provider.
+	// fullURL adalah URL publik yang dipanggil provider, termasuk query string.
+	ValidateRequest(account sms.Account, r *http.Request, fullURL string) error
+	ParseInbound(r *http.Request) (*sms.InboundMessage, error)
+	ParseStatus(r *http.Request) (*sms.StatusUpdate, error)
+}
+
+// Registry menyimpan provider SMS yang tersedia berdasarkan nama
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider)}
+	for _, p := range providers {
+		r.providers[p.Name()] = p
+	}
+	return r
+}
+
+// Get mengambil provider berdasarkan nama
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown sms provider %q", name)
+	}
+	return p, nil
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"backend/internal/sms"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTwilioBaseURL adalah endpoint REST API Twilio
+const DefaultTwilioBaseURL = "https://api.twilio.com"
+
+// Twilio adalah implementasi Provider untuk API Twilio dan layanan yang kompatibel
+type Twilio struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewTwilio membuat provider Twilio. baseURL dapat diganti untuk layanan yang kompatibel
+// dengan API Twilio; string kosong berarti memakai DefaultTwilioBaseURL.
+func NewTwilio(baseURL string) *Twilio {
+	if baseURL == "" {
+		baseURL = DefaultTwilioBaseURL
+	}
+	return &Twilio{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (t *Twilio) Name() string {
+	return "twilio"
+}
+
+// Send mengirim SMS melalui endpoint Messages.json
+func (t *Twilio) Send(ctx context.Context, account sms.Account, msg sms.OutboundMessage) (*sms.SendResult, error) {
+	form := url.Values{}
+	form.Set("To", msg.To)
+	form.Set("From", msg.From)
+	form.Set("Body", msg.Body)
+	if msg.StatusCallback != "" {
+		form.Set("StatusCallback", msg.StatusCallback)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", t.baseURL, url.PathEscape(account.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(account.AccountSID, account.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call twilio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		SID     string `json:"sid"`
+		Status  string `json:"status"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode twilio response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("twilio rejected message (%d): %s", body.Code, body.Message)
+	}
+	return &sms.SendResult{ExternalID: body.SID, Status: body.Status}, nil
+}
+
+// ValidateRequest memeriksa header X-Twilio-Signature
+func (t *Twilio) ValidateRequest(account sms.Account, r *http.Request, fullURL string) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	expected := TwilioSignature(account.AuthToken, fullURL, r.PostForm)
+	given := r.Header.Get("X-Twilio-Signature")
+	if given == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(given)) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// TwilioSignature menghitung signature webhook Twilio: HMAC-SHA1 dari URL lengkap
+// ditambah semua parameter POST (diurutkan berdasarkan nama), di-encode base64.
+func TwilioSignature(authToken, fullURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(fullURL)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (t *Twilio) ParseInbound(r *http.Request) (*sms.InboundMessage, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	msg := &sms.InboundMessage{
+		ExternalID: r.PostForm.Get("MessageSid"),
+		From:       r.PostForm.Get("From"),
+		To:         r.PostForm.Get("To"),
+		Body:       r.PostForm.Get("Body"),
+	}
+	msg.NumMedia, _ = strconv.Atoi(r.PostForm.Get("NumMedia"))
+	if msg.ExternalID == "" || msg.From == "" {
+		return nil, fmt.Errorf("missing MessageSid or From")
+	}
+	return msg, nil
+}
+
+func (t *Twilio) ParseStatus(r *http.Request) (*sms.StatusUpdate, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	update := &sms.StatusUpdate{
+		ExternalID: r.PostForm.Get("MessageSid"),
+		Status:     r.PostForm.Get("MessageStatus"),
+		ErrorCode:  r.PostForm.Get("ErrorCode"),
+	}
+	if update.ExternalID == "" || update.Status == "" {
+		return nil, fmt.Errorf("missing MessageSid or MessageStatus")
+	}
+	return update, nil
+}
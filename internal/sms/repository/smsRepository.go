@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"backend/internal/sms"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// SmsRepository adalah interface untuk akun provider dan daftar opt-out SMS
+type SmsRepository interface {
+	GetAccount(clientID int, provider string) (*sms.Account, error)
+	GetDefaultAccount(clientID int) (*sms.Account, error)
+	IsOptedOut(clientID int, phone string) (bool, error)
+	SetOptOut(o sms.OptOut) error
+	FetchOptOuts(clientID int) ([]sms.OptOut, error)
+}
+
+type smsRepo struct {
+	db *sql.DB
+}
+
+func NewSmsRepository(db *sql.DB) SmsRepository {
+	return &smsRepo{db: db}
+}
+
+const accountColumns = "client_id, provider, account_sid, auth_token, from_number, price_per_segment"
+
+func scanAccount(row *sql.Row) (*sms.Account, error) {
+	var a sms.Account
+	err := row.Scan(&a.ClientID, &a.Provider, &a.AccountSID, &a.AuthToken, &a.FromNumber, &a.PricePerSegment)
+	if err != nil {
+		return nil, fmt.Errorf("sms account not found: %w", err)
+	}
+	return &a, nil
+}
+
+// GetAccount mengambil konfigurasi provider SMS milik client
+func (r *smsRepo) GetAccount(clientID int, provider string) (*sms.Account, error) {
+	return scanAccount(r.db.QueryRow(
+		"SELECT "+accountColumns+" FROM sms_accounts WHERE client_id = $1 AND provider = $2", clientID, provider,
+	))
+}
+
+// GetDefaultAccount mengambil akun SMS pertama milik client untuk pengiriman outbound
+func (r *smsRepo) GetDefaultAccount(clientID int) (*sms.Account, error) {
+	return scanAccount(r.db.QueryRow(
+		"SELECT "+accountColumns+" FROM sms_accounts WHERE client_id = $1 ORDER BY sms_account_id LIMIT 1", clientID,
+	))
+}
+
+func (r *smsRepo) IsOptedOut(clientID int, phone string) (bool, error) {
+	var optedOut bool
+	err := r.db.QueryRow("SELECT opted_out FROM sms_opt_outs WHERE client_id = $1 AND phone = $2", clientID, phone).Scan(&optedOut)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return optedOut, err
+}
+
+// SetOptOut mencatat STOP/START terakhir dari sebuah nomor
+func (r *smsRepo) SetOptOut(o sms.OptOut) error {
+	_, err := r.db.Exec(
+		`INSERT INTO sms_opt_outs (client_id, phone, contact_id, opted_out, keyword, updated_at) VALUES ($1, $2, $3, $4, $5, NOW())
+		 ON CONFLICT (client_id, phone) DO UPDATE SET contact_id = COALESCE(EXCLUDED.contact_id, sms_opt_outs.contact_id),
+		 opted_out = EXCLUDED.opted_out, keyword = EXCLUDED.keyword, updated_at = NOW()`,
+		o.ClientID, o.Phone, o.ContactID, o.OptedOut, o.Keyword,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save opt-out for %s: %w", o.Phone, err)
+	}
+	return nil
+}
+
+func (r *smsRepo) FetchOptOuts(clientID int) ([]sms.OptOut, error) {
+	rows, err := r.db.Query(
+		"SELECT client_id, phone, contact_id, opted_out, keyword, updated_at FROM sms_opt_outs WHERE client_id = $1 AND opted_out ORDER BY updated_at DESC",
+		clientID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []sms.OptOut
+	for rows.Next() {
+		var o sms.OptOut
+		if err := rows.Scan(&o.ClientID, &o.Phone, &o.ContactID, &o.OptedOut, &o.Keyword, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, o)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,97 @@
+package sms
+
+import "unicode/utf16"
+
+const (
+	EncodingGSM7 = "GSM-7"
+	EncodingUCS2 = "UCS-2"
+
+	gsm7SingleLimit = 160
+	gsm7MultiLimit  = 153 // 7 septet dipakai oleh User Data Header
+	ucs2SingleLimit = 70
+	ucs2MultiLimit  = 67
+)
+
+// gsm7Basic adalah karakter pada GSM 03.38 basic character set (1 septet)
+var gsm7Basic = map[rune]bool{}
+
+// gsm7Extension adalah karakter extension table yang membutuhkan escape (2 septet)
+var gsm7Extension = map[rune]bool{
+	'\f': true, '^': true, '{': true, '}': true, '\\': true,
+	'[': true, '~': true, ']': true, '|': true, '€': true,
+}
+
+func init() {
+	basic := "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	for _, r := range basic {
+		gsm7Basic[r] = true
+	}
+}
+
+// SegmentInfo adalah hasil perhitungan segmen SMS
+type SegmentInfo struct {
+	Encoding   string `json:"encoding"`
+	Units      int    `json:"units"` // Septet untuk GSM-7, code unit 16-bit untuk UCS-2
+	Segments   int    `json:"segments"`
+	Characters int    `json:"characters"`
+}
+
+// CountSegments menghitung encoding dan jumlah segmen SMS untuk sebuah pesan.
+// Karakter escape GSM-7 dan surrogate pair UCS-2 tidak pernah dipecah di antara dua segmen.
+func CountSegments(body string) SegmentInfo {
+	runes := []rune(body)
+	info := SegmentInfo{Encoding: EncodingGSM7, Characters: len(runes)}
+	for _, r := range runes {
+		if !gsm7Basic[r] && !gsm7Extension[r] {
+			info.Encoding = EncodingUCS2
+			break
+		}
+	}
+
+	singleLimit, multiLimit := gsm7SingleLimit, gsm7MultiLimit
+	if info.Encoding == EncodingUCS2 {
+		singleLimit, multiLimit = ucs2SingleLimit, ucs2MultiLimit
+	}
+
+	costs := make([]int, len(runes))
+	for i, r := range runes {
+		switch {
+		case info.Encoding == EncodingUCS2:
+			costs[i] = len(utf16.Encode([]rune{r}))
+		case gsm7Extension[r]:
+			costs[i] = 2
+		default:
+			costs[i] = 1
+		}
+	}
+
+	for _, c := range costs {
+		info.Units += c
+	}
+	if info.Units == 0 {
+		return info
+	}
+	if info.Units <= singleLimit {
+		info.Segments = 1
+		return info
+	}
+
+	// Pesan multipart: isi segmen satu per satu tanpa memecah karakter
+	info.Segments = 1
+	used := 0
+	for _, c := range costs {
+		if used+c > multiLimit {
+			info.Segments++
+			used = 0
+		}
+		used += c
+	}
+	return info
+}
+
+// EstimateCost menghitung perkiraan biaya pengiriman berdasarkan jumlah segmen
+func EstimateCost(body string, pricePerSegment float64) (SegmentInfo, float64) {
+	info := CountSegments(body)
+	return info, float64(info.Segments) * pricePerSegment
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"backend/internal/contacts"
+	"backend/internal/conversations"
+	conversationUsecase "backend/internal/conversations/usecase"
+	"backend/internal/sms"
+	"backend/internal/sms/provider"
+	"backend/internal/sms/repository"
+	"backend/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrOptedOut     = errors.New("recipient has opted out of sms")
+	ErrNoSmsAccount = errors.New("sms account not configured")
+)
+
+// AutoReplier menentukan balasan otomatis di luar jam kerja
+type AutoReplier interface {
+	OutOfOffice(clientID int, teamID *int, at time.Time) (string, bool, error)
+}
+
+// SurveyResponder menangkap balasan pelanggan atas survei yang masih menunggu jawaban.
+// Nilai true berarti pesan sudah ditangani dan tidak perlu masuk ke percakapan baru.
+type SurveyResponder interface {
+	HandleReply(ctx context.Context, address string, m conversations.Message) (bool, error)
+}
+
+type SmsUsecase interface {
+	ProviderFor(clientID int, name string) (provider.Provider, *sms.Account, error)
+	HandleInbound(ctx context.Context, clientID int, msg sms.InboundMessage, webhookBaseURL string) error
+	HandleStatus(clientID int, update sms.StatusUpdate) error
+	Send(ctx context.Context, clientID, userID int, to, body, webhookBaseURL string) (*conversations.Message, sms.SegmentInfo, error)
+	Estimate(clientID int, body string) (sms.SegmentInfo, float64, error)
+	GetOptOuts(clientID int) ([]sms.OptOut, error)
+	Reply(ctx context.Context, conv conversations.Conversation, senderUserID *int, body string) (*conversations.Message, error)
+	Notify(ctx context.Context, conv conversations.Conversation, body string) (*conversations.Message, error)
+}
+
+type smsUsecase struct {
+	repo          repository.SmsRepository
+	providers     *provider.Registry
+	conversations conversationUsecase.ConversationUsecase
+	contacts      conversationUsecase.ContactResolver
+	autoReply     AutoReplier
+	surveys       SurveyResponder
+	publicBaseURL string
+}
+
+// NewSmsUsecase membuat usecase SMS. publicBaseURL dipakai untuk status callback
+// pesan yang dikirim tanpa request HTTP (misalnya dari automation). surveys boleh nil.
+func NewSmsUsecase(repo repository.SmsRepository, providers *provider.Registry, conv conversationUsecase.ConversationUsecase, contactResolver conversationUsecase.ContactResolver, autoReply AutoReplier, surveys SurveyResponder, publicBaseURL string) SmsUsecase {
+	return &smsUsecase{repo: repo, providers: providers, conversations: conv, contacts: contactResolver, autoReply: autoReply, surveys: surveys, publicBaseURL: publicBaseURL}
+}
+
+// optOutPhone menyeragamkan nomor untuk daftar opt-out. Provider mengirim nomor dalam format
+// E.164, sedangkan nomor tujuan dari agent bisa berisi spasi atau tanda hubung; keduanya harus
+// menghasilkan kunci yang sama agar STOP selalu dihormati.
+func optOutPhone(phone string) string {
+	return contacts.NormalizeIdentity(contacts.IdentityPhone, phone)
+}
+
+// ProviderFor mengambil provider beserta akun client yang dipakai untuk memvalidasi webhook
+func (u *smsUsecase) ProviderFor(clientID int, name string) (provider.Provider, *sms.Account, error) {
+	p, err := u.providers.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	account, err := u.repo.GetAccount(clientID, p.Name())
+	if err != nil {
+		return nil, nil, ErrNoSmsAccount
+	}
+	return p, account, nil
+}
+
+// HandleInbound mencatat keyword STOP/START lalu menyimpan pesan ke percakapan pengirim.
+// Jawaban survei dicatat ke percakapan yang disurvei; percakapan baru di luar jam kerja
+// mendapat balasan otomatis. Webhook yang dikirim ulang provider dengan ID pesan yang sama
+// diabaikan.
+func (u *smsUsecase) HandleInbound(ctx context.Context, clientID int, msg sms.InboundMessage, webhookBaseURL string) error {
+	keyword, optOut, isKeyword := sms.ParseKeyword(msg.Body)
+	if isKeyword {
+		o := sms.OptOut{ClientID: clientID, Phone: optOutPhone(msg.From), OptedOut: optOut, Keyword: keyword}
+		// Opt-out tetap dicatat walaupun kontak gagal ditemukan; kontak hanya pelengkap
+		if contactID, err := u.contacts.ResolveIdentity(clientID, contacts.IdentityPhone, o.Phone); err != nil {
+			logger.From(ctx).Error("Failed to resolve opt-out contact", "client_id", clientID, "error", err)
+		} else {
+			o.ContactID = &contactID
+		}
+		if err := u.repo.SetOptOut(o); err != nil {
+			return err
+		}
+	}
+
+	inbound := conversations.Message{
+		ClientID:   clientID,
+		Direction:  conversations.DirectionInbound,
+		Channel:    conversations.ChannelSMS,
+		Body:       msg.Body,
+		ExternalID: msg.ExternalID,
+		Status:     "received",
+	}
+	if !isKeyword && u.surveys != nil {
+		handled, err := u.surveys.HandleReply(ctx, msg.From, inbound)
+		if err != nil {
+			return err
+		}
+		if handled {
+			return nil
+		}
+	}
+
+	conv, _, created, err := u.conversations.AppendMessage(msg.From, inbound)
+	if errors.Is(err, conversations.ErrDuplicateMessage) {
+		return nil
+	}
+	if err != nil || !created || isKeyword {
+		return err
+	}
+
+	// Kegagalan balasan otomatis hanya dicatat agar provider tidak mengirim ulang webhook
+	reply, ok, err := u.autoReply.OutOfOffice(clientID, conv.TeamID, time.Now())
+	if err != nil {
+		logger.From(ctx).Error("Failed to check business hours", "client_id", clientID, "error", err)
+	} else if ok {
+		if _, _, err := u.send(ctx, clientID, nil, msg.From, reply, webhookBaseURL); err != nil {
+			logger.From(ctx).Error("Failed to send out-of-office reply", "conversation_id", conv.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// HandleStatus memperbarui status pesan milik client yang menerima callback
+func (u *smsUsecase) HandleStatus(clientID int, update sms.StatusUpdate) error {
+	return u.conversations.UpdateMessageStatus(clientID, conversations.ChannelSMS, update.ExternalID, update.Status)
+}
+
+// Send mengirim SMS ke nomor tujuan kecuali nomor tersebut sudah opt-out.
+// webhookBaseURL dipakai untuk membangun URL status callback provider.
+func (u *smsUsecase) Send(ctx context.Context, clientID, userID int, to, body, webhookBaseURL string) (*conversations.Message, sms.SegmentInfo, error) {
+	return u.send(ctx, clientID, &userID, to, body, webhookBaseURL)
+}
+
+// send mengirim SMS; senderUserID nil untuk pesan otomatis dari sistem
+func (u *smsUsecase) send(ctx context.Context, clientID int, senderUserID *int, to, body, webhookBaseURL string) (*conversations.Message, sms.SegmentInfo, error) {
+	info := sms.CountSegments(body)
+	result, err := u.deliver(ctx, clientID, to, body, webhookBaseURL)
+	if err != nil {
+		return nil, info, err
+	}
+
+	_, msg, _, err := u.conversations.AppendMessage(to, conversations.Message{
+		ClientID:     clientID,
+		Direction:    conversations.DirectionOutbound,
+		Channel:      conversations.ChannelSMS,
+		Body:         body,
+		ExternalID:   result.ExternalID,
+		Status:       result.Status,
+		SenderUserID: senderUserID,
+	})
+	return msg, info, err
+}
+
+// deliver mengirim SMS melalui provider akun default client kecuali nomor tujuan sudah opt-out
+func (u *smsUsecase) deliver(ctx context.Context, clientID int, to, body, webhookBaseURL string) (*sms.SendResult, error) {
+	optedOut, err := u.repo.IsOptedOut(clientID, optOutPhone(to))
+	if err != nil {
+		return nil, err
+	}
+	if optedOut {
+		return nil, ErrOptedOut
+	}
+
+	account, err := u.repo.GetDefaultAccount(clientID)
+	if err != nil {
+		return nil, ErrNoSmsAccount
+	}
+	p, err := u.providers.Get(account.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	out := sms.OutboundMessage{From: account.FromNumber, To: to, Body: body}
+	if webhookBaseURL != "" {
+		out.StatusCallback = fmt.Sprintf("%s/webhooks/sms/%s/%d/status", webhookBaseURL, account.Provider, clientID)
+	}
+	result, err := p.Send(ctx, *account, out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send sms: %w", err)
+	}
+	return result, nil
+}
+
+// Notify mengirim pesan sistem ke pelanggan dan mencatatnya pada percakapan conv walaupun
+// percakapan sudah selesai, sehingga tidak membuka percakapan baru
+func (u *smsUsecase) Notify(ctx context.Context, conv conversations.Conversation, body string) (*conversations.Message, error) {
+	result, err := u.deliver(ctx, conv.ClientID, conv.ExternalAddress, body, u.publicBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return u.conversations.RecordMessage(conversations.Message{
+		ConversationID: conv.ID,
+		ClientID:       conv.ClientID,
+		Direction:      conversations.DirectionOutbound,
+		Channel:        conversations.ChannelSMS,
+		Body:           body,
+		ExternalID:     result.ExternalID,
+		Status:         result.Status,
+	})
+}
+
+// Reply mengirim pesan ke pelanggan pada percakapan SMS yang sudah ada
+func (u *smsUsecase) Reply(ctx context.Context, conv conversations.Conversation, senderUserID *int, body string) (*conversations.Message, error) {
+	msg, _, err := u.send(ctx, conv.ClientID, senderUserID, conv.ExternalAddress, body, u.publicBaseURL)
+	return msg, err
+}
+
+// Estimate menghitung segmen dan perkiraan biaya berdasarkan tarif akun client
+func (u *smsUsecase) Estimate(clientID int, body string) (sms.SegmentInfo, float64, error) {
+	account, err := u.repo.GetDefaultAccount(clientID)
+	if err != nil {
+		return sms.CountSegments(body), 0, ErrNoSmsAccount
+	}
+	info, cost := sms.EstimateCost(body, account.PricePerSegment)
+	return info, cost, nil
+}
+
+func (u *smsUsecase) GetOptOuts(clientID int) ([]sms.OptOut, error) {
+	return u.repo.FetchOptOuts(clientID)
+}
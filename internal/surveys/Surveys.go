@@ -0,0 +1,220 @@
+package surveys
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Jenis survei
+const (
+	TypeCSAT = "csat" // Kepuasan 1-5
+	TypeNPS  = "nps"  // Kemungkinan merekomendasikan 0-10
+)
+
+// Status undangan survei
+const (
+	StatusPending  = "pending" // Dibuat, belum terkirim
+	StatusSent     = "sent"
+	StatusAnswered = "answered"
+)
+
+// Sumber jawaban
+const (
+	SourceReply = "reply" // Balasan pesan di channel percakapan
+	SourceWeb   = "web"   // Formulir dari link bertanda tangan
+)
+
+// Batas pengaturan survei
+const (
+	MaxDelayMinutes   = 24 * 60
+	MaxExpiryHours    = 30 * 24
+	MaxQuestionLength = 480
+	MaxCommentLength  = 1000
+)
+
+// Pertanyaan bawaan bila client tidak menulis sendiri
+var DefaultQuestions = map[string]string{
+	TypeCSAT: "How satisfied were you with our support? Reply with a number from 1 (very unsatisfied) to 5 (very satisfied).",
+	TypeNPS:  "How likely are you to recommend us to a friend or colleague? Reply with a number from 0 (not likely) to 10 (very likely).",
+}
+
+// Pertanyaan pada formulir web; pertanyaan pesan menyebut cara membalas sehingga tidak cocok di sini
+var WebQuestions = map[string]string{
+	TypeCSAT: "How satisfied were you with our support?",
+	TypeNPS:  "How likely are you to recommend us to a friend or colleague?",
+}
+
+// Settings adalah konfigurasi survei per client
+type Settings struct {
+	ClientID     int       `json:"client_id"`
+	Enabled      bool      `json:"enabled"`
+	Type         string    `json:"type"`
+	Question     string    `json:"question"`      // Kosong berarti DefaultQuestions
+	ThankYou     string    `json:"thank_you"`     // Dikirim setelah jawaban lewat balasan pesan; kosong berarti tidak ada
+	IncludeLink  bool      `json:"include_link"`  // Tambahkan link formulir web bertanda tangan
+	DelayMinutes int       `json:"delay_minutes"` // Jeda setelah percakapan diselesaikan
+	ExpiryHours  int       `json:"expiry_hours"`  // Jawaban setelah ini tidak diterima lagi
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DefaultSettings dipakai client yang belum menyimpan pengaturan; survei belum aktif
+func DefaultSettings(clientID int) Settings {
+	return Settings{ClientID: clientID, Type: TypeCSAT, IncludeLink: true, ExpiryHours: 72}
+}
+
+// QuestionText mengembalikan pertanyaan yang dikirim ke pelanggan
+func (s Settings) QuestionText() string {
+	if s.Question != "" {
+		return s.Question
+	}
+	return DefaultQuestions[s.Type]
+}
+
+// Survey adalah undangan survei untuk satu percakapan yang diselesaikan
+type Survey struct {
+	ID             int        `json:"id"`
+	ClientID       int        `json:"client_id"`
+	ConversationID int        `json:"conversation_id"`
+	AgentID        *int       `json:"agent_id"` // Agent yang menangani percakapan saat survei dikirim
+	Channel        string     `json:"channel"`
+	Address        string     `json:"-"`
+	Type           string     `json:"type"`
+	Status         string     `json:"status"`
+	SentAt         *time.Time `json:"sent_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Response adalah jawaban survei
+type Response struct {
+	ID             int       `json:"id"`
+	SurveyID       int       `json:"survey_id"`
+	ClientID       int       `json:"client_id"`
+	ConversationID int       `json:"conversation_id"`
+	AgentID        *int      `json:"agent_id"`
+	Type           string    `json:"type"`
+	Score          int       `json:"score"`
+	Comment        string    `json:"comment"`
+	Source         string    `json:"source"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ResponseFilter membatasi daftar jawaban survei
+type ResponseFilter struct {
+	Type    string
+	AgentID int
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+}
+
+// Scale mengembalikan skor minimum dan maksimum jenis survei
+func Scale(surveyType string) (int, int) {
+	if surveyType == TypeNPS {
+		return 0, 10
+	}
+	return 1, 5
+}
+
+// ValidType memeriksa jenis survei
+func ValidType(surveyType string) bool {
+	return surveyType == TypeCSAT || surveyType == TypeNPS
+}
+
+var numericReply = regexp.MustCompile(`(?s)^(\d{1,2})(?:\s*/\s*(?:5|10))?(?:[\s.,!:;)\-]+(.*))?$`)
+
+// Emoji wajah untuk CSAT; dicocokkan pada awal balasan
+var emojiScores = []struct {
+	emoji string
+	score int
+}{
+	{"😡", 1}, {"😠", 1}, {"🤬", 1}, {"👎", 1},
+	{"😞", 2}, {"🙁", 2}, {"☹", 2}, {"😟", 2}, {"😢", 2},
+	{"😐", 3}, {"😑", 3}, {"😶", 3},
+	{"🙂", 4}, {"😊", 4}, {"👍", 4},
+	{"😀", 5}, {"😃", 5}, {"😄", 5}, {"😁", 5}, {"😍", 5}, {"🤩", 5}, {"🥰", 5}, {"❤", 5},
+}
+
+// ParseReply membaca skor dari balasan pelanggan: angka ("4", "4/5", "9 cepat sekali"), emoji
+// keycap ("4️⃣", "🔟"), dan untuk CSAT juga bintang ("⭐⭐⭐⭐") atau emoji wajah. Sisa teks
+// setelah skor menjadi komentar. Balasan lain atau skor di luar skala tidak dianggap jawaban.
+func ParseReply(body, surveyType string) (int, string, bool) {
+	s := strings.TrimSpace(body)
+	s = strings.ReplaceAll(s, "🔟", "10")
+	s = strings.ReplaceAll(s, "\ufe0f\u20e3", "")
+	s = strings.ReplaceAll(s, "\u20e3", "")
+
+	score, rest, ok := -1, "", false
+	if m := numericReply.FindStringSubmatch(s); m != nil {
+		score, _ = strconv.Atoi(m[1])
+		rest, ok = m[2], true
+	} else if surveyType == TypeCSAT {
+		score, rest, ok = parseEmoji(s)
+	}
+	if !ok {
+		return 0, "", false
+	}
+
+	min, max := Scale(surveyType)
+	if score < min || score > max {
+		return 0, "", false
+	}
+	return score, TrimComment(rest), true
+}
+
+func parseEmoji(s string) (int, string, bool) {
+	stars := 0
+	rest := s
+	for {
+		rest = strings.TrimLeft(rest, "\ufe0f ")
+		if r := strings.TrimPrefix(rest, "⭐"); r != rest {
+			rest, stars = r, stars+1
+		} else if r := strings.TrimPrefix(rest, "★"); r != rest {
+			rest, stars = r, stars+1
+		} else {
+			break
+		}
+	}
+	if stars > 0 {
+		return stars, rest, true
+	}
+
+	for _, e := range emojiScores {
+		if strings.HasPrefix(s, e.emoji) {
+			return e.score, strings.TrimLeft(strings.TrimPrefix(s, e.emoji), "\ufe0f"), true
+		}
+	}
+	return 0, "", false
+}
+
+// TrimComment merapikan komentar dan membatasi panjangnya
+func TrimComment(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > MaxCommentLength {
+		s = strings.ToValidUTF8(s[:MaxCommentLength], "")
+	}
+	return s
+}
+
+// Sign menghasilkan signature HMAC-SHA256 untuk link survei yang berlaku sampai expires
+func Sign(key []byte, id int, expires time.Time) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "survey:%d:%d", id, expires.Unix())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify memeriksa signature dan masa berlaku link survei
+func Verify(key []byte, id int, expires, signature string, now time.Time) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > exp {
+		return false
+	}
+	expected := Sign(key, id, time.Unix(exp, 0))
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
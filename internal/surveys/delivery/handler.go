@@ -0,0 +1,199 @@
+package delivery
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/surveys"
+	"backend/internal/surveys/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SurveyHandler struct {
+	usecase usecase.SurveyUsecase
+}
+
+func NewSurveyHandler(uc usecase.SurveyUsecase) *SurveyHandler {
+	return &SurveyHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidSettings):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *SurveyHandler) GetSettings(c *gin.Context) {
+	settings, err := h.usecase.GetSettings(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch survey settings"})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *SurveyHandler) UpdateSettings(c *gin.Context) {
+	var req struct {
+		Enabled      bool   `json:"enabled"`
+		Type         string `json:"type" binding:"required"`
+		Question     string `json:"question"`
+		ThankYou     string `json:"thank_you"`
+		IncludeLink  bool   `json:"include_link"`
+		DelayMinutes int    `json:"delay_minutes"`
+		ExpiryHours  int    `json:"expiry_hours" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	settings := surveys.Settings{
+		ClientID:     c.GetInt("client_id"),
+		Enabled:      req.Enabled,
+		Type:         req.Type,
+		Question:     req.Question,
+		ThankYou:     req.ThankYou,
+		IncludeLink:  req.IncludeLink,
+		DelayMinutes: req.DelayMinutes,
+		ExpiryHours:  req.ExpiryHours,
+	}
+	if err := h.usecase.UpdateSettings(settings); err != nil {
+		respondError(c, err, "Failed to update survey settings")
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// GetResponses mengambil jawaban survei terbaru.
+// Query: type (csat atau nps), agent_id, from dan to (RFC3339), limit.
+func (h *SurveyHandler) GetResponses(c *gin.Context) {
+	f := surveys.ResponseFilter{Type: c.Query("type")}
+	if f.Type != "" && !surveys.ValidType(f.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type"})
+		return
+	}
+	for name, target := range map[string]*int{"agent_id": &f.AgentID, "limit": &f.Limit} {
+		if v := c.Query(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+				return
+			}
+			*target = n
+		}
+	}
+	for name, target := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		if v := c.Query(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+				return
+			}
+			*target = &t
+		}
+	}
+
+	list, err := h.usecase.GetResponses(c.GetInt("client_id"), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch survey responses"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// formTemplate adalah halaman survei untuk pelanggan; tidak memuat script atau resource luar
+var formTemplate = template.Must(template.New("survey").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>Survey</title>
+<style>body{font-family:sans-serif;max-width:32rem;margin:2rem auto;padding:0 1rem}button{min-width:2.5rem;margin:.2rem;padding:.5rem}textarea{width:100%}</style>
+</head><body>
+{{if .Message}}<p>{{.Message}}</p>{{else}}
+<form method="post" action="{{.Action}}">
+<p>{{.Question}}</p>
+<p>{{range .Scale}}<button type="submit" name="score" value="{{.}}">{{.}}</button>{{end}}</p>
+<p><label>Comment (optional)<br><textarea name="comment" rows="4" maxlength="1000"></textarea></label></p>
+</form>{{end}}
+</body></html>`))
+
+type formPage struct {
+	Message  string
+	Action   string
+	Question string
+	Scale    []int
+}
+
+// renderForm menulis halaman survei. Link bertanda tangan tidak boleh bocor lewat Referer
+// atau tersimpan di cache bersama.
+func renderForm(c *gin.Context, status int, page formPage) {
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	formTemplate.Execute(c.Writer, page)
+}
+
+func linkError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidLink):
+		renderForm(c, http.StatusForbidden, formPage{Message: "This survey link is invalid or has expired."})
+	case errors.Is(err, usecase.ErrClosed):
+		renderForm(c, http.StatusConflict, formPage{Message: "This survey has already been answered. Thank you!"})
+	case errors.Is(err, usecase.ErrInvalidScore):
+		renderForm(c, http.StatusBadRequest, formPage{Message: "Please choose a score from the scale."})
+	default:
+		renderForm(c, http.StatusInternalServerError, formPage{Message: "Something went wrong, please try again later."})
+	}
+}
+
+// GetForm menampilkan formulir survei dari link bertanda tangan (tanpa JWT)
+func (h *SurveyHandler) GetForm(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		linkError(c, usecase.ErrInvalidLink)
+		return
+	}
+	form, err := h.usecase.GetForm(id, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		linkError(c, err)
+		return
+	}
+	if form.Answered {
+		linkError(c, usecase.ErrClosed)
+		return
+	}
+
+	page := formPage{Action: c.Request.URL.RequestURI(), Question: form.Question}
+	for i := form.Min; i <= form.Max; i++ {
+		page.Scale = append(page.Scale, i)
+	}
+	renderForm(c, http.StatusOK, page)
+}
+
+// SubmitForm menyimpan jawaban dari formulir survei
+func (h *SurveyHandler) SubmitForm(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		linkError(c, usecase.ErrInvalidLink)
+		return
+	}
+	score, err := strconv.Atoi(c.PostForm("score"))
+	if err != nil {
+		linkError(c, usecase.ErrInvalidScore)
+		return
+	}
+
+	err = h.usecase.SubmitForm(id, c.Query("expires"), c.Query("signature"), score, c.PostForm("comment"))
+	if err != nil {
+		linkError(c, err)
+		return
+	}
+	renderForm(c, http.StatusOK, formPage{Message: "Thank you for your feedback!"})
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"backend/internal/surveys"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SurveyRepository adalah interface untuk pengaturan, undangan dan jawaban survei
+type SurveyRepository interface {
+	GetSettings(clientID int) (*surveys.Settings, error)
+	SaveSettings(s surveys.Settings) error
+	// CreateSurvey membuat undangan untuk percakapan, atau mengembalikan undangan yang sudah ada
+	CreateSurvey(s surveys.Survey) (*surveys.Survey, error)
+	MarkSent(id int, expiresAt time.Time) error
+	GetSurvey(id int) (*surveys.Survey, error)
+	// FindAwaitingReply mencari survei terbaru ke alamat pelanggan yang masih menunggu jawaban
+	FindAwaitingReply(clientID int, channel, address string) (*surveys.Survey, error)
+	// SaveResponse menyimpan jawaban; false jika survei sudah dijawab atau kedaluwarsa
+	SaveResponse(r surveys.Response) (bool, error)
+	FetchResponses(clientID int, f surveys.ResponseFilter) ([]surveys.Response, error)
+}
+
+type surveyRepo struct {
+	db *sql.DB
+}
+
+func NewSurveyRepository(db *sql.DB) SurveyRepository {
+	return &surveyRepo{db: db}
+}
+
+// GetSettings mengambil pengaturan survei client, default jika belum pernah disimpan
+func (r *surveyRepo) GetSettings(clientID int) (*surveys.Settings, error) {
+	s := surveys.DefaultSettings(clientID)
+	err := r.db.QueryRow(
+		`SELECT enabled, survey_type, question, thank_you, include_link, delay_minutes, expiry_hours, updated_at
+		 FROM survey_settings WHERE client_id = $1`, clientID,
+	).Scan(&s.Enabled, &s.Type, &s.Question, &s.ThankYou, &s.IncludeLink, &s.DelayMinutes, &s.ExpiryHours, &s.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *surveyRepo) SaveSettings(s surveys.Settings) error {
+	_, err := r.db.Exec(
+		`INSERT INTO survey_settings (client_id, enabled, survey_type, question, thank_you, include_link, delay_minutes, expiry_hours, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		 ON CONFLICT (client_id) DO UPDATE SET enabled = EXCLUDED.enabled, survey_type = EXCLUDED.survey_type,
+		 question = EXCLUDED.question, thank_you = EXCLUDED.thank_you, include_link = EXCLUDED.include_link,
+		 delay_minutes = EXCLUDED.delay_minutes, expiry_hours = EXCLUDED.expiry_hours, updated_at = NOW()`,
+		s.ClientID, s.Enabled, s.Type, s.Question, s.ThankYou, s.IncludeLink, s.DelayMinutes, s.ExpiryHours,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save survey settings: %w", err)
+	}
+	return nil
+}
+
+const surveyColumns = "survey_id, client_id, conversation_id, agent_id, channel, address, survey_type, status, sent_at, expires_at, created_at"
+
+func scanSurvey(row interface{ Scan(...interface{}) error }) (*surveys.Survey, error) {
+	var s surveys.Survey
+	var agentID sql.NullInt64
+	var sentAt, expiresAt sql.NullTime
+	err := row.Scan(&s.ID, &s.ClientID, &s.ConversationID, &agentID, &s.Channel, &s.Address, &s.Type, &s.Status,
+		&sentAt, &expiresAt, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if agentID.Valid {
+		id := int(agentID.Int64)
+		s.AgentID = &id
+	}
+	if sentAt.Valid {
+		s.SentAt = &sentAt.Time
+	}
+	if expiresAt.Valid {
+		s.ExpiresAt = &expiresAt.Time
+	}
+	return &s, nil
+}
+
+// CreateSurvey memakai upsert tanpa perubahan agar undangan yang sudah ada ikut dikembalikan;
+// satu percakapan hanya disurvei sekali walaupun diselesaikan berulang kali
+func (r *surveyRepo) CreateSurvey(s surveys.Survey) (*surveys.Survey, error) {
+	return scanSurvey(r.db.QueryRow(
+		`INSERT INTO surveys (client_id, conversation_id, agent_id, channel, address, survey_type, status)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 ON CONFLICT (conversation_id) DO UPDATE SET conversation_id = EXCLUDED.conversation_id
+		 RETURNING `+surveyColumns,
+		s.ClientID, s.ConversationID, s.AgentID, s.Channel, s.Address, s.Type, surveys.StatusPending,
+	))
+}
+
+func (r *surveyRepo) MarkSent(id int, expiresAt time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE surveys SET status = $1, sent_at = NOW(), expires_at = $2 WHERE survey_id = $3 AND status = $4",
+		surveys.StatusSent, expiresAt, id, surveys.StatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark survey %d as sent: %w", id, err)
+	}
+	return nil
+}
+
+func (r *surveyRepo) GetSurvey(id int) (*surveys.Survey, error) {
+	return scanSurvey(r.db.QueryRow("SELECT "+surveyColumns+" FROM surveys WHERE survey_id = $1", id))
+}
+
+func (r *surveyRepo) FindAwaitingReply(clientID int, channel, address string) (*surveys.Survey, error) {
+	return scanSurvey(r.db.QueryRow(
+		"SELECT "+surveyColumns+` FROM surveys
+		 WHERE client_id = $1 AND channel = $2 AND address = $3 AND status = $4 AND expires_at > NOW()
+		 ORDER BY sent_at DESC LIMIT 1`,
+		clientID, channel, address, surveys.StatusSent,
+	))
+}
+
+// SaveResponse menandai survei terjawab lalu menyimpan jawabannya dalam satu transaksi.
+// Syarat status pada UPDATE mencegah dua jawaban tersimpan untuk survei yang sama.
+func (r *surveyRepo) SaveResponse(resp surveys.Response) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"UPDATE surveys SET status = $1, answered_at = NOW() WHERE survey_id = $2 AND status = $3 AND expires_at > NOW()",
+		surveys.StatusAnswered, resp.SurveyID, surveys.StatusSent,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO survey_responses (survey_id, client_id, conversation_id, agent_id, survey_type, score, comment, source)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		resp.SurveyID, resp.ClientID, resp.ConversationID, resp.AgentID, resp.Type, resp.Score, resp.Comment, resp.Source,
+	); err != nil {
+		return false, fmt.Errorf("failed to save survey response: %w", err)
+	}
+	return true, tx.Commit()
+}
+
+// FetchResponses mengambil jawaban terbaru client sesuai filter
+func (r *surveyRepo) FetchResponses(clientID int, f surveys.ResponseFilter) ([]surveys.Response, error) {
+	rows, err := r.db.Query(
+		`SELECT response_id, survey_id, client_id, conversation_id, agent_id, survey_type, score, comment, source, created_at
+		 FROM survey_responses
+		 WHERE client_id = $1 AND ($2 = '' OR survey_type = $2) AND ($3 = 0 OR agent_id = $3)
+		   AND ($4::timestamptz IS NULL OR created_at >= $4) AND ($5::timestamptz IS NULL OR created_at < $5)
+		 ORDER BY created_at DESC LIMIT $6`,
+		clientID, f.Type, f.AgentID, f.From, f.To, f.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []surveys.Response
+	for rows.Next() {
+		var resp surveys.Response
+		var agentID sql.NullInt64
+		if err := rows.Scan(&resp.ID, &resp.SurveyID, &resp.ClientID, &resp.ConversationID, &agentID, &resp.Type,
+			&resp.Score, &resp.Comment, &resp.Source, &resp.CreatedAt); err != nil {
+			return nil, err
+		}
+		if agentID.Valid {
+			id := int(agentID.Int64)
+			resp.AgentID = &id
+		}
+		list = append(list, resp)
+	}
+	return list, rows.Err()
+}
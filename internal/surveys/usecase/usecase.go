@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"backend/internal/conversations"
+	"backend/internal/events"
+	"backend/internal/jobs"
+	"backend/internal/realtime"
+	"backend/internal/surveys"
+	"backend/internal/surveys/repository"
+	"backend/pkg/logger"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSettings = errors.New("invalid survey settings")
+	ErrInvalidLink     = errors.New("invalid or expired survey link")
+	ErrInvalidScore    = errors.New("score is outside the survey scale")
+	ErrClosed          = errors.New("survey is already answered or expired")
+)
+
+const maxResponsesLimit = 500
+
+// ConversationService membaca percakapan dan mencatat pesan sistem tanpa membuka percakapan baru
+type ConversationService interface {
+	GetConversation(clientID, id int) (*conversations.Conversation, error)
+	RecordMessage(m conversations.Message) (*conversations.Message, error)
+}
+
+// Notifier mengirim pesan sistem ke pelanggan melalui channel percakapan
+type Notifier interface {
+	Notify(ctx context.Context, conv conversations.Conversation, body string) (*conversations.Message, error)
+}
+
+// JobEnqueuer dipakai untuk menjadwalkan pengiriman survei setelah jeda
+type JobEnqueuer interface {
+	Enqueue(payload jobs.Payload, opts ...jobs.Option) (int, error)
+}
+
+// SendSurvey mengirim survei untuk percakapan yang diselesaikan
+type SendSurvey struct {
+	ClientID       int `json:"client_id"`
+	ConversationID int `json:"conversation_id"`
+}
+
+func (SendSurvey) JobType() string { return "surveys.send" }
+
+// Form adalah data formulir web survei
+type Form struct {
+	Survey   surveys.Survey
+	Question string
+	Min, Max int
+	Answered bool
+}
+
+type SurveyUsecase interface {
+	GetSettings(clientID int) (*surveys.Settings, error)
+	UpdateSettings(s surveys.Settings) error
+	GetResponses(clientID int, f surveys.ResponseFilter) ([]surveys.Response, error)
+	// HandleEvent menjadwalkan survei saat percakapan diselesaikan; didaftarkan sebagai subscriber event bus
+	HandleEvent(ctx context.Context, e events.Event) error
+	SendSurvey(ctx context.Context, job SendSurvey) error
+	HandleReply(ctx context.Context, address string, m conversations.Message) (bool, error)
+	GetForm(id int, expires, signature string) (*Form, error)
+	SubmitForm(id int, expires, signature string, score int, comment string) error
+}
+
+type surveyUsecase struct {
+	repo          repository.SurveyRepository
+	conversations ConversationService
+	senders       map[string]Notifier
+	jobs          JobEnqueuer
+	signingKey    []byte
+	baseURL       string
+}
+
+// NewSurveyUsecase membuat usecase survei. senders dipetakan per channel dan boleh diisi setelah
+// konstruksi; baseURL dipakai untuk link formulir web.
+func NewSurveyUsecase(repo repository.SurveyRepository, convs ConversationService, senders map[string]Notifier, jobs JobEnqueuer, signingKey []byte, baseURL string) SurveyUsecase {
+	return &surveyUsecase{
+		repo:          repo,
+		conversations: convs,
+		senders:       senders,
+		jobs:          jobs,
+		signingKey:    signingKey,
+		baseURL:       strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (uc *surveyUsecase) GetSettings(clientID int) (*surveys.Settings, error) {
+	return uc.repo.GetSettings(clientID)
+}
+
+func (uc *surveyUsecase) UpdateSettings(s surveys.Settings) error {
+	s.Question = strings.TrimSpace(s.Question)
+	s.ThankYou = strings.TrimSpace(s.ThankYou)
+	switch {
+	case !surveys.ValidType(s.Type):
+		return fmt.Errorf("%w: type must be %s or %s", ErrInvalidSettings, surveys.TypeCSAT, surveys.TypeNPS)
+	case s.DelayMinutes < 0 || s.DelayMinutes > surveys.MaxDelayMinutes:
+		return fmt.Errorf("%w: delay_minutes must be between 0 and %d", ErrInvalidSettings, surveys.MaxDelayMinutes)
+	case s.ExpiryHours < 1 || s.ExpiryHours > surveys.MaxExpiryHours:
+		return fmt.Errorf("%w: expiry_hours must be between 1 and %d", ErrInvalidSettings, surveys.MaxExpiryHours)
+	case len(s.Question) > surveys.MaxQuestionLength || len(s.ThankYou) > surveys.MaxQuestionLength:
+		return fmt.Errorf("%w: question and thank_you must be at most %d characters", ErrInvalidSettings, surveys.MaxQuestionLength)
+	}
+	return uc.repo.SaveSettings(s)
+}
+
+func (uc *surveyUsecase) GetResponses(clientID int, f surveys.ResponseFilter) ([]surveys.Response, error) {
+	if f.Limit < 1 || f.Limit > maxResponsesLimit {
+		f.Limit = maxResponsesLimit
+	}
+	list, err := uc.repo.FetchResponses(clientID, f)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []surveys.Response{}
+	}
+	return list, nil
+}
+
+func (uc *surveyUsecase) HandleEvent(ctx context.Context, e events.Event) error {
+	if e.Type != realtime.EventConversationStatusChanged {
+		return nil
+	}
+	var conv conversations.Conversation
+	if err := json.Unmarshal(e.Payload, &conv); err != nil {
+		return err
+	}
+	if conv.Status != conversations.StatusResolved {
+		return nil
+	}
+
+	settings, err := uc.repo.GetSettings(conv.ClientID)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled {
+		return nil
+	}
+	// UniqueKey mencegah survei ganda saat event yang sama dikirim ulang oleh relay
+	_, err = uc.jobs.Enqueue(SendSurvey{ClientID: conv.ClientID, ConversationID: conv.ID},
+		jobs.ForClient(conv.ClientID),
+		jobs.UniqueKey(fmt.Sprintf("surveys.send:%d", conv.ID)),
+		jobs.After(time.Duration(settings.DelayMinutes)*time.Minute))
+	return err
+}
+
+// link membuat URL formulir web bertanda tangan yang berlaku sampai survei kedaluwarsa
+func (uc *surveyUsecase) link(id int, expires time.Time) string {
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	q.Set("signature", surveys.Sign(uc.signingKey, id, expires))
+	return fmt.Sprintf("%s/surveys/%d?%s", uc.baseURL, id, q.Encode())
+}
+
+// SendSurvey mengirim pertanyaan survei melalui channel percakapan. Percakapan yang sudah dibuka
+// lagi, sudah pernah disurvei, atau channel-nya tidak mendukung pengiriman dilewati.
+func (uc *surveyUsecase) SendSurvey(ctx context.Context, job SendSurvey) error {
+	settings, err := uc.repo.GetSettings(job.ClientID)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled {
+		return nil
+	}
+	conv, err := uc.conversations.GetConversation(job.ClientID, job.ConversationID)
+	if err != nil {
+		return errors.Join(jobs.ErrPermanent, err)
+	}
+	sender, ok := uc.senders[conv.Channel]
+	if conv.Status != conversations.StatusResolved || !ok {
+		return nil
+	}
+
+	survey, err := uc.repo.CreateSurvey(surveys.Survey{
+		ClientID:       conv.ClientID,
+		ConversationID: conv.ID,
+		AgentID:        conv.AssigneeID,
+		Channel:        conv.Channel,
+		Address:        conv.ExternalAddress,
+		Type:           settings.Type,
+	})
+	if err != nil {
+		return err
+	}
+	if survey.Status != surveys.StatusPending {
+		return nil
+	}
+
+	expires := time.Now().Add(time.Duration(settings.ExpiryHours) * time.Hour).Truncate(time.Second)
+	body := settings.QuestionText()
+	if settings.IncludeLink && uc.baseURL != "" {
+		body += "\n" + uc.link(survey.ID, expires)
+	}
+	if _, err := sender.Notify(ctx, *conv, body); err != nil {
+		return err
+	}
+	return uc.repo.MarkSent(survey.ID, expires)
+}
+
+// HandleReply mencatat balasan pelanggan sebagai jawaban survei jika alamatnya sedang menunggu
+// jawaban dan isinya berupa skor. Balasan lain diteruskan ke alur percakapan biasa.
+func (uc *surveyUsecase) HandleReply(ctx context.Context, address string, m conversations.Message) (bool, error) {
+	survey, err := uc.repo.FindAwaitingReply(m.ClientID, m.Channel, address)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	score, comment, ok := surveys.ParseReply(m.Body, survey.Type)
+	if !ok {
+		return false, nil
+	}
+
+	saved, err := uc.repo.SaveResponse(surveys.Response{
+		SurveyID:       survey.ID,
+		ClientID:       survey.ClientID,
+		ConversationID: survey.ConversationID,
+		AgentID:        survey.AgentID,
+		Type:           survey.Type,
+		Score:          score,
+		Comment:        comment,
+		Source:         surveys.SourceReply,
+	})
+	if err != nil || !saved {
+		return false, err
+	}
+
+	m.ConversationID = survey.ConversationID
+	if _, err := uc.conversations.RecordMessage(m); err != nil {
+		logger.From(ctx).Error("Failed to record survey reply", "conversation_id", survey.ConversationID, "error", err)
+	}
+	uc.thank(ctx, survey)
+	return true, nil
+}
+
+// thank mengirim ucapan terima kasih bila client mengaturnya; kegagalan hanya dicatat
+func (uc *surveyUsecase) thank(ctx context.Context, survey *surveys.Survey) {
+	settings, err := uc.repo.GetSettings(survey.ClientID)
+	if err != nil || settings.ThankYou == "" {
+		return
+	}
+	sender, ok := uc.senders[survey.Channel]
+	if !ok {
+		return
+	}
+	conv, err := uc.conversations.GetConversation(survey.ClientID, survey.ConversationID)
+	if err == nil {
+		_, err = sender.Notify(ctx, *conv, settings.ThankYou)
+	}
+	if err != nil {
+		logger.From(ctx).Error("Failed to send survey thank-you", "conversation_id", survey.ConversationID, "error", err)
+	}
+}
+
+func (uc *surveyUsecase) openLink(id int, expires, signature string) (*surveys.Survey, error) {
+	if !surveys.Verify(uc.signingKey, id, expires, signature, time.Now()) {
+		return nil, ErrInvalidLink
+	}
+	survey, err := uc.repo.GetSurvey(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidLink
+	}
+	return survey, err
+}
+
+func (uc *surveyUsecase) GetForm(id int, expires, signature string) (*Form, error) {
+	survey, err := uc.openLink(id, expires, signature)
+	if err != nil {
+		return nil, err
+	}
+	min, max := surveys.Scale(survey.Type)
+	return &Form{
+		Survey:   *survey,
+		Question: surveys.WebQuestions[survey.Type],
+		Min:      min,
+		Max:      max,
+		Answered: survey.Status == surveys.StatusAnswered,
+	}, nil
+}
+
+func (uc *surveyUsecase) SubmitForm(id int, expires, signature string, score int, comment string) error {
+	survey, err := uc.openLink(id, expires, signature)
+	if err != nil {
+		return err
+	}
+	if min, max := surveys.Scale(survey.Type); score < min || score > max {
+		return ErrInvalidScore
+	}
+
+	saved, err := uc.repo.SaveResponse(surveys.Response{
+		SurveyID:       survey.ID,
+		ClientID:       survey.ClientID,
+		ConversationID: survey.ConversationID,
+		AgentID:        survey.AgentID,
+		Type:           survey.Type,
+		Score:          score,
+		Comment:        surveys.TrimComment(comment),
+		Source:         surveys.SourceWeb,
+	})
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return ErrClosed
+	}
+	return nil
+}
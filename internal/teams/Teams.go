@@ -0,0 +1,38 @@
+package teams
+
+import "time"
+
+// Peran anggota dalam tim
+const (
+	RoleMember = "member"
+	RoleLead   = "lead"
+)
+
+// Team adalah antrean (queue) percakapan milik client beserta pengaturannya
+type Team struct {
+	ID             int       `json:"id"`
+	ClientID       int       `json:"client_id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Priority       int       `json:"priority"`         // Antrean dengan prioritas lebih tinggi dilayani lebih dulu
+	OverflowTeamID *int      `json:"overflow_team_id"` // Tujuan percakapan yang menunggu melebihi MaxWaitSeconds
+	MaxWaitSeconds int       `json:"max_wait_seconds"` // 0 berarti tanpa batas
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Member adalah agent yang tergabung dalam tim beserta skill-nya
+type Member struct {
+	TeamID   int            `json:"team_id"`
+	UserID   int            `json:"user_id"`
+	Username string         `json:"username"`
+	Role     string         `json:"role"`
+	Skills   map[string]int `json:"skills"`
+}
+
+// QueueChange adalah perpindahan percakapan ke antrean tim, baik manual maupun karena overflow
+type QueueChange struct {
+	ConversationID int `json:"conversation_id"`
+	ClientID       int `json:"client_id"`
+	FromTeamID     int `json:"from_team_id,omitempty"`
+	ToTeamID       int `json:"to_team_id"`
+}
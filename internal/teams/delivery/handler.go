@@ -0,0 +1,218 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/teams"
+	"backend/internal/teams/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeamHandler struct {
+	usecase usecase.TeamUsecase
+}
+
+func NewTeamHandler(uc usecase.TeamUsecase) *TeamHandler {
+	return &TeamHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+	case errors.Is(err, usecase.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, usecase.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+	case errors.Is(err, usecase.ErrInvalidTeam):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team settings"})
+	case errors.Is(err, usecase.ErrNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this team"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *TeamHandler) GetTeams(c *gin.Context) {
+	list, err := h.usecase.GetTeams(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch teams"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GetMyTeams mengembalikan tim tempat user yang login menjadi anggota
+func (h *TeamHandler) GetMyTeams(c *gin.Context) {
+	list, err := h.usecase.GetMyTeams(c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch teams"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *TeamHandler) GetTeam(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	team, err := h.usecase.GetTeam(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch team")
+		return
+	}
+	c.JSON(http.StatusOK, team)
+}
+
+func (h *TeamHandler) CreateTeam(c *gin.Context) {
+	var req teams.Team
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ClientID = c.GetInt("client_id")
+
+	id, err := h.usecase.CreateTeam(req)
+	if err != nil {
+		respondError(c, err, "Failed to create team")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Team created", "id": id})
+}
+
+func (h *TeamHandler) UpdateTeam(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	var req teams.Team
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ID, req.ClientID = id, c.GetInt("client_id")
+
+	if err := h.usecase.UpdateTeam(req); err != nil {
+		respondError(c, err, "Failed to update team")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Team updated", "id": id})
+}
+
+func (h *TeamHandler) DeleteTeam(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	if err := h.usecase.DeleteTeam(c.GetInt("client_id"), id); err != nil {
+		respondError(c, err, "Failed to delete team")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Team deleted", "id": id})
+}
+
+func (h *TeamHandler) GetMembers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	members, err := h.usecase.GetMembers(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch members")
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// AddMember menambahkan atau mengubah peran anggota tim
+func (h *TeamHandler) AddMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	var req teams.Member
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+	req.TeamID = id
+
+	if err := h.usecase.AddMember(c.GetInt("client_id"), req); err != nil {
+		respondError(c, err, "Failed to add member")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member added", "team_id": id, "user_id": req.UserID})
+}
+
+func (h *TeamHandler) RemoveMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.usecase.RemoveMember(c.GetInt("client_id"), id, userID); err != nil {
+		respondError(c, err, "Failed to remove member")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed", "team_id": id, "user_id": userID})
+}
+
+// GetQueue mengembalikan percakapan terbuka di antrean tim
+func (h *TeamHandler) GetQueue(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	queue, err := h.usecase.GetQueue(c.GetInt("client_id"), id, c.GetInt("user_id"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch queue")
+		return
+	}
+	c.JSON(http.StatusOK, queue)
+}
+
+// Enqueue memasukkan percakapan pada URL ke antrean tim
+func (h *TeamHandler) Enqueue(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req struct {
+		TeamID int `json:"team_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.TeamID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "team_id is required"})
+		return
+	}
+
+	if err := h.usecase.Enqueue(c.GetInt("client_id"), id, req.TeamID); err != nil {
+		respondError(c, err, "Failed to enqueue conversation")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation enqueued", "id": id, "team_id": req.TeamID})
+}
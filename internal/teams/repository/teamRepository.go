@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"backend/internal/conversations"
+	"backend/internal/events"
+	outbox "backend/internal/events/repository"
+	"backend/internal/teams"
+	"backend/pkg/txn"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// TeamRepository adalah interface untuk repository Team dan keanggotaannya
+type TeamRepository interface {
+	FetchByClient(clientID int) ([]teams.Team, error)
+	FetchByMember(userID int) ([]teams.Team, error)
+	GetByID(id int) (*teams.Team, error)
+	Create(t teams.Team) (int, error)
+	Update(t teams.Team) error
+	Delete(id int) error
+	FetchMembers(teamID int) ([]teams.Member, error)
+	IsMember(teamID, userID int) (bool, error)
+	AddMember(m teams.Member) (bool, error)
+	RemoveMember(teamID, userID int) error
+	Enqueue(conversationID, teamID int) (bool, error)
+	FetchQueue(teamID int) ([]conversations.Conversation, error)
+	MoveOverflowed() ([]teams.QueueChange, error)
+	// InTx menjalankan fn dalam satu transaksi bersama publisher outbox, sehingga event antrean
+	// hanya tercatat jika perpindahan antrean berhasil di-commit
+	InTx(fn func(repo TeamRepository, publisher events.Publisher) error) error
+}
+
+type teamRepo struct {
+	db  *sql.DB
+	tx  txn.Runner
+	ctx context.Context // Membawa transaksi dari InTx
+}
+
+func NewTeamRepository(db *sql.DB) TeamRepository {
+	return &teamRepo{db: db, tx: txn.NewRunner(db), ctx: context.Background()}
+}
+
+// q mengembalikan transaksi InTx yang sedang berjalan, atau pool koneksi
+func (r *teamRepo) q() txn.Querier {
+	return txn.From(r.ctx, r.db)
+}
+
+func (r *teamRepo) InTx(fn func(repo TeamRepository, publisher events.Publisher) error) error {
+	return r.tx.Do(r.ctx, func(ctx context.Context) error {
+		repo := &teamRepo{db: r.db, tx: r.tx, ctx: ctx}
+		return fn(repo, outbox.NewOutbox(repo.q()))
+	})
+}
+
+const teamColumns = "team_id, client_id, name, description, priority, overflow_team_id, max_wait_seconds, created_at"
+
+func scanTeam(row interface{ Scan(...interface{}) error }) (*teams.Team, error) {
+	var t teams.Team
+	var overflow sql.NullInt64
+	err := row.Scan(&t.ID, &t.ClientID, &t.Name, &t.Description, &t.Priority, &overflow, &t.MaxWaitSeconds, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if overflow.Valid {
+		id := int(overflow.Int64)
+		t.OverflowTeamID = &id
+	}
+	return &t, nil
+}
+
+func scanTeams(rows *sql.Rows) ([]teams.Team, error) {
+	defer rows.Close()
+
+	var list []teams.Team
+	for rows.Next() {
+		t, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *t)
+	}
+	return list, rows.Err()
+}
+
+func (r *teamRepo) FetchByClient(clientID int) ([]teams.Team, error) {
+	rows, err := r.q().Query("SELECT "+teamColumns+" FROM teams WHERE client_id = $1 ORDER BY priority DESC, name", clientID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTeams(rows)
+}
+
+// FetchByMember mengambil tim tempat user menjadi anggota
+func (r *teamRepo) FetchByMember(userID int) ([]teams.Team, error) {
+	rows, err := r.q().Query(
+		"SELECT "+teamColumns+" FROM teams WHERE team_id IN (SELECT team_id FROM team_members WHERE user_id = $1) ORDER BY priority DESC, name",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTeams(rows)
+}
+
+func (r *teamRepo) GetByID(id int) (*teams.Team, error) {
+	return scanTeam(r.q().QueryRow("SELECT "+teamColumns+" FROM teams WHERE team_id = $1", id))
+}
+
+func (r *teamRepo) Create(t teams.Team) (int, error) {
+	var id int
+	err := r.q().QueryRow(
+		`INSERT INTO teams (client_id, name, description, priority, overflow_team_id, max_wait_seconds)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING team_id`,
+		t.ClientID, t.Name, t.Description, t.Priority, t.OverflowTeamID, t.MaxWaitSeconds,
+	).Scan(&id)
+	return id, err
+}
+
+func (r *teamRepo) Update(t teams.Team) error {
+	_, err := r.q().Exec(
+		"UPDATE teams SET name = $1, description = $2, priority = $3, overflow_team_id = $4, max_wait_seconds = $5 WHERE team_id = $6",
+		t.Name, t.Description, t.Priority, t.OverflowTeamID, t.MaxWaitSeconds, t.ID,
+	)
+	return err
+}
+
+func (r *teamRepo) Delete(id int) error {
+	_, err := r.q().Exec("DELETE FROM teams WHERE team_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete team with id %d: %w", id, err)
+	}
+	return nil
+}
+
+// FetchMembers mengambil anggota tim beserta skill agent
+func (r *teamRepo) FetchMembers(teamID int) ([]teams.Member, error) {
+	rows, err := r.q().Query(`SELECT m.team_id, m.user_id, u.username, m.role,
+		COALESCE(array_agg(k.skill) FILTER (WHERE k.skill IS NOT NULL), '{}'),
+		COALESCE(array_agg(k.proficiency) FILTER (WHERE k.skill IS NOT NULL), '{}')
+		FROM team_members m JOIN users u ON u.user_id = m.user_id
+		LEFT JOIN agent_skills k ON k.user_id = m.user_id
+		WHERE m.team_id = $1 GROUP BY m.team_id, m.user_id, u.username, m.role ORDER BY u.username`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []teams.Member
+	for rows.Next() {
+		var m teams.Member
+		var skills []string
+		var levels []int64
+		if err := rows.Scan(&m.TeamID, &m.UserID, &m.Username, &m.Role, pq.Array(&skills), pq.Array(&levels)); err != nil {
+			return nil, err
+		}
+		m.Skills = make(map[string]int, len(skills))
+		for i, skill := range skills {
+			m.Skills[skill] = int(levels[i])
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+func (r *teamRepo) IsMember(teamID, userID int) (bool, error) {
+	var exists bool
+	err := r.q().QueryRow("SELECT EXISTS(SELECT 1 FROM team_members WHERE team_id = $1 AND user_id = $2)", teamID, userID).Scan(&exists)
+	return exists, err
+}
+
+// AddMember menambahkan user ke tim. Nilai false berarti user tidak ditemukan pada client pemilik tim.
+func (r *teamRepo) AddMember(m teams.Member) (bool, error) {
+	res, err := r.q().Exec(
+		`INSERT INTO team_members (team_id, user_id, role)
+		 SELECT t.team_id, u.user_id, $3 FROM teams t JOIN users u ON u.client_id = t.client_id
+		 WHERE t.team_id = $1 AND u.user_id = $2
+		 ON CONFLICT (team_id, user_id) DO UPDATE SET role = EXCLUDED.role`,
+		m.TeamID, m.UserID, m.Role,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *teamRepo) RemoveMember(teamID, userID int) error {
+	_, err := r.q().Exec("DELETE FROM team_members WHERE team_id = $1 AND user_id = $2", teamID, userID)
+	return err
+}
+
+// Enqueue memasukkan percakapan ke antrean tim dan memulai ulang waktu tunggunya.
+// Nilai false berarti percakapan tidak ditemukan pada client pemilik tim.
+func (r *teamRepo) Enqueue(conversationID, teamID int) (bool, error) {
+	res, err := r.q().Exec(
+		`UPDATE conversations c SET team_id = t.team_id, enqueued_at = NOW(), updated_at = NOW()
+		 FROM teams t WHERE t.team_id = $1 AND c.conversation_id = $2 AND c.client_id = t.client_id`,
+		teamID, conversationID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// FetchQueue mengambil percakapan terbuka di antrean tim, dari prioritas tertinggi lalu yang paling lama menunggu
+func (r *teamRepo) FetchQueue(teamID int) ([]conversations.Conversation, error) {
+	rows, err := r.q().Query(`SELECT conversation_id, client_id, channel, external_address, status, assignee_id, created_at, updated_at
+		FROM conversations WHERE team_id = $1 AND status = 'open' ORDER BY priority DESC, enqueued_at`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []conversations.Conversation
+	for rows.Next() {
+		var c conversations.Conversation
+		var assignee sql.NullInt64
+		err := rows.Scan(&c.ID, &c.ClientID, &c.Channel, &c.ExternalAddress, &c.Status, &assignee, &c.CreatedAt, &c.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if assignee.Valid {
+			id := int(assignee.Int64)
+			c.AssigneeID = &id
+		}
+		c.TeamID = &teamID
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// MoveOverflowed memindahkan percakapan yang belum diassign melebihi batas tunggu tim
+// ke antrean overflow-nya
+func (r *teamRepo) MoveOverflowed() ([]teams.QueueChange, error) {
+	rows, err := r.q().Query(`UPDATE conversations c SET team_id = t.overflow_team_id, enqueued_at = NOW(), updated_at = NOW()
+		FROM teams t
+		WHERE c.team_id = t.team_id AND t.overflow_team_id IS NOT NULL AND t.max_wait_seconds > 0
+		AND c.status = 'open' AND c.assignee_id IS NULL
+		AND c.enqueued_at < NOW() - make_interval(secs => t.max_wait_seconds)
+		RETURNING c.conversation_id, c.client_id, t.team_id, t.overflow_team_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []teams.QueueChange
+	for rows.Next() {
+		var o teams.QueueChange
+		if err := rows.Scan(&o.ConversationID, &o.ClientID, &o.FromTeamID, &o.ToTeamID); err != nil {
+			return nil, err
+		}
+		list = append(list, o)
+	}
+	return list, rows.Err()
+}
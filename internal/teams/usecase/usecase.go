@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"backend/internal/conversations"
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/teams"
+	"backend/internal/teams/repository"
+	"backend/pkg/logger"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound             = errors.New("team not found")
+	ErrInvalidTeam          = errors.New("invalid team")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrNotMember            = errors.New("user is not a member of the team")
+)
+
+// QueueRouter mengassign antrean percakapan client ke agent yang tersedia
+type QueueRouter interface {
+	AssignPending(clientID int) (int, error)
+}
+
+type TeamUsecase interface {
+	GetTeams(clientID int) ([]teams.Team, error)
+	GetMyTeams(userID int) ([]teams.Team, error)
+	GetTeam(clientID, id int) (*teams.Team, error)
+	CreateTeam(t teams.Team) (int, error)
+	UpdateTeam(t teams.Team) error
+	DeleteTeam(clientID, id int) error
+	GetMembers(clientID, teamID int) ([]teams.Member, error)
+	AddMember(clientID int, m teams.Member) error
+	RemoveMember(clientID, teamID, userID int) error
+	Enqueue(clientID, conversationID, teamID int) error
+	GetQueue(clientID, teamID, userID int) ([]conversations.Conversation, error)
+	ProcessOverflow() (int, error)
+	RunOverflowChecker(ctx context.Context, interval time.Duration)
+}
+
+type teamUsecase struct {
+	repo      repository.TeamRepository
+	router    QueueRouter
+	publisher realtime.Publisher
+}
+
+func NewTeamUsecase(repo repository.TeamRepository, router QueueRouter, publisher realtime.Publisher) TeamUsecase {
+	return &teamUsecase{repo: repo, router: router, publisher: publisher}
+}
+
+func (u *teamUsecase) GetTeams(clientID int) ([]teams.Team, error) {
+	return u.repo.FetchByClient(clientID)
+}
+
+func (u *teamUsecase) GetMyTeams(userID int) ([]teams.Team, error) {
+	return u.repo.FetchByMember(userID)
+}
+
+// GetTeam mengambil tim dan memastikan tim milik client yang sama
+func (u *teamUsecase) GetTeam(clientID, id int) (*teams.Team, error) {
+	t, err := u.repo.GetByID(id)
+	if err != nil || t.ClientID != clientID {
+		return nil, ErrNotFound
+	}
+	return t, nil
+}
+
+// validate memeriksa nama dan antrean overflow (harus milik client yang sama dan bukan tim itu sendiri)
+func (u *teamUsecase) validate(t teams.Team) error {
+	if strings.TrimSpace(t.Name) == "" || t.MaxWaitSeconds < 0 {
+		return ErrInvalidTeam
+	}
+	if t.OverflowTeamID != nil {
+		if *t.OverflowTeamID == t.ID {
+			return ErrInvalidTeam
+		}
+		if _, err := u.GetTeam(t.ClientID, *t.OverflowTeamID); err != nil {
+			return ErrInvalidTeam
+		}
+	}
+	return nil
+}
+
+func (u *teamUsecase) CreateTeam(t teams.Team) (int, error) {
+	if err := u.validate(t); err != nil {
+		return 0, err
+	}
+	return u.repo.Create(t)
+}
+
+func (u *teamUsecase) UpdateTeam(t teams.Team) error {
+	if _, err := u.GetTeam(t.ClientID, t.ID); err != nil {
+		return err
+	}
+	if err := u.validate(t); err != nil {
+		return err
+	}
+	return u.repo.Update(t)
+}
+
+func (u *teamUsecase) DeleteTeam(clientID, id int) error {
+	if _, err := u.GetTeam(clientID, id); err != nil {
+		return err
+	}
+	return u.repo.Delete(id)
+}
+
+func (u *teamUsecase) GetMembers(clientID, teamID int) ([]teams.Member, error) {
+	if _, err := u.GetTeam(clientID, teamID); err != nil {
+		return nil, err
+	}
+	return u.repo.FetchMembers(teamID)
+}
+
+// AddMember menambahkan agent ke tim; anggota baru bisa langsung menerima antrean tim
+func (u *teamUsecase) AddMember(clientID int, m teams.Member) error {
+	if _, err := u.GetTeam(clientID, m.TeamID); err != nil {
+		return err
+	}
+	if m.Role == "" {
+		m.Role = teams.RoleMember
+	}
+	if m.Role != teams.RoleMember && m.Role != teams.RoleLead {
+		return ErrInvalidTeam
+	}
+
+	added, err := u.repo.AddMember(m)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return ErrUserNotFound
+	}
+	u.assignPending(clientID)
+	return nil
+}
+
+func (u *teamUsecase) RemoveMember(clientID, teamID, userID int) error {
+	if _, err := u.GetTeam(clientID, teamID); err != nil {
+		return err
+	}
+	return u.repo.RemoveMember(teamID, userID)
+}
+
+// Enqueue memasukkan percakapan ke antrean tim lalu mencoba mengassign ke anggota tim
+func (u *teamUsecase) Enqueue(clientID, conversationID, teamID int) error {
+	if _, err := u.GetTeam(clientID, teamID); err != nil {
+		return err
+	}
+	change := teams.QueueChange{ConversationID: conversationID, ClientID: clientID, ToTeamID: teamID}
+	err := u.repo.InTx(func(repo repository.TeamRepository, publisher events.Publisher) error {
+		ok, err := repo.Enqueue(conversationID, teamID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrConversationNotFound
+		}
+		return record(publisher, change)
+	})
+	if err != nil {
+		return err
+	}
+
+	u.publish(change)
+	u.assignPending(clientID)
+	return nil
+}
+
+// GetQueue mengambil antrean tim; hanya anggota tim yang boleh melihatnya
+func (u *teamUsecase) GetQueue(clientID, teamID, userID int) ([]conversations.Conversation, error) {
+	if _, err := u.GetTeam(clientID, teamID); err != nil {
+		return nil, err
+	}
+	member, err := u.repo.IsMember(teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		return nil, ErrNotMember
+	}
+	return u.repo.FetchQueue(teamID)
+}
+
+// ProcessOverflow memindahkan percakapan yang menunggu terlalu lama ke antrean overflow
+func (u *teamUsecase) ProcessOverflow() (int, error) {
+	var moved []teams.QueueChange
+	err := u.repo.InTx(func(repo repository.TeamRepository, publisher events.Publisher) error {
+		var err error
+		if moved, err = repo.MoveOverflowed(); err != nil {
+			return err
+		}
+		for _, o := range moved {
+			if err := record(publisher, o); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	clients := make(map[int]bool)
+	for _, o := range moved {
+		u.publish(o)
+		clients[o.ClientID] = true
+	}
+	for clientID := range clients {
+		u.assignPending(clientID)
+	}
+	return len(moved), nil
+}
+
+// RunOverflowChecker menjalankan ProcessOverflow secara berkala sampai ctx dibatalkan
+func (u *teamUsecase) RunOverflowChecker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.ProcessOverflow(); err != nil {
+				logger.From(ctx).Error("Failed to process queue overflow", "error", err)
+			}
+		}
+	}
+}
+
+func (u *teamUsecase) assignPending(clientID int) {
+	if _, err := u.router.AssignPending(clientID); err != nil {
+		slog.Error("Failed to assign pending conversations", "client_id", clientID, "error", err)
+	}
+}
+
+// record mencatat perpindahan antrean ke outbox di dalam transaksi perpindahannya
+func record(publisher events.Publisher, o teams.QueueChange) error {
+	return publisher.Publish(o.ClientID, realtime.Payload{Type: realtime.EventConversationEnqueued, Data: o})
+}
+
+func (u *teamUsecase) publish(o teams.QueueChange) {
+	err := u.publisher.Publish(realtime.Event{Type: realtime.EventConversationEnqueued, ClientID: o.ClientID, Data: o})
+	if err != nil {
+		slog.Error("Failed to publish queue change", "conversation_id", o.ConversationID, "error", err)
+	}
+}
@@ -9,3 +9,33 @@ type Pengguna struct {
 	ClientID  int    `json:"client_id"`
 	CreatedAt string `json:"created_at"`
 }
+
+// Tipe event domain pengguna
+const (
+	EventCreated = "user.created"
+	EventDeleted = "user.deleted"
+)
+
+// Created dipublikasikan setelah pengguna baru dibuat
+type Created struct {
+	UserID   int    `json:"user_id"`
+	ClientID int    `json:"client_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	RoleID   int    `json:"role_id"`
+	// CreatedBy adalah pengguna yang membuat akun ini; kosong jika tidak dibuat lewat request terautentikasi
+	CreatedBy int `json:"created_by,omitempty"`
+}
+
+func (Created) EventType() string { return EventCreated }
+
+// Deleted dipublikasikan setelah pengguna dihapus
+type Deleted struct {
+	UserID   int    `json:"user_id"`
+	ClientID int    `json:"client_id"`
+	Username string `json:"username"`
+	// DeletedBy adalah pengguna yang menghapus akun ini
+	DeletedBy int `json:"deleted_by,omitempty"`
+}
+
+func (Deleted) EventType() string { return EventDeleted }
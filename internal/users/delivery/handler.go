@@ -1,17 +1,33 @@
 package delivery
 
 import (
-	"log"
+	"errors"
 	"net/http"
+	"strings"
 
 	"backend/internal/users"
 	"backend/internal/users/usecase"
+	"backend/pkg/logger"
 	"backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Hasil login: success, failure (email atau password salah) atau error (kesalahan server)
+const (
+	loginSuccess = "success"
+	loginFailure = "failure"
+	loginError   = "error"
+)
+
+var loginAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "auth_login_attempts_total",
+	Help: "Login attempts by result (success, failure or error).",
+}, []string{"result"})
+
 type UserHandler struct {
 	usecase usecase.UserUsecase
 }
@@ -21,7 +37,7 @@ func NewUserHandler(uc usecase.UserUsecase) *UserHandler {
 }
 
 func (h *UserHandler) GetAllUsers(c *gin.Context) {
-	users, err := h.usecase.GetAllUsers()
+	users, err := h.usecase.GetAllUsers(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
@@ -35,7 +51,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 	// Binding JSON request ke struct Pengguna
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("Error binding JSON:", err) // Log error saat binding JSON
+		logger.From(c.Request.Context()).Warn("Invalid create user payload", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
@@ -43,7 +59,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	// Hash password sebelum menyimpannya
 	hashedPassword, err := HashPassword(req.Password)
 	if err != nil {
-		log.Println("Error hashing password:", err) // Log error saat hashing password
+		logger.From(c.Request.Context()).Error("Failed to hash password", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
 	}
@@ -52,9 +68,9 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	req.Password = hashedPassword
 
 	// Menyimpan user menggunakan usecase
-	id, err := h.usecase.CreateUser(req)
+	id, err := h.usecase.CreateUser(c.Request.Context(), req)
 	if err != nil {
-		log.Println("Error creating user:", err) // Log error saat membuat user
+		logger.From(c.Request.Context()).Error("Failed to create user", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
@@ -79,28 +95,20 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	// Cek apakah pengguna ada
-	user, err := h.usecase.GetUserByID(req.ID)
-	if err != nil {
+	// Hapus pengguna dan masukkan token ke dalam blacklist dalam satu transaksi. Middleware
+	// memeriksa blacklist tanpa prefix "Bearer ", jadi token disimpan dalam bentuk yang sama.
+	tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	user, err := h.usecase.DeleteUser(c.Request.Context(), req.ID, tokenString)
+	if errors.Is(err, usecase.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
-
-	// Panggil usecase untuk menghapus pengguna berdasarkan ID
-	err = h.usecase.DeleteUser(req.ID)
 	if err != nil {
+		logger.From(c.Request.Context()).Error("Failed to delete user", "target_user_id", req.ID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
 
-	// Masukkan token ke dalam blacklist setelah penghapusan pengguna
-	tokenString := c.GetHeader("Authorization")
-	// Menambahkan token ke dalam blacklist di database (atau memcached)
-	err = h.usecase.BlacklistToken(tokenString)
-	if err != nil {
-		log.Printf("Failed to blacklist token: %v", err)
-	}
-
 	// Jika berhasil
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User successfully deleted",
@@ -119,8 +127,9 @@ func (h *UserHandler) Login(c *gin.Context) {
 	}
 
 	// Mencari pengguna berdasarkan email
-	user, err := h.usecase.GetUserByEmail(req.Email)
+	user, err := h.usecase.GetUserByEmail(c.Request.Context(), req.Email)
 	if err != nil {
+		loginAttempts.WithLabelValues(loginFailure).Inc()
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Email not foun"})
 		return
 	}
@@ -128,18 +137,22 @@ func (h *UserHandler) Login(c *gin.Context) {
 	// Verifikasi password (bandingkan dengan password yang di-hash)
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
+		loginAttempts.WithLabelValues(loginFailure).Inc()
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
 	// Membuat token JWT setelah verifikasi berhasil
-	token, err := utils.CreateToken(user.ID, user.Username)
+	token, err := utils.CreateToken(user.ID, user.Username, user.ClientID)
 	if err != nil {
+		loginAttempts.WithLabelValues(loginError).Inc()
+		logger.From(c.Request.Context()).Error("Failed to generate token", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
 	// Kembalikan token ke pengguna
+	loginAttempts.WithLabelValues(loginSuccess).Inc()
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Login successful",
 		"token":   token,
@@ -147,13 +160,9 @@ func (h *UserHandler) Login(c *gin.Context) {
 }
 
 func HashPassword(password string) (string, error) {
-	// Debugging: Pastikan password yang diterima benar
-	log.Println("Hashing password:", password)
-
 	// Menggunakan bcrypt untuk hashing password
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Println("Error generating hash:", err) // Log error
 		return "", err
 	}
 	return string(bytes), nil
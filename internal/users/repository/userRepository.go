@@ -1,33 +1,56 @@
 package repository
 
 import (
+	"backend/internal/events"
+	outbox "backend/internal/events/repository"
 	"backend/internal/users"
+	"backend/pkg/txn"
+	"context"
 	"database/sql"
 	"fmt"
 )
 
 // UserRepository adalah interface untuk repository User
+// Semua method menerima ctx dari request; query dibatalkan saat client memutus koneksi
+// atau deadline request habis.
 type UserRepository interface {
-	FetchAll() ([]users.Pengguna, error) // Menggunakan slice
-	GetByID(id int) (*users.Pengguna, error)
-	GetByEmail(email string) (*users.Pengguna, error)
-	Create(u users.Pengguna) (int, error)
-	Update(u users.Pengguna) error
-	Delete(id int) error
-	SaveBlacklistedToken(token string) error
+	FetchAll(ctx context.Context) ([]users.Pengguna, error) // Menggunakan slice
+	GetByID(ctx context.Context, id int) (*users.Pengguna, error)
+	GetByEmail(ctx context.Context, email string) (*users.Pengguna, error)
+	Create(ctx context.Context, u users.Pengguna) (int, error)
+	Update(ctx context.Context, u users.Pengguna) error
+	Delete(ctx context.Context, id int) error
+	SaveBlacklistedToken(ctx context.Context, token string) error
+	// InTx menjalankan fn sebagai satu unit of work. Semua panggilan repository dengan ctx yang
+	// diberikan ke fn, termasuk repository lain yang memakai txn.From, ikut transaksi tersebut
+	// bersama publisher, sehingga event domain hanya tersimpan jika perubahan data berhasil.
+	// InTx di dalam InTx lain memakai savepoint; lihat txn.Runner untuk rollback dan retry.
+	InTx(ctx context.Context, fn func(ctx context.Context, repo UserRepository, publisher events.Publisher) error) error
 }
 
 type userRepo struct {
 	db *sql.DB
+	tx txn.Runner
 }
 
 func NewUserRepository(db *sql.DB) UserRepository {
-	return &userRepo{db: db}
+	return &userRepo{db: db, tx: txn.NewRunner(db)}
+}
+
+// q mengembalikan transaksi yang sedang berjalan di ctx, atau pool koneksi
+func (r *userRepo) q(ctx context.Context) txn.Querier {
+	return txn.From(ctx, r.db)
+}
+
+func (r *userRepo) InTx(ctx context.Context, fn func(ctx context.Context, repo UserRepository, publisher events.Publisher) error) error {
+	return r.tx.Do(ctx, func(ctx context.Context) error {
+		return fn(ctx, r, outbox.NewOutbox(r.q(ctx)))
+	})
 }
 
 // SaveBlacklistedToken menyimpan token yang diblacklist
-func (r *userRepo) SaveBlacklistedToken(token string) error {
-	_, err := r.db.Exec("INSERT INTO blacklisted_tokens (token) VALUES ($1)", token)
+func (r *userRepo) SaveBlacklistedToken(ctx context.Context, token string) error {
+	_, err := r.q(ctx).ExecContext(ctx, "INSERT INTO blacklisted_tokens (token) VALUES ($1)", token)
 	if err != nil {
 		return fmt.Errorf("failed to blacklist token: %w", err)
 	}
@@ -35,9 +58,9 @@ func (r *userRepo) SaveBlacklistedToken(token string) error {
 }
 
 // FetchAll mengambil semua pengguna dari database
-func (r *userRepo) FetchAll() ([]users.Pengguna, error) {
+func (r *userRepo) FetchAll(ctx context.Context) ([]users.Pengguna, error) {
 	// Menjalankan query untuk mengambil data pengguna
-	rows, err := r.db.Query("SELECT user_id, username, email, role_id, client_id, created_at FROM users")
+	rows, err := r.q(ctx).QueryContext(ctx, "SELECT user_id, username, email, role_id, client_id, created_at FROM users")
 	if err != nil {
 		return nil, err
 	}
@@ -52,13 +75,14 @@ func (r *userRepo) FetchAll() ([]users.Pengguna, error) {
 		}
 		userList = append(userList, u) // Menambahkan pengguna ke slice
 	}
-	return userList, nil
+	// rows.Err berisi error ctx jika request dibatalkan di tengah iterasi
+	return userList, rows.Err()
 }
 
 // Method lainnya tetap sama
-func (r *userRepo) GetByID(id int) (*users.Pengguna, error) {
+func (r *userRepo) GetByID(ctx context.Context, id int) (*users.Pengguna, error) {
 	var u users.Pengguna
-	err := r.db.QueryRow("SELECT user_id, username, email, role_id, client_id, created_at FROM users WHERE user_id = $1", id).
+	err := r.q(ctx).QueryRowContext(ctx, "SELECT user_id, username, email, role_id, client_id, created_at FROM users WHERE user_id = $1", id).
 		Scan(&u.ID, &u.Username, &u.Email, &u.RoleID, &u.ClientID, &u.CreatedAt)
 	if err != nil {
 		return nil, err
@@ -66,26 +90,26 @@ func (r *userRepo) GetByID(id int) (*users.Pengguna, error) {
 	return &u, nil
 }
 
-func (r *userRepo) Create(u users.Pengguna) (int, error) {
+func (r *userRepo) Create(ctx context.Context, u users.Pengguna) (int, error) {
 	var id int
-	err := r.db.QueryRow(
+	err := r.q(ctx).QueryRowContext(ctx,
 		"INSERT INTO users (username, email, password_hash, role_id, client_id) VALUES ($1, $2, $3, $4, $5) RETURNING user_id",
 		u.Username, u.Email, u.Password, u.RoleID, u.ClientID,
 	).Scan(&id)
 	return id, err
 }
 
-func (r *userRepo) Update(u users.Pengguna) error {
-	_, err := r.db.Exec(
+func (r *userRepo) Update(ctx context.Context, u users.Pengguna) error {
+	_, err := r.q(ctx).ExecContext(ctx,
 		"UPDATE users SET username = $1, email = $2, role_id = $3, client_id = $4 WHERE user_id = $5",
 		u.Username, u.Email, u.RoleID, u.ClientID, u.ID,
 	)
 	return err
 }
 
-func (r *userRepo) Delete(id int) error {
+func (r *userRepo) Delete(ctx context.Context, id int) error {
 	// Query untuk menghapus pengguna berdasarkan ID
-	_, err := r.db.Exec("DELETE FROM users WHERE user_id = $1", id)
+	_, err := r.q(ctx).ExecContext(ctx, "DELETE FROM users WHERE user_id = $1", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete user with id %d: %w", id, err)
 	}
@@ -93,9 +117,9 @@ func (r *userRepo) Delete(id int) error {
 }
 
 // GetByEmail mencari pengguna berdasarkan email
-func (r *userRepo) GetByEmail(email string) (*users.Pengguna, error) {
+func (r *userRepo) GetByEmail(ctx context.Context, email string) (*users.Pengguna, error) {
 	var u users.Pengguna
-	err := r.db.QueryRow("SELECT user_id, username, email, password_hash, role_id, client_id, created_at FROM users WHERE email = $1", email).
+	err := r.q(ctx).QueryRowContext(ctx, "SELECT user_id, username, email, password_hash, role_id, client_id, created_at FROM users WHERE email = $1", email).
 		Scan(&u.ID, &u.Username, &u.Email, &u.Password, &u.RoleID, &u.ClientID, &u.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
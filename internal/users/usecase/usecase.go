@@ -1,19 +1,29 @@
 package usecase
 
 import (
+	"backend/internal/events"
 	"backend/internal/users"
 	"backend/internal/users/repository"
+	"backend/pkg/requestctx"
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 )
 
+var ErrNotFound = errors.New("user not found")
+
+// UserUsecase menerima ctx request; actor pada ctx (requestctx) dicatat di event domain
 type UserUsecase interface {
-	GetAllUsers() ([]users.Pengguna, error) // Perhatikan penggunaan singular "User"
-	GetUserByID(id int) (*users.Pengguna, error)
-	CreateUser(u users.Pengguna) (int, error)
-	GetUserByEmail(email string) (*users.Pengguna, error)
-	UpdateUser(u users.Pengguna) error
-	DeleteUser(id int) error
-	BlacklistToken(token string) error
+	GetAllUsers(ctx context.Context) ([]users.Pengguna, error) // Perhatikan penggunaan singular "User"
+	GetUserByID(ctx context.Context, id int) (*users.Pengguna, error)
+	CreateUser(ctx context.Context, u users.Pengguna) (int, error)
+	GetUserByEmail(ctx context.Context, email string) (*users.Pengguna, error)
+	UpdateUser(ctx context.Context, u users.Pengguna) error
+	// DeleteUser menghapus pengguna, mencatat event dan mem-blacklist token (jika diisi) secara
+	// atomik, lalu mengembalikan data pengguna yang dihapus
+	DeleteUser(ctx context.Context, id int, token string) (*users.Pengguna, error)
+	BlacklistToken(ctx context.Context, token string) error
 }
 
 type userUsecase struct {
@@ -24,56 +34,109 @@ func NewUserUsecase(repo repository.UserRepository) UserUsecase {
 	return &userUsecase{repo: repo}
 }
 
-func (u *userUsecase) GetAllUsers() ([]users.Pengguna, error) {
-	return u.repo.FetchAll()
+func (u *userUsecase) GetAllUsers(ctx context.Context) ([]users.Pengguna, error) {
+	return u.repo.FetchAll(ctx)
 }
 
 // func (u *userUsecase) GetUserByID(id int) (*users.Pengguna, error) {
 // 	return u.repo.GetByID(id)
 // }
 
-func (u *userUsecase) CreateUser(uData users.Pengguna) (int, error) {
+func (u *userUsecase) CreateUser(ctx context.Context, uData users.Pengguna) (int, error) {
 	if uData.Username == "" || uData.Email == "" {
 		return 0, nil
 	}
-	return u.repo.Create(uData)
+
+	var id int
+	err := u.repo.InTx(ctx, func(ctx context.Context, repo repository.UserRepository, publisher events.Publisher) error {
+		var err error
+		if id, err = repo.Create(ctx, uData); err != nil {
+			return err
+		}
+		return publisher.Publish(uData.ClientID, users.Created{
+			UserID:    id,
+			ClientID:  uData.ClientID,
+			Username:  uData.Username,
+			Email:     uData.Email,
+			RoleID:    uData.RoleID,
+			CreatedBy: actorID(ctx),
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
 }
 
-func (u *userUsecase) UpdateUser(uData users.Pengguna) error {
-	return u.repo.Update(uData)
+func (u *userUsecase) UpdateUser(ctx context.Context, uData users.Pengguna) error {
+	return u.repo.Update(ctx, uData)
 }
 
 // GetUserByID mencari pengguna berdasarkan ID
-func (u *userUsecase) GetUserByID(id int) (*users.Pengguna, error) {
-	user, err := u.repo.GetByID(id)
+func (u *userUsecase) GetUserByID(ctx context.Context, id int) (*users.Pengguna, error) {
+	user, err := u.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
 	return user, nil
 }
 
-func (u *userUsecase) BlacklistToken(token string) error {
-	err := u.repo.SaveBlacklistedToken(token)
+func (u *userUsecase) BlacklistToken(ctx context.Context, token string) error {
+	err := u.repo.SaveBlacklistedToken(ctx, token)
 	if err != nil {
 		return errors.New("failed to blacklist token")
 	}
 	return nil
 }
 
-func (u *userUsecase) DeleteUser(id int) error {
-	// Memanggil repository untuk menghapus pengguna berdasarkan ID
-	err := u.repo.Delete(id)
+func (u *userUsecase) DeleteUser(ctx context.Context, id int, token string) (*users.Pengguna, error) {
+	// Pencarian, penghapusan, event dan blacklist token dijalankan dalam satu transaksi sehingga
+	// kegagalan di tengah tidak meninggalkan data setengah jadi
+	var deleted *users.Pengguna
+	err := u.repo.InTx(ctx, func(ctx context.Context, repo repository.UserRepository, publisher events.Publisher) error {
+		user, err := repo.GetByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if token != "" {
+			if err := repo.SaveBlacklistedToken(ctx, token); err != nil {
+				return err
+			}
+		}
+		deleted = user
+		return publisher.Publish(user.ClientID, users.Deleted{
+			UserID:    user.ID,
+			ClientID:  user.ClientID,
+			Username:  user.Username,
+			DeletedBy: actorID(ctx),
+		})
+	})
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
-		return errors.New("failed to delete user")
+		return nil, fmt.Errorf("failed to delete user: %w", err)
 	}
-	return nil
+	return deleted, nil
 }
 
 // GetUserByEmail mencari pengguna berdasarkan email
-func (u *userUsecase) GetUserByEmail(email string) (*users.Pengguna, error) {
-	user, err := u.repo.GetByEmail(email)
+func (u *userUsecase) GetUserByEmail(ctx context.Context, email string) (*users.Pengguna, error) {
+	user, err := u.repo.GetByEmail(ctx, email)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
 	return user, nil
 }
+
+// actorID mengembalikan ID pengguna yang melakukan request, atau 0 di luar request terautentikasi
+func actorID(ctx context.Context) int {
+	actor, _ := requestctx.ActorFrom(ctx)
+	return actor.UserID
+}
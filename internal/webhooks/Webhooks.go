@@ -0,0 +1,129 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Header yang dikirim pada setiap delivery webhook
+const (
+	HeaderSignature = "X-Webhook-Signature" // t=<unix>,v1=<hex hmac-sha256>
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+// ErrLeaseLost berarti lease delivery sudah habis atau diambil dispatcher lain sebelum hasil
+// percobaan dicatat, sehingga hasil tersebut dibuang
+var ErrLeaseLost = errors.New("webhook delivery is no longer claimed by this dispatcher")
+
+// Status delivery
+const (
+	StatusPending   = "pending"
+	StatusRetrying  = "retrying"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead" // Gagal setelah MaxAttempts percobaan
+)
+
+// Batas percobaan dan backoff delivery
+const (
+	MaxAttempts = 8
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+// Subscription adalah endpoint milik client yang menerima event terpilih
+type Subscription struct {
+	ID          int       `json:"id"`
+	ClientID    int       `json:"client_id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Events      []string  `json:"events"`
+	Secret      string    `json:"secret,omitempty"` // Hanya dikembalikan saat subscription dibuat
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Subscribes memeriksa apakah subscription menerima tipe event tertentu
+func (s Subscription) Subscribes(eventType string) bool {
+	for _, e := range s.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery adalah satu event yang harus dikirim ke satu subscription
+type Delivery struct {
+	ID             int             `json:"id"`
+	SubscriptionID int             `json:"subscription_id"`
+	ClientID       int             `json:"client_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	AttemptLog     []Attempt       `json:"attempt_log,omitempty"`
+
+	// Diisi saat delivery diambil untuk dikirim
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// Attempt adalah catatan satu percobaan pengiriman
+type Attempt struct {
+	ID           int       `json:"id"`
+	DeliveryID   int       `json:"delivery_id"`
+	Attempt      int       `json:"attempt"`
+	StatusCode   *int      `json:"status_code"`
+	Error        string    `json:"error,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Succeeded memeriksa apakah percobaan mendapat respons 2xx
+func (a Attempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode != nil && *a.StatusCode >= 200 && *a.StatusCode < 300
+}
+
+// Sign menghitung HMAC-SHA256 dari "<timestamp>.<body>" dengan secret subscription.
+// Timestamp ikut ditandatangani agar penerima bisa menolak replay.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureHeader menyusun nilai header HeaderSignature
+func SignatureHeader(secret string, timestamp time.Time, body []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp.Unix(), Sign(secret, timestamp, body))
+}
+
+// Backoff mengembalikan jeda sebelum percobaan berikutnya setelah attempts kali gagal
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	d := baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,175 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/webhooks"
+	"backend/internal/webhooks/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	usecase usecase.WebhookUsecase
+}
+
+func NewWebhookHandler(uc usecase.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{usecase: uc}
+}
+
+// respondError memetakan error usecase ke status HTTP
+func respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook subscription not found"})
+	case errors.Is(err, usecase.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+	case errors.Is(err, usecase.ErrInvalidSubscription):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook subscription", "events": usecase.SubscribableEvents})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *WebhookHandler) GetSubscriptions(c *gin.Context) {
+	list, err := h.usecase.GetSubscriptions(c.GetInt("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook subscriptions"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *WebhookHandler) GetSubscription(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	s, err := h.usecase.GetSubscription(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch webhook subscription")
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// CreateSubscription membuat subscription; secret penandatanganan hanya ditampilkan sekali di respons ini
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req webhooks.Subscription
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ClientID = c.GetInt("client_id")
+
+	s, err := h.usecase.CreateSubscription(req)
+	if err != nil {
+		respondError(c, err, "Failed to create webhook subscription")
+		return
+	}
+	c.JSON(http.StatusCreated, s)
+}
+
+func (h *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	var req webhooks.Subscription
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.ID, req.ClientID = id, c.GetInt("client_id")
+
+	if err := h.usecase.UpdateSubscription(req); err != nil {
+		respondError(c, err, "Failed to update webhook subscription")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook subscription updated", "id": id})
+}
+
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	secret, err := h.usecase.RotateSecret(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to rotate webhook secret")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook secret rotated", "id": id, "secret": secret})
+}
+
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	if err := h.usecase.DeleteSubscription(c.GetInt("client_id"), id); err != nil {
+		respondError(c, err, "Failed to delete webhook subscription")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook subscription deleted", "id": id})
+}
+
+// GetDeliveries mengembalikan log delivery, bisa difilter dengan subscription_id dan status
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	var subscriptionID *int
+	if raw := c.Query("subscription_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription_id"})
+			return
+		}
+		subscriptionID = &id
+	}
+
+	list, err := h.usecase.GetDeliveries(c.GetInt("client_id"), subscriptionID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook deliveries"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	d, err := h.usecase.GetDelivery(c.GetInt("client_id"), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch webhook delivery")
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// Redeliver menjadwalkan ulang delivery untuk segera dikirim
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	if err := h.usecase.Redeliver(c.GetInt("client_id"), id); err != nil {
+		respondError(c, err, "Failed to redeliver webhook")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Webhook delivery scheduled", "id": id})
+}
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"backend/internal/webhooks"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// WebhookRepository adalah interface untuk subscription webhook dan antrean delivery-nya
+type WebhookRepository interface {
+	FetchSubscriptions(clientID int) ([]webhooks.Subscription, error)
+	GetSubscription(id int) (*webhooks.Subscription, error)
+	CreateSubscription(s webhooks.Subscription) (int, error)
+	UpdateSubscription(s webhooks.Subscription) error
+	DeleteSubscription(id int) error
+	EnqueueDeliveries(clientID int, eventType, eventKey string, payload []byte) (int, error)
+	ClaimDue(limit int, lease time.Duration, owner string) ([]webhooks.Delivery, error)
+	RecordAttempt(d webhooks.Delivery, a webhooks.Attempt, owner string) error
+	FetchDeliveries(clientID int, subscriptionID *int, status string, limit int) ([]webhooks.Delivery, error)
+	GetDelivery(id int) (*webhooks.Delivery, error)
+	FetchAttempts(deliveryID int) ([]webhooks.Attempt, error)
+	Redeliver(id int) error
+}
+
+type webhookRepo struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return &webhookRepo{db: db}
+}
+
+const subscriptionColumns = "subscription_id, client_id, url, description, events, active, created_at, updated_at"
+
+func scanSubscription(row interface{ Scan(...interface{}) error }) (*webhooks.Subscription, error) {
+	var s webhooks.Subscription
+	err := row.Scan(&s.ID, &s.ClientID, &s.URL, &s.Description, pq.Array(&s.Events), &s.Active, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *webhookRepo) FetchSubscriptions(clientID int) ([]webhooks.Subscription, error) {
+	rows, err := r.db.Query("SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE client_id = $1 ORDER BY subscription_id", clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []webhooks.Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *s)
+	}
+	return list, rows.Err()
+}
+
+func (r *webhookRepo) GetSubscription(id int) (*webhooks.Subscription, error) {
+	return scanSubscription(r.db.QueryRow("SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE subscription_id = $1", id))
+}
+
+func (r *webhookRepo) CreateSubscription(s webhooks.Subscription) (int, error) {
+	var id int
+	err := r.db.QueryRow(
+		`INSERT INTO webhook_subscriptions (client_id, url, description, events, secret, active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW()) RETURNING subscription_id`,
+		s.ClientID, s.URL, s.Description, pq.Array(s.Events), s.Secret, s.Active,
+	).Scan(&id)
+	return id, err
+}
+
+// UpdateSubscription mengubah pengaturan subscription; secret hanya diganti jika diisi
+func (r *webhookRepo) UpdateSubscription(s webhooks.Subscription) error {
+	_, err := r.db.Exec(
+		`UPDATE webhook_subscriptions SET url = $1, description = $2, events = $3, active = $4,
+		 secret = COALESCE(NULLIF($5, ''), secret), updated_at = NOW() WHERE subscription_id = $6`,
+		s.URL, s.Description, pq.Array(s.Events), s.Active, s.Secret, s.ID,
+	)
+	return err
+}
+
+func (r *webhookRepo) DeleteSubscription(id int) error {
+	_, err := r.db.Exec("DELETE FROM webhook_subscriptions WHERE subscription_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription with id %d: %w", id, err)
+	}
+	return nil
+}
+
+// EnqueueDeliveries membuat delivery untuk setiap subscription aktif client yang berlangganan event.
+// eventKey yang tidak kosong mencegah delivery ganda saat event yang sama diterima lebih dari sekali.
+func (r *webhookRepo) EnqueueDeliveries(clientID int, eventType, eventKey string, payload []byte) (int, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO webhook_deliveries (subscription_id, client_id, event_type, event_key, payload, status, attempts, next_attempt_at, created_at)
+		 SELECT subscription_id, client_id, $2, NULLIF($3, ''), $4, $5, 0, NOW(), NOW() FROM webhook_subscriptions
+		 WHERE client_id = $1 AND active AND $2 = ANY(events)
+		 ON CONFLICT (subscription_id, event_key) DO NOTHING`,
+		clientID, eventType, eventKey, payload, webhooks.StatusPending,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// ClaimDue mengambil delivery yang jatuh tempo dan menguncinya atas nama owner selama lease,
+// sehingga replika lain tidak mengirim delivery yang sama secara bersamaan
+func (r *webhookRepo) ClaimDue(limit int, lease time.Duration, owner string) ([]webhooks.Delivery, error) {
+	rows, err := r.db.Query(`UPDATE webhook_deliveries d SET locked_until = NOW() + make_interval(secs => $2), locked_by = $5
+		FROM webhook_subscriptions s
+		WHERE s.subscription_id = d.subscription_id AND d.delivery_id IN (
+			SELECT delivery_id FROM webhook_deliveries
+			WHERE status IN ($3, $4) AND next_attempt_at <= NOW() AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING d.delivery_id, d.subscription_id, d.client_id, d.event_type, d.payload, d.status, d.attempts,
+		d.next_attempt_at, d.created_at, s.url, s.secret`,
+		limit, lease.Seconds(), webhooks.StatusPending, webhooks.StatusRetrying, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []webhooks.Delivery
+	for rows.Next() {
+		var d webhooks.Delivery
+		var payload []byte
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.ClientID, &d.EventType, &payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.CreatedAt, &d.URL, &d.Secret)
+		if err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// RecordAttempt mencatat hasil percobaan dan memperbarui status delivery dalam satu transaksi.
+// d harus sudah berisi status, jumlah percobaan dan jadwal berikutnya yang baru. Hasil hanya
+// dicatat selama owner masih memegang lease; jika tidak, ErrLeaseLost dikembalikan.
+func (r *webhookRepo) RecordAttempt(d webhooks.Delivery, a webhooks.Attempt, owner string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4,
+		 last_error = $5, delivered_at = $6, locked_until = NULL, locked_by = NULL
+		 WHERE delivery_id = $7 AND locked_by = $8 AND locked_until > NOW()`,
+		d.Status, d.Attempts, d.NextAttemptAt, a.StatusCode, a.Error, d.DeliveredAt, d.ID, owner,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return webhooks.ErrLeaseLost
+	}
+	_, err = tx.Exec(
+		`INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, response_body, duration_ms, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, NOW())`,
+		d.ID, a.Attempt, a.StatusCode, a.Error, a.ResponseBody, a.DurationMs,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const deliveryColumns = `delivery_id, subscription_id, client_id, event_type, payload, status, attempts,
+	next_attempt_at, last_status_code, last_error, created_at, delivered_at`
+
+func scanDelivery(row interface{ Scan(...interface{}) error }) (*webhooks.Delivery, error) {
+	var d webhooks.Delivery
+	var code sql.NullInt64
+	var delivered sql.NullTime
+	var payload []byte
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.ClientID, &d.EventType, &payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &code, &d.LastError, &d.CreatedAt, &delivered)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	if code.Valid {
+		c := int(code.Int64)
+		d.LastStatusCode = &c
+	}
+	if delivered.Valid {
+		d.DeliveredAt = &delivered.Time
+	}
+	return &d, nil
+}
+
+func (r *webhookRepo) FetchDeliveries(clientID int, subscriptionID *int, status string, limit int) ([]webhooks.Delivery, error) {
+	rows, err := r.db.Query(
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		 WHERE client_id = $1 AND ($2::int IS NULL OR subscription_id = $2) AND ($3 = '' OR status = $3)
+		 ORDER BY created_at DESC LIMIT $4`,
+		clientID, subscriptionID, status, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []webhooks.Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *d)
+	}
+	return list, rows.Err()
+}
+
+func (r *webhookRepo) GetDelivery(id int) (*webhooks.Delivery, error) {
+	return scanDelivery(r.db.QueryRow("SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE delivery_id = $1", id))
+}
+
+func (r *webhookRepo) FetchAttempts(deliveryID int) ([]webhooks.Attempt, error) {
+	rows, err := r.db.Query(`SELECT attempt_id, delivery_id, attempt, status_code, error, response_body, duration_ms, created_at
+		FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY attempt_id`, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []webhooks.Attempt
+	for rows.Next() {
+		var a webhooks.Attempt
+		var code sql.NullInt64
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.Attempt, &code, &a.Error, &a.ResponseBody, &a.DurationMs, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		if code.Valid {
+			c := int(code.Int64)
+			a.StatusCode = &c
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// Redeliver menjadwalkan ulang delivery segera dengan jatah percobaan penuh
+func (r *webhookRepo) Redeliver(id int) error {
+	_, err := r.db.Exec(
+		`UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = NOW(), locked_until = NULL, locked_by = NULL, delivered_at = NULL
+		 WHERE delivery_id = $2`,
+		webhooks.StatusPending, id,
+	)
+	return err
+}
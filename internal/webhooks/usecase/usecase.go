@@ -0,0 +1,325 @@
+package usecase
+
+import (
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/users"
+	"backend/internal/webhooks"
+	"backend/internal/webhooks/repository"
+	"backend/pkg/logger"
+	"backend/pkg/safehttp"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrNotFound            = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound    = errors.New("webhook delivery not found")
+	ErrInvalidSubscription = errors.New("invalid webhook subscription")
+)
+
+const (
+	claimBatch  = 20
+	sendTimeout = 10 * time.Second
+	// Delivery satu batch dikirim berurutan, sehingga lease harus cukup untuk seluruh batch
+	// yang semuanya mencapai sendTimeout
+	claimLease        = claimBatch*sendTimeout + time.Minute
+	responseBodyLimit = 256 // Potongan body respons yang disimpan di log percobaan
+	deliveryPageSize  = 100
+)
+
+// SubscribableEvents adalah tipe event yang bisa dilanggan melalui webhook. Semuanya dicatat
+// di outbox bersama perubahan datanya dan diterima dari event bus.
+var SubscribableEvents = []string{
+	realtime.EventConversationCreated,
+	realtime.EventConversationAssigned,
+	realtime.EventConversationEnqueued,
+	realtime.EventConversationStatusChanged,
+	realtime.EventMessageCreated,
+	realtime.EventPresenceUpdated,
+	realtime.EventSLAWarning,
+	realtime.EventSLABreached,
+	users.EventCreated,
+	users.EventDeleted,
+}
+
+type WebhookUsecase interface {
+	GetSubscriptions(clientID int) ([]webhooks.Subscription, error)
+	GetSubscription(clientID, id int) (*webhooks.Subscription, error)
+	CreateSubscription(s webhooks.Subscription) (*webhooks.Subscription, error)
+	UpdateSubscription(s webhooks.Subscription) error
+	RotateSecret(clientID, id int) (string, error)
+	DeleteSubscription(clientID, id int) error
+	GetDeliveries(clientID int, subscriptionID *int, status string) ([]webhooks.Delivery, error)
+	GetDelivery(clientID, id int) (*webhooks.Delivery, error)
+	Redeliver(clientID, id int) error
+	HandleEvent(ctx context.Context, e events.Event) error
+	DeliverDue(ctx context.Context) (int, error)
+	RunDispatcher(ctx context.Context, interval time.Duration)
+}
+
+type webhookUsecase struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+}
+
+func NewWebhookUsecase(repo repository.WebhookRepository, client *http.Client) WebhookUsecase {
+	// URL subscription ditentukan tenant, sehingga client default menolak alamat internal
+	if client == nil {
+		client = safehttp.NewClient(sendTimeout)
+	}
+	return &webhookUsecase{repo: repo, client: client}
+}
+
+func (u *webhookUsecase) GetSubscriptions(clientID int) ([]webhooks.Subscription, error) {
+	return u.repo.FetchSubscriptions(clientID)
+}
+
+func (u *webhookUsecase) GetSubscription(clientID, id int) (*webhooks.Subscription, error) {
+	s, err := u.repo.GetSubscription(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && s.ClientID != clientID) {
+		return nil, ErrNotFound
+	}
+	return s, err
+}
+
+func contains(list []string, eventType string) bool {
+	for _, e := range list {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func validEvent(eventType string) bool {
+	return contains(SubscribableEvents, eventType)
+}
+
+func (u *webhookUsecase) validate(s *webhooks.Subscription) error {
+	target, err := safehttp.CheckURL(s.URL)
+	if err != nil {
+		return ErrInvalidSubscription
+	}
+	s.URL = target.String()
+	if len(s.Events) == 0 {
+		return ErrInvalidSubscription
+	}
+	for _, e := range s.Events {
+		if !validEvent(e) {
+			return ErrInvalidSubscription
+		}
+	}
+	return nil
+}
+
+// newSecret membuat secret penandatanganan acak
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// CreateSubscription menyimpan subscription dengan secret baru. Secret hanya dikembalikan di sini.
+func (u *webhookUsecase) CreateSubscription(s webhooks.Subscription) (*webhooks.Subscription, error) {
+	if err := u.validate(&s); err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	s.Secret = secret
+
+	id, err := u.repo.CreateSubscription(s)
+	if err != nil {
+		return nil, err
+	}
+	s.ID = id
+	return &s, nil
+}
+
+func (u *webhookUsecase) UpdateSubscription(s webhooks.Subscription) error {
+	if _, err := u.GetSubscription(s.ClientID, s.ID); err != nil {
+		return err
+	}
+	if err := u.validate(&s); err != nil {
+		return err
+	}
+	s.Secret = "" // Secret hanya diganti melalui RotateSecret
+	return u.repo.UpdateSubscription(s)
+}
+
+// RotateSecret mengganti secret subscription dan mengembalikan secret baru
+func (u *webhookUsecase) RotateSecret(clientID, id int) (string, error) {
+	s, err := u.GetSubscription(clientID, id)
+	if err != nil {
+		return "", err
+	}
+	if s.Secret, err = newSecret(); err != nil {
+		return "", err
+	}
+	if err := u.repo.UpdateSubscription(*s); err != nil {
+		return "", err
+	}
+	return s.Secret, nil
+}
+
+func (u *webhookUsecase) DeleteSubscription(clientID, id int) error {
+	if _, err := u.GetSubscription(clientID, id); err != nil {
+		return err
+	}
+	return u.repo.DeleteSubscription(id)
+}
+
+func (u *webhookUsecase) GetDeliveries(clientID int, subscriptionID *int, status string) ([]webhooks.Delivery, error) {
+	return u.repo.FetchDeliveries(clientID, subscriptionID, status, deliveryPageSize)
+}
+
+// GetDelivery mengembalikan delivery beserta log percobaannya
+func (u *webhookUsecase) GetDelivery(clientID, id int) (*webhooks.Delivery, error) {
+	d, err := u.repo.GetDelivery(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && d.ClientID != clientID) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.AttemptLog, err = u.repo.FetchAttempts(id); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Redeliver menjadwalkan ulang delivery, termasuk yang sudah dead atau terkirim
+func (u *webhookUsecase) Redeliver(clientID, id int) error {
+	d, err := u.repo.GetDelivery(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && d.ClientID != clientID) {
+		return ErrDeliveryNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return u.repo.Redeliver(id)
+}
+
+// HandleEvent adalah subscriber event bus yang menyimpan delivery durable untuk setiap
+// subscription yang berlangganan event. ID event dipakai sebagai idempotency key sehingga
+// relay ulang tidak membuat delivery ganda.
+func (u *webhookUsecase) HandleEvent(ctx context.Context, e events.Event) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":         e.ID,
+		"type":       e.Type,
+		"client_id":  e.ClientID,
+		"created_at": e.OccurredAt,
+		"data":       e.Payload,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = u.repo.EnqueueDeliveries(e.ClientID, e.Type, e.ID, payload)
+	return err
+}
+
+// DeliverDue mengirim delivery yang jatuh tempo dan mengembalikan jumlah yang terkirim
+func (u *webhookUsecase) DeliverDue(ctx context.Context) (int, error) {
+	// Token klaim unik per batch; hasil percobaan hanya dicatat selama token ini masih memegang lease
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return 0, err
+	}
+	owner := hex.EncodeToString(token)
+	due, err := u.repo.ClaimDue(claimBatch, claimLease, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, d := range due {
+		attempt := u.send(ctx, d)
+		d.Attempts++
+		switch {
+		case attempt.Succeeded():
+			now := time.Now()
+			d.Status, d.DeliveredAt = webhooks.StatusDelivered, &now
+		case d.Attempts >= webhooks.MaxAttempts:
+			d.Status = webhooks.StatusDead
+		default:
+			d.Status, d.NextAttemptAt = webhooks.StatusRetrying, time.Now().Add(webhooks.Backoff(d.Attempts))
+		}
+		err := u.repo.RecordAttempt(d, attempt, owner)
+		switch {
+		case errors.Is(err, webhooks.ErrLeaseLost):
+			logger.From(ctx).Warn("Webhook delivery lease lost; attempt discarded", "delivery_id", d.ID)
+		case err != nil:
+			logger.From(ctx).Error("Failed to record webhook attempt", "delivery_id", d.ID, "error", err)
+		case d.Status == webhooks.StatusDelivered:
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// send melakukan satu percobaan HTTP POST yang ditandatangani. Durasinya dibatasi sendTimeout
+// apa pun client-nya agar batch selesai sebelum lease habis.
+func (u *webhookUsecase) send(ctx context.Context, d webhooks.Delivery) (attempt webhooks.Attempt) {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	attempt = webhooks.Attempt{DeliveryID: d.ID, Attempt: d.Attempts + 1}
+	start := time.Now()
+	defer func() { attempt.DurationMs = time.Since(start).Milliseconds() }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooks.HeaderEvent, d.EventType)
+	req.Header.Set(webhooks.HeaderDelivery, strconv.Itoa(d.ID))
+	req.Header.Set(webhooks.HeaderSignature, webhooks.SignatureHeader(d.Secret, start, d.Payload))
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	// Hanya potongan pendek yang sudah dibersihkan disimpan; body lengkap tidak ditampilkan ulang
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, responseBodyLimit))
+	attempt.StatusCode = &resp.StatusCode
+	attempt.ResponseBody = safehttp.Snippet(body, responseBodyLimit)
+	if !attempt.Succeeded() {
+		attempt.Error = "unexpected status " + strconv.Itoa(resp.StatusCode)
+	}
+	return attempt
+}
+
+// RunDispatcher mengirim delivery yang jatuh tempo setiap interval sampai ctx dibatalkan
+func (u *webhookUsecase) RunDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.DeliverDue(ctx); err != nil {
+				logger.From(ctx).Error("Failed to deliver webhooks", "error", err)
+			}
+		}
+	}
+}
@@ -1,14 +1,25 @@
 package middleware
 
 import (
+	"backend/pkg/logger"
+	"backend/pkg/requestctx"
 	"backend/pkg/utils"
 	"database/sql"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// revokedTokenHits menghitung request yang ditolak karena token sudah dicabut (logout atau
+// pengguna dihapus); lonjakan bisa menandakan token curian yang dicoba ulang
+var revokedTokenHits = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "auth_revoked_token_hits_total",
+	Help: "Requests rejected because their token was revoked.",
+})
+
 func JWTMiddleware(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -18,33 +29,67 @@ func JWTMiddleware(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		authorize(c, db, strings.TrimPrefix(authHeader, "Bearer "))
+	}
+}
 
-		// Periksa apakah token ada di blacklist
-		var exists bool
-		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM blacklisted_tokens WHERE token = $1 LIMIT 1)", tokenString).Scan(&exists)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
-			c.Abort()
-			return
+// StreamJWTMiddleware sama dengan JWTMiddleware, tetapi juga menerima token dari
+// query parameter access_token karena browser tidak bisa mengirim header
+// Authorization saat membuka WebSocket atau EventSource.
+func StreamJWTMiddleware(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString == "" {
+			tokenString = c.Query("access_token")
 		}
-
-		if exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token is blacklisted"})
+		if tokenString == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Access token is missing"})
 			c.Abort()
 			return
 		}
 
-		// Verifikasi token
-		claims, err := utils.VerifyToken(tokenString)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
-			return
-		}
+		authorize(c, db, tokenString)
+	}
+}
+
+// authorize memeriksa blacklist dan memverifikasi token, lalu menyimpan klaim ke context
+func authorize(c *gin.Context, db *sql.DB, tokenString string) {
+	// Periksa apakah token ada di blacklist
+	var exists bool
+	err := db.QueryRowContext(c.Request.Context(), "SELECT EXISTS(SELECT 1 FROM blacklisted_tokens WHERE token = $1 LIMIT 1)", tokenString).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
+		c.Abort()
+		return
+	}
+
+	if exists {
+		revokedTokenHits.Inc()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token is blacklisted"})
+		c.Abort()
+		return
+	}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Next()
+	// Verifikasi token
+	claims, err := utils.VerifyToken(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return
 	}
+
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("client_id", claims.ClientID)
+	c.Set("claims", claims)
+	// Actor dan tenant juga dibawa context request agar bisa dibaca usecase dan repository,
+	// dan log selanjutnya dari request ini mencantumkan pengguna dan client-nya
+	ctx := requestctx.WithActor(c.Request.Context(), requestctx.Actor{
+		UserID:   claims.UserID,
+		Username: claims.Username,
+		ClientID: claims.ClientID,
+	})
+	ctx = logger.WithContext(ctx, logger.From(ctx).With("user_id", claims.UserID, "client_id", claims.ClientID))
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
 }
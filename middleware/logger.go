@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"backend/pkg/logger"
+	"backend/pkg/requestctx"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger menyimpan logger per request (berisi ID request) di context request lalu menulis
+// access log setelah request selesai. Harus dipasang setelah RequestContext. Query string
+// tidak dicatat karena bisa berisi token, misalnya access_token pada stream real-time.
+func Logger(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		l := base.With("request_id", requestctx.RequestID(c.Request.Context()))
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), l))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if userID := c.GetInt("user_id"); userID != 0 {
+			attrs = append(attrs, slog.Int("user_id", userID), slog.Int("client_id", c.GetInt("client_id")))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		l.LogAttrs(c.Request.Context(), level, "HTTP request", attrs...)
+	}
+}
+
+// Recovery mengubah panic di handler menjadi respon 500 dan mencatatnya beserta stack trace
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				logger.From(c.Request.Context()).Error("Panic while handling request", "panic", p, "stack", string(debug.Stack()))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			}
+		}()
+		c.Next()
+	}
+}
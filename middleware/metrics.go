@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// unmatchedRoute adalah label route untuk request yang tidak cocok dengan route mana pun,
+// agar path acak dari scanner tidak membuat seri metrik baru
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method and route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Metrics mencatat jumlah dan latensi request per template route (misalnya /api/contacts/:id)
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		httpDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	}
+}
+
+// InitRouteMetrics membuat seri latensi untuk setiap route terdaftar, sehingga route yang
+// belum pernah dipanggil tetap muncul di /metrics dengan nilai 0
+func InitRouteMetrics(routes gin.RoutesInfo) {
+	for _, r := range routes {
+		httpDuration.WithLabelValues(r.Method, r.Path)
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"backend/pkg/requestctx"
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader berisi ID request. ID dari proxy di depan server dipakai ulang jika valid,
+// sehingga log kedua sisi bisa dicocokkan.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// RequestContext memberi setiap request ID dan deadline pada context request, sehingga query
+// database dibatalkan saat client memutus koneksi atau deadline habis. Route pada exempt (path
+// route gin, misalnya stream real-time dan transfer file) tidak diberi deadline.
+func RequestContext(timeout time.Duration, exempt ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = requestctx.NewRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Set("request_id", id)
+
+		ctx := requestctx.WithRequestID(c.Request.Context(), id)
+		if timeout > 0 && !skip[c.FullPath()] {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// validRequestID hanya menerima karakter yang aman ditulis ke log dan header
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,540 @@
+-- Skema awal semua tabel yang dipakai aplikasi. Kolom client_id tidak diberi foreign key ke
+-- clients karena baris clients bersifat opsional (hanya menyimpan pengaturan per client).
+-- IF NOT EXISTS membuat migrasi ini bisa dijalankan pada database lama yang tabelnya dibuat manual.
+
+CREATE TABLE IF NOT EXISTS clients (
+    client_id       SERIAL PRIMARY KEY,
+    name            TEXT NOT NULL DEFAULT '',
+    timezone        TEXT NOT NULL DEFAULT 'UTC',
+    search_language TEXT NOT NULL DEFAULT 'simple',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Pengguna dan autentikasi
+
+CREATE TABLE IF NOT EXISTS users (
+    user_id       SERIAL PRIMARY KEY,
+    username      TEXT NOT NULL,
+    email         TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    role_id       INT NOT NULL DEFAULT 0,
+    client_id     INT NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS users_client_idx ON users (client_id);
+
+CREATE TABLE IF NOT EXISTS blacklisted_tokens (
+    token      TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Kontak
+
+CREATE TABLE IF NOT EXISTS contacts (
+    contact_id        SERIAL PRIMARY KEY,
+    client_id         INT NOT NULL,
+    name              TEXT NOT NULL DEFAULT '',
+    attributes        JSONB NOT NULL DEFAULT '{}',
+    search_vector     TSVECTOR,
+    search_indexed_at TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS contacts_client_idx ON contacts (client_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS contacts_search_idx ON contacts USING GIN (search_vector);
+
+CREATE TABLE IF NOT EXISTS contact_identities (
+    identity_id SERIAL PRIMARY KEY,
+    contact_id  INT NOT NULL REFERENCES contacts (contact_id) ON DELETE CASCADE,
+    client_id   INT NOT NULL,
+    type        TEXT NOT NULL,
+    value       TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS contact_identities_lookup_idx ON contact_identities (client_id, type, value);
+CREATE INDEX IF NOT EXISTS contact_identities_contact_idx ON contact_identities (contact_id);
+
+CREATE TABLE IF NOT EXISTS contact_tags (
+    contact_id INT NOT NULL REFERENCES contacts (contact_id) ON DELETE CASCADE,
+    tag        TEXT NOT NULL,
+    PRIMARY KEY (contact_id, tag)
+);
+
+-- Riwayat penggabungan; kontak yang digabung sudah dihapus sehingga tanpa foreign key
+CREATE TABLE IF NOT EXISTS contact_merges (
+    merge_id           SERIAL PRIMARY KEY,
+    client_id          INT NOT NULL,
+    primary_contact_id INT NOT NULL,
+    merged_contact_id  INT NOT NULL,
+    merged_by          INT NOT NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Tim dan routing agent
+
+CREATE TABLE IF NOT EXISTS teams (
+    team_id          SERIAL PRIMARY KEY,
+    client_id        INT NOT NULL,
+    name             TEXT NOT NULL,
+    description      TEXT NOT NULL DEFAULT '',
+    priority         INT NOT NULL DEFAULT 0,
+    overflow_team_id INT REFERENCES teams (team_id) ON DELETE SET NULL,
+    max_wait_seconds INT NOT NULL DEFAULT 0,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS teams_client_idx ON teams (client_id);
+
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id INT NOT NULL REFERENCES teams (team_id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    role    TEXT NOT NULL DEFAULT 'member',
+    PRIMARY KEY (team_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS team_members_user_idx ON team_members (user_id);
+
+CREATE TABLE IF NOT EXISTS routing_settings (
+    client_id              INT PRIMARY KEY,
+    strategy               TEXT NOT NULL,
+    fallback_strategy      TEXT NOT NULL DEFAULT '',
+    auto_assign            BOOLEAN NOT NULL DEFAULT TRUE,
+    default_max_concurrent INT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS agent_settings (
+    user_id          INT PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    max_concurrent   INT,
+    last_assigned_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS agent_skills (
+    user_id     INT NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    skill       TEXT NOT NULL,
+    proficiency INT NOT NULL,
+    PRIMARY KEY (user_id, skill)
+);
+
+-- Percakapan dan pesan
+
+CREATE TABLE IF NOT EXISTS conversations (
+    conversation_id  SERIAL PRIMARY KEY,
+    client_id        INT NOT NULL,
+    contact_id       INT REFERENCES contacts (contact_id) ON DELETE SET NULL,
+    channel          TEXT NOT NULL,
+    external_address TEXT NOT NULL DEFAULT '',
+    status           TEXT NOT NULL DEFAULT 'open',
+    team_id          INT REFERENCES teams (team_id) ON DELETE SET NULL,
+    assignee_id      INT REFERENCES users (user_id) ON DELETE SET NULL,
+    required_skills  TEXT[] DEFAULT '{}',
+    priority         INT NOT NULL DEFAULT 0,
+    enqueued_at      TIMESTAMPTZ,
+    resolved_at      TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS conversations_client_idx ON conversations (client_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS conversations_contact_idx ON conversations (contact_id);
+CREATE INDEX IF NOT EXISTS conversations_address_idx ON conversations (client_id, channel, external_address) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS conversations_assignee_idx ON conversations (assignee_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS conversations_queue_idx ON conversations (team_id, priority DESC, enqueued_at) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS conversations_created_idx ON conversations (created_at);
+CREATE INDEX IF NOT EXISTS conversations_resolved_idx ON conversations (resolved_at);
+
+CREATE TABLE IF NOT EXISTS messages (
+    message_id      BIGSERIAL PRIMARY KEY,
+    conversation_id INT NOT NULL REFERENCES conversations (conversation_id) ON DELETE CASCADE,
+    client_id       INT NOT NULL,
+    direction       TEXT NOT NULL,
+    channel         TEXT NOT NULL,
+    body            TEXT NOT NULL DEFAULT '',
+    external_id     TEXT NOT NULL DEFAULT '',
+    status          TEXT NOT NULL DEFAULT '',
+    sender_user_id  INT REFERENCES users (user_id) ON DELETE SET NULL,
+    search_vector   TSVECTOR,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS messages_conversation_idx ON messages (conversation_id, created_at);
+CREATE INDEX IF NOT EXISTS messages_external_idx ON messages (channel, external_id) WHERE external_id <> '';
+CREATE INDEX IF NOT EXISTS messages_created_idx ON messages (created_at);
+CREATE INDEX IF NOT EXISTS messages_search_idx ON messages USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS messages_unindexed_idx ON messages (message_id) WHERE search_vector IS NULL;
+
+-- Riwayat assignment disimpan apa adanya walaupun user sudah dihapus
+CREATE TABLE IF NOT EXISTS conversation_assignments (
+    assignment_id   SERIAL PRIMARY KEY,
+    conversation_id INT NOT NULL REFERENCES conversations (conversation_id) ON DELETE CASCADE,
+    client_id       INT NOT NULL,
+    from_user_id    INT,
+    to_user_id      INT NOT NULL,
+    assigned_by     INT,
+    reason          TEXT NOT NULL,
+    strategy        TEXT NOT NULL DEFAULT '',
+    note            TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS conversation_assignments_conversation_idx ON conversation_assignments (conversation_id, created_at);
+
+-- SMS
+
+CREATE TABLE IF NOT EXISTS sms_accounts (
+    sms_account_id    SERIAL PRIMARY KEY,
+    client_id         INT NOT NULL,
+    provider          TEXT NOT NULL,
+    account_sid       TEXT NOT NULL,
+    auth_token        TEXT NOT NULL,
+    from_number       TEXT NOT NULL,
+    price_per_segment NUMERIC(12, 6) NOT NULL DEFAULT 0,
+    UNIQUE (client_id, provider)
+);
+
+CREATE TABLE IF NOT EXISTS sms_opt_outs (
+    client_id  INT NOT NULL,
+    phone      TEXT NOT NULL,
+    opted_out  BOOLEAN NOT NULL,
+    keyword    TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (client_id, phone)
+);
+
+-- Presence agent
+
+CREATE TABLE IF NOT EXISTS presence_settings (
+    client_id            INT PRIMARY KEY,
+    idle_timeout_seconds INT NOT NULL,
+    break_reasons        TEXT[] DEFAULT '{}'
+);
+
+CREATE TABLE IF NOT EXISTS agent_presence (
+    user_id          INT PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    client_id        INT NOT NULL,
+    status           TEXT NOT NULL,
+    reason           TEXT NOT NULL DEFAULT '',
+    auto             BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_activity_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS agent_status_history (
+    history_id BIGSERIAL PRIMARY KEY,
+    user_id    INT NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    client_id  INT NOT NULL,
+    status     TEXT NOT NULL,
+    reason     TEXT NOT NULL DEFAULT '',
+    auto       BOOLEAN NOT NULL DEFAULT FALSE,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ended_at   TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS agent_status_history_client_idx ON agent_status_history (client_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS agent_status_history_open_idx ON agent_status_history (user_id) WHERE ended_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS presence_connections (
+    connection_id   TEXT PRIMARY KEY,
+    user_id         INT NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    client_id       INT NOT NULL,
+    connected_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    disconnected_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS presence_connections_user_idx ON presence_connections (user_id);
+
+-- Jam kerja dan SLA
+
+CREATE TABLE IF NOT EXISTS business_hours (
+    business_hours_id     SERIAL PRIMARY KEY,
+    client_id             INT NOT NULL,
+    team_id               INT REFERENCES teams (team_id) ON DELETE CASCADE,
+    timezone              TEXT NOT NULL,
+    days                  JSONB NOT NULL,
+    auto_reply            BOOLEAN NOT NULL DEFAULT FALSE,
+    out_of_office_message TEXT NOT NULL DEFAULT '',
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS business_hours_scope_idx ON business_hours (client_id, (COALESCE(team_id, 0)));
+
+CREATE TABLE IF NOT EXISTS holidays (
+    holiday_id SERIAL PRIMARY KEY,
+    client_id  INT NOT NULL,
+    team_id    INT REFERENCES teams (team_id) ON DELETE CASCADE,
+    date       DATE NOT NULL,
+    name       TEXT NOT NULL,
+    recurring  BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE INDEX IF NOT EXISTS holidays_client_idx ON holidays (client_id, date);
+
+CREATE TABLE IF NOT EXISTS sla_policies (
+    policy_id              SERIAL PRIMARY KEY,
+    client_id              INT NOT NULL,
+    name                   TEXT NOT NULL,
+    priority               INT NOT NULL DEFAULT 0,
+    channels               TEXT[] DEFAULT '{}',
+    team_ids               INT[] DEFAULT '{}',
+    tags                   TEXT[] DEFAULT '{}',
+    first_response_seconds INT NOT NULL DEFAULT 0,
+    next_response_seconds  INT NOT NULL DEFAULT 0,
+    resolution_seconds     INT NOT NULL DEFAULT 0,
+    business_hours         BOOLEAN NOT NULL DEFAULT FALSE,
+    warning_percent        INT NOT NULL DEFAULT 0,
+    active                 BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS sla_policies_client_idx ON sla_policies (client_id, priority DESC);
+
+-- Timer tetap disimpan setelah kebijakannya dihapus agar laporan SLA tidak berubah
+CREATE TABLE IF NOT EXISTS sla_timers (
+    conversation_id INT NOT NULL REFERENCES conversations (conversation_id) ON DELETE CASCADE,
+    client_id       INT NOT NULL,
+    policy_id       INT NOT NULL,
+    metric          TEXT NOT NULL,
+    started_at      TIMESTAMPTZ NOT NULL,
+    warn_at         TIMESTAMPTZ,
+    due_at          TIMESTAMPTZ NOT NULL,
+    completed_at    TIMESTAMPTZ,
+    warned_at       TIMESTAMPTZ,
+    breached_at     TIMESTAMPTZ,
+    PRIMARY KEY (conversation_id, metric)
+);
+CREATE INDEX IF NOT EXISTS sla_timers_due_idx ON sla_timers (due_at) WHERE completed_at IS NULL AND breached_at IS NULL;
+CREATE INDEX IF NOT EXISTS sla_timers_finished_idx ON sla_timers ((COALESCE(breached_at, completed_at)));
+
+-- Canned response dan automation
+
+CREATE TABLE IF NOT EXISTS canned_responses (
+    canned_response_id SERIAL PRIMARY KEY,
+    client_id          INT NOT NULL,
+    team_id            INT REFERENCES teams (team_id) ON DELETE CASCADE,
+    user_id            INT REFERENCES users (user_id) ON DELETE CASCADE,
+    shortcode          TEXT NOT NULL,
+    title              TEXT NOT NULL,
+    body               TEXT NOT NULL,
+    category           TEXT NOT NULL DEFAULT '',
+    attachments        JSONB NOT NULL DEFAULT '[]',
+    created_by         INT NOT NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS canned_responses_shortcode_idx
+    ON canned_responses (client_id, (COALESCE(team_id, 0)), (COALESCE(user_id, 0)), shortcode);
+
+CREATE TABLE IF NOT EXISTS automation_rules (
+    rule_id    SERIAL PRIMARY KEY,
+    client_id  INT NOT NULL,
+    name       TEXT NOT NULL,
+    event      TEXT NOT NULL,
+    condition  TEXT NOT NULL DEFAULT '',
+    actions    JSONB NOT NULL DEFAULT '[]',
+    position   INT NOT NULL DEFAULT 0,
+    stop_after BOOLEAN NOT NULL DEFAULT FALSE,
+    active     BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS automation_rules_event_idx ON automation_rules (client_id, event, position);
+
+CREATE TABLE IF NOT EXISTS automation_executions (
+    execution_id    BIGSERIAL PRIMARY KEY,
+    client_id       INT NOT NULL,
+    rule_id         INT NOT NULL REFERENCES automation_rules (rule_id) ON DELETE CASCADE,
+    conversation_id INT NOT NULL REFERENCES conversations (conversation_id) ON DELETE CASCADE,
+    event           TEXT NOT NULL,
+    status          TEXT NOT NULL,
+    results         JSONB,
+    error           TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS automation_executions_client_idx ON automation_executions (client_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS automation_executions_conversation_idx ON automation_executions (conversation_id, created_at);
+
+-- Webhook keluar dan outbox event domain
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    subscription_id SERIAL PRIMARY KEY,
+    client_id       INT NOT NULL,
+    url             TEXT NOT NULL,
+    description     TEXT NOT NULL DEFAULT '',
+    events          TEXT[] DEFAULT '{}',
+    secret          TEXT NOT NULL,
+    active          BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS webhook_subscriptions_client_idx ON webhook_subscriptions (client_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id      BIGSERIAL PRIMARY KEY,
+    subscription_id  INT NOT NULL REFERENCES webhook_subscriptions (subscription_id) ON DELETE CASCADE,
+    client_id        INT NOT NULL,
+    event_type       TEXT NOT NULL,
+    event_key        TEXT,
+    payload          JSONB NOT NULL,
+    status           TEXT NOT NULL,
+    attempts         INT NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until     TIMESTAMPTZ,
+    last_status_code INT,
+    last_error       TEXT NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at     TIMESTAMPTZ,
+    UNIQUE (subscription_id, event_key)
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status IN ('pending', 'retrying');
+CREATE INDEX IF NOT EXISTS webhook_deliveries_client_idx ON webhook_deliveries (client_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    attempt_id    BIGSERIAL PRIMARY KEY,
+    delivery_id   BIGINT NOT NULL REFERENCES webhook_deliveries (delivery_id) ON DELETE CASCADE,
+    attempt       INT NOT NULL,
+    status_code   INT,
+    error         TEXT NOT NULL DEFAULT '',
+    response_body TEXT NOT NULL DEFAULT '',
+    duration_ms   BIGINT NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS webhook_delivery_attempts_delivery_idx ON webhook_delivery_attempts (delivery_id);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    event_id        TEXT PRIMARY KEY,
+    client_id       INT NOT NULL,
+    event_type      TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    status          TEXT NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMPTZ,
+    last_error      TEXT NOT NULL DEFAULT '',
+    occurred_at     TIMESTAMPTZ NOT NULL,
+    published_at    TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS outbox_events_pending_idx ON outbox_events (occurred_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS outbox_handled (
+    event_id   TEXT NOT NULL REFERENCES outbox_events (event_id) ON DELETE CASCADE,
+    subscriber TEXT NOT NULL,
+    handled_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, subscriber)
+);
+
+-- Antrean job latar belakang
+
+CREATE TABLE IF NOT EXISTS jobs (
+    job_id       BIGSERIAL PRIMARY KEY,
+    client_id    INT,
+    job_type     TEXT NOT NULL,
+    payload      JSONB NOT NULL,
+    status       TEXT NOT NULL,
+    attempts     INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL,
+    run_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    unique_key   TEXT,
+    last_error   TEXT NOT NULL DEFAULT '',
+    locked_by    TEXT,
+    locked_at    TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at  TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS jobs_unique_key_idx ON jobs (unique_key) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS jobs_queued_idx ON jobs (run_at, job_id) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS jobs_running_idx ON jobs (locked_at) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS jobs_client_idx ON jobs (client_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS job_schedules (
+    name        TEXT PRIMARY KEY,
+    spec        TEXT NOT NULL,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ
+);
+
+-- Attachment
+
+CREATE TABLE IF NOT EXISTS attachments (
+    attachment_id   SERIAL PRIMARY KEY,
+    client_id       INT NOT NULL,
+    conversation_id INT REFERENCES conversations (conversation_id) ON DELETE SET NULL,
+    message_id      BIGINT REFERENCES messages (message_id) ON DELETE SET NULL,
+    uploaded_by     INT REFERENCES users (user_id) ON DELETE SET NULL,
+    file_name       TEXT NOT NULL,
+    content_type    TEXT NOT NULL,
+    kind            TEXT NOT NULL,
+    size            BIGINT NOT NULL,
+    checksum        TEXT NOT NULL,
+    storage_key     TEXT NOT NULL UNIQUE,
+    thumbnail_key   TEXT,
+    width           INT,
+    height          INT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS attachments_conversation_idx ON attachments (client_id, conversation_id, created_at);
+
+CREATE TABLE IF NOT EXISTS storage_usage (
+    client_id   INT PRIMARY KEY,
+    used_bytes  BIGINT NOT NULL DEFAULT 0,
+    quota_bytes BIGINT NOT NULL
+);
+
+-- Survei CSAT/NPS
+
+CREATE TABLE IF NOT EXISTS survey_settings (
+    client_id     INT PRIMARY KEY,
+    enabled       BOOLEAN NOT NULL DEFAULT FALSE,
+    survey_type   TEXT NOT NULL,
+    question      TEXT NOT NULL DEFAULT '',
+    thank_you     TEXT NOT NULL DEFAULT '',
+    include_link  BOOLEAN NOT NULL DEFAULT FALSE,
+    delay_minutes INT NOT NULL DEFAULT 0,
+    expiry_hours  INT NOT NULL,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS surveys (
+    survey_id       SERIAL PRIMARY KEY,
+    client_id       INT NOT NULL,
+    conversation_id INT NOT NULL UNIQUE REFERENCES conversations (conversation_id) ON DELETE CASCADE,
+    agent_id        INT REFERENCES users (user_id) ON DELETE SET NULL,
+    channel         TEXT NOT NULL,
+    address         TEXT NOT NULL,
+    survey_type     TEXT NOT NULL,
+    status          TEXT NOT NULL,
+    sent_at         TIMESTAMPTZ,
+    expires_at      TIMESTAMPTZ,
+    answered_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS surveys_awaiting_idx ON surveys (client_id, channel, address) WHERE status = 'sent';
+
+CREATE TABLE IF NOT EXISTS survey_responses (
+    response_id     SERIAL PRIMARY KEY,
+    survey_id       INT NOT NULL UNIQUE REFERENCES surveys (survey_id) ON DELETE CASCADE,
+    client_id       INT NOT NULL,
+    conversation_id INT NOT NULL,
+    agent_id        INT,
+    survey_type     TEXT NOT NULL,
+    score           INT NOT NULL,
+    comment         TEXT NOT NULL DEFAULT '',
+    source          TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS survey_responses_client_idx ON survey_responses (client_id, created_at DESC);
+
+-- Rollup analytics per jam pada zona waktu client; team_id dan agent_id 0 berarti tanpa tim/agent
+
+CREATE TABLE IF NOT EXISTS analytics_hourly (
+    client_id              INT NOT NULL,
+    bucket                 TIMESTAMPTZ NOT NULL,
+    channel                TEXT NOT NULL,
+    team_id                INT NOT NULL DEFAULT 0,
+    agent_id               INT NOT NULL DEFAULT 0,
+    conversations_created  INT NOT NULL DEFAULT 0,
+    conversations_resolved INT NOT NULL DEFAULT 0,
+    first_responses        INT NOT NULL DEFAULT 0,
+    first_response_seconds BIGINT NOT NULL DEFAULT 0,
+    handled                INT NOT NULL DEFAULT 0,
+    handle_seconds         BIGINT NOT NULL DEFAULT 0,
+    sla_met                INT NOT NULL DEFAULT 0,
+    sla_breached           INT NOT NULL DEFAULT 0,
+    csat_responses         INT NOT NULL DEFAULT 0,
+    csat_score_sum         BIGINT NOT NULL DEFAULT 0,
+    csat_satisfied         INT NOT NULL DEFAULT 0,
+    nps_responses          INT NOT NULL DEFAULT 0,
+    nps_promoters          INT NOT NULL DEFAULT 0,
+    nps_detractors         INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (client_id, bucket, channel, team_id, agent_id)
+);
+CREATE INDEX IF NOT EXISTS analytics_hourly_bucket_idx ON analytics_hourly (bucket);
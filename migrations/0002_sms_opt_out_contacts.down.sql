@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS sms_opt_outs_contact_idx;
+ALTER TABLE sms_opt_outs DROP COLUMN IF EXISTS contact_id;
@@ -0,0 +1,27 @@
+-- Nomor opt-out disimpan dalam bentuk yang sama dengan contacts.NormalizeIdentity (hanya digit
+-- dengan awalan '+') dan dihubungkan ke kontak pemilik nomor.
+
+-- Baris mentah yang menjadi nomor yang sama setelah dinormalisasi digabung menjadi satu baris.
+-- Opt-out dipertahankan jika salah satu baris masih opt-out, karena mengirim ke nomor yang
+-- meminta STOP lebih berbahaya daripada melewatkan satu nomor yang sudah START.
+CREATE TEMPORARY TABLE sms_opt_outs_normalised AS
+SELECT DISTINCT ON (client_id, phone) client_id, phone, opted_out, keyword, updated_at
+FROM (
+    SELECT client_id, opted_out, keyword, updated_at,
+           CASE WHEN left(btrim(phone), 1) = '+' THEN '+' ELSE '' END || regexp_replace(phone, '[^0-9]', '', 'g') AS phone
+    FROM sms_opt_outs
+) n
+ORDER BY client_id, phone, opted_out DESC, updated_at DESC;
+
+DELETE FROM sms_opt_outs;
+INSERT INTO sms_opt_outs (client_id, phone, opted_out, keyword, updated_at)
+SELECT client_id, phone, opted_out, keyword, updated_at FROM sms_opt_outs_normalised;
+DROP TABLE sms_opt_outs_normalised;
+
+ALTER TABLE sms_opt_outs ADD COLUMN IF NOT EXISTS contact_id INT REFERENCES contacts (contact_id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS sms_opt_outs_contact_idx ON sms_opt_outs (contact_id);
+
+UPDATE sms_opt_outs o
+SET contact_id = i.contact_id
+FROM contact_identities i
+WHERE o.contact_id IS NULL AND i.client_id = o.client_id AND i.type = 'phone' AND i.value = o.phone;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS messages_inbound_external_idx;
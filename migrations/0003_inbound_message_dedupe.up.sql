@@ -0,0 +1,12 @@
+-- Provider mengirim ulang webhook pesan masuk dengan ID yang sama saat respons lambat atau gagal.
+-- Index unik ini membuat pesan inbound tersimpan sekali per client dan channel; duplikat lama
+-- dihapus lebih dulu dengan mempertahankan salinan pertama.
+
+DELETE FROM messages m
+USING messages first
+WHERE m.direction = 'inbound' AND m.external_id <> ''
+  AND first.direction = 'inbound' AND first.client_id = m.client_id AND first.channel = m.channel
+  AND first.external_id = m.external_id AND first.message_id < m.message_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS messages_inbound_external_idx ON messages (client_id, channel, external_id)
+    WHERE direction = 'inbound' AND external_id <> '';
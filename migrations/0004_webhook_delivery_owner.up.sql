@@ -0,0 +1,3 @@
+-- Dispatcher yang mengklaim delivery dicatat agar hasil percobaan dari dispatcher yang lease-nya
+-- sudah habis tidak menimpa hasil dispatcher yang mengklaim ulang
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS locked_by TEXT;
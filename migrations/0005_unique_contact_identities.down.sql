@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS contact_identities_lookup_idx ON contact_identities (client_id, type, value);
+DROP INDEX IF EXISTS contact_identities_unique_idx;
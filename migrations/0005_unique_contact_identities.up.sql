@@ -0,0 +1,11 @@
+-- Satu identitas hanya boleh dimiliki satu kontak per client, agar pesan bersamaan dari nomor
+-- baru tidak membuat kontak ganda. Identitas ganda yang sudah ada dipertahankan pada kontak
+-- terlama, sama seperti kontak yang selama ini dipilih FindByIdentity.
+
+DELETE FROM contact_identities d
+USING contact_identities k
+WHERE d.client_id = k.client_id AND d.type = k.type AND d.value = k.value
+  AND (k.contact_id, k.identity_id) < (d.contact_id, d.identity_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS contact_identities_unique_idx ON contact_identities (client_id, type, value);
+DROP INDEX IF EXISTS contact_identities_lookup_idx;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS outbox_events_published_idx;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS locked_by;
@@ -0,0 +1,6 @@
+-- Relay yang mengklaim event dicatat agar relay yang lease-nya sudah habis tidak mencatat
+-- subscriber atau status event yang sudah diklaim ulang relay lain
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS locked_by TEXT;
+
+-- Dipakai job events.prune untuk menghapus event yang sudah terkirim
+CREATE INDEX IF NOT EXISTS outbox_events_published_idx ON outbox_events (published_at) WHERE status = 'published';
@@ -0,0 +1,8 @@
+// Package migrations berisi migrasi skema database bernomor versi. Setiap versi terdiri dari
+// file <versi>_<nama>.up.sql dan <versi>_<nama>.down.sql yang ikut di-embed ke binary.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
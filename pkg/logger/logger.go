@@ -0,0 +1,65 @@
+// Package logger mengatur log terstruktur (log/slog) aplikasi. Logger per request dibawa ctx
+// sehingga handler dan usecase menulis log yang sudah berisi ID request dan pengguna.
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"backend/config"
+)
+
+// redacted menggantikan nilai atribut sensitif
+const redacted = "[REDACTED]"
+
+// sensitiveKeys adalah potongan nama atribut yang nilainya tidak boleh masuk log
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "cookie", "api_key"}
+
+// New membuat logger sesuai konfigurasi. Atribut dengan nama sensitif selalu disamarkan.
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(cfg.Level), ReplaceAttr: Redact}
+	if cfg.Format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// ParseLevel mengubah nama level (debug, info, warn, error) menjadi slog.Level; default info
+func ParseLevel(name string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// Redact adalah ReplaceAttr yang menyamarkan nilai atribut sensitif, termasuk di dalam group
+func Redact(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindGroup {
+		return a
+	}
+	key := strings.ToLower(a.Key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	return a
+}
+
+type ctxKey struct{}
+
+// WithContext menyimpan logger di ctx
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// From mengembalikan logger yang dibawa ctx, atau logger default di luar request
+func From(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
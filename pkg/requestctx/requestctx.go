@@ -0,0 +1,63 @@
+// Package requestctx menyimpan nilai yang berlaku selama satu request (ID request, tenant dan
+// actor) di context.Context, sehingga usecase dan repository bisa membacanya tanpa bergantung
+// pada gin.
+package requestctx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	tenantKey
+	actorKey
+)
+
+// Actor adalah pengguna terautentikasi yang melakukan request
+type Actor struct {
+	UserID   int
+	Username string
+	ClientID int
+}
+
+// NewRequestID membuat ID request acak
+func NewRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID mengembalikan ID request, atau string kosong di luar request (misalnya di worker)
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithTenant(ctx context.Context, clientID int) context.Context {
+	return context.WithValue(ctx, tenantKey, clientID)
+}
+
+// Tenant mengembalikan client pemilik request, atau 0 jika belum diketahui
+func Tenant(ctx context.Context) int {
+	id, _ := ctx.Value(tenantKey).(int)
+	return id
+}
+
+// WithActor menyimpan actor sekaligus tenant-nya
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return WithTenant(context.WithValue(ctx, actorKey, a), a.ClientID)
+}
+
+// ActorFrom mengembalikan actor request; false untuk request tanpa autentikasi
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	a, ok := ctx.Value(actorKey).(Actor)
+	return a, ok
+}
@@ -0,0 +1,109 @@
+// Package safehttp menyediakan HTTP client untuk memanggil URL yang ditentukan tenant (webhook,
+// aksi automation). Koneksi ke alamat internal seperti loopback, jaringan privat dan link-local
+// (termasuk metadata cloud 169.254.169.254) ditolak, sehingga URL tersebut tidak bisa dipakai
+// untuk menjangkau layanan internal dari server.
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress dikembalikan saat tujuan adalah alamat internal
+var ErrForbiddenAddress = errors.New("destination address is not allowed")
+
+// blockedPrefixes adalah rentang non-publik di luar yang sudah dikenali method netip.Addr
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "Jaringan ini"
+	netip.MustParsePrefix("100.64.0.0/10"),  // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // Reserved dan broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, bisa memetakan ke IPv4 internal
+	netip.MustParsePrefix("64:ff9b:1::/48"), // NAT64 lokal
+	netip.MustParsePrefix("2002::/16"),      // 6to4, bisa membungkus IPv4 internal
+	netip.MustParsePrefix("2001::/32"),      // Teredo
+	netip.MustParsePrefix("192.88.99.0/24"), // 6to4 relay anycast
+}
+
+// Allowed memeriksa apakah ip adalah alamat unicast publik
+func Allowed(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// control memeriksa alamat yang sudah di-resolve tepat sebelum koneksi dibuat, sehingga DNS
+// yang berubah setelah validasi (DNS rebinding) dan redirect ke alamat internal ikut ditolak
+func control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !Allowed(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// NewClient membuat HTTP client yang hanya terhubung ke alamat publik. Proxy dari environment
+// tidak dipakai karena pemeriksaan alamat akan berlaku untuk proxy, bukan tujuan sebenarnya.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// CheckURL memvalidasi URL tujuan saat disimpan: harus http(s) dengan host, dan tidak boleh
+// menunjuk langsung ke IP internal atau localhost. Host berupa nama domain tetap diperiksa lagi
+// oleh client NewClient saat koneksi dibuat.
+func CheckURL(raw string) (*url.URL, error) {
+	target, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, err
+	}
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https url")
+	}
+	host := strings.TrimSuffix(strings.ToLower(target.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, ErrForbiddenAddress
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !Allowed(ip) {
+		return nil, ErrForbiddenAddress
+	}
+	return target, nil
+}
+
+// Snippet mengembalikan potongan teks yang aman disimpan dan ditampilkan dari body respons
+// pihak luar: paling banyak limit byte, UTF-8 valid dan tanpa karakter kontrol
+func Snippet(body []byte, limit int) string {
+	if len(body) > limit {
+		body = body[:limit]
+	}
+	s := strings.ToValidUTF8(string(body), "")
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return ' '
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCertCheckInterval adalah jarak minimum antar pemeriksaan perubahan file sertifikat
+const DefaultCertCheckInterval = 30 * time.Second
+
+// CertReloader menyajikan sertifikat TLS dari file dan membacanya ulang saat file berubah,
+// misalnya setelah diperbarui cert-manager atau certbot. Jika file baru tidak valid (misalnya
+// sertifikat sudah ditulis tetapi kuncinya belum), sertifikat lama tetap dipakai.
+type CertReloader struct {
+	// CheckInterval membatasi seberapa sering handshake memeriksa waktu modifikasi file
+	CheckInterval time.Duration
+
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// NewCertReloader memuat pasangan sertifikat dan kunci; error jika file tidak bisa dibaca
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{CheckInterval: DefaultCertCheckInterval, certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload membaca ulang sertifikat dan kunci dari file
+func (r *CertReloader) Reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.checked = time.Now()
+	return nil
+}
+
+// GetCertificate dipakai sebagai tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.reloadIfChanged()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *CertReloader) reloadIfChanged() {
+	r.mu.Lock()
+	if time.Since(r.checked) < r.CheckInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.checked = time.Now()
+	current := r.modTime
+	r.mu.Unlock()
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		slog.Error("Failed to check TLS certificate", "error", err)
+		return
+	}
+	if modTime.Equal(current) {
+		return
+	}
+	if err := r.Reload(); err != nil {
+		slog.Error("Failed to reload TLS certificate, keeping current one", "error", err)
+		return
+	}
+	slog.Info("TLS certificate reloaded", "cert_file", r.certFile)
+}
+
+// latestModTime mengembalikan waktu modifikasi terbaru dari file sertifikat dan kunci
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to read TLS file: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
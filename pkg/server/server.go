@@ -0,0 +1,65 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"time"
+
+	"backend/config"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// New membuat http.Server dengan timeout dari konfigurasi. Jika file sertifikat diisi, TLS
+// diaktifkan dengan sertifikat dari CertReloader sehingga pembaruan sertifikat tidak perlu restart.
+func New(cfg config.ServerConfig, handler http.Handler) (*http.Server, error) {
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(cfg.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.IdleTimeout),
+	}
+	if cfg.TLSCertFile != "" {
+		certs, err := NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+	}
+	return srv, nil
+}
+
+// NewMetrics membuat http.Server internal yang hanya melayani /metrics Prometheus. Server ini
+// berjalan di listener terpisah agar metrik tidak bisa diakses dari alamat publik.
+func NewMetrics(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute,
+		WriteTimeout:      time.Minute,
+	}
+}
+
+// ListenAndServe menjalankan srv dengan TLS jika TLSConfig diisi. Berhenti karena Shutdown
+// tidak dianggap error.
+func ListenAndServe(srv *http.Server) error {
+	var err error
+	if srv.TLSConfig != nil {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+// Package txn menjalankan beberapa panggilan repository sebagai satu unit of work. Transaksi
+// dibawa ctx, sehingga repository yang memilih koneksi lewat Querier otomatis ikut transaksi
+// yang sedang berjalan tanpa perlu dibuat ulang.
+package txn
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Querier adalah bagian dari *sql.DB dan *sql.Tx yang dipakai repository
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Runner menjalankan fungsi di dalam transaksi
+type Runner interface {
+	// Do menjalankan fn dalam transaksi dan commit jika fn berhasil. Error atau panic dari fn
+	// me-rollback transaksi; panic diteruskan setelah rollback. Jika ctx sudah membawa transaksi,
+	// fn dijalankan dalam SAVEPOINT sehingga kegagalannya hanya membatalkan perubahan fn sendiri.
+	// Transaksi terluar diulang dari awal saat Postgres melaporkan serialization failure atau
+	// deadlock, sehingga fn tidak boleh punya efek samping di luar database.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Percobaan ulang untuk konflik transaksi
+const (
+	maxAttempts  = 3
+	retryBackoff = 20 * time.Millisecond
+)
+
+// Kode SQLSTATE yang aman diulang karena seluruh transaksi sudah dibatalkan server
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+type txKey struct{}
+
+// txState adalah transaksi yang dibawa ctx beserta kedalaman savepoint-nya
+type txState struct {
+	tx    *sql.Tx
+	depth int
+}
+
+type runner struct {
+	db *sql.DB
+}
+
+func NewRunner(db *sql.DB) Runner {
+	return &runner{db: db}
+}
+
+// From mengembalikan transaksi yang dibawa ctx, atau db jika ctx tidak berada di dalam Do
+func From(ctx context.Context, db Querier) Querier {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db
+}
+
+func (r *runner) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return savepoint(ctx, state, fn)
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = r.run(ctx, fn); err == nil || !Retryable(err) || attempt == maxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryBackoff * time.Duration(attempt)):
+		}
+	}
+	return err
+}
+
+// run menjalankan satu percobaan transaksi terluar
+func (r *runner) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// savepoint menjalankan fn di dalam transaksi yang sudah ada
+func savepoint(ctx context.Context, parent *txState, fn func(ctx context.Context) error) (err error) {
+	state := &txState{tx: parent.tx, depth: parent.depth + 1}
+	name := fmt.Sprintf("sp_%d", state.depth)
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			state.tx.ExecContext(context.WithoutCancel(ctx), "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+		if err != nil {
+			if _, rbErr := state.tx.ExecContext(context.WithoutCancel(ctx), "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+				err = errors.Join(err, rbErr)
+			}
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+	_, err = state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// Retryable bernilai true untuk konflik transaksi yang bisa diselesaikan dengan mengulang
+func Retryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == codeSerializationFailure || pqErr.Code == codeDeadlockDetected
+	}
+	return false
+}
@@ -2,41 +2,50 @@ package utils
 
 import (
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-// Secret key untuk signing dan verifying token
-var secretKey = []byte("mysecretkey")
+// Secret key untuk signing dan verifying token, diisi dari konfigurasi saat start
+var secretKey []byte
+
+// SetSecretKey mengatur secret key JWT. Harus dipanggil sebelum server menerima request.
+func SetSecretKey(key []byte) {
+	secretKey = key
+}
 
 // Claims adalah struct custom untuk payload JWT
 type Claims struct {
 	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
+	ClientID int    `json:"client_id"`
 	jwt.RegisteredClaims
 }
 
-// CreateToken untuk membuat JWT dari user ID dan username
-func CreateToken(userID int, username string) (string, error) {
+// CreateToken untuk membuat JWT dari user ID, username dan client (tenant) pengguna
+func CreateToken(userID int, username string, clientID int) (string, error) {
 	// Atur klaim (payload)
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
+		ClientID: clientID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "myapp",                                            // Pengeluarnya (issuer)
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // Token valid selama 24 jam
 		},
 	}
 
+	if len(secretKey) == 0 {
+		return "", fmt.Errorf("jwt secret key is not configured")
+	}
+
 	// Membuat token JWT dengan algoritma HMAC dan klaim
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Tanda tangani token dengan secretKey
 	tokenString, err := token.SignedString(secretKey)
 	if err != nil {
-		log.Println("Error signing token:", err)
 		return "", err
 	}
 	return tokenString, nil
@@ -50,6 +59,9 @@ func VerifyToken(tokenString string) (*Claims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
 		}
+		if len(secretKey) == 0 {
+			return nil, fmt.Errorf("jwt secret key is not configured")
+		}
 		// Mengembalikan secret key untuk verifikasi
 		return secretKey, nil
 	})
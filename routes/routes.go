@@ -1,25 +1,264 @@
 package routes
 
 import (
+	"backend/config"
+	analyticsDelivery "backend/internal/analytics/delivery"
+	analyticsRepository "backend/internal/analytics/repository"
+	analyticsUsecase "backend/internal/analytics/usecase"
+	attachmentDelivery "backend/internal/attachments/delivery"
+	attachmentRepository "backend/internal/attachments/repository"
+	attachmentStorage "backend/internal/attachments/storage"
+	attachmentUsecase "backend/internal/attachments/usecase"
+	automationDelivery "backend/internal/automation/delivery"
+	automationRepository "backend/internal/automation/repository"
+	automationUsecase "backend/internal/automation/usecase"
+	businessHoursDelivery "backend/internal/businesshours/delivery"
+	businessHoursRepository "backend/internal/businesshours/repository"
+	businessHoursUsecase "backend/internal/businesshours/usecase"
+	cannedDelivery "backend/internal/canned/delivery"
+	cannedRepository "backend/internal/canned/repository"
+	cannedUsecase "backend/internal/canned/usecase"
+	contactDelivery "backend/internal/contacts/delivery"
+	contactRepository "backend/internal/contacts/repository"
+	contactUsecase "backend/internal/contacts/usecase"
+	"backend/internal/conversations"
+	conversationDelivery "backend/internal/conversations/delivery"
+	conversationRepository "backend/internal/conversations/repository"
+	conversationUsecase "backend/internal/conversations/usecase"
+	"backend/internal/events"
+	eventsRepository "backend/internal/events/repository"
+	eventsUsecase "backend/internal/events/usecase"
+	"backend/internal/health"
+	healthDelivery "backend/internal/health/delivery"
+	"backend/internal/jobs"
+	jobDelivery "backend/internal/jobs/delivery"
+	jobRepository "backend/internal/jobs/repository"
+	jobUsecase "backend/internal/jobs/usecase"
+	"backend/internal/migrate"
+	migrateRepository "backend/internal/migrate/repository"
+	migrateUsecase "backend/internal/migrate/usecase"
+	presenceDelivery "backend/internal/presence/delivery"
+	presenceRepository "backend/internal/presence/repository"
+	presenceUsecase "backend/internal/presence/usecase"
+	"backend/internal/realtime"
+	realtimeDelivery "backend/internal/realtime/delivery"
+	routingDelivery "backend/internal/routing/delivery"
+	routingRepository "backend/internal/routing/repository"
+	routingUsecase "backend/internal/routing/usecase"
+	searchDelivery "backend/internal/search/delivery"
+	searchRepository "backend/internal/search/repository"
+	searchUsecase "backend/internal/search/usecase"
+	slaDelivery "backend/internal/sla/delivery"
+	slaRepository "backend/internal/sla/repository"
+	slaUsecase "backend/internal/sla/usecase"
+	smsDelivery "backend/internal/sms/delivery"
+	smsProvider "backend/internal/sms/provider"
+	smsRepository "backend/internal/sms/repository"
+	smsUsecase "backend/internal/sms/usecase"
+	surveyDelivery "backend/internal/surveys/delivery"
+	surveyRepository "backend/internal/surveys/repository"
+	surveyUsecase "backend/internal/surveys/usecase"
+	teamDelivery "backend/internal/teams/delivery"
+	teamRepository "backend/internal/teams/repository"
+	teamUsecase "backend/internal/teams/usecase"
+	"backend/internal/users"
 	"backend/internal/users/delivery"
 	"backend/internal/users/repository"
 	"backend/internal/users/usecase"
+	webhookDelivery "backend/internal/webhooks/delivery"
+	webhookRepository "backend/internal/webhooks/repository"
+	webhookUsecase "backend/internal/webhooks/usecase"
 	"backend/middleware"
+	"backend/migrations"
+	"context"
+	"crypto/rand"
 	"database/sql"
-	"log"
+	"log/slog"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
-func SetupRoutes(router *gin.Engine, db *sql.DB) {
+// Worker adalah proses latar belakang yang berjalan sampai ctx dibatalkan
+type Worker func(ctx context.Context)
+
+// SetupRoutes mendaftarkan semua route dan mengembalikan worker latar belakang
+// yang harus dijalankan oleh pemanggil
+func SetupRoutes(router *gin.Engine, db *sql.DB, hub *realtime.Hub, cfg *config.Config) []Worker {
+	// Setiap request mendapat ID dan deadline. Stream real-time dan transfer file berjalan lebih
+	// lama dari deadline request; batasnya diatur timeout server dan deadline tulis stream.
+	// Logger per request (dengan ID request) dibawa ctx ke handler dan usecase, lalu access log
+	// dan metrik request ditulis setelah request selesai; panic di handler dicatat dan dijawab 500.
+	router.Use(middleware.RequestContext(time.Duration(cfg.Server.RequestTimeout),
+		"/api/realtime", "/files/:id", "/api/attachments", "/api/conversations/:id/attachments"),
+		middleware.Logger(slog.Default()), middleware.Metrics(), middleware.Recovery())
+
 	// Setup User Repository dan Usecase
 	userRepo := repository.NewUserRepository(db)
 	userUsecase := usecase.NewUserUsecase(userRepo)
 	userHandler := delivery.NewUserHandler(userUsecase)
 
+	// Setup antrean job latar belakang; handler job didaftarkan oleh fitur yang membutuhkannya
+	jobQueue := jobUsecase.NewJobQueue(jobRepository.NewJobRepository(db))
+	jobHandler := jobDelivery.NewJobHandler(jobQueue)
+	if err := jobQueue.RegisterCron("jobs.prune", "@daily", jobUsecase.PruneJobs{RetentionHours: 14 * 24}); err != nil {
+		fatal("Failed to register job schedule", "error", err)
+	}
+
+	// Setup Contact
+	contactRepo := contactRepository.NewContactRepository(db)
+	contactUc := contactUsecase.NewContactUsecase(contactRepo)
+	contactHandler := contactDelivery.NewContactHandler(contactUc)
+
+	// Setup routing engine untuk assignment percakapan ke agent
+	routingRepo := routingRepository.NewRoutingRepository(db)
+	routingUc := routingUsecase.NewRoutingUsecase(routingRepo, hub)
+	routingHandler := routingDelivery.NewRoutingHandler(routingUc)
+
+	// Setup Team (antrean) dan keanggotaan agent
+	teamRepo := teamRepository.NewTeamRepository(db)
+	teamUc := teamUsecase.NewTeamUsecase(teamRepo, routingUc, hub)
+	teamHandler := teamDelivery.NewTeamHandler(teamUc)
+
+	// Setup jam kerja dan hari libur per client/tim
+	businessHoursRepo := businessHoursRepository.NewBusinessHoursRepository(db)
+	businessHoursUc := businessHoursUsecase.NewBusinessHoursUsecase(businessHoursRepo)
+	businessHoursHandler := businessHoursDelivery.NewBusinessHoursHandler(businessHoursUc)
+
+	// Setup SLA; jam SLA hanya berjalan pada jam kerja jika kebijakan memintanya
+	slaRepo := slaRepository.NewSLARepository(db)
+	slaUc := slaUsecase.NewSLAUsecase(slaRepo, businessHoursUc, hub)
+	slaHandler := slaDelivery.NewSLAHandler(slaUc)
+
+	// Setup Conversation
+	conversationRepo := conversationRepository.NewConversationRepository(db)
+	convUsecase := conversationUsecase.NewConversationUsecase(conversationRepo, contactUc, routingUc, slaUc, hub)
+	conversationHandler := conversationDelivery.NewConversationHandler(convUsecase)
+
+	// Setup survei CSAT/NPS; survei dijadwalkan saat percakapan diselesaikan dan dikirim lewat
+	// channel percakapan yang didaftarkan ke surveySenders setelah channel dibuat
+	surveySenders := map[string]surveyUsecase.Notifier{}
+	surveyUc := surveyUsecase.NewSurveyUsecase(surveyRepository.NewSurveyRepository(db), convUsecase, surveySenders, jobQueue,
+		signingKey("SURVEY_SIGNING_KEY", cfg.Signing.SurveyKey), cfg.PublicBaseURL)
+	surveyHandler := surveyDelivery.NewSurveyHandler(surveyUc)
+	jobQueue.Register(surveyUsecase.SendSurvey{}.JobType(), jobs.Typed(surveyUc.SendSurvey))
+
+	// Setup SMS channel dengan provider yang tersedia
+	smsProviders := smsProvider.NewRegistry(smsProvider.NewTwilio(cfg.Twilio.APIBaseURL))
+	smsRepo := smsRepository.NewSmsRepository(db)
+	smsUc := smsUsecase.NewSmsUsecase(smsRepo, smsProviders, convUsecase, contactUc, businessHoursUc, surveyUc, cfg.PublicBaseURL)
+	surveySenders[conversations.ChannelSMS] = smsUc
+	smsHandler := smsDelivery.NewSmsHandler(smsUc, cfg.PublicBaseURL)
+
+	// Setup attachment; isi file disimpan di storage, thumbnail dibuat oleh antrean job
+	attachmentStore := newAttachmentStorage(cfg.Storage)
+	attachmentRepo := attachmentRepository.NewAttachmentRepository(db)
+	attachmentUc := attachmentUsecase.NewAttachmentUsecase(attachmentRepo, attachmentStore, convUsecase, jobQueue,
+		signingKey("ATTACHMENT_SIGNING_KEY", cfg.Signing.AttachmentKey), cfg.PublicBaseURL)
+	attachmentHandler := attachmentDelivery.NewAttachmentHandler(attachmentUc)
+	jobQueue.Register(attachmentUsecase.GenerateThumbnail{}.JobType(), jobs.Typed(attachmentUc.GenerateThumbnail))
+
+	// Setup presence agent; ketersediaan agent untuk routing mengikuti status presence
+	presenceRepo := presenceRepository.NewPresenceRepository(db)
+	presenceUc := presenceUsecase.NewPresenceUsecase(presenceRepo, routingUc, hub)
+	presenceHandler := presenceDelivery.NewPresenceHandler(presenceUc)
+
+	// Setup canned response; balasan dikirim melalui channel percakapan
+	cannedRepo := cannedRepository.NewCannedRepository(db)
+	cannedUc := cannedUsecase.NewCannedUsecase(cannedRepo, convUsecase, contactUc, map[string]cannedUsecase.ReplySender{
+		conversations.ChannelSMS: smsUc,
+	})
+	cannedHandler := cannedDelivery.NewCannedHandler(cannedUc)
+
+	// Setup automation rule; rule dipicu oleh event percakapan dari outbox melalui event bus
+	automationRepo := automationRepository.NewAutomationRepository(db)
+	automationUc := automationUsecase.NewAutomationUsecase(automationRepo, convUsecase, contactUc, routingUc, teamUc, cannedUc, nil)
+	automationHandler := automationDelivery.NewAutomationHandler(automationUc)
+
+	// Setup webhook keluar; event dari outbox disimpan sebagai delivery durable lalu dikirim oleh dispatcher
+	webhookRepo := webhookRepository.NewWebhookRepository(db)
+	webhookUc := webhookUsecase.NewWebhookUsecase(webhookRepo, nil)
+	webhookHandler := webhookDelivery.NewWebhookHandler(webhookUc)
+
+	// Setup event bus; usecase menulis event ke outbox dalam transaksi perubahan datanya lalu relay
+	// meneruskannya ke subscriber secara at-least-once. Event percakapan, SLA dan presence sudah
+	// dikirim langsung ke hub oleh usecase-nya, jadi hanya event domain user yang diteruskan ke hub.
+	eventBus := eventsUsecase.NewEventBus(eventsRepository.NewOutboxRepository(db))
+	eventBus.Subscribe("webhooks", webhookUc.HandleEvent, webhookUsecase.SubscribableEvents...)
+	eventBus.Subscribe("realtime", func(ctx context.Context, e events.Event) error {
+		return hub.Publish(realtime.Event{Type: e.Type, ClientID: e.ClientID, Data: e, CreatedAt: e.OccurredAt})
+	}, users.EventCreated, users.EventDeleted)
+	eventBus.Subscribe("automation", automationUc.HandleEvent, automationUsecase.SourceEvents...)
+	eventBus.Subscribe("surveys", surveyUc.HandleEvent, realtime.EventConversationStatusChanged)
+	jobQueue.Register(eventsUsecase.PruneEvents{}.JobType(), jobs.Typed(eventBus.PruneEvents))
+	if err := jobQueue.RegisterCron("events.prune", "@daily", eventsUsecase.PruneEvents{RetentionHours: 7 * 24}); err != nil {
+		fatal("Failed to register job schedule", "error", err)
+	}
+
+	// Setup pencarian full-text; pesan baru diindeks dari event bus, indexer latar belakang
+	// mengindeks kontak dan pesan yang tertinggal
+	searchUc := searchUsecase.NewSearchUsecase(searchRepository.NewSearchRepository(db))
+	searchHandler := searchDelivery.NewSearchHandler(searchUc)
+	eventBus.Subscribe("search", searchUc.HandleEvent, realtime.EventMessageCreated)
+
+	// Setup analytics; rollup per jam diperbarui antrean job, jadwal malam menutup data yang terlambat
+	analyticsUc := analyticsUsecase.NewAnalyticsUsecase(analyticsRepository.NewAnalyticsRepository(db), jobQueue)
+	analyticsHandler := analyticsDelivery.NewAnalyticsHandler(analyticsUc)
+	jobQueue.Register(analyticsUsecase.RefreshRollups{}.JobType(), jobs.Typed(analyticsUc.RefreshRollups))
+	if err := jobQueue.RegisterCron("analytics.rollup", "*/5 * * * *", analyticsUsecase.RefreshRollups{LookbackHours: 3}); err != nil {
+		fatal("Failed to register job schedule", "error", err)
+	}
+	if err := jobQueue.RegisterCron("analytics.rollup.nightly", "30 2 * * *", analyticsUsecase.RefreshRollups{LookbackHours: 72}); err != nil {
+		fatal("Failed to register job schedule", "error", err)
+	}
+
+	// Setup probe kesehatan; readiness gagal selama database tidak bisa di-ping atau migrasi tertunda
+	migrationList, err := migrate.Load(migrations.FS)
+	if err != nil {
+		fatal("Invalid migrations", "error", err)
+	}
+	migrator := migrateUsecase.NewMigrator(migrateRepository.NewMigrationRepository(db), migrationList)
+	healthHandler := healthDelivery.NewHealthHandler(
+		health.Check{Name: "database", Run: db.PingContext},
+		health.Check{Name: "migrations", Run: migrator.Check},
+	)
+	// Statistik pool koneksi dan kedalaman antrean job dibaca saat /metrics di-scrape
+	for _, c := range []prometheus.Collector{
+		collectors.NewDBStatsCollector(db, cfg.Database.Name),
+		jobUsecase.DepthCollector(jobQueue),
+	} {
+		if err := prometheus.Register(c); err != nil {
+			fatal("Failed to register metrics collector", "error", err)
+		}
+	}
+
+	// Setup stream real-time untuk agent; koneksi stream dipakai untuk mendeteksi agent idle/terputus
+	realtimeHandler := realtimeDelivery.NewRealtimeHandler(hub, presenceUc)
+
+	// Probe liveness/readiness; metrik Prometheus dilayani listener internal terpisah (metrics.addr)
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+
 	// Setup routes untuk User
 	router.POST("/api/login", userHandler.Login)
 
+	// Webhook dari provider channel (diautentikasi dengan signature provider, bukan JWT)
+	router.POST("/webhooks/sms/:provider/:client_id", smsHandler.Inbound)
+	router.POST("/webhooks/sms/:provider/:client_id/status", smsHandler.Status)
+
+	// Download attachment dengan URL bertanda tangan yang kedaluwarsa (tanpa JWT)
+	router.GET("/files/:id", attachmentHandler.Download)
+
+	// Formulir survei pelanggan dengan link bertanda tangan (tanpa JWT)
+	router.GET("/surveys/:id", surveyHandler.GetForm)
+	router.POST("/surveys/:id", surveyHandler.SubmitForm)
+
+	// Stream real-time (WebSocket dengan fallback SSE); token juga diterima dari query string
+	router.GET("/api/realtime", middleware.StreamJWTMiddleware(db), realtimeHandler.Stream)
+
 	// Routes dengan autentikasi JWT
 	auth := router.Group("/api")
 	auth.Use(middleware.JWTMiddleware(db)) // Menggunakan JWT Middleware
@@ -28,8 +267,181 @@ func SetupRoutes(router *gin.Engine, db *sql.DB) {
 		auth.POST("/users/delete", userHandler.DeleteUser)
 		auth.POST("/users", userHandler.CreateUser)
 
+		auth.GET("/contacts", contactHandler.GetContacts)
+		auth.POST("/contacts", contactHandler.CreateContact)
+		auth.GET("/contacts/duplicates", contactHandler.GetDuplicates)
+		auth.GET("/contacts/:id", contactHandler.GetContact)
+		auth.PUT("/contacts/:id", contactHandler.UpdateContact)
+		auth.DELETE("/contacts/:id", contactHandler.DeleteContact)
+		auth.POST("/contacts/:id/identities", contactHandler.AddIdentity)
+		auth.DELETE("/contacts/:id/identities/:identity_id", contactHandler.RemoveIdentity)
+		auth.PUT("/contacts/:id/tags", contactHandler.SetTags)
+		auth.POST("/contacts/:id/merge", contactHandler.Merge)
+		auth.GET("/contacts/:id/conversations", conversationHandler.GetContactConversations)
+
+		auth.GET("/conversations", conversationHandler.GetConversations)
+		auth.GET("/conversations/:id", conversationHandler.GetConversation)
+		auth.GET("/conversations/:id/messages", conversationHandler.GetMessages)
+		auth.GET("/conversations/:id/attachments", attachmentHandler.GetConversationAttachments)
+		auth.POST("/conversations/:id/attachments", attachmentHandler.Upload)
+		auth.PUT("/conversations/:id/status", conversationHandler.UpdateStatus)
+		auth.PUT("/conversations/:id/priority", conversationHandler.SetPriority)
+		auth.POST("/conversations/:id/assign", routingHandler.Assign)
+		auth.POST("/conversations/:id/transfer", routingHandler.Transfer)
+		auth.PUT("/conversations/:id/skills", routingHandler.SetRequiredSkills)
+		auth.GET("/conversations/:id/assignments", routingHandler.GetAssignments)
+		auth.POST("/conversations/:id/team", teamHandler.Enqueue)
+
+		auth.GET("/teams", teamHandler.GetTeams)
+		auth.POST("/teams", teamHandler.CreateTeam)
+		auth.GET("/teams/mine", teamHandler.GetMyTeams)
+		auth.GET("/teams/:id", teamHandler.GetTeam)
+		auth.PUT("/teams/:id", teamHandler.UpdateTeam)
+		auth.DELETE("/teams/:id", teamHandler.DeleteTeam)
+		auth.GET("/teams/:id/members", teamHandler.GetMembers)
+		auth.POST("/teams/:id/members", teamHandler.AddMember)
+		auth.DELETE("/teams/:id/members/:user_id", teamHandler.RemoveMember)
+		auth.GET("/teams/:id/queue", teamHandler.GetQueue)
+
+		auth.GET("/routing/settings", routingHandler.GetSettings)
+		auth.PUT("/routing/settings", routingHandler.UpdateSettings)
+		auth.GET("/routing/agents", routingHandler.GetAgents)
+		auth.PUT("/routing/agents/:user_id", routingHandler.UpdateAgent)
+		auth.PUT("/routing/agents/:user_id/skills", routingHandler.SetAgentSkills)
+
+		auth.GET("/canned-responses", cannedHandler.GetResponses)
+		auth.POST("/canned-responses", cannedHandler.CreateResponse)
+		auth.GET("/canned-responses/search", cannedHandler.Search)
+		auth.GET("/canned-responses/:id", cannedHandler.GetResponse)
+		auth.PUT("/canned-responses/:id", cannedHandler.UpdateResponse)
+		auth.DELETE("/canned-responses/:id", cannedHandler.DeleteResponse)
+		auth.POST("/canned-responses/:id/render", cannedHandler.Render)
+		auth.POST("/canned-responses/:id/send", cannedHandler.Send)
+
+		auth.GET("/automation/rules", automationHandler.GetRules)
+		auth.POST("/automation/rules", automationHandler.CreateRule)
+		auth.POST("/automation/rules/validate", automationHandler.ValidateRule)
+		auth.GET("/automation/rules/:id", automationHandler.GetRule)
+		auth.PUT("/automation/rules/:id", automationHandler.UpdateRule)
+		auth.DELETE("/automation/rules/:id", automationHandler.DeleteRule)
+		auth.GET("/automation/executions", automationHandler.GetExecutions)
+
+		auth.GET("/webhooks/subscriptions", webhookHandler.GetSubscriptions)
+		auth.POST("/webhooks/subscriptions", webhookHandler.CreateSubscription)
+		auth.GET("/webhooks/subscriptions/:id", webhookHandler.GetSubscription)
+		auth.PUT("/webhooks/subscriptions/:id", webhookHandler.UpdateSubscription)
+		auth.DELETE("/webhooks/subscriptions/:id", webhookHandler.DeleteSubscription)
+		auth.POST("/webhooks/subscriptions/:id/rotate-secret", webhookHandler.RotateSecret)
+		auth.GET("/webhooks/deliveries", webhookHandler.GetDeliveries)
+		auth.GET("/webhooks/deliveries/:id", webhookHandler.GetDelivery)
+		auth.POST("/webhooks/deliveries/:id/redeliver", webhookHandler.Redeliver)
+
+		auth.POST("/attachments", attachmentHandler.Upload)
+		auth.GET("/attachments/:id", attachmentHandler.GetAttachment)
+		auth.DELETE("/attachments/:id", attachmentHandler.DeleteAttachment)
+		auth.GET("/storage/usage", attachmentHandler.GetUsage)
+
+		auth.GET("/search", searchHandler.Search)
+		auth.GET("/search/settings", searchHandler.GetSettings)
+		auth.PUT("/search/settings", searchHandler.UpdateSettings)
+
+		auth.GET("/surveys/settings", surveyHandler.GetSettings)
+		auth.PUT("/surveys/settings", surveyHandler.UpdateSettings)
+		auth.GET("/surveys/responses", surveyHandler.GetResponses)
+
+		auth.GET("/analytics/summary", analyticsHandler.GetSummary)
+		auth.GET("/analytics/timeseries", analyticsHandler.GetTimeseries)
+		auth.GET("/analytics/agents", analyticsHandler.GetAgents)
+		auth.GET("/analytics/teams", analyticsHandler.GetTeams)
+		auth.GET("/analytics/channels", analyticsHandler.GetChannels)
+		auth.POST("/analytics/rebuild", analyticsHandler.Rebuild)
+
+		auth.GET("/jobs", jobHandler.GetJobs)
+		auth.GET("/jobs/:id", jobHandler.GetJob)
+		auth.POST("/jobs/:id/retry", jobHandler.Retry)
+
+		auth.GET("/business-hours", businessHoursHandler.GetSchedule)
+		auth.PUT("/business-hours", businessHoursHandler.SaveSchedule)
+		auth.GET("/business-hours/status", businessHoursHandler.GetStatus)
+		auth.DELETE("/business-hours/teams/:team_id", businessHoursHandler.DeleteTeamSchedule)
+		auth.GET("/holidays", businessHoursHandler.GetHolidays)
+		auth.POST("/holidays", businessHoursHandler.CreateHoliday)
+		auth.DELETE("/holidays/:id", businessHoursHandler.DeleteHoliday)
+
+		auth.GET("/sla/policies", slaHandler.GetPolicies)
+		auth.POST("/sla/policies", slaHandler.CreatePolicy)
+		auth.GET("/sla/policies/:id", slaHandler.GetPolicy)
+		auth.PUT("/sla/policies/:id", slaHandler.UpdatePolicy)
+		auth.DELETE("/sla/policies/:id", slaHandler.DeletePolicy)
+
+		auth.GET("/presence", presenceHandler.GetPresence)
+		auth.PUT("/presence/status", presenceHandler.SetMyStatus)
+		auth.POST("/presence/activity", presenceHandler.RecordActivity)
+		auth.PUT("/presence/agents/:user_id/status", presenceHandler.SetAgentStatus)
+		auth.GET("/presence/teams/:team_id", presenceHandler.GetTeamPresence)
+		auth.GET("/presence/history", presenceHandler.GetHistory)
+		auth.GET("/presence/settings", presenceHandler.GetSettings)
+		auth.PUT("/presence/settings", presenceHandler.UpdateSettings)
+
+		auth.POST("/sms/send", smsHandler.Send)
+		auth.POST("/sms/estimate", smsHandler.Estimate)
+		auth.GET("/sms/opt-outs", smsHandler.GetOptOuts)
 	}
 	for _, route := range router.Routes() {
-		log.Printf("Route %s %s", route.Method, route.Path)
+		slog.Debug("Route registered", "method", route.Method, "path", route.Path)
+	}
+	middleware.InitRouteMetrics(router.Routes())
+
+	return []Worker{
+		func(ctx context.Context) { teamUc.RunOverflowChecker(ctx, 30*time.Second) },
+		// Interval harus di bawah TTL heartbeat koneksi presence (90 detik)
+		func(ctx context.Context) { presenceUc.RunSweeper(ctx, hub, 30*time.Second) },
+		func(ctx context.Context) { slaUc.RunScheduler(ctx, 30*time.Second) },
+		func(ctx context.Context) { webhookUc.RunDispatcher(ctx, 5*time.Second) },
+		func(ctx context.Context) { eventBus.RunRelay(ctx, time.Second) },
+		func(ctx context.Context) { jobQueue.Run(ctx, 4) },
+		func(ctx context.Context) { searchUc.RunIndexer(ctx, 5*time.Second) },
+	}
+}
+
+// newAttachmentStorage memilih storage attachment sesuai storage.driver ("local" atau "s3")
+func newAttachmentStorage(cfg config.StorageConfig) attachmentStorage.Storage {
+	if cfg.Driver == "s3" {
+		store, err := attachmentStorage.NewS3(attachmentStorage.S3Config{
+			Endpoint:        cfg.S3.Endpoint,
+			Region:          cfg.S3.Region,
+			Bucket:          cfg.S3.Bucket,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			PathStyle:       cfg.S3.PathStyle,
+		}, nil)
+		if err != nil {
+			fatal("Failed to configure S3 storage", "error", err)
+		}
+		return store
 	}
+
+	store, err := attachmentStorage.NewLocal(cfg.LocalDir)
+	if err != nil {
+		fatal("Failed to configure local storage", "error", err)
+	}
+	return store
+}
+
+// signingKey mengembalikan kunci penandatanganan link dari konfigurasi. Tanpa kunci tersebut
+// dipakai kunci acak, sehingga link hanya berlaku di replika ini sampai proses dimulai ulang.
+func signingKey(env, key string) []byte {
+	if key != "" {
+		return []byte(key)
+	}
+	slog.Warn("Signing key is not set; using a random key", "env", env)
+	random := make([]byte, 32)
+	rand.Read(random)
+	return random
+}
+
+// fatal mencatat kesalahan konfigurasi route lalu menghentikan proses
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/analytics/delivery"
+	"backend/internal/analytics/repository"
+	"backend/internal/analytics/usecase"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var analyticsColumns = []string{"bucket", "channel", "team_id", "agent_id", "name",
+	"created", "resolved", "first_responses", "first_response_seconds", "handled", "handle_seconds",
+	"sla_met", "sla_breached", "csat_responses", "csat_score_sum", "csat_satisfied", "nps_responses", "nps_promoters", "nps_detractors"}
+
+// TestAnalyticsMetrics tests derived ratios and empty denominators
+func TestAnalyticsMetrics(t *testing.T) {
+	m := analytics.Totals{
+		Created: 40, Resolved: 30, FirstResponses: 3, FirstResponseSeconds: 200,
+		SLAMet: 9, SLABreached: 3, CSATResponses: 4, CSATScoreSum: 15, CSATSatisfied: 3,
+	}.Metrics()
+
+	assert.Equal(t, 75.0, *m.ResolutionRate)
+	assert.Equal(t, 66.67, *m.AvgFirstResponseSeconds)
+	assert.Nil(t, m.AvgHandleSeconds)
+	assert.Equal(t, 75.0, *m.SLACompliance)
+	assert.Equal(t, 3.75, *m.CSATAverage)
+	assert.Equal(t, 75.0, *m.CSATScore)
+
+	empty := analytics.Totals{}.Metrics()
+	assert.Nil(t, empty.ResolutionRate)
+	assert.Nil(t, empty.CSATAverage)
+}
+
+// TestAnalyticsTimeseries_ClientTimezone tests daily buckets in the client timezone with gaps filled
+func TestAnalyticsTimeseries_ClientTimezone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	uc := usecase.NewAnalyticsUsecase(repository.NewAnalyticsRepository(db), &fakeJobEnqueuer{})
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 3)
+	day2 := from.AddDate(0, 0, 1)
+
+	mock.ExpectQuery("SELECT timezone FROM clients").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"timezone"}).AddRow("Asia/Kolkata"))
+	mock.ExpectQuery("date_trunc\\('day', a.bucket, \\$7\\)").
+		WithArgs(1, from, to, "sms", 0, 5, "Asia/Kolkata").
+		WillReturnRows(sqlmock.NewRows(analyticsColumns).
+			AddRow(day2, "", nil, nil, "", 10, 5, 4, 400, 5, 3000, 2, 0, 0, 0, 0, 0, 0, 0))
+
+	report, err := uc.Timeseries(1, analytics.Filter{From: from, To: to, Channel: "sms", AgentID: 5}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, analytics.GroupDay, report.Interval)
+	if assert.Len(t, report.Rows, 3) {
+		assert.True(t, report.Rows[0].Bucket.Equal(from))
+		assert.Equal(t, 0, report.Rows[0].Conversations)
+		assert.True(t, report.Rows[1].Bucket.Equal(day2))
+		assert.Equal(t, 10, report.Rows[1].Conversations)
+		assert.Equal(t, 600.0, *report.Rows[1].AvgHandleSeconds)
+		assert.True(t, report.Rows[2].Bucket.Equal(from.AddDate(0, 0, 2)))
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAnalyticsTimeseries_InvalidInput tests interval and range validation
+func TestAnalyticsTimeseries_InvalidInput(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	uc := usecase.NewAnalyticsUsecase(repository.NewAnalyticsRepository(db), &fakeJobEnqueuer{})
+	to := time.Now()
+
+	_, err = uc.Timeseries(1, analytics.Filter{To: to}, "week")
+	assert.ErrorIs(t, err, usecase.ErrInvalidInterval)
+	_, err = uc.Timeseries(1, analytics.Filter{From: to.AddDate(0, 0, -40), To: to}, analytics.GroupHour)
+	assert.ErrorIs(t, err, usecase.ErrRangeTooLarge)
+	_, err = uc.Summary(1, analytics.Filter{From: to, To: to.Add(-time.Hour)})
+	assert.ErrorIs(t, err, usecase.ErrInvalidRange)
+	_, err = uc.Breakdown(1, analytics.Filter{}, "contact")
+	assert.ErrorIs(t, err, usecase.ErrInvalidGrouping)
+}
+
+// TestAnalyticsRefreshRollups tests that a rebuild is split into weekly transactions
+func TestAnalyticsRefreshRollups(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	uc := usecase.NewAnalyticsUsecase(repository.NewAnalyticsRepository(db), &fakeJobEnqueuer{})
+	from := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)
+	split := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+
+	for _, window := range [][2]time.Time{{from.Truncate(time.Hour), split}, {split, to}} {
+		mock.ExpectBegin()
+		mock.ExpectExec("pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM analytics_hourly").WithArgs(window[0], window[1], 3).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("INSERT INTO analytics_hourly").
+			WithArgs(window[0], window[1], 3, analytics.SatisfiedScore, analytics.PromoterScore, analytics.DetractorScore).
+			WillReturnResult(sqlmock.NewResult(0, 20))
+		mock.ExpectCommit()
+	}
+
+	err = uc.RefreshRollups(context.Background(), usecase.RefreshRollups{ClientID: 3, From: &from, To: &to})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAnalyticsRequestRebuild tests that rebuilds are queued as client-scoped jobs
+func TestAnalyticsRequestRebuild(t *testing.T) {
+	enqueuer := &fakeJobEnqueuer{}
+	uc := usecase.NewAnalyticsUsecase(nil, enqueuer)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := uc.RequestRebuild(3, from, from.AddDate(2, 0, 0))
+	assert.ErrorIs(t, err, usecase.ErrRangeTooLarge)
+
+	_, err = uc.RequestRebuild(3, from, from.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	if assert.Len(t, enqueuer.payloads, 1) {
+		p := enqueuer.payloads[0].(usecase.RefreshRollups)
+		assert.Equal(t, 3, p.ClientID)
+		assert.True(t, p.From.Equal(from))
+	}
+}
+
+// TestAnalyticsHandler_AgentsCSV tests CSV export of the per-agent report
+func TestAnalyticsHandler_AgentsCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("client_id", 1) })
+	router.GET("/api/analytics/agents", delivery.NewAnalyticsHandler(
+		usecase.NewAnalyticsUsecase(repository.NewAnalyticsRepository(db), &fakeJobEnqueuer{})).GetAgents)
+
+	mock.ExpectQuery("SELECT timezone FROM clients").WillReturnRows(sqlmock.NewRows([]string{"timezone"}).AddRow(""))
+	mock.ExpectQuery("LEFT JOIN users u").
+		WillReturnRows(sqlmock.NewRows(analyticsColumns).
+			AddRow(nil, "", nil, 0, "", 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0).
+			AddRow(nil, "", nil, 7, "=HYPERLINK(\"x\")", 12, 9, 12, 1200, 9, 5400, 8, 1, 2, 9, 2, 4, 2, 1))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+		"/api/analytics/agents?format=csv&from=2024-01-01T00:00:00Z&to=2024-01-08T00:00:00Z", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "agents_20240101_20240108.csv")
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, "agent_id", records[0][3])
+		assert.Equal(t, []string{"", "", "", "0", "", "3", "0", "0", "0", "", "", "0", "0", "", "0", "", "", "0", ""}, records[1])
+		assert.Equal(t, "7", records[2][3])
+		assert.Equal(t, `'=HYPERLINK("x")`, records[2][4])
+		assert.Equal(t, "75", records[2][7])
+		assert.Equal(t, "100", records[2][9])
+		assert.Equal(t, "25", records[2][18])
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAnalyticsHandler_InvalidQuery tests request validation in the analytics endpoints
+func TestAnalyticsHandler_InvalidQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	h := delivery.NewAnalyticsHandler(usecase.NewAnalyticsUsecase(repository.NewAnalyticsRepository(db), &fakeJobEnqueuer{}))
+	router := gin.New()
+	router.GET("/api/analytics/summary", h.GetSummary)
+	router.GET("/api/analytics/timeseries", h.GetTimeseries)
+
+	for _, path := range []string{
+		"/api/analytics/summary?from=last-week",
+		"/api/analytics/summary?team_id=abc",
+		"/api/analytics/summary?from=2024-02-01T00:00:00Z&to=2024-01-01T00:00:00Z",
+		"/api/analytics/timeseries?interval=minute",
+		"/api/analytics/timeseries?interval=hour&from=2024-01-01T00:00:00Z&to=2024-03-01T00:00:00Z",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.NotEmpty(t, body["error"])
+	}
+}
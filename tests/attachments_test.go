@@ -0,0 +1,301 @@
+package tests
+
+import (
+	"backend/internal/attachments"
+	"backend/internal/attachments/delivery"
+	"backend/internal/attachments/repository"
+	"backend/internal/attachments/storage"
+	"backend/internal/attachments/usecase"
+	"backend/internal/conversations"
+	"backend/internal/jobs"
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeJobEnqueuer struct {
+	payloads []jobs.Payload
+}
+
+func (f *fakeJobEnqueuer) Enqueue(payload jobs.Payload, opts ...jobs.Option) (int, error) {
+	f.payloads = append(f.payloads, payload)
+	return len(f.payloads), nil
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestDetectContentType tests that file types are detected from content, not names
+func TestDetectContentType(t *testing.T) {
+	ct, kind, err := usecase.DetectContentType(testPNG(t, 4, 4))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", ct)
+	assert.Equal(t, attachments.KindImage, kind)
+
+	ct, kind, err = usecase.DetectContentType([]byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", ct)
+	assert.Equal(t, attachments.KindDocument, kind)
+
+	for _, bad := range [][]byte{
+		[]byte("<!DOCTYPE html><html><script>alert(1)</script></html>"),
+		append([]byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00"), make([]byte, 64)...),
+	} {
+		_, _, err := usecase.DetectContentType(bad)
+		assert.ErrorIs(t, err, usecase.ErrUnsupportedType)
+	}
+}
+
+// TestSignedDownloadURL tests download signature verification and expiry
+func TestSignedDownloadURL(t *testing.T) {
+	key := []byte("secret")
+	now := time.Unix(1_700_000_000, 0)
+	expires := now.Add(time.Minute)
+	sig := attachments.Sign(key, 7, attachments.VariantOriginal, expires)
+	exp := "1700000060"
+
+	assert.True(t, attachments.Verify(key, 7, attachments.VariantOriginal, exp, sig, now))
+	assert.False(t, attachments.Verify(key, 8, attachments.VariantOriginal, exp, sig, now))
+	assert.False(t, attachments.Verify(key, 7, attachments.VariantThumbnail, exp, sig, now))
+	assert.False(t, attachments.Verify(key, 7, attachments.VariantOriginal, "1700000120", sig, now))
+	assert.False(t, attachments.Verify(key, 7, attachments.VariantOriginal, exp, sig, now.Add(2*time.Minute)))
+	assert.False(t, attachments.Verify([]byte("other"), 7, attachments.VariantOriginal, exp, sig, now))
+}
+
+// TestLocalStorage tests the filesystem storage round trip and path traversal protection
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewLocal(dir)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, store.Put(ctx, "1/2024/05/abc", []byte("hello"), "text/plain"))
+	r, err := store.Get(ctx, "1/2024/05/abc")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "hello", string(data))
+
+	assert.NoError(t, store.Delete(ctx, "1/2024/05/abc"))
+	assert.NoError(t, store.Delete(ctx, "1/2024/05/abc"))
+	_, err = store.Get(ctx, "1/2024/05/abc")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+
+	assert.Error(t, store.Put(ctx, "../escape", []byte("x"), "text/plain"))
+	_, err = os.Stat(filepath.Join(filepath.Dir(dir), "escape"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// TestS3Storage tests signed requests against an S3-compatible endpoint
+func TestS3Storage(t *testing.T) {
+	objects := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	store, err := storage.NewS3(storage.S3Config{
+		Endpoint: server.URL, Region: "eu-west-1", Bucket: "media", AccessKeyID: "AKID", SecretAccessKey: "secret", PathStyle: true,
+	}, server.Client())
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, store.Put(ctx, "3/2024/05/file", []byte("voice"), "audio/ogg"))
+	assert.Equal(t, []byte("voice"), objects["/media/3/2024/05/file"])
+
+	r, err := store.Get(ctx, "3/2024/05/file")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "voice", string(data))
+
+	assert.NoError(t, store.Delete(ctx, "3/2024/05/file"))
+	_, err = store.Get(ctx, "3/2024/05/file")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+}
+
+// TestThumbnail tests image downscaling with preserved aspect ratio
+func TestThumbnail(t *testing.T) {
+	thumb, err := attachments.Thumbnail(testPNG(t, 1000, 500), attachments.ThumbnailSize)
+	assert.NoError(t, err)
+	img, err := jpeg.Decode(bytes.NewReader(thumb))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Pt(320, 160), img.Bounds().Size())
+
+	thumb, err = attachments.Thumbnail(testPNG(t, 40, 90), attachments.ThumbnailSize)
+	assert.NoError(t, err)
+	img, _ = jpeg.Decode(bytes.NewReader(thumb))
+	assert.Equal(t, image.Pt(40, 90), img.Bounds().Size())
+
+	_, err = attachments.Thumbnail([]byte("%PDF-1.7"), attachments.ThumbnailSize)
+	assert.ErrorIs(t, err, attachments.ErrUnsupportedImage)
+}
+
+// TestAttachmentUpload_Success tests storing an image and scheduling its thumbnail
+func TestAttachmentUpload_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	store, _ := storage.NewLocal(t.TempDir())
+	queue := &fakeJobEnqueuer{}
+	convs := &fakeConversationReader{conv: conversations.Conversation{ID: 10, ClientID: 1}}
+	uc := usecase.NewAttachmentUsecase(repository.NewAttachmentRepository(db), store, convs, queue, []byte("k"), "https://app.example.com")
+	img := testPNG(t, 64, 32)
+
+	mock.ExpectQuery("SELECT used_bytes, quota_bytes FROM storage_usage").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"used_bytes", "quota_bytes"}).AddRow(100, attachments.DefaultQuotaBytes))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO storage_usage").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE storage_usage SET used_bytes").WithArgs(1, int64(len(img))).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO attachments").
+		WillReturnRows(sqlmock.NewRows([]string{"attachment_id", "created_at"}).AddRow(55, time.Now()))
+	mock.ExpectCommit()
+
+	convID, userID := 10, 4
+	a, err := uc.Upload(context.Background(), usecase.Upload{
+		ClientID: 1, ConversationID: &convID, UploadedBy: &userID, FileName: "../../photo.exe", Body: bytes.NewReader(img),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 55, a.ID)
+	assert.Equal(t, "image/png", a.ContentType)
+	assert.Equal(t, "photo.exe", a.FileName)
+	assert.Equal(t, 64, *a.Width)
+	assert.True(t, strings.HasPrefix(a.URL, "https://app.example.com/files/55?expires="))
+	assert.Equal(t, []jobs.Payload{usecase.GenerateThumbnail{AttachmentID: 55}}, queue.payloads)
+
+	r, err := store.Get(context.Background(), a.StorageKey)
+	assert.NoError(t, err)
+	stored, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, img, stored)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAttachmentUpload_QuotaExceeded tests that concurrent quota exhaustion removes the stored object
+func TestAttachmentUpload_QuotaExceeded(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	dir := t.TempDir()
+	store, _ := storage.NewLocal(dir)
+	uc := usecase.NewAttachmentUsecase(repository.NewAttachmentRepository(db), store, &fakeConversationReader{}, &fakeJobEnqueuer{}, []byte("k"), "")
+
+	mock.ExpectQuery("SELECT used_bytes, quota_bytes FROM storage_usage").
+		WillReturnRows(sqlmock.NewRows([]string{"used_bytes", "quota_bytes"}).AddRow(0, 1000))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO storage_usage").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE storage_usage SET used_bytes").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err = uc.Upload(context.Background(), usecase.Upload{ClientID: 1, FileName: "notes.txt", Body: strings.NewReader("meeting notes")})
+	assert.ErrorIs(t, err, usecase.ErrQuotaExceeded)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Objek yang sempat diunggah harus dihapus lagi
+	var files []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	assert.Empty(t, files)
+
+	// Pengecekan awal menolak tanpa menyentuh storage
+	mock.ExpectQuery("SELECT used_bytes, quota_bytes FROM storage_usage").
+		WillReturnRows(sqlmock.NewRows([]string{"used_bytes", "quota_bytes"}).AddRow(995, 1000))
+	_, err = uc.Upload(context.Background(), usecase.Upload{ClientID: 1, FileName: "notes.txt", Body: strings.NewReader("meeting notes")})
+	assert.ErrorIs(t, err, usecase.ErrQuotaExceeded)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAttachmentDownload tests that downloads require a valid signature and are served safely
+func TestAttachmentDownload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	store, _ := storage.NewLocal(t.TempDir())
+	store.Put(context.Background(), "1/2024/05/doc", []byte("%PDF-1.7"), "application/pdf")
+	key := []byte("k")
+	uc := usecase.NewAttachmentUsecase(repository.NewAttachmentRepository(db), store, &fakeConversationReader{}, &fakeJobEnqueuer{}, key, "")
+
+	router := gin.New()
+	router.GET("/files/:id", delivery.NewAttachmentHandler(uc).Download)
+
+	expires := time.Now().Add(time.Minute)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	sig := attachments.Sign(key, 9, attachments.VariantOriginal, expires)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/9?expires="+exp+"&signature=bad", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	mock.ExpectQuery("SELECT (.+) FROM attachments WHERE attachment_id").WithArgs(9).WillReturnRows(
+		sqlmock.NewRows([]string{"attachment_id", "client_id", "conversation_id", "message_id", "uploaded_by", "file_name",
+			"content_type", "kind", "size", "checksum", "storage_key", "thumbnail_key", "width", "height", "created_at"}).
+			AddRow(9, 1, nil, nil, nil, "invoice.pdf", "application/pdf", attachments.KindDocument, 8, "", "1/2024/05/doc", nil, nil, nil, time.Now()))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/9?expires="+exp+"&signature="+sig, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "%PDF-1.7", w.Body.String())
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="invoice.pdf"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
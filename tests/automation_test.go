@@ -0,0 +1,284 @@
+package tests
+
+import (
+	"backend/internal/automation"
+	"backend/internal/automation/repository"
+	"backend/internal/automation/usecase"
+	"backend/internal/contacts"
+	"backend/internal/conversations"
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/webhooks"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConversationService records status and priority changes made by automation
+type fakeConversationService struct {
+	conv       conversations.Conversation
+	statuses   []string
+	priorities []int
+}
+
+func (f *fakeConversationService) GetConversation(clientID, id int) (*conversations.Conversation, error) {
+	conv := f.conv
+	return &conv, nil
+}
+
+func (f *fakeConversationService) UpdateStatus(clientID, id int, status string) error {
+	f.statuses = append(f.statuses, status)
+	return nil
+}
+
+func (f *fakeConversationService) SetPriority(clientID, id, priority int) error {
+	f.priorities = append(f.priorities, priority)
+	return nil
+}
+
+type fakeContactService struct {
+	contact contacts.Contact
+	tags    [][]string
+}
+
+func (f *fakeContactService) GetContact(clientID, id int) (*contacts.Contact, error) {
+	contact := f.contact
+	return &contact, nil
+}
+
+func (f *fakeContactService) SetTags(clientID, contactID int, tags []string) error {
+	f.tags = append(f.tags, tags)
+	return nil
+}
+
+var automationRuleColumns = []string{"rule_id", "client_id", "name", "event", "condition", "actions", "position", "stop_after", "active", "created_at", "updated_at"}
+
+// TestAutomationExpressionEval tests the condition language operators against a sample environment
+func TestAutomationExpressionEval(t *testing.T) {
+	priority := 2
+	env := map[string]interface{}{
+		"conversation": map[string]interface{}{"channel": "sms", "priority": &priority, "assignee_id": (*int)(nil)},
+		"contact":      map[string]interface{}{"tags": []string{"vip", "b2b"}, "attributes": map[string]interface{}{"plan": "gold"}},
+		"message":      map[string]interface{}{"body": "I want a REFUND please"},
+	}
+
+	cases := map[string]bool{
+		``:                                         true,
+		`conversation.channel == "sms"`:            true,
+		`conversation.priority >= 3`:               false,
+		`conversation.priority < 3 && true`:        true,
+		`"vip" in contact.tags`:                    true,
+		`contact.tags contains "enterprise"`:       false,
+		`conversation.channel in ["sms", 'email']`: true,
+		`message.body matches "(?i)refund"`:        true,
+		`message.body startswith "I want" and not (message.body endswith "!")`: true,
+		`contact.attributes.plan == "gold" or conversation.priority > 10`:      true,
+		`conversation.assignee_id == null`:                                     true,
+		`contact.missing.field != null`:                                        false,
+	}
+	for src, want := range cases {
+		expr, err := automation.Compile(src)
+		if !assert.NoError(t, err, src) {
+			continue
+		}
+		assert.Equal(t, want, expr.Eval(env), src)
+	}
+}
+
+// TestAutomationExpressionSyntaxError tests that parse errors report the offending position
+func TestAutomationExpressionSyntaxError(t *testing.T) {
+	_, err := automation.Compile(`conversation.channel == "sms" and (`)
+	var syntaxErr *automation.SyntaxError
+	if assert.True(t, errors.As(err, &syntaxErr)) {
+		assert.Equal(t, 35, syntaxErr.Pos)
+	}
+
+	_, err = automation.Compile(`message.body matches "("`)
+	assert.Error(t, err)
+}
+
+// TestAutomationValidateRule tests that invalid conditions and actions are rejected on save
+func TestAutomationValidateRule(t *testing.T) {
+	uc := usecase.NewAutomationUsecase(nil, nil, nil, nil, nil, nil, nil)
+	rule := automation.Rule{
+		Name:      "Urgent refunds",
+		Event:     automation.EventMessageReceived,
+		Condition: `message.body contains "refund"`,
+		Actions:   []automation.Action{{Type: automation.ActionSetPriority, Params: map[string]interface{}{"priority": float64(4)}}},
+	}
+	assert.NoError(t, uc.ValidateRule(rule))
+
+	bad := rule
+	bad.Condition = `message.body contains`
+	err := uc.ValidateRule(bad)
+	assert.ErrorIs(t, err, usecase.ErrInvalidRule)
+	var syntaxErr *automation.SyntaxError
+	assert.True(t, errors.As(err, &syntaxErr))
+
+	bad = rule
+	bad.Actions = []automation.Action{{Type: automation.ActionSetPriority, Params: map[string]interface{}{"priority": float64(9)}}}
+	assert.ErrorIs(t, uc.ValidateRule(bad), usecase.ErrInvalidRule)
+
+	bad = rule
+	bad.Event = "user.created"
+	assert.ErrorIs(t, uc.ValidateRule(bad), usecase.ErrInvalidRule)
+
+	for _, target := range []string{"http://127.0.0.1:8080/hook", "http://169.254.169.254/latest/meta-data", "http://localhost/hook", "ftp://example.com"} {
+		bad = rule
+		bad.Actions = []automation.Action{{Type: automation.ActionCallWebhook, Params: map[string]interface{}{"url": target}}}
+		assert.ErrorIs(t, uc.ValidateRule(bad), usecase.ErrInvalidRule, target)
+	}
+
+	bad = rule
+	bad.Actions = []automation.Action{{Type: automation.ActionCallWebhook, Params: map[string]interface{}{"url": "https://example.com/hook", "secret": "short"}}}
+	assert.ErrorIs(t, uc.ValidateRule(bad), usecase.ErrInvalidRule)
+}
+
+// TestAutomationCallWebhook_SignsPayload tests that call_webhook actions send a signature verifiable with the action secret
+func TestAutomationCallWebhook_SignsPayload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	const secret = "whsec_0123456789abcdef"
+	received := make(chan *http.Request, 1)
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+	}))
+	defer server.Close()
+
+	actions := fmt.Sprintf(`[{"type":"call_webhook","params":{"url":%q,"secret":%q}}]`, server.URL, secret)
+	mock.ExpectQuery("SELECT rule_id, client_id, name").WithArgs(1, automation.EventMessageReceived).
+		WillReturnRows(sqlmock.NewRows(automationRuleColumns).AddRow(
+			9, 1, "Notify", automation.EventMessageReceived, "", actions, 0, false, true, time.Now(), time.Now()))
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("INSERT INTO automation_executions").
+		WithArgs(1, 9, 10, automation.EventMessageReceived, automation.ExecutionSuccess, sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	convs := &fakeConversationService{conv: conversations.Conversation{ID: 10, ClientID: 1, Channel: conversations.ChannelSMS, Status: conversations.StatusOpen}}
+	// Server test berjalan di localhost, sehingga client biasa dipakai menggantikan client default
+	uc := usecase.NewAutomationUsecase(repository.NewAutomationRepository(db), convs, nil, nil, nil, nil, server.Client())
+
+	err = uc.Process(context.Background(), realtime.Event{
+		Type:     realtime.EventMessageCreated,
+		ClientID: 1,
+		Data:     &conversations.Message{ConversationID: 10, Direction: conversations.DirectionInbound, Body: "Hello"},
+	})
+	assert.NoError(t, err)
+
+	r := <-received
+	assert.Equal(t, automation.EventMessageReceived, r.Header.Get(webhooks.HeaderEvent))
+	parts := strings.Split(r.Header.Get(webhooks.HeaderSignature), ",")
+	if assert.Len(t, parts, 2) {
+		unix, err := strconv.ParseInt(strings.TrimPrefix(parts[0], "t="), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, "v1="+webhooks.Sign(secret, time.Unix(unix, 0), body), parts[1])
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAutomationProcess_RunsMatchingActions tests that a matching rule runs its actions and skips no-ops
+func TestAutomationProcess_RunsMatchingActions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT rule_id, client_id, name").WithArgs(1, automation.EventMessageReceived).
+		WillReturnRows(sqlmock.NewRows(automationRuleColumns).AddRow(
+			7, 1, "Refunds", automation.EventMessageReceived, `message.body contains "refund" and conversation.priority < 3`,
+			`[{"type":"set_priority","params":{"priority":3}},{"type":"tag","params":{"tags":["VIP"]}},{"type":"close","params":{"status":"resolved"}}]`,
+			0, false, true, time.Now(), time.Now()))
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("INSERT INTO automation_executions").
+		WithArgs(1, 7, 10, automation.EventMessageReceived, automation.ExecutionSuccess, sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	contactID := 3
+	convs := &fakeConversationService{conv: conversations.Conversation{ID: 10, ClientID: 1, ContactID: &contactID, Channel: conversations.ChannelSMS, Status: conversations.StatusOpen}}
+	contactSvc := &fakeContactService{contact: contacts.Contact{ID: 3, ClientID: 1, Tags: []string{"vip"}}}
+	uc := usecase.NewAutomationUsecase(repository.NewAutomationRepository(db), convs, contactSvc, nil, nil, nil, nil)
+
+	err = uc.Process(context.Background(), realtime.Event{
+		Type:     realtime.EventMessageCreated,
+		ClientID: 1,
+		Data:     &conversations.Message{ConversationID: 10, Direction: conversations.DirectionInbound, Body: "Need a refund"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{conversations.PriorityHigh}, convs.priorities)
+	assert.Empty(t, contactSvc.tags) // Kontak sudah memiliki tag, action tag dilewati
+	assert.Equal(t, []string{conversations.StatusResolved}, convs.statuses)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAutomationProcess_LoopProtection tests that a rule which keeps re-triggering is skipped
+func TestAutomationProcess_LoopProtection(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT rule_id, client_id, name").WithArgs(1, automation.EventStatusChanged).
+		WillReturnRows(sqlmock.NewRows(automationRuleColumns).AddRow(
+			8, 1, "Reopen", automation.EventStatusChanged, "", `[{"type":"close","params":{"status":"resolved"}}]`,
+			0, false, true, time.Now(), time.Now()))
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectExec("INSERT INTO automation_executions").
+		WithArgs(1, 8, 10, automation.EventStatusChanged, automation.ExecutionSkipped, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	convs := &fakeConversationService{conv: conversations.Conversation{ID: 10, ClientID: 1, Status: conversations.StatusOpen}}
+	uc := usecase.NewAutomationUsecase(repository.NewAutomationRepository(db), convs, nil, nil, nil, nil, nil)
+
+	err = uc.Process(context.Background(), realtime.Event{
+		Type:     realtime.EventConversationStatusChanged,
+		ClientID: 1,
+		Data:     &convs.conv,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, convs.statuses)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAutomationHandleEvent_DecodesOutboxPayload tests that outbox events trigger rules only for inbound messages
+func TestAutomationHandleEvent_DecodesOutboxPayload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT rule_id, client_id, name").WithArgs(1, automation.EventMessageReceived).
+		WillReturnRows(sqlmock.NewRows(automationRuleColumns))
+
+	uc := usecase.NewAutomationUsecase(repository.NewAutomationRepository(db), &fakeConversationService{}, nil, nil, nil, nil, nil)
+	for _, direction := range []string{conversations.DirectionOutbound, conversations.DirectionInbound} {
+		e, err := events.New(1, realtime.Payload{Type: realtime.EventMessageCreated,
+			Data: conversations.Message{ID: 5, ConversationID: 10, ClientID: 1, Direction: direction, Body: "Hi"}})
+		assert.NoError(t, err)
+		assert.NoError(t, uc.HandleEvent(context.Background(), e))
+	}
+
+	broken := events.Event{ID: "evt-1", Type: realtime.EventMessageCreated, ClientID: 1, Payload: []byte(`{"id":`)}
+	assert.Error(t, uc.HandleEvent(context.Background(), broken))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"backend/internal/businesshours"
+	"backend/internal/sla"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBusinessHoursStatus_Holidays tests that holidays close the calendar and next open time skips them
+func TestBusinessHoursStatus_Holidays(t *testing.T) {
+	holidays := []businesshours.Holiday{
+		{Date: "2020-12-25", Name: "Christmas", Recurring: true},
+		{Date: "2026-12-28", Name: "Office closed"},
+	}
+	cal, err := businesshours.Calendar(businesshours.DefaultSchedule(1, "Asia/Jakarta"), holidays)
+	if err != nil {
+		t.Fatalf("Failed to build calendar: %v", err)
+	}
+	loc := cal.Location
+
+	// Kamis 24 Desember 2026 10:00 buka sampai 17:00
+	status := businesshours.StatusAt(cal, time.Date(2026, 12, 24, 10, 0, 0, 0, loc))
+	assert.True(t, status.Open)
+	assert.Equal(t, time.Date(2026, 12, 24, 17, 0, 0, 0, loc), *status.ClosesAt)
+
+	// Jumat 25 (libur berulang), akhir pekan, lalu Senin 28 (libur) dilewati
+	status = businesshours.StatusAt(cal, time.Date(2026, 12, 25, 10, 0, 0, 0, loc))
+	assert.False(t, status.Open)
+	assert.Equal(t, time.Date(2026, 12, 29, 9, 0, 0, 0, loc), *status.NextOpenAt)
+	assert.Equal(t, "Asia/Jakarta", status.Timezone)
+}
+
+// TestBusinessHoursStatus_DSTFallBack tests that opening hours follow local time when clocks go back
+func TestBusinessHoursStatus_DSTFallBack(t *testing.T) {
+	schedule := businesshours.Schedule{
+		Timezone: "Europe/Berlin",
+		Days: map[string][]businesshours.Period{
+			"sunday": {{Open: "00:00", Close: "04:00"}},
+			"monday": {{Open: "08:00", Close: "12:00"}, {Open: "13:00", Close: "17:00"}},
+		},
+	}
+	cal, err := businesshours.Calendar(schedule, nil)
+	if err != nil {
+		t.Fatalf("Failed to build calendar: %v", err)
+	}
+	loc := cal.Location
+
+	// Minggu 25 Oktober 2026 jam 03:00 CEST mundur ke 02:00 CET, sehingga periode 00:00-04:00 berlangsung 5 jam
+	due := sla.AddBusinessTime(cal, time.Date(2026, 10, 25, 0, 0, 0, 0, loc), 5*time.Hour)
+	assert.Equal(t, time.Date(2026, 10, 25, 4, 0, 0, 0, loc), due)
+
+	// Senin istirahat siang: tutup pada 12:30 dan buka kembali 13:00 waktu lokal
+	status := businesshours.StatusAt(cal, time.Date(2026, 10, 26, 12, 30, 0, 0, loc))
+	assert.False(t, status.Open)
+	assert.Equal(t, time.Date(2026, 10, 26, 13, 0, 0, 0, loc), *status.NextOpenAt)
+	assert.Equal(t, 12, status.NextOpenAt.UTC().Hour())
+}
+
+// TestBusinessHoursCalendar_Invalid tests schedule validation
+func TestBusinessHoursCalendar_Invalid(t *testing.T) {
+	invalid := []businesshours.Schedule{
+		{Timezone: "Mars/Olympus"},
+		{Timezone: "UTC", Days: map[string][]businesshours.Period{"funday": {{Open: "09:00", Close: "17:00"}}}},
+		{Timezone: "UTC", Days: map[string][]businesshours.Period{"monday": {{Open: "17:00", Close: "09:00"}}}},
+		{Timezone: "UTC", Days: map[string][]businesshours.Period{"monday": {{Open: "9:00", Close: "25:00"}}}},
+	}
+	for _, s := range invalid {
+		_, err := businesshours.Calendar(s, nil)
+		assert.ErrorIs(t, err, businesshours.ErrInvalidSchedule)
+	}
+
+	_, err := businesshours.Calendar(businesshours.Schedule{
+		Timezone: "UTC", Days: map[string][]businesshours.Period{"Friday": {{Open: "00:00", Close: "24:00"}}},
+	}, nil)
+	assert.NoError(t, err)
+}
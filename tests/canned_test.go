@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"backend/internal/canned"
+	"backend/internal/canned/repository"
+	"backend/internal/canned/usecase"
+	"backend/internal/contacts"
+	"backend/internal/conversations"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeConversationReader struct {
+	conv conversations.Conversation
+}
+
+func (f *fakeConversationReader) GetConversation(clientID, id int) (*conversations.Conversation, error) {
+	if clientID != f.conv.ClientID || id != f.conv.ID {
+		return nil, usecase.ErrConversationNotFound
+	}
+	conv := f.conv
+	return &conv, nil
+}
+
+type fakeContactReader struct {
+	contact contacts.Contact
+}
+
+func (f *fakeContactReader) GetContact(clientID, id int) (*contacts.Contact, error) {
+	contact := f.contact
+	return &contact, nil
+}
+
+// fakeReplySender records replies instead of sending them to a provider
+type fakeReplySender struct {
+	bodies  []string
+	senders []*int
+}
+
+func (f *fakeReplySender) Reply(ctx context.Context, conv conversations.Conversation, senderUserID *int, body string) (*conversations.Message, error) {
+	f.bodies = append(f.bodies, body)
+	f.senders = append(f.senders, senderUserID)
+	return &conversations.Message{ConversationID: conv.ID, Body: body, SenderUserID: senderUserID}, nil
+}
+
+var cannedRowColumns = []string{"canned_response_id", "client_id", "team_id", "user_id", "shortcode", "title", "body", "category", "attachments", "created_by", "created_at", "updated_at"}
+
+// TestCannedRender tests variable interpolation with defaults and missing variables
+func TestCannedRender(t *testing.T) {
+	vars := map[string]string{"contact.name": "Budi", "agent.username": "sari"}
+
+	out, missing := canned.Render("Hi {{contact.name}}, this is {{ agent.username }}.", vars)
+	assert.Equal(t, "Hi Budi, this is sari.", out)
+	assert.Empty(t, missing)
+
+	out, missing = canned.Render("Hi {{contact.first_name|there}}! Order {{contact.attributes.order_id}}", vars)
+	assert.Equal(t, "Hi there! Order ", out)
+	assert.Equal(t, []string{"contact.attributes.order_id"}, missing)
+}
+
+// TestCannedSend tests that a canned response is resolved from the contact and agent and sent on the conversation channel
+func TestCannedSend(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	body := "Hello {{contact.first_name}}, {{agent.username}} here. Your order {{contact.attributes.order_id}} ships to {{contact.phone}}."
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(cannedRowColumns).AddRow(3, 1, nil, nil, "ship", "Shipping", body, "orders", []byte(`[]`), 9, time.Now(), time.Now())
+	}
+	mock.ExpectQuery("SELECT canned_response_id").WithArgs(3).WillReturnRows(rows())
+
+	contactID := 5
+	conv := conversations.Conversation{ID: 12, ClientID: 1, ContactID: &contactID, Channel: conversations.ChannelSMS, ExternalAddress: "+6281234"}
+	contact := contacts.Contact{
+		ID: 5, ClientID: 1, Name: "Budi Santoso",
+		Attributes: map[string]interface{}{"Order_ID": 1042},
+		Identities: []contacts.Identity{{Type: contacts.IdentityPhone, Value: "+6281234"}},
+	}
+	sender := &fakeReplySender{}
+	uc := usecase.NewCannedUsecase(repository.NewCannedRepository(db), &fakeConversationReader{conv: conv}, &fakeContactReader{contact: contact},
+		map[string]usecase.ReplySender{conversations.ChannelSMS: sender})
+
+	msg, err := uc.Send(context.Background(), 1, canned.Agent{UserID: 7, Username: "sari"}, 3, 12)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello Budi, sari here. Your order 1042 ships to +6281234.", msg.Body)
+	assert.Equal(t, 7, *sender.senders[0])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestCannedGetResponse_TeamScope tests that team canned responses are hidden from non-members
+func TestCannedGetResponse_TeamScope(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT canned_response_id").WithArgs(4).
+		WillReturnRows(sqlmock.NewRows(cannedRowColumns).AddRow(4, 1, 2, nil, "vip", "VIP", "Hi", "", []byte(`[]`), 9, time.Now(), time.Now()))
+	mock.ExpectQuery("SELECT EXISTS").WithArgs(2, 7).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	uc := usecase.NewCannedUsecase(repository.NewCannedRepository(db), &fakeConversationReader{}, &fakeContactReader{}, nil)
+	_, err = uc.GetResponse(1, canned.Agent{UserID: 7}, 4)
+	assert.ErrorIs(t, err, usecase.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"backend/config"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var configEnv = []string{"CONFIG_FILE", "SERVER_ADDR", "SERVER_READ_HEADER_TIMEOUT", "SERVER_READ_TIMEOUT", "SERVER_WRITE_TIMEOUT",
+	"SERVER_IDLE_TIMEOUT", "SERVER_REQUEST_TIMEOUT", "SERVER_SHUTDOWN_TIMEOUT", "SERVER_TLS_CERT_FILE", "SERVER_TLS_KEY_FILE", "DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME", "DB_SSLMODE",
+	"DB_AUTO_MIGRATE", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "DB_CONN_MAX_LIFETIME", "DB_CONN_MAX_IDLE_TIME", "DB_CONNECT_TIMEOUT",
+	"JWT_SECRET", "PUBLIC_BASE_URL", "STORAGE_DRIVER", "STORAGE_LOCAL_DIR", "S3_ENDPOINT", "S3_REGION", "S3_BUCKET",
+	"S3_ACCESS_KEY_ID", "S3_SECRET_ACCESS_KEY", "S3_PATH_STYLE", "ATTACHMENT_SIGNING_KEY", "SURVEY_SIGNING_KEY", "TWILIO_API_BASE_URL", "LOG_LEVEL", "LOG_FORMAT",
+	"METRICS_ADDR"}
+
+// clearConfigEnv menghapus variabel konfigurasi selama test; nilainya dipulihkan oleh t.Setenv
+func clearConfigEnv(t *testing.T) {
+	for _, name := range configEnv {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+// TestConfigLoad_Precedence tests that env overrides the file and flags override env
+func TestConfigLoad_Precedence(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeConfigFile(t, "config.yaml", `
+server:
+  addr: ":9000"
+database:
+  host: file-host
+  port: 6543
+  user: file-user
+  name: file-db
+auth:
+  jwt_secret: file-secret-file-secret-file-secret
+storage:
+  local_dir: /var/lib/attachments
+`)
+	t.Setenv("DB_HOST", "env-host")
+	t.Setenv("DB_USER", "env-user")
+	t.Setenv("S3_PATH_STYLE", "true")
+
+	cfg, err := config.Load([]string{"-config", path, "-db-user", "flag-user", "-addr", ":7000"})
+	assert.NoError(t, err)
+	assert.Equal(t, ":7000", cfg.Server.Addr)
+	assert.Equal(t, "env-host", cfg.Database.Host)
+	assert.Equal(t, "flag-user", cfg.Database.User)
+	assert.Equal(t, "file-db", cfg.Database.Name)
+	assert.Equal(t, 6543, cfg.Database.Port)
+	assert.Equal(t, "disable", cfg.Database.SSLMode) // default
+	assert.Equal(t, "local", cfg.Storage.Driver)
+	assert.Equal(t, "/var/lib/attachments", cfg.Storage.LocalDir)
+	assert.True(t, cfg.Storage.S3.PathStyle)
+}
+
+// TestConfigLoad_TOML tests loading a TOML file selected through CONFIG_FILE
+func TestConfigLoad_TOML(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, "config.toml", `
+public_base_url = "https://support.example.com"
+
+[database]
+host = "db"
+user = "app"
+name = "omchannel"
+max_open_conns = 50
+conn_max_lifetime = "1h"
+
+[auth]
+jwt_secret = "toml-secret-toml-secret-toml-secret"
+`))
+
+	cfg, err := config.Load(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "db", cfg.Database.Host)
+	assert.Equal(t, 5432, cfg.Database.Port)
+	assert.Equal(t, "https://support.example.com", cfg.PublicBaseURL)
+	assert.Equal(t, 50, cfg.Database.MaxOpenConns)
+	assert.Equal(t, config.Duration(time.Hour), cfg.Database.ConnMaxLifetime)
+}
+
+// TestConfigLoad_Pool tests pool defaults and durations given through env and flags
+func TestConfigLoad_Pool(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DB_HOST", "db")
+	t.Setenv("DB_USER", "app")
+	t.Setenv("DB_NAME", "omchannel")
+	t.Setenv("JWT_SECRET", "env-secret-env-secret-env-secret-env")
+	t.Setenv("DB_CONN_MAX_IDLE_TIME", "90s")
+
+	cfg, err := config.Load([]string{"-db-connect-timeout", "15s"})
+	assert.NoError(t, err)
+	assert.Equal(t, 25, cfg.Database.MaxOpenConns) // default
+	assert.Equal(t, config.Duration(30*time.Minute), cfg.Database.ConnMaxLifetime)
+	assert.Equal(t, config.Duration(90*time.Second), cfg.Database.ConnMaxIdleTime)
+	assert.Equal(t, config.Duration(15*time.Second), cfg.Database.ConnectTimeout)
+	assert.Contains(t, cfg.String(), "conn_max_idle_time: 1m30s")
+
+	t.Setenv("DB_MAX_IDLE_CONNS", "40")
+	_, err = config.Load(nil)
+	assert.ErrorContains(t, err, "database.max_idle_conns (40) must not exceed database.max_open_conns (25)")
+
+	t.Setenv("DB_CONNECT_TIMEOUT", "soon")
+	_, err = config.Load(nil)
+	assert.ErrorContains(t, err, "database.connect_timeout: invalid value for DB_CONNECT_TIMEOUT")
+}
+
+// TestConfigLoad_Invalid tests that every validation problem is reported together
+func TestConfigLoad_Invalid(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("JWT_SECRET", "short")
+	t.Setenv("STORAGE_DRIVER", "s3")
+	t.Setenv("PUBLIC_BASE_URL", "support.example.com")
+	t.Setenv("SERVER_TLS_CERT_FILE", "/etc/tls/tls.crt")
+	t.Setenv("METRICS_ADDR", ":8080")
+
+	_, err := config.Load([]string{"-db-port", "70000"})
+	if assert.Error(t, err) {
+		msg := err.Error()
+		assert.Contains(t, msg, "database.user is required (set DB_USER")
+		assert.Contains(t, msg, "database.name is required (set DB_NAME")
+		assert.Contains(t, msg, "database.port must be between 1 and 65535")
+		assert.Contains(t, msg, "auth.jwt_secret must be at least 32 characters")
+		assert.Contains(t, msg, "public_base_url")
+		assert.Contains(t, msg, "storage.s3.bucket is required")
+		assert.Contains(t, msg, "server.tls_cert_file and server.tls_key_file must be set together")
+		assert.Contains(t, msg, "metrics.addr must differ from server.addr")
+		assert.NotContains(t, msg, "database.host")
+	}
+
+	t.Setenv("DB_PORT", "abc")
+	_, err = config.Load(nil)
+	assert.ErrorContains(t, err, "database.port: invalid value for DB_PORT")
+
+	_, err = config.Load([]string{"-config", writeConfigFile(t, "config.yaml", "databse:\n  host: x\n")})
+	assert.ErrorContains(t, err, "databse")
+}
+
+// TestConfigLoad_RequiresJWTSecret tests that startup fails when no JWT secret is configured
+func TestConfigLoad_RequiresJWTSecret(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_USER", "app")
+	t.Setenv("DB_NAME", "app")
+
+	_, err := config.Load(nil)
+	assert.ErrorContains(t, err, "auth.jwt_secret is required (set JWT_SECRET")
+
+	// File .env contoh tidak boleh membawa secret yang bisa terpakai di produksi
+	example, err := os.ReadFile("../.env.example")
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(example), "\nJWT_SECRET=\n")
+	}
+}
+
+// TestConfigString_RedactsSecrets tests that printing the config never exposes secrets
+func TestConfigString_RedactsSecrets(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Host = "db"
+	cfg.Database.Password = "db-password"
+	cfg.Auth.JWTSecret = "jwt-secret-value"
+	cfg.Storage.S3.SecretAccessKey = "s3-secret"
+
+	out := cfg.String()
+	for _, secret := range []string{"db-password", "jwt-secret-value", "s3-secret"} {
+		assert.NotContains(t, out, secret)
+	}
+	assert.Contains(t, out, "host: db")
+	assert.Equal(t, 3, strings.Count(out, "[REDACTED]"))
+	assert.Equal(t, "db-password", cfg.Database.Password) // salinan asli tidak berubah
+}
+
+// TestConfigConnectionString tests quoting of connection string values
+func TestConfigConnectionString(t *testing.T) {
+	db := config.DatabaseConfig{Host: "localhost", Port: 5432, User: "app", Password: `p a'ss\`, Name: "omchannel", SSLMode: "disable"}
+	assert.Equal(t, `host='localhost' port=5432 user='app' password='p a\'ss\\' dbname='omchannel' sslmode='disable'`, db.ConnectionString())
+}
+
+// TestConnectDB_Unreachable tests that connecting gives up after the connect timeout
+func TestConnectDB_Unreachable(t *testing.T) {
+	db := config.DatabaseConfig{Host: "127.0.0.1", Port: 1, User: "app", Name: "omchannel", SSLMode: "disable",
+		ConnectTimeout: config.Duration(700 * time.Millisecond)}
+
+	start := time.Now()
+	err := config.ConnectDB(context.Background(), db)
+	assert.ErrorContains(t, err, "database not reachable after 2 attempt(s)")
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
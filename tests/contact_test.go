@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"backend/internal/contacts"
+	"backend/internal/contacts/repository"
+	"backend/internal/contacts/usecase"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalizeIdentity tests phone and email normalization used for matching
+func TestNormalizeIdentity(t *testing.T) {
+	assert.Equal(t, "+6281234567890", contacts.NormalizeIdentity(contacts.IdentityPhone, " +62 812-3456-7890 "))
+	assert.Equal(t, "6281234567890", contacts.NormalizeIdentity(contacts.IdentityWhatsApp, "(62) 812 3456 7890"))
+	assert.Equal(t, "john@example.com", contacts.NormalizeIdentity(contacts.IdentityEmail, " John@Example.COM"))
+	assert.Equal(t, "AbC123", contacts.NormalizeIdentity(contacts.IdentityPSID, "AbC123"))
+}
+
+// TestMergeContacts_Success tests that merging moves data and deletes the secondary contact in one transaction
+func TestMergeContacts_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM contact_identities").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE contact_identities SET contact_id").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO contact_tags").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE contacts p SET attributes").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE conversations SET contact_id").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("INSERT INTO contact_merges").WithArgs(1, 2, 7).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE sms_opt_outs SET contact_id").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM contacts").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := repository.NewContactRepository(db)
+	assert.NoError(t, repo.Merge(1, 2, 7))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestMergeContacts_Rollback tests that a failed step rolls back the whole merge
+func TestMergeContacts_Rollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM contact_identities").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE contact_identities SET contact_id").WithArgs(1, 2).WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	repo := repository.NewContactRepository(db)
+	assert.Error(t, repo.Merge(1, 2, 7))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestCreateContact_SingleTransaction tests that a contact, its identities and tags are created together
+func TestCreateContact_SingleTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO contacts").WillReturnRows(sqlmock.NewRows([]string{"contact_id"}).AddRow(4))
+	mock.ExpectExec("INSERT INTO contact_identities").WithArgs(4, 1, contacts.IdentityPhone, "+6281234567890").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO contact_identities").WithArgs(4, 1, contacts.IdentityEmail, "john@example.com").
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	uc := usecase.NewContactUsecase(repository.NewContactRepository(db))
+	_, err = uc.CreateContact(contacts.Contact{ClientID: 1, Name: "John", Identities: []contacts.Identity{
+		{Type: contacts.IdentityPhone, Value: "+62 812-3456-7890"},
+		{Type: contacts.IdentityEmail, Value: "John@Example.com"},
+	}})
+	assert.ErrorIs(t, err, repository.ErrIdentityTaken)
+
+	// Identitas tidak valid ditolak sebelum ada yang ditulis
+	_, err = uc.CreateContact(contacts.Contact{ClientID: 1, Identities: []contacts.Identity{{Type: contacts.IdentityEmail, Value: " "}}})
+	assert.ErrorIs(t, err, usecase.ErrInvalidIdentity)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestResolveIdentity_ConcurrentCreate tests that losing the race for a new identity returns the winner's contact
+func TestResolveIdentity_ConcurrentCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT c.contact_id").WithArgs(1, contacts.IdentityPhone, "+15551234567").
+		WillReturnRows(sqlmock.NewRows([]string{"contact_id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO contacts").WillReturnRows(sqlmock.NewRows([]string{"contact_id"}).AddRow(9))
+	mock.ExpectQuery("INSERT INTO contact_identities .* ON CONFLICT \\(client_id, type, value\\) DO NOTHING").
+		WithArgs(9, 1, contacts.IdentityPhone, "+15551234567").WillReturnRows(sqlmock.NewRows([]string{"identity_id"}))
+	mock.ExpectRollback()
+	mock.ExpectQuery("SELECT contact_id FROM contact_identities").WithArgs(1, contacts.IdentityPhone, "+15551234567").
+		WillReturnRows(sqlmock.NewRows([]string{"contact_id"}).AddRow(3))
+
+	uc := usecase.NewContactUsecase(repository.NewContactRepository(db))
+	id, err := uc.ResolveIdentity(1, contacts.IdentityPhone, "+1 555 123 4567")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
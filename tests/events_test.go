@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"backend/internal/events"
+	eventsRepository "backend/internal/events/repository"
+	eventsUsecase "backend/internal/events/usecase"
+	"backend/internal/users"
+	"backend/internal/users/repository"
+	"backend/internal/users/usecase"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var outboxClaimColumns = []string{"event_id", "client_id", "event_type", "payload", "occurred_at", "attempts", "handled"}
+
+// TestDeleteUser_PublishesEventInTransaction tests that the delete and its outbox event share one transaction
+func TestDeleteUser_PublishesEventInTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, username, email").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "email", "role_id", "client_id", "created_at"}).
+			AddRow(1, "john_doe", "john@example.com", 2, 7, "2024-01-01"))
+	mock.ExpectExec("DELETE FROM users").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO outbox_events").
+		WithArgs(sqlmock.AnyArg(), 7, users.EventDeleted, []byte(`{"user_id":1,"client_id":7,"username":"john_doe"}`), events.StatusPending, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	uc := usecase.NewUserUsecase(repository.NewUserRepository(db))
+	_, err = uc.DeleteUser(context.Background(), 1, "")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestCreateUser_RollsBackWhenOutboxFails tests that a failed event write undoes the insert
+func TestCreateUser_RollsBackWhenOutboxFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO users").WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(5))
+	mock.ExpectExec("INSERT INTO outbox_events").WillReturnError(errors.New("disk full"))
+	mock.ExpectRollback()
+
+	uc := usecase.NewUserUsecase(repository.NewUserRepository(db))
+	_, err = uc.CreateUser(context.Background(), users.Pengguna{Username: "jane", Email: "jane@example.com", ClientID: 7})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestEventRelay_SkipsHandledSubscribers tests that redelivery only reaches subscribers that have not processed the event
+func TestEventRelay_SkipsHandledSubscribers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE outbox_events o SET locked_until").
+		WithArgs(1, sqlmock.AnyArg(), events.StatusPending, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(outboxClaimColumns).
+			AddRow("evt-1", 7, users.EventCreated, `{"user_id":5}`, time.Now(), 1, "{webhooks}"))
+	mock.ExpectExec("INSERT INTO outbox_handled").WithArgs("evt-1", "realtime", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE outbox_events SET status").WithArgs(events.StatusPublished, "evt-1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE outbox_events o SET locked_until").WillReturnRows(sqlmock.NewRows(outboxClaimColumns))
+
+	var webhookCalls, realtimeCalls []string
+	bus := eventsUsecase.NewEventBus(eventsRepository.NewOutboxRepository(db))
+	bus.Subscribe("webhooks", func(ctx context.Context, e events.Event) error {
+		webhookCalls = append(webhookCalls, e.ID)
+		return nil
+	})
+	bus.Subscribe("realtime", func(ctx context.Context, e events.Event) error {
+		var created users.Created
+		assert.NoError(t, e.Decode(&created))
+		assert.Equal(t, 5, created.UserID)
+		realtimeCalls = append(realtimeCalls, e.ID)
+		return nil
+	}, users.EventCreated)
+
+	n, err := bus.RelayPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, webhookCalls)
+	assert.Equal(t, []string{"evt-1"}, realtimeCalls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestEventRelay_RetriesFailedSubscriber tests that a failing subscriber reschedules the event with backoff
+func TestEventRelay_RetriesFailedSubscriber(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE outbox_events o SET locked_until").
+		WillReturnRows(sqlmock.NewRows(outboxClaimColumns).
+			AddRow("evt-2", 7, users.EventDeleted, `{}`, time.Now(), 0, "{}"))
+	mock.ExpectExec("UPDATE outbox_events SET status").
+		WithArgs(events.StatusPending, 1, sqlmock.AnyArg(), "webhooks: connection refused", "evt-2", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE outbox_events o SET locked_until").WillReturnRows(sqlmock.NewRows(outboxClaimColumns))
+
+	bus := eventsUsecase.NewEventBus(eventsRepository.NewOutboxRepository(db))
+	bus.Subscribe("webhooks", func(ctx context.Context, e events.Event) error {
+		return errors.New("connection refused")
+	})
+
+	n, err := bus.RelayPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestEventRelay_LeaseLost tests that an event whose lease expired is not counted or marked published
+func TestEventRelay_LeaseLost(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE outbox_events o SET locked_until").
+		WillReturnRows(sqlmock.NewRows(outboxClaimColumns).
+			AddRow("evt-3", 7, users.EventCreated, `{}`, time.Now(), 0, "{}"))
+	mock.ExpectExec("INSERT INTO outbox_handled").WithArgs("evt-3", "realtime", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("UPDATE outbox_events o SET locked_until").WillReturnRows(sqlmock.NewRows(outboxClaimColumns))
+
+	bus := eventsUsecase.NewEventBus(eventsRepository.NewOutboxRepository(db))
+	bus.Subscribe("realtime", func(ctx context.Context, e events.Event) error { return nil })
+
+	n, err := bus.RelayPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestPruneEvents tests that only published events older than the retention are deleted
+func TestPruneEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM outbox_events WHERE status").
+		WithArgs(events.StatusPublished, float64(48*3600)).
+		WillReturnResult(sqlmock.NewResult(0, 12))
+
+	bus := eventsUsecase.NewEventBus(eventsRepository.NewOutboxRepository(db))
+	assert.NoError(t, bus.PruneEvents(context.Background(), eventsUsecase.PruneEvents{RetentionHours: 48}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"backend/internal/health"
+	"backend/internal/health/delivery"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHealthRouter(checks ...health.Check) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := delivery.NewHealthHandler(checks...)
+	router.GET("/healthz", h.Liveness)
+	router.GET("/readyz", h.Readiness)
+	return router
+}
+
+// TestHealthRun tests that failing and slow checks mark the report as failed
+func TestHealthRun(t *testing.T) {
+	report := health.Run(context.Background(), []health.Check{
+		{Name: "ok", Run: func(ctx context.Context) error { return nil }},
+		{Name: "broken", Run: func(ctx context.Context) error { return errors.New("boom") }},
+		{Name: "slow", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}, 50*time.Millisecond)
+
+	assert.False(t, report.OK())
+	assert.Equal(t, health.StatusOK, report.Checks["ok"].Status)
+	assert.Equal(t, "boom", report.Checks["broken"].Error)
+	assert.Equal(t, health.StatusFail, report.Checks["slow"].Status)
+	assert.Contains(t, report.Checks["slow"].Error, "deadline exceeded")
+}
+
+// TestHealthHandler_Readiness tests the probe status codes and that check errors are not exposed
+func TestHealthHandler_Readiness(t *testing.T) {
+	var dbErr error
+	router := newHealthRouter(
+		health.Check{Name: "database", Run: func(ctx context.Context) error { return dbErr }},
+		health.Check{Name: "migrations", Run: func(ctx context.Context) error { return nil }},
+	)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	dbErr = errors.New("connection refused")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), "connection refused")
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusFail, report.Checks["database"].Status)
+	assert.Equal(t, health.StatusOK, report.Checks["migrations"].Status)
+
+	// Liveness tidak bergantung pada database
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"backend/internal/jobs"
+	"backend/internal/jobs/repository"
+	"backend/internal/jobs/usecase"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type sendReminder struct {
+	ConversationID int `json:"conversation_id"`
+}
+
+func (sendReminder) JobType() string { return "test.reminder" }
+
+var jobRowColumns = []string{"job_id", "client_id", "job_type", "payload", "status", "attempts", "max_attempts", "run_at", "unique_key",
+	"last_error", "locked_by", "locked_at", "created_at", "finished_at"}
+
+func claimedJobRow(attempts, maxAttempts int) *sqlmock.Rows {
+	return sqlmock.NewRows(jobRowColumns).AddRow(3, 1, "test.reminder", `{"conversation_id":10}`, jobs.StatusRunning,
+		attempts, maxAttempts, time.Now(), nil, "", "worker-1", time.Now(), time.Now(), nil)
+}
+
+// TestCronScheduleNext tests cron parsing and next-run calculation
+func TestCronScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	cases := []struct {
+		spec, from, want string
+	}{
+		{"*/15 * * * *", "2024-03-01 10:07", "2024-03-01 10:15"},
+		{"*/15 * * * *", "2024-03-01 10:45", "2024-03-01 11:00"},
+		{"0 9 * * 1-5", "2024-03-01 17:00", "2024-03-04 09:00"}, // Jumat sore ke Senin pagi
+		{"@daily", "2024-12-31 23:59", "2025-01-01 00:00"},
+		{"30 2 29 2 *", "2024-03-01 00:00", "2028-02-29 02:30"},
+		{"0 0 13 * 5", "2024-09-01 00:00", "2024-09-06 00:00"}, // Tanggal 13 atau hari Jumat
+	}
+	for _, c := range cases {
+		s, err := jobs.ParseSchedule(c.spec)
+		if !assert.NoError(t, err, c.spec) {
+			continue
+		}
+		assert.Equal(t, at(c.want), s.Next(at(c.from)), c.spec)
+	}
+
+	for _, bad := range []string{"61 * * * *", "* * *", "*/0 * * * *", "5-1 * * * *"} {
+		_, err := jobs.ParseSchedule(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestJobEnqueue_UniqueKeyReturnsExisting tests that a duplicate unique key returns the active job
+func TestJobEnqueue_UniqueKeyReturnsExisting(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO jobs").WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT job_id FROM jobs WHERE unique_key").WithArgs("reminder:10").
+		WillReturnRows(sqlmock.NewRows([]string{"job_id"}).AddRow(42))
+
+	q := usecase.NewJobQueue(repository.NewJobRepository(db))
+	id, err := q.Enqueue(sendReminder{ConversationID: 10}, jobs.UniqueKey("reminder:10"), jobs.After(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 42, id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestJobProcessNext_Success tests that a typed handler receives its payload and the job completes
+func TestJobProcessNext_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE jobs SET status").WillReturnRows(claimedJobRow(1, 5))
+	mock.ExpectExec("UPDATE jobs SET status").WithArgs(jobs.StatusSucceeded, 3, sqlmock.AnyArg(), jobs.StatusRunning).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var got []int
+	q := usecase.NewJobQueue(repository.NewJobRepository(db))
+	q.Register("test.reminder", jobs.Typed(func(ctx context.Context, p sendReminder) error {
+		got = append(got, p.ConversationID)
+		return nil
+	}))
+
+	processed, err := q.ProcessNext(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, []int{10}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestJobProcessNext_LostOwnership tests that a job requeued while running is not overwritten by the stale worker
+func TestJobProcessNext_LostOwnership(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE jobs SET status").WillReturnRows(claimedJobRow(1, 5))
+	mock.ExpectExec("UPDATE jobs SET status .* WHERE job_id = \\$2 AND locked_by = \\$3 AND status = \\$4").
+		WithArgs(jobs.StatusSucceeded, 3, sqlmock.AnyArg(), jobs.StatusRunning).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	q := usecase.NewJobQueue(repository.NewJobRepository(db))
+	q.Register("test.reminder", func(ctx context.Context, j jobs.Job) error { return nil })
+
+	processed, err := q.ProcessNext(context.Background())
+	assert.True(t, processed)
+	assert.ErrorIs(t, err, jobs.ErrLostOwnership)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestJobProcessNext_RetryThenFail tests backoff retries and failure after the last attempt
+func TestJobProcessNext_RetryThenFail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE jobs SET status").WillReturnRows(claimedJobRow(1, 2))
+	mock.ExpectExec("UPDATE jobs SET status").
+		WithArgs(jobs.StatusQueued, sqlmock.AnyArg(), "smtp unavailable", 3, sqlmock.AnyArg(), jobs.StatusRunning).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE jobs SET status").WillReturnRows(claimedJobRow(2, 2))
+	mock.ExpectExec("UPDATE jobs SET status").
+		WithArgs(jobs.StatusFailed, "smtp unavailable", 3, sqlmock.AnyArg(), jobs.StatusRunning).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	q := usecase.NewJobQueue(repository.NewJobRepository(db))
+	q.Register("test.reminder", func(ctx context.Context, j jobs.Job) error {
+		return errors.New("smtp unavailable")
+	})
+
+	for i := 0; i < 2; i++ {
+		processed, err := q.ProcessNext(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, processed)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestJobProcessNext_PanicIsPermanentFailure tests that panics are recovered and permanent errors skip retries
+func TestJobProcessNext_PanicIsPermanentFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE jobs SET status").WillReturnRows(claimedJobRow(1, 5))
+	mock.ExpectExec("UPDATE jobs SET status").
+		WithArgs(jobs.StatusQueued, sqlmock.AnyArg(), "panic: boom", 3, sqlmock.AnyArg(), jobs.StatusRunning).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE jobs SET status").WillReturnRows(claimedJobRow(1, 5))
+	mock.ExpectExec("UPDATE jobs SET status").
+		WithArgs(jobs.StatusFailed, sqlmock.AnyArg(), 3, sqlmock.AnyArg(), jobs.StatusRunning).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	calls := 0
+	q := usecase.NewJobQueue(repository.NewJobRepository(db))
+	q.Register("test.reminder", func(ctx context.Context, j jobs.Job) error {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		return fmt.Errorf("%w: conversation deleted", jobs.ErrPermanent)
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := q.ProcessNext(context.Background())
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestJobRequeueStale_FailsExhaustedJobs tests that stale jobs without attempts left are failed instead of requeued
+func TestJobRequeueStale_FailsExhaustedJobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE jobs SET status = CASE WHEN attempts >= max_attempts THEN \$1 ELSE \$2 END`).
+		WithArgs(jobs.StatusFailed, jobs.StatusQueued, jobs.StatusRunning, float64(300)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := repository.NewJobRepository(db).RequeueStale(5 * time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
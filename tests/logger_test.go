@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"backend/config"
+	"backend/internal/users/delivery"
+	"backend/internal/users/repository"
+	"backend/internal/users/usecase"
+	"backend/middleware"
+	"backend/pkg/logger"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// logLines mengurai output logger JSON menjadi satu map per baris
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+// TestLogger_RedactsSensitiveAttributes tests that secrets never reach the log output
+func TestLogger_RedactsSensitiveAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	l := logger.New(config.LogConfig{Level: "info", Format: "json"}, &buf)
+
+	l.Info("login", "username", "jane", "password", "hunter2", "Authorization", "Bearer abc.def",
+		slog.Group("request", "access_token", "xyz", "path", "/login"))
+	l.Debug("hidden below the configured level")
+
+	out := buf.String()
+	for _, secret := range []string{"hunter2", "abc.def", "xyz"} {
+		assert.NotContains(t, out, secret)
+	}
+	lines := logLines(t, &buf)
+	if assert.Len(t, lines, 1) {
+		assert.Equal(t, "jane", lines[0]["username"])
+		assert.Equal(t, "[REDACTED]", lines[0]["password"])
+		assert.Equal(t, map[string]any{"access_token": "[REDACTED]", "path": "/login"}, lines[0]["request"])
+	}
+}
+
+// TestLoggerMiddleware_AccessLog tests request-scoped logging, the access log and panic recovery
+func TestLoggerMiddleware_AccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	router := gin.New()
+	router.Use(middleware.RequestContext(time.Second), middleware.Logger(logger.New(config.LogConfig{Level: "info", Format: "json"}, &buf)), middleware.Recovery())
+	router.GET("/hello", func(c *gin.Context) {
+		logger.From(c.Request.Context()).Info("inside handler")
+		c.String(http.StatusOK, "hi")
+	})
+	router.GET("/boom", func(c *gin.Context) {
+		panic("kaboom")
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/hello?access_token=secret", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	lines := logLines(t, &buf)
+	if assert.Len(t, lines, 2) {
+		// Log dari handler dan access log sama-sama membawa ID request
+		assert.Equal(t, "inside handler", lines[0]["msg"])
+		assert.Equal(t, "req-1", lines[0]["request_id"])
+		assert.Equal(t, "HTTP request", lines[1]["msg"])
+		assert.Equal(t, "req-1", lines[1]["request_id"])
+		assert.Equal(t, "INFO", lines[1]["level"])
+		assert.Equal(t, "/hello", lines[1]["path"])
+		assert.Equal(t, float64(http.StatusOK), lines[1]["status"])
+		assert.Equal(t, float64(2), lines[1]["bytes"])
+		assert.Contains(t, lines[1], "latency")
+	}
+	assert.NotContains(t, buf.String(), "secret")
+
+	buf.Reset()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	lines = logLines(t, &buf)
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, "kaboom", lines[0]["panic"])
+		assert.Equal(t, "ERROR", lines[1]["level"])
+		assert.Equal(t, float64(http.StatusInternalServerError), lines[1]["status"])
+	}
+}
+
+// TestCreateUser_DoesNotLogPassword tests that the plaintext password is not logged at any level
+func TestCreateUser_DoesNotLogPassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO users").WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(5))
+	mock.ExpectExec("INSERT INTO outbox_events").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	router := gin.New()
+	router.Use(middleware.RequestContext(time.Second), middleware.Logger(logger.New(config.LogConfig{Level: "debug", Format: "text"}, &buf)))
+	router.POST("/users", delivery.NewUserHandler(usecase.NewUserUsecase(repository.NewUserRepository(db))).CreateUser)
+
+	w := httptest.NewRecorder()
+	body := `{"username":"jane","email":"jane@example.com","password":"S3cret-Passw0rd","client_id":7}`
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, buf.String(), "S3cret-Passw0rd")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
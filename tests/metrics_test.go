@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"backend/internal/conversations"
+	conversationRepository "backend/internal/conversations/repository"
+	conversationUsecase "backend/internal/conversations/usecase"
+	"backend/internal/events"
+	"backend/internal/jobs/repository"
+	jobUsecase "backend/internal/jobs/usecase"
+	"backend/internal/realtime"
+	userDelivery "backend/internal/users/delivery"
+	userRepository "backend/internal/users/repository"
+	userUsecase "backend/internal/users/usecase"
+	"backend/middleware"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// metricValue mengembalikan nilai counter di registry default dengan label persis labels,
+// atau 0 jika seri tersebut belum ada
+func metricValue(t *testing.T, name string, labels prometheus.Labels) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			if len(m.GetLabel()) != len(labels) {
+				continue
+			}
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] != l.GetValue() {
+					continue metrics
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+// discardPublisher menerima event real-time tanpa meneruskannya
+type discardPublisher struct{}
+
+func (discardPublisher) Publish(realtime.Event) error { return nil }
+
+// TestMetricsMiddleware_RouteTemplates tests that requests are recorded per route template and exposed on /metrics
+func TestMetricsMiddleware_RouteTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Metrics())
+	router.GET("/metrics-test/items/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	router.GET("/metrics-test/idle", func(c *gin.Context) {})
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	middleware.InitRouteMetrics(router.Routes())
+
+	items := prometheus.Labels{"method": "GET", "route": "/metrics-test/items/:id", "status": "204"}
+	before := metricValue(t, "http_requests_total", items)
+	for _, path := range []string{"/metrics-test/items/1", "/metrics-test/items/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics-test/random-scan", nil))
+	assert.Equal(t, before+2, metricValue(t, "http_requests_total", items))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "version=0.0.4")
+	body := w.Body.String()
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/metrics-test/items/:id"}`)
+	// Route yang belum pernah dipanggil sudah muncul, path yang tidak dikenal digabung
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/metrics-test/idle"} 0`)
+	assert.Contains(t, body, `route="unmatched",status="404"`)
+	assert.NotContains(t, body, "random-scan")
+}
+
+// TestLogin_CountsAttempts tests the login success and failure counters
+func TestLogin_CountsAttempts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").WillReturnError(sql.ErrNoRows)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/login", userDelivery.NewUserHandler(userUsecase.NewUserUsecase(userRepository.NewUserRepository(db))).Login)
+
+	failure := prometheus.Labels{"result": "failure"}
+	before := metricValue(t, "auth_login_attempts_total", failure)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"email":"nobody@example.com","password":"x"}`)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, before+1, metricValue(t, "auth_login_attempts_total", failure))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestJWTMiddleware_CountsRevokedTokens tests that blacklisted tokens are counted
+func TestJWTMiddleware_CountsRevokedTokens(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT EXISTS").WithArgs("revoked-token").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/me", middleware.JWTMiddleware(db), func(c *gin.Context) {})
+
+	before := metricValue(t, "auth_revoked_token_hits_total", nil)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer revoked-token")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, before+1, metricValue(t, "auth_revoked_token_hits_total", nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestDepthCollector tests that job queue depths are exposed per type and status alongside pool stats
+func TestDepthCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT job_type, status, COUNT").
+		WillReturnRows(sqlmock.NewRows([]string{"job_type", "status", "count"}).
+			AddRow("webhooks.deliver", "queued", 12).
+			AddRow("webhooks.deliver", "running", 2))
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(jobUsecase.DepthCollector(jobUsecase.NewJobQueue(repository.NewJobRepository(db))))
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(`# HELP jobs_queue_depth Unfinished background jobs by type and status.
+# TYPE jobs_queue_depth gauge
+jobs_queue_depth{status="queued",type="webhooks.deliver"} 12
+jobs_queue_depth{status="running",type="webhooks.deliver"} 2
+`), "jobs_queue_depth")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Kegagalan query dilaporkan sebagai error scrape, bukan nilai 0 yang menyesatkan
+	mock.ExpectQuery("SELECT job_type, status, COUNT").WillReturnError(sql.ErrConnDone)
+	_, err = reg.Gather()
+	assert.Error(t, err)
+
+	dbReg := prometheus.NewPedanticRegistry()
+	dbReg.MustRegister(collectors.NewDBStatsCollector(db, "test"))
+	count, err := testutil.GatherAndCount(dbReg, "go_sql_open_connections")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+// TestRecordMessage_CountsPerChannel tests the per-channel message counters
+func TestRecordMessage_CountsPerChannel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO messages").WillReturnRows(sqlmock.NewRows([]string{"message_id"}).AddRow(9))
+	mock.ExpectExec("UPDATE conversations SET updated_at").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO outbox_events").
+		WithArgs(sqlmock.AnyArg(), 7, realtime.EventMessageCreated, sqlmock.AnyArg(), events.StatusPending, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	uc := conversationUsecase.NewConversationUsecase(conversationRepository.NewConversationRepository(db), nil, nil, nil, discardPublisher{})
+	labels := prometheus.Labels{"channel": conversations.ChannelSMS, "direction": conversations.DirectionOutbound}
+	before := metricValue(t, "messages_total", labels)
+	_, err = uc.RecordMessage(conversations.Message{ConversationID: 3, ClientID: 7, Channel: conversations.ChannelSMS, Direction: conversations.DirectionOutbound, Body: "Thanks!"})
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, metricValue(t, "messages_total", labels))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
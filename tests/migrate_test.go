@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"backend/internal/migrate"
+	"backend/internal/migrate/repository"
+	"backend/internal/migrate/usecase"
+	"backend/migrations"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMigrations = fstest.MapFS{
+	"0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+	"0001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	"0002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+	"0002_create_b.down.sql": {Data: []byte("DROP TABLE b;")},
+	"README.md":              {Data: []byte("ignored")},
+}
+
+var appliedColumns = []string{"version", "name", "checksum", "applied_at"}
+
+// expectMigrationLock menyiapkan lock, tabel schema_migrations dan daftar versi yang sudah diterapkan
+func expectMigrationLock(mock sqlmock.Sqlmock, applied *sqlmock.Rows) {
+	mock.ExpectExec("SELECT pg_advisory_lock").WithArgs(44001).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").WillReturnRows(applied)
+}
+
+func newTestMigrator(t *testing.T) (usecase.Migrator, sqlmock.Sqlmock, []migrate.Migration) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	list, err := migrate.Load(testMigrations)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	return usecase.NewMigrator(repository.NewMigrationRepository(db), list), mock, list
+}
+
+// TestMigrateLoad_Embedded tests that the embedded migrations are ordered and reversible
+func TestMigrateLoad_Embedded(t *testing.T) {
+	list, err := migrate.Load(migrations.FS)
+	assert.NoError(t, err)
+	if !assert.NotEmpty(t, list) {
+		return
+	}
+	for i, m := range list {
+		if i > 0 {
+			assert.Greater(t, m.Version, list[i-1].Version)
+		}
+		assert.NotEmpty(t, strings.TrimSpace(m.Down), m.String())
+	}
+
+	// Setiap tabel yang dibuat skema awal juga dihapus oleh down-nya
+	created := regexp.MustCompile(`CREATE TABLE IF NOT EXISTS (\w+)`).FindAllStringSubmatch(list[0].Up, -1)
+	assert.NotEmpty(t, created)
+	for _, match := range created {
+		assert.Contains(t, list[0].Down, "DROP TABLE IF EXISTS "+match[1]+";")
+	}
+}
+
+// TestMigrateLoad_Invalid tests rejection of inconsistent migration files
+func TestMigrateLoad_Invalid(t *testing.T) {
+	_, err := migrate.Load(fstest.MapFS{"0001_a.down.sql": {Data: []byte("DROP TABLE a;")}})
+	assert.ErrorContains(t, err, "has no up file")
+
+	_, err = migrate.Load(fstest.MapFS{
+		"0001_a.up.sql": {Data: []byte("CREATE TABLE a ();")},
+		"0001_b.up.sql": {Data: []byte("CREATE TABLE b ();")},
+	})
+	assert.ErrorContains(t, err, "migration version 1 is used by both")
+}
+
+// TestMigrator_Up tests that only pending migrations are applied, each in its own transaction
+func TestMigrator_Up(t *testing.T) {
+	m, mock, list := newTestMigrator(t)
+
+	expectMigrationLock(mock, sqlmock.NewRows(appliedColumns).AddRow(1, "create_a", list[0].Checksum, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b (id INT);")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(int64(2), "create_b", list[1].Checksum).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(44001).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := m.Up(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, applied, 1) {
+		assert.Equal(t, "0002_create_b", applied[0].String())
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestMigrator_Up_SingleConnection tests that migrations run on the connection holding the lock when the pool allows only one
+func TestMigrator_Up_SingleConnection(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	list, err := migrate.Load(testMigrations)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	expectMigrationLock(mock, sqlmock.NewRows(appliedColumns).AddRow(1, "create_a", list[0].Checksum, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b (id INT);")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(44001).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	applied, err := usecase.NewMigrator(repository.NewMigrationRepository(db), list).Up(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestMigrator_ChecksumMismatch tests that a modified applied migration blocks further migrations
+func TestMigrator_ChecksumMismatch(t *testing.T) {
+	m, mock, _ := newTestMigrator(t)
+
+	expectMigrationLock(mock, sqlmock.NewRows(appliedColumns).AddRow(1, "create_a", "edited", time.Now()))
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err := m.Up(context.Background())
+	assert.ErrorIs(t, err, usecase.ErrChecksumMismatch)
+	assert.ErrorContains(t, err, "0001_create_a")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestMigrator_Down tests reverting the latest applied migration
+func TestMigrator_Down(t *testing.T) {
+	m, mock, list := newTestMigrator(t)
+
+	expectMigrationLock(mock, sqlmock.NewRows(appliedColumns).
+		AddRow(1, "create_a", list[0].Checksum, time.Now()).
+		AddRow(2, "create_b", list[1].Checksum, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("DROP TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations").WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	reverted, err := m.Down(context.Background(), 1)
+	assert.NoError(t, err)
+	if assert.Len(t, reverted, 1) {
+		assert.Equal(t, int64(2), reverted[0].Version)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = m.Down(context.Background(), 0)
+	assert.ErrorIs(t, err, usecase.ErrInvalidSteps)
+}
+
+// TestMigrator_Status tests pending, modified and unknown versions in the status report
+func TestMigrator_Status(t *testing.T) {
+	m, mock, _ := newTestMigrator(t)
+
+	expectMigrationLock(mock, sqlmock.NewRows(appliedColumns).
+		AddRow(1, "create_a", "edited", time.Now()).
+		AddRow(7, "from_newer_release", "abc", time.Now()))
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	status, err := m.Status(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, status, 3) {
+		assert.Equal(t, migrate.StateModified, status[0].State)
+		assert.Equal(t, migrate.StatePending, status[1].State)
+		assert.Nil(t, status[1].AppliedAt)
+		assert.Equal(t, migrate.StateMissing, status[2].State)
+		assert.Equal(t, "from_newer_release", status[2].Name)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestMigrator_Check tests the lock-free check used by the readiness probe
+func TestMigrator_Check(t *testing.T) {
+	m, mock, list := newTestMigrator(t)
+
+	query := "SELECT version, name, checksum, applied_at FROM schema_migrations"
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(appliedColumns).
+		AddRow(1, "create_a", list[0].Checksum, time.Now()))
+	err := m.Check(context.Background())
+	assert.ErrorIs(t, err, usecase.ErrPending)
+	assert.ErrorContains(t, err, "0002_create_b")
+
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(appliedColumns).
+		AddRow(1, "create_a", list[0].Checksum, time.Now()).
+		AddRow(2, "create_b", list[1].Checksum, time.Now()))
+	assert.NoError(t, m.Check(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
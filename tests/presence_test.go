@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"backend/internal/events"
+	"backend/internal/presence"
+	"backend/internal/presence/repository"
+	"backend/internal/presence/usecase"
+	"backend/internal/realtime"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var presenceRowColumns = []string{"user_id", "client_id", "username", "status", "reason", "auto", "last_activity_at", "changed_at", "count"}
+
+// expectStatusChange mengharapkan perubahan status, pembacaan ulang presence dan event outbox-nya
+// dalam satu transaksi
+func expectStatusChange(mock sqlmock.Sqlmock, userID, clientID int, status, reason string, auto bool, current *sqlmock.Rows) {
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO agent_presence").WithArgs(userID, clientID, status, reason, auto).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE agent_status_history SET ended_at").WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO agent_status_history").WithArgs(userID, clientID, status, reason, auto).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT u.user_id, u.client_id, u.username").WithArgs(userID).WillReturnRows(current)
+	mock.ExpectExec("INSERT INTO outbox_events").
+		WithArgs(sqlmock.AnyArg(), clientID, realtime.EventPresenceUpdated, sqlmock.AnyArg(), events.StatusPending, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+// TestPresenceSetStatus_UnknownBreakReason tests that agents can only pick break reasons configured by the client
+func TestPresenceSetStatus_UnknownBreakReason(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT idle_timeout_seconds, break_reasons FROM presence_settings").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"idle_timeout_seconds", "break_reasons"}).AddRow(300, pq.StringArray{"lunch", "training"}))
+
+	router := &fakeQueueRouter{}
+	uc := usecase.NewPresenceUsecase(repository.NewPresenceRepository(db), router, realtime.NewHub(nil, ""))
+	_, err = uc.SetStatus(1, 7, presence.StatusAway, "gaming")
+	assert.ErrorIs(t, err, usecase.ErrInvalidReason)
+
+	_, err = uc.SetStatus(1, 7, presence.StatusOnline, "lunch")
+	assert.ErrorIs(t, err, usecase.ErrInvalidReason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestPresenceProcessIdle tests that idle agents are automatically set away and the change is broadcast
+func TestPresenceProcessIdle(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT p.user_id, p.client_id").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "client_id", "reason"}).AddRow(7, 1, presence.ReasonDisconnected))
+	expectStatusChange(mock, 7, 1, presence.StatusAway, presence.ReasonDisconnected, true,
+		sqlmock.NewRows(presenceRowColumns).AddRow(7, 1, "agent", presence.StatusAway, presence.ReasonDisconnected, true, time.Now(), time.Now(), 0))
+
+	hub := realtime.NewHub(nil, "")
+	sub := hub.Subscribe(1, 99)
+	router := &fakeQueueRouter{}
+	uc := usecase.NewPresenceUsecase(repository.NewPresenceRepository(db), router, hub)
+
+	n, err := uc.ProcessIdle()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, router.clients)
+
+	e := <-sub.Events
+	assert.Equal(t, realtime.EventPresenceUpdated, e.Type)
+	assert.Equal(t, presence.StatusAway, e.Data.(*presence.Presence).Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestPresenceReconnect_RestoresAutoAway tests that an agent set away by the system comes back online
+// on reconnect and the queue is routed to them
+func TestPresenceReconnect_RestoresAutoAway(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	hub := realtime.NewHub(nil, "")
+	sub := hub.Subscribe(1, 7)
+
+	mock.ExpectExec("INSERT INTO presence_connections").WithArgs(sub.ID, 7, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE agent_presence SET last_activity_at").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT u.user_id, u.client_id, u.username").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(presenceRowColumns).AddRow(7, 1, "agent", presence.StatusAway, presence.ReasonIdle, true, time.Now(), time.Now(), 1))
+	expectStatusChange(mock, 7, 1, presence.StatusOnline, "", false,
+		sqlmock.NewRows(presenceRowColumns).AddRow(7, 1, "agent", presence.StatusOnline, "", false, time.Now(), time.Now(), 1))
+
+	router := &fakeQueueRouter{}
+	uc := usecase.NewPresenceUsecase(repository.NewPresenceRepository(db), router, hub)
+	uc.Connected(sub)
+
+	assert.Equal(t, []int{1}, router.clients)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
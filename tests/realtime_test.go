@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"backend/internal/realtime"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHub_TenantFanOut tests that events only reach subscribers of the same client
+func TestHub_TenantFanOut(t *testing.T) {
+	hub := realtime.NewHub(nil, "")
+	agentA := hub.Subscribe(1, 10)
+	agentB := hub.Subscribe(1, 11)
+	otherTenant := hub.Subscribe(2, 20)
+
+	err := hub.Publish(realtime.Event{Type: realtime.EventMessageCreated, ClientID: 1, Data: "hello"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, realtime.EventMessageCreated, (<-agentA.Events).Type)
+	assert.Equal(t, realtime.EventMessageCreated, (<-agentB.Events).Type)
+	assert.Len(t, otherTenant.Events, 0)
+}
+
+// TestHub_UserTargetedEvent tests that events with UserID only reach that user
+func TestHub_UserTargetedEvent(t *testing.T) {
+	hub := realtime.NewHub(nil, "")
+	agentA := hub.Subscribe(1, 10)
+	agentB := hub.Subscribe(1, 11)
+
+	hub.Publish(realtime.Event{Type: realtime.EventConversationAssigned, ClientID: 1, UserID: 11})
+
+	assert.Len(t, agentA.Events, 0)
+	assert.Equal(t, realtime.EventConversationAssigned, (<-agentB.Events).Type)
+}
+
+// TestHub_SlowSubscriberDropped tests that a full subscriber buffer closes the stream
+func TestHub_SlowSubscriberDropped(t *testing.T) {
+	hub := realtime.NewHub(nil, "")
+	slow := hub.Subscribe(1, 10)
+
+	for i := 0; i < 100; i++ {
+		hub.Publish(realtime.Event{Type: realtime.EventMessageCreated, ClientID: 1})
+	}
+
+	count := 0
+	for range slow.Events {
+		count++
+	}
+	assert.Equal(t, 64, count)
+
+	// Unsubscribing an already dropped subscriber must not panic
+	assert.NotPanics(t, func() { hub.Unsubscribe(slow) })
+}
+
+// TestHub_CloseStreams tests that shutdown ends every stream and waits for handlers to unsubscribe
+func TestHub_CloseStreams(t *testing.T) {
+	hub := realtime.NewHub(nil, "")
+	agentA := hub.Subscribe(1, 10)
+	agentB := hub.Subscribe(2, 20)
+
+	hub.CloseStreams()
+	_, open := <-agentA.Events
+	assert.False(t, open)
+	_, open = <-agentB.Events
+	assert.False(t, open)
+
+	// Subscriber baru setelah shutdown langsung ditutup
+	late := hub.Subscribe(1, 11)
+	_, open = <-late.Events
+	assert.False(t, open)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, hub.WaitStreams(ctx), context.DeadlineExceeded)
+
+	hub.Unsubscribe(agentA)
+	hub.Unsubscribe(agentB)
+	hub.Unsubscribe(late)
+	assert.NoError(t, hub.WaitStreams(context.Background()))
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"backend/internal/events"
+	"backend/internal/users"
+	"backend/internal/users/repository"
+	"backend/internal/users/usecase"
+	"backend/middleware"
+	"backend/pkg/requestctx"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequestContext_RequestIDAndDeadline tests request ID reuse/generation and deadline exemptions
+func TestRequestContext_RequestIDAndDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestContext(time.Second, "/stream"))
+	handler := func(c *gin.Context) {
+		_, hasDeadline := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"request_id": requestctx.RequestID(c.Request.Context()), "deadline": hasDeadline})
+	}
+	router.GET("/api", handler)
+	router.GET("/stream", handler)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set(middleware.RequestIDHeader, "proxy-abc.123")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "proxy-abc.123", w.Header().Get(middleware.RequestIDHeader))
+	assert.JSONEq(t, `{"request_id":"proxy-abc.123","deadline":true}`, w.Body.String())
+
+	// ID yang tidak aman untuk log diganti ID baru
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/stream", nil)
+	req.Header.Set(middleware.RequestIDHeader, "bad id\nInjected: 1")
+	router.ServeHTTP(w, req)
+	id := w.Header().Get(middleware.RequestIDHeader)
+	assert.Len(t, id, 32)
+	assert.JSONEq(t, `{"request_id":"`+id+`","deadline":false}`, w.Body.String())
+}
+
+// TestUserUsecase_CancelledContext tests that a cancelled request context stops the query
+func TestUserUsecase_CancelledContext(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, username, email").WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "email", "role_id", "client_id", "created_at"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	uc := usecase.NewUserUsecase(repository.NewUserRepository(db))
+
+	start := time.Now()
+	_, err = uc.GetAllUsers(ctx)
+	// Driver melaporkan pembatalan dengan error-nya sendiri, seperti lib/pq
+	assert.ErrorContains(t, err, "canceling query")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+// TestDeleteUser_RecordsActor tests that the actor from the request context reaches the domain event
+func TestDeleteUser_RecordsActor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, username, email").WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "email", "role_id", "client_id", "created_at"}).
+			AddRow(3, "jane", "jane@example.com", 2, 7, "2024-01-01"))
+	mock.ExpectExec("DELETE FROM users").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO outbox_events").
+		WithArgs(sqlmock.AnyArg(), 7, users.EventDeleted, []byte(`{"user_id":3,"client_id":7,"username":"jane","deleted_by":42}`), events.StatusPending, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ctx := requestctx.WithActor(context.Background(), requestctx.Actor{UserID: 42, Username: "admin", ClientID: 7})
+	assert.Equal(t, 7, requestctx.Tenant(ctx))
+
+	uc := usecase.NewUserUsecase(repository.NewUserRepository(db))
+	_, err = uc.DeleteUser(ctx, 3, "")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
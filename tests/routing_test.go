@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"backend/internal/routing"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func routingAgents() []routing.Agent {
+	earlier := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+	return []routing.Agent{
+		{UserID: 1, Available: true, MaxConcurrent: 3, ActiveCount: 1, LastAssignedAt: &later, Skills: map[string]int{"billing": 2}},
+		{UserID: 2, Available: true, MaxConcurrent: 3, ActiveCount: 2, LastAssignedAt: &earlier, Skills: map[string]int{"billing": 5, "english": 3}},
+		{UserID: 3, Available: false, MaxConcurrent: 3, ActiveCount: 0, Skills: map[string]int{"billing": 5, "english": 5}},
+		{UserID: 4, Available: true, MaxConcurrent: 2, ActiveCount: 2, Skills: map[string]int{"english": 5}},
+	}
+}
+
+// TestSelectAgent_RoundRobin tests that the least recently assigned available agent is chosen
+func TestSelectAgent_RoundRobin(t *testing.T) {
+	settings := routing.DefaultSettings(1)
+	settings.Strategy = routing.StrategyRoundRobin
+
+	agent := routing.SelectAgent(settings, routingAgents(), routing.Request{})
+	assert.Equal(t, 2, agent.UserID)
+}
+
+// TestSelectAgent_LeastBusy tests that the agent with the fewest open conversations is chosen
+func TestSelectAgent_LeastBusy(t *testing.T) {
+	settings := routing.DefaultSettings(1)
+	settings.Strategy = routing.StrategyLeastBusy
+
+	agent := routing.SelectAgent(settings, routingAgents(), routing.Request{})
+	assert.Equal(t, 1, agent.UserID)
+}
+
+// TestSelectAgent_SkillsBased tests skill matching, proficiency scoring and capacity limits
+func TestSelectAgent_SkillsBased(t *testing.T) {
+	settings := routing.DefaultSettings(1)
+	settings.Strategy = routing.StrategySkillsBased
+
+	agent := routing.SelectAgent(settings, routingAgents(), routing.Request{RequiredSkills: []string{"billing"}})
+	assert.Equal(t, 2, agent.UserID)
+
+	// Agent 3 is offline and agent 4 is at capacity
+	agent = routing.SelectAgent(settings, routingAgents(), routing.Request{RequiredSkills: []string{"english", "billing"}})
+	assert.Equal(t, 2, agent.UserID)
+
+	agent = routing.SelectAgent(settings, routingAgents(), routing.Request{RequiredSkills: []string{"spanish"}})
+	assert.Nil(t, agent)
+}
+
+// TestSelectAgent_Sticky tests that the last agent is preferred and the fallback strategy is used otherwise
+func TestSelectAgent_Sticky(t *testing.T) {
+	settings := routing.DefaultSettings(1)
+	settings.Strategy = routing.StrategySticky
+	settings.FallbackStrategy = routing.StrategyLeastBusy
+
+	agent := routing.SelectAgent(settings, routingAgents(), routing.Request{LastAgentID: 2})
+	assert.Equal(t, 2, agent.UserID)
+
+	// Last agent is offline, so the least busy agent is chosen
+	agent = routing.SelectAgent(settings, routingAgents(), routing.Request{LastAgentID: 3})
+	assert.Equal(t, 1, agent.UserID)
+}
+
+// TestSelectAgent_NoCapacity tests that conversations wait in the queue when nobody is available
+func TestSelectAgent_NoCapacity(t *testing.T) {
+	agents := []routing.Agent{{UserID: 1, Available: true, MaxConcurrent: 1, ActiveCount: 1}, {UserID: 2, Available: false, MaxConcurrent: 5}}
+	assert.Nil(t, routing.SelectAgent(routing.DefaultSettings(1), agents, routing.Request{}))
+}
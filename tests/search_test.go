@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"backend/internal/conversations"
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/search"
+	"backend/internal/search/delivery"
+	"backend/internal/search/repository"
+	"backend/internal/search/usecase"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var searchHitColumns = []string{"id", "conversation_id", "message_id", "contact_id", "contact_name", "channel", "status",
+	"assignee_id", "headline", "rank", "total", "created_at"}
+
+// TestSearchMessages_HighlightAndFilters tests tenant-scoped message search with escaped highlights
+func TestSearchMessages_HighlightAndFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	uc := usecase.NewSearchUsecase(repository.NewSearchRepository(db))
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	agentID := 4
+
+	mock.ExpectQuery("SELECT search_language FROM clients").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"search_language"}).AddRow("english"))
+	mock.ExpectQuery("websearch_to_tsquery").
+		WithArgs(1, "english", `"refund request"`, "sms", "open", &agentID, &from, nil, 10, 10, sqlmock.AnyArg(), search.MarkStart+search.MarkStop).
+		WillReturnRows(sqlmock.NewRows(searchHitColumns).AddRow(
+			31, 7, 31, 3, "Budi", "sms", "open", 4,
+			"<script>x</script> my "+search.MarkStart+"refund"+search.MarkStop+" please", 0.4, 11, time.Now()))
+
+	page, err := uc.Search(1, search.Query{
+		Text: `  "refund request" `, Scope: search.ScopeMessages, Channel: "sms", Status: "open",
+		AgentID: &agentID, From: &from, Page: 2, PerPage: 10,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 11, page.Total)
+	assert.Equal(t, 2, page.Page)
+	if assert.Len(t, page.Results, 1) {
+		hit := page.Results[0]
+		assert.Equal(t, "message", hit.Type)
+		assert.Equal(t, 7, *hit.ConversationID)
+		assert.Equal(t, "&lt;script&gt;x&lt;/script&gt; my <mark>refund</mark> please", hit.Highlight)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSearchContacts_PhoneQueryNormalized tests that phone numbers are searched by digits
+func TestSearchContacts_PhoneQueryNormalized(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	uc := usecase.NewSearchUsecase(repository.NewSearchRepository(db))
+
+	mock.ExpectQuery("SELECT search_language FROM clients").WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("FROM contacts ct").
+		WithArgs(1, search.DefaultLanguage, "628123456789", nil, nil, search.DefaultPerPage, 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(searchHitColumns))
+
+	page, err := uc.Search(1, search.Query{Text: "+62 812-3456-789", Scope: search.ScopeContacts})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, page.Total)
+	assert.NotNil(t, page.Results)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSearch_InvalidInput tests query and scope validation
+func TestSearch_InvalidInput(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	uc := usecase.NewSearchUsecase(repository.NewSearchRepository(db))
+
+	_, err = uc.Search(1, search.Query{Text: "   "})
+	assert.ErrorIs(t, err, usecase.ErrEmptyQuery)
+
+	mock.ExpectQuery("SELECT search_language FROM clients").
+		WillReturnRows(sqlmock.NewRows([]string{"search_language"}).AddRow("simple"))
+	_, err = uc.Search(1, search.Query{Text: "invoice", Scope: "users"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidScope)
+
+	assert.ErrorIs(t, uc.SetLanguage(1, "klingon"), usecase.ErrUnsupportedLanguage)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSearchSetLanguage_Reindexes tests that changing the language queues a reindex
+func TestSearchSetLanguage_Reindexes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	uc := usecase.NewSearchUsecase(repository.NewSearchRepository(db))
+
+	mock.ExpectQuery("SELECT search_language FROM clients").
+		WillReturnRows(sqlmock.NewRows([]string{"search_language"}).AddRow("simple"))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO clients").WithArgs(1, "indonesian").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE messages SET search_vector = NULL").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 120))
+	mock.ExpectExec("UPDATE contacts SET search_indexed_at = NULL").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 15))
+	mock.ExpectCommit()
+
+	assert.NoError(t, uc.SetLanguage(1, "indonesian"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSearchIndexPending tests that the indexer drains pending documents in batches
+func TestSearchIndexPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	uc := usecase.NewSearchUsecase(repository.NewSearchRepository(db))
+
+	mock.ExpectExec("UPDATE messages m SET search_vector").WithArgs(500).WillReturnResult(sqlmock.NewResult(0, 500))
+	mock.ExpectExec("UPDATE messages m SET search_vector").WithArgs(500).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("UPDATE contacts c SET search_indexed_at").WithArgs(500).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := uc.IndexPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 505, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSearchHandleEvent_IndexesNewMessage tests that message.created events from the outbox index the message right away
+func TestSearchHandleEvent_IndexesNewMessage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	uc := usecase.NewSearchUsecase(repository.NewSearchRepository(db))
+	mock.ExpectExec("UPDATE messages m SET search_vector").WithArgs(42).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	e, err := events.New(1, realtime.Payload{Type: realtime.EventMessageCreated, Data: conversations.Message{ID: 42, ClientID: 1, Body: "refund"}})
+	assert.NoError(t, err)
+	assert.NoError(t, uc.HandleEvent(context.Background(), e))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSearchHandler_InvalidDateRange tests request validation in the search endpoint
+func TestSearchHandler_InvalidDateRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	router := gin.New()
+	router.GET("/api/search", delivery.NewSearchHandler(usecase.NewSearchUsecase(repository.NewSearchRepository(db))).Search)
+
+	for _, query := range []string{
+		"q=refund&from=yesterday",
+		"q=refund&from=2024-02-01T00:00:00Z&to=2024-01-01T00:00:00Z",
+		"q=refund&per_page=0",
+		"q=",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/search?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.NotEmpty(t, body["error"])
+	}
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"backend/config"
+	"backend/pkg/server"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestCert menulis sertifikat self-signed untuk commonName ke dir
+func writeTestCert(t *testing.T, dir, commonName string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func servedCommonName(t *testing.T, r *server.CertReloader) string {
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+// TestCertReloader tests that a replaced certificate is picked up and a broken one is ignored
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir, "old.example.com")
+
+	r, err := server.NewCertReloader(certFile, keyFile)
+	assert.NoError(t, err)
+	r.CheckInterval = 0
+	assert.Equal(t, "old.example.com", servedCommonName(t, r))
+
+	writeTestCert(t, dir, "new.example.com")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	assert.Equal(t, "new.example.com", servedCommonName(t, r))
+
+	// Sertifikat baru sudah ditulis tetapi kuncinya belum cocok: sertifikat lama tetap dipakai
+	os.WriteFile(keyFile, []byte("not a key"), 0o600)
+	later = later.Add(time.Minute)
+	os.Chtimes(keyFile, later, later)
+	assert.Equal(t, "new.example.com", servedCommonName(t, r))
+
+	_, err = server.NewCertReloader(filepath.Join(dir, "missing.crt"), keyFile)
+	assert.Error(t, err)
+}
+
+// TestServerNew tests that timeouts and TLS come from the server config
+func TestServerNew(t *testing.T) {
+	cfg := config.Default().Server
+	srv, err := server.New(cfg, http.NotFoundHandler())
+	assert.NoError(t, err)
+	assert.Equal(t, ":8080", srv.Addr)
+	assert.Equal(t, 10*time.Second, srv.ReadHeaderTimeout)
+	assert.Equal(t, 2*time.Minute, srv.WriteTimeout)
+	assert.Nil(t, srv.TLSConfig)
+
+	cfg.TLSCertFile, cfg.TLSKeyFile = writeTestCert(t, t.TempDir(), "api.example.com")
+	srv, err = server.New(cfg, http.NotFoundHandler())
+	assert.NoError(t, err)
+	if assert.NotNil(t, srv.TLSConfig) {
+		assert.NotNil(t, srv.TLSConfig.GetCertificate)
+	}
+}
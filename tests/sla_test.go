@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"backend/internal/businesshours"
+	"backend/internal/sla"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAddBusinessTime_AcrossWeekendAndDST tests that SLA due times skip closed hours
+// and keep local business hours across a DST change
+func TestAddBusinessTime_AcrossWeekendAndDST(t *testing.T) {
+	cal, err := businesshours.Calendar(businesshours.DefaultSchedule(1, "America/New_York"), nil)
+	if err != nil {
+		t.Fatalf("Failed to build calendar: %v", err)
+	}
+	loc := cal.Location
+
+	// Jumat 16:00 EST + 2 jam kerja = Senin 10:00 EDT (DST dimulai Minggu 8 Maret 2026)
+	start := time.Date(2026, 3, 6, 16, 0, 0, 0, loc)
+	due := sla.AddBusinessTime(cal, start, 2*time.Hour)
+	assert.Equal(t, time.Date(2026, 3, 9, 10, 0, 0, 0, loc), due)
+	assert.Equal(t, 14, due.UTC().Hour())
+
+	// Pesan di luar jam kerja mulai dihitung saat jam buka berikutnya
+	due = sla.AddBusinessTime(cal, time.Date(2026, 3, 9, 7, 30, 0, 0, loc), 30*time.Minute)
+	assert.Equal(t, time.Date(2026, 3, 9, 9, 30, 0, 0, loc), due)
+
+	// Kalender 24/7 menghitung durasi absolut
+	due = sla.AddBusinessTime(sla.AlwaysOpen{}, time.Date(2026, 3, 7, 23, 0, 0, 0, loc), 4*time.Hour)
+	assert.Equal(t, time.Date(2026, 3, 8, 4, 0, 0, 0, loc), due)
+}
+
+// TestSelectPolicy tests that the highest priority active policy matching all conditions is chosen
+func TestSelectPolicy(t *testing.T) {
+	team := 3
+	policies := []sla.Policy{
+		{ID: 1, Active: false, Conditions: sla.Conditions{Channels: []string{"sms"}}},
+		{ID: 2, Active: true, Conditions: sla.Conditions{Channels: []string{"sms"}, Tags: []string{"vip"}}},
+		{ID: 3, Active: true, Conditions: sla.Conditions{TeamIDs: []int{3}}},
+		{ID: 4, Active: true},
+	}
+
+	p := sla.SelectPolicy(policies, sla.Subject{Channel: "sms", Tags: []string{"new", "vip"}})
+	assert.Equal(t, 2, p.ID)
+
+	p = sla.SelectPolicy(policies, sla.Subject{Channel: "sms", TeamID: &team})
+	assert.Equal(t, 3, p.ID)
+
+	p = sla.SelectPolicy(policies, sla.Subject{Channel: "email"})
+	assert.Equal(t, 4, p.ID)
+
+	assert.Nil(t, sla.SelectPolicy(policies[:3], sla.Subject{Channel: "email"}))
+}
+
+// TestTimerState tests the SLA state shown on conversation endpoints
+func TestTimerState(t *testing.T) {
+	start := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	warnAt := start.Add(48 * time.Minute)
+	timer := sla.Timer{StartedAt: start, WarnAt: &warnAt, DueAt: start.Add(time.Hour)}
+
+	assert.Equal(t, sla.StateRunning, timer.StateAt(start.Add(10*time.Minute)))
+	assert.Equal(t, sla.StateWarning, timer.StateAt(start.Add(50*time.Minute)))
+	assert.Equal(t, sla.StateBreached, timer.StateAt(start.Add(time.Hour)))
+
+	completed := start.Add(30 * time.Minute)
+	timer.CompletedAt = &completed
+	assert.Equal(t, sla.StateMet, timer.StateAt(start.Add(2*time.Hour)))
+
+	late := start.Add(61 * time.Minute)
+	timer.CompletedAt = &late
+	assert.Equal(t, sla.StateMissed, timer.StateAt(start.Add(2*time.Hour)))
+}
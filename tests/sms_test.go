@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"backend/internal/contacts"
+	"backend/internal/conversations"
+	conversationRepository "backend/internal/conversations/repository"
+	conversationUsecase "backend/internal/conversations/usecase"
+	"backend/internal/sms"
+	"backend/internal/sms/provider"
+	"backend/internal/sms/repository"
+	"backend/internal/sms/usecase"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCountSegments_GSM7 tests single and multipart GSM-7 messages
+func TestCountSegments_GSM7(t *testing.T) {
+	info := sms.CountSegments(strings.Repeat("a", 160))
+	assert.Equal(t, sms.EncodingGSM7, info.Encoding)
+	assert.Equal(t, 1, info.Segments)
+
+	info = sms.CountSegments(strings.Repeat("a", 161))
+	assert.Equal(t, 2, info.Segments)
+
+	// Extension characters take two septets
+	info = sms.CountSegments(strings.Repeat("€", 80))
+	assert.Equal(t, sms.EncodingGSM7, info.Encoding)
+	assert.Equal(t, 160, info.Units)
+	assert.Equal(t, 1, info.Segments)
+
+	// An escape sequence is never split across segments: 152 + 2 does not fit into 153
+	info = sms.CountSegments(strings.Repeat("a", 152) + "{" + strings.Repeat("a", 10))
+	assert.Equal(t, 2, info.Segments)
+	info = sms.CountSegments(strings.Repeat("a", 152) + "{" + strings.Repeat("a", 152))
+	assert.Equal(t, 3, info.Segments)
+}
+
+// TestCountSegments_UCS2 tests messages that fall back to UCS-2
+func TestCountSegments_UCS2(t *testing.T) {
+	info := sms.CountSegments(strings.Repeat("é", 10) + "ç")
+	assert.Equal(t, sms.EncodingUCS2, info.Encoding)
+	assert.Equal(t, 1, info.Segments)
+
+	info = sms.CountSegments(strings.Repeat("日", 71))
+	assert.Equal(t, 2, info.Segments)
+
+	// Emoji are surrogate pairs and count as two code units
+	info = sms.CountSegments(strings.Repeat("😀", 35))
+	assert.Equal(t, 70, info.Units)
+	assert.Equal(t, 1, info.Segments)
+
+	_, cost := sms.EstimateCost(strings.Repeat("日", 140), 0.0075)
+	assert.InDelta(t, 0.0225, cost, 1e-9)
+}
+
+// TestParseKeyword tests STOP/START keyword detection
+func TestParseKeyword(t *testing.T) {
+	keyword, optOut, ok := sms.ParseKeyword("  stop ")
+	assert.True(t, ok)
+	assert.True(t, optOut)
+	assert.Equal(t, "STOP", keyword)
+
+	_, optOut, ok = sms.ParseKeyword("Start")
+	assert.True(t, ok)
+	assert.False(t, optOut)
+
+	_, _, ok = sms.ParseKeyword("please stop calling")
+	assert.False(t, ok)
+}
+
+// TestTwilioSignature tests signature calculation and request validation
+func TestTwilioSignature(t *testing.T) {
+	params := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+12349013030"},
+		"Digits":  {"1234"},
+		"From":    {"+12349013030"},
+		"To":      {"+18005551212"},
+	}
+	fullURL := "https://mycompany.com/myapp.php?foo=1&bar=2"
+	assert.Equal(t, "0/KCTR6DLpKmkAf8muzZqo1nDgQ=", provider.TwilioSignature("12345", fullURL, params))
+
+	twilio := provider.NewTwilio("")
+	account := sms.Account{AuthToken: "12345"}
+
+	req := httptest.NewRequest(http.MethodPost, "/myapp.php?foo=1&bar=2", strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", "0/KCTR6DLpKmkAf8muzZqo1nDgQ=")
+	assert.NoError(t, twilio.ValidateRequest(account, req, fullURL))
+
+	req = httptest.NewRequest(http.MethodPost, "/myapp.php?foo=1&bar=2", strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", "invalid")
+	assert.ErrorIs(t, twilio.ValidateRequest(account, req, fullURL), provider.ErrInvalidSignature)
+}
+
+// fakeSmsConversations records the addresses messages are appended to
+type fakeSmsConversations struct {
+	conversationUsecase.ConversationUsecase
+	addresses []string
+	appendErr error
+	statuses  []string
+}
+
+func (f *fakeSmsConversations) AppendMessage(address string, m conversations.Message) (*conversations.Conversation, *conversations.Message, bool, error) {
+	f.addresses = append(f.addresses, address)
+	if f.appendErr != nil {
+		return nil, nil, false, f.appendErr
+	}
+	return &conversations.Conversation{ID: 1, ClientID: m.ClientID}, &m, false, nil
+}
+
+func (f *fakeSmsConversations) UpdateMessageStatus(clientID int, channel, externalID, status string) error {
+	f.statuses = append(f.statuses, fmt.Sprintf("%d:%s:%s:%s", clientID, channel, externalID, status))
+	return nil
+}
+
+// fakeIdentityResolver returns a fixed contact for every identity
+type fakeIdentityResolver struct {
+	values []string
+}
+
+func (f *fakeIdentityResolver) ResolveIdentity(clientID int, identityType, value string) (int, error) {
+	f.values = append(f.values, identityType+":"+value)
+	return 7, nil
+}
+
+// TestSmsOptOut_NormalizesPhoneAndLinksContact tests that STOP is stored in E.164 form and honoured for formatted recipients
+func TestSmsOptOut_NormalizesPhoneAndLinksContact(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO sms_opt_outs").WithArgs(1, "+15551234567", 7, true, "STOP").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT opted_out FROM sms_opt_outs").WithArgs(1, "+15551234567").
+		WillReturnRows(sqlmock.NewRows([]string{"opted_out"}).AddRow(true))
+
+	convs := &fakeSmsConversations{}
+	resolver := &fakeIdentityResolver{}
+	uc := usecase.NewSmsUsecase(repository.NewSmsRepository(db), provider.NewRegistry(), convs, resolver, nil, nil, "")
+
+	err = uc.HandleInbound(context.Background(), 1, sms.InboundMessage{ExternalID: "SM1", From: "+1 555 123 4567", Body: "STOP"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{contacts.IdentityPhone + ":+15551234567"}, resolver.values)
+
+	_, _, err = uc.Send(context.Background(), 1, 3, "+1 (555) 123-4567", "Hello", "")
+	assert.ErrorIs(t, err, usecase.ErrOptedOut)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSmsHandleInbound_SkipsDuplicateWebhook tests that a redelivered inbound webhook is acknowledged without reprocessing
+func TestSmsHandleInbound_SkipsDuplicateWebhook(t *testing.T) {
+	convs := &fakeSmsConversations{appendErr: conversations.ErrDuplicateMessage}
+	uc := usecase.NewSmsUsecase(nil, provider.NewRegistry(), convs, &fakeIdentityResolver{}, nil, nil, "")
+
+	err := uc.HandleInbound(context.Background(), 1, sms.InboundMessage{ExternalID: "SM1", From: "+15551234567", Body: "Hello"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"+15551234567"}, convs.addresses)
+
+	assert.NoError(t, uc.HandleStatus(2, sms.StatusUpdate{ExternalID: "SM9", Status: "delivered"}))
+	assert.Equal(t, []string{"2:sms:SM9:delivered"}, convs.statuses)
+}
+
+// TestCreateMessage_DuplicateInbound tests that the inbound unique index conflict is reported as a duplicate
+func TestCreateMessage_DuplicateInbound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO messages .* ON CONFLICT \\(client_id, channel, external_id\\) WHERE direction = 'inbound'").
+		WillReturnRows(sqlmock.NewRows([]string{"message_id"}))
+	mock.ExpectExec("UPDATE messages SET status = \\$1 WHERE client_id = \\$2 AND channel = \\$3 AND external_id = \\$4").
+		WithArgs("delivered", 1, conversations.ChannelSMS, "SM1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := conversationRepository.NewConversationRepository(db)
+	_, err = repo.CreateMessage(conversations.Message{ConversationID: 10, ClientID: 1, Direction: conversations.DirectionInbound, Channel: conversations.ChannelSMS, ExternalID: "SM1"})
+	assert.ErrorIs(t, err, conversations.ErrDuplicateMessage)
+	assert.NoError(t, repo.UpdateMessageStatus(1, conversations.ChannelSMS, "SM1", "delivered"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,265 @@
+package tests
+
+import (
+	"backend/internal/conversations"
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/surveys"
+	"backend/internal/surveys/delivery"
+	"backend/internal/surveys/repository"
+	"backend/internal/surveys/usecase"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	surveySettingsColumns = []string{"enabled", "survey_type", "question", "thank_you", "include_link", "delay_minutes", "expiry_hours", "updated_at"}
+	surveyColumns         = []string{"survey_id", "client_id", "conversation_id", "agent_id", "channel", "address", "survey_type", "status",
+		"sent_at", "expires_at", "created_at"}
+)
+
+type fakeSurveyConversations struct {
+	conv     conversations.Conversation
+	recorded []conversations.Message
+}
+
+func (f *fakeSurveyConversations) GetConversation(clientID, id int) (*conversations.Conversation, error) {
+	conv := f.conv
+	return &conv, nil
+}
+
+func (f *fakeSurveyConversations) RecordMessage(m conversations.Message) (*conversations.Message, error) {
+	f.recorded = append(f.recorded, m)
+	return &m, nil
+}
+
+type fakeNotifier struct {
+	bodies []string
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, conv conversations.Conversation, body string) (*conversations.Message, error) {
+	f.bodies = append(f.bodies, body)
+	return &conversations.Message{ConversationID: conv.ID, Body: body}, nil
+}
+
+// TestParseSurveyReply tests numeric, keycap, star and emoji replies
+func TestParseSurveyReply(t *testing.T) {
+	cases := []struct {
+		body, surveyType string
+		score            int
+		comment          string
+		ok               bool
+	}{
+		{"5", surveys.TypeCSAT, 5, "", true},
+		{" 4/5 fast and friendly ", surveys.TypeCSAT, 4, "fast and friendly", true},
+		{"3. ok", surveys.TypeCSAT, 3, "ok", true},
+		{"4️⃣", surveys.TypeCSAT, 4, "", true},
+		{"⭐⭐⭐⭐ good", surveys.TypeCSAT, 4, "good", true},
+		{"😡 never again", surveys.TypeCSAT, 1, "never again", true},
+		{"🔟", surveys.TypeNPS, 10, "", true},
+		{"0", surveys.TypeNPS, 0, "", true},
+		{"0", surveys.TypeCSAT, 0, "", false},
+		{"9", surveys.TypeCSAT, 0, "", false},
+		{"😀", surveys.TypeNPS, 0, "", false},
+		{"5pm works for me", surveys.TypeCSAT, 0, "", false},
+		{"08123456789", surveys.TypeNPS, 0, "", false},
+		{"thanks!", surveys.TypeCSAT, 0, "", false},
+	}
+	for _, tc := range cases {
+		score, comment, ok := surveys.ParseReply(tc.body, tc.surveyType)
+		assert.Equal(t, tc.ok, ok, tc.body)
+		if tc.ok {
+			assert.Equal(t, tc.score, score, tc.body)
+			assert.Equal(t, tc.comment, comment, tc.body)
+		}
+	}
+}
+
+// TestSurveyHandleEvent tests that resolving a conversation schedules a delayed survey
+func TestSurveyHandleEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	enqueuer := &fakeJobEnqueuer{}
+	uc := usecase.NewSurveyUsecase(repository.NewSurveyRepository(db), &fakeSurveyConversations{}, nil, enqueuer, []byte("key"), "")
+
+	mock.ExpectQuery("FROM survey_settings").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(surveySettingsColumns).AddRow(true, "nps", "", "", false, 30, 48, time.Now()))
+
+	for _, status := range []string{conversations.StatusOpen, conversations.StatusResolved} {
+		e, err := events.New(1, realtime.Payload{Type: realtime.EventConversationStatusChanged,
+			Data: &conversations.Conversation{ID: 9, ClientID: 1, Status: status}})
+		assert.NoError(t, err)
+		assert.NoError(t, uc.HandleEvent(context.Background(), e))
+	}
+
+	if assert.Len(t, enqueuer.payloads, 1) {
+		assert.Equal(t, usecase.SendSurvey{ClientID: 1, ConversationID: 9}, enqueuer.payloads[0])
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSurveySend_QuestionWithSignedLink tests sending a survey through the conversation channel
+func TestSurveySend_QuestionWithSignedLink(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	agentID := 4
+	convs := &fakeSurveyConversations{conv: conversations.Conversation{ID: 9, ClientID: 1, Channel: conversations.ChannelSMS,
+		ExternalAddress: "+628123", Status: conversations.StatusResolved, AssigneeID: &agentID}}
+	notifier := &fakeNotifier{}
+	key := []byte("survey-key")
+	uc := usecase.NewSurveyUsecase(repository.NewSurveyRepository(db), convs,
+		map[string]usecase.Notifier{conversations.ChannelSMS: notifier}, &fakeJobEnqueuer{}, key, "https://app.example.com/")
+
+	mock.ExpectQuery("FROM survey_settings").
+		WillReturnRows(sqlmock.NewRows(surveySettingsColumns).AddRow(true, "csat", "", "", true, 0, 24, time.Now()))
+	mock.ExpectQuery("INSERT INTO surveys").WithArgs(1, 9, &agentID, "sms", "+628123", "csat", surveys.StatusPending).
+		WillReturnRows(sqlmock.NewRows(surveyColumns).AddRow(15, 1, 9, 4, "sms", "+628123", "csat", "pending", nil, nil, time.Now()))
+	mock.ExpectExec("UPDATE surveys SET status").WithArgs(surveys.StatusSent, sqlmock.AnyArg(), 15, surveys.StatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = uc.SendSurvey(context.Background(), usecase.SendSurvey{ClientID: 1, ConversationID: 9})
+	assert.NoError(t, err)
+	if assert.Len(t, notifier.bodies, 1) {
+		lines := strings.Split(notifier.bodies[0], "\n")
+		assert.Equal(t, surveys.DefaultQuestions[surveys.TypeCSAT], lines[0])
+
+		link, err := url.Parse(lines[1])
+		assert.NoError(t, err)
+		assert.Equal(t, "/surveys/15", link.Path)
+		assert.True(t, surveys.Verify(key, 15, link.Query().Get("expires"), link.Query().Get("signature"), time.Now()))
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Percakapan yang sudah dibuka lagi tidak disurvei
+	convs.conv.Status = conversations.StatusOpen
+	mock.ExpectQuery("FROM survey_settings").
+		WillReturnRows(sqlmock.NewRows(surveySettingsColumns).AddRow(true, "csat", "", "", true, 0, 24, time.Now()))
+	assert.NoError(t, uc.SendSurvey(context.Background(), usecase.SendSurvey{ClientID: 1, ConversationID: 9}))
+	assert.Len(t, notifier.bodies, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSurveyHandleReply tests that score replies are stored against the surveyed conversation and agent
+func TestSurveyHandleReply(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	convs := &fakeSurveyConversations{conv: conversations.Conversation{ID: 9, ClientID: 1, Channel: conversations.ChannelSMS}}
+	notifier := &fakeNotifier{}
+	uc := usecase.NewSurveyUsecase(repository.NewSurveyRepository(db), convs,
+		map[string]usecase.Notifier{conversations.ChannelSMS: notifier}, &fakeJobEnqueuer{}, []byte("key"), "")
+	inbound := conversations.Message{ClientID: 1, Channel: conversations.ChannelSMS, Direction: conversations.DirectionInbound}
+	sentAt := time.Now().Add(-time.Hour)
+	expiresAt := time.Now().Add(time.Hour)
+
+	// Balasan yang bukan skor diteruskan ke alur percakapan biasa
+	mock.ExpectQuery("FROM surveys").WithArgs(1, "sms", "+628123", surveys.StatusSent).
+		WillReturnRows(sqlmock.NewRows(surveyColumns).AddRow(15, 1, 9, 4, "sms", "+628123", "nps", "sent", sentAt, expiresAt, sentAt))
+	inbound.Body = "can you call me back?"
+	handled, err := uc.HandleReply(context.Background(), "+628123", inbound)
+	assert.NoError(t, err)
+	assert.False(t, handled)
+
+	agentID := 4
+	mock.ExpectQuery("FROM surveys").
+		WillReturnRows(sqlmock.NewRows(surveyColumns).AddRow(15, 1, 9, 4, "sms", "+628123", "nps", "sent", sentAt, expiresAt, sentAt))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE surveys SET status").WithArgs(surveys.StatusAnswered, 15, surveys.StatusSent).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO survey_responses").WithArgs(15, 1, 9, &agentID, "nps", 9, "great help", surveys.SourceReply).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("FROM survey_settings").
+		WillReturnRows(sqlmock.NewRows(surveySettingsColumns).AddRow(true, "nps", "", "Thanks for your feedback!", false, 0, 24, time.Now()))
+
+	inbound.Body = "9 great help"
+	handled, err = uc.HandleReply(context.Background(), "+628123", inbound)
+	assert.NoError(t, err)
+	assert.True(t, handled)
+	if assert.Len(t, convs.recorded, 1) {
+		assert.Equal(t, 9, convs.recorded[0].ConversationID)
+	}
+	assert.Equal(t, []string{"Thanks for your feedback!"}, notifier.bodies)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSurveyForm tests the signed web survey form
+func TestSurveyForm(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	key := []byte("survey-key")
+	h := delivery.NewSurveyHandler(usecase.NewSurveyUsecase(repository.NewSurveyRepository(db), &fakeSurveyConversations{},
+		nil, &fakeJobEnqueuer{}, key, ""))
+	router := gin.New()
+	router.GET("/surveys/:id", h.GetForm)
+	router.POST("/surveys/:id", h.SubmitForm)
+
+	expires := time.Now().Add(time.Hour)
+	path := fmt.Sprintf("/surveys/15?expires=%d&signature=%s", expires.Unix(), surveys.Sign(key, 15, expires))
+	surveyRow := func(status string) *sqlmock.Rows {
+		return sqlmock.NewRows(surveyColumns).AddRow(15, 1, 9, nil, "sms", "+628123", "csat", status, time.Now(), expires, time.Now())
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/surveys/15?expires="+strconv.FormatInt(expires.Unix(), 10)+"&signature=bad", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	mock.ExpectQuery("FROM surveys WHERE survey_id").WithArgs(15).WillReturnRows(surveyRow("sent"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+	assert.Contains(t, w.Body.String(), `value="5"`)
+	assert.NotContains(t, w.Body.String(), `value="6"`)
+
+	mock.ExpectQuery("FROM surveys WHERE survey_id").WillReturnRows(surveyRow("sent"))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE surveys SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO survey_responses").WithArgs(15, 1, 9, nil, "csat", 2, "<b>slow</b>", surveys.SourceWeb).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("score=2&comment=%3Cb%3Eslow%3C%2Fb%3E"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Jawaban kedua ditolak
+	mock.ExpectQuery("FROM surveys WHERE survey_id").WillReturnRows(surveyRow("answered"))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE surveys SET status").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, path, strings.NewReader("score=4"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
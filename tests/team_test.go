@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/teams"
+	"backend/internal/teams/repository"
+	"backend/internal/teams/usecase"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeQueueRouter records which clients had their queue re-routed
+type fakeQueueRouter struct {
+	clients []int
+}
+
+func (f *fakeQueueRouter) AssignPending(clientID int) (int, error) {
+	f.clients = append(f.clients, clientID)
+	return 0, nil
+}
+
+var teamRowColumns = []string{"team_id", "client_id", "name", "description", "priority", "overflow_team_id", "max_wait_seconds", "created_at"}
+
+// TestTeamQueue_NonMemberForbidden tests that only team members can see the team queue
+func TestTeamQueue_NonMemberForbidden(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT team_id, client_id, name").WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(teamRowColumns).AddRow(5, 1, "Billing", "", 10, nil, 300, time.Now()))
+	mock.ExpectQuery("SELECT EXISTS").WithArgs(5, 42).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	uc := usecase.NewTeamUsecase(repository.NewTeamRepository(db), &fakeQueueRouter{}, realtime.NewHub(nil, ""))
+	_, err = uc.GetQueue(1, 5, 42)
+	assert.ErrorIs(t, err, usecase.ErrNotMember)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestTeamAddMember_UserFromOtherClient tests that users outside the team's client cannot join
+func TestTeamAddMember_UserFromOtherClient(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT team_id, client_id, name").WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(teamRowColumns).AddRow(5, 1, "Billing", "", 10, nil, 300, time.Now()))
+	mock.ExpectExec("INSERT INTO team_members").WithArgs(5, 99, teams.RoleMember).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	router := &fakeQueueRouter{}
+	uc := usecase.NewTeamUsecase(repository.NewTeamRepository(db), router, realtime.NewHub(nil, ""))
+	err = uc.AddMember(1, teams.Member{TeamID: 5, UserID: 99})
+	assert.ErrorIs(t, err, usecase.ErrUserNotFound)
+	assert.Empty(t, router.clients)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestTeamProcessOverflow tests that overflowed conversations trigger routing for their clients
+func TestTeamProcessOverflow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE conversations c SET team_id = t.overflow_team_id").
+		WillReturnRows(sqlmock.NewRows([]string{"conversation_id", "client_id", "team_id", "overflow_team_id"}).
+			AddRow(10, 1, 5, 6).AddRow(11, 1, 5, 6))
+	// Setiap perpindahan dicatat ke outbox dalam transaksi yang sama
+	for i := 0; i < 2; i++ {
+		mock.ExpectExec("INSERT INTO outbox_events").
+			WithArgs(sqlmock.AnyArg(), 1, realtime.EventConversationEnqueued, sqlmock.AnyArg(), events.StatusPending, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	router := &fakeQueueRouter{}
+	uc := usecase.NewTeamUsecase(repository.NewTeamRepository(db), router, realtime.NewHub(nil, ""))
+	moved, err := uc.ProcessOverflow()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, moved)
+	assert.Equal(t, []int{1}, router.clients)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"backend/internal/users/repository"
+	"backend/internal/users/usecase"
+	"backend/pkg/txn"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTxnMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, mock
+}
+
+// TestTxnRunner_NestedSavepoint tests that a failing nested unit only rolls back to its savepoint
+func TestTxnRunner_NestedSavepoint(t *testing.T) {
+	db, mock := newTxnMock(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO b").WillReturnError(errors.New("constraint violation"))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO c").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	runner := txn.NewRunner(db)
+	err := runner.Do(context.Background(), func(ctx context.Context) error {
+		if _, err := txn.From(ctx, db).ExecContext(ctx, "INSERT INTO a DEFAULT VALUES"); err != nil {
+			return err
+		}
+		nestedErr := runner.Do(ctx, func(ctx context.Context) error {
+			_, err := txn.From(ctx, db).ExecContext(ctx, "INSERT INTO b DEFAULT VALUES")
+			return err
+		})
+		assert.ErrorContains(t, nestedErr, "constraint violation")
+		return runner.Do(ctx, func(ctx context.Context) error {
+			_, err := txn.From(ctx, db).ExecContext(ctx, "INSERT INTO c DEFAULT VALUES")
+			return err
+		})
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestTxnRunner_RollbackOnPanic tests that a panic rolls back and is re-raised
+func TestTxnRunner_RollbackOnPanic(t *testing.T) {
+	db, mock := newTxnMock(t)
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	assert.PanicsWithValue(t, "boom", func() {
+		txn.NewRunner(db).Do(context.Background(), func(ctx context.Context) error {
+			panic("boom")
+		})
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestTxnRunner_RetriesSerializationFailure tests that conflicts are retried and other errors are not
+func TestTxnRunner_RetriesSerializationFailure(t *testing.T) {
+	db, mock := newTxnMock(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE counters").WillReturnError(&pq.Error{Code: "40001", Message: "could not serialize access"})
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE counters").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	attempts := 0
+	err := txn.NewRunner(db).Do(context.Background(), func(ctx context.Context) error {
+		attempts++
+		_, err := txn.From(ctx, db).ExecContext(ctx, "UPDATE counters SET n = n + 1")
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	attempts = 0
+	err = txn.NewRunner(db).Do(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return errors.New("validation failed")
+	})
+	assert.EqualError(t, err, "validation failed")
+	assert.Equal(t, 1, attempts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestDeleteUser_AtomicWithBlacklist tests that a failed token blacklist undoes the delete
+func TestDeleteUser_AtomicWithBlacklist(t *testing.T) {
+	db, mock := newTxnMock(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, username, email").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "email", "role_id", "client_id", "created_at"}).
+			AddRow(1, "john_doe", "john@example.com", 2, 7, "2024-01-01"))
+	mock.ExpectExec("DELETE FROM users").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO blacklisted_tokens").WithArgs("token-1").WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	uc := usecase.NewUserUsecase(repository.NewUserRepository(db))
+	user, err := uc.DeleteUser(context.Background(), 1, "token-1")
+	assert.ErrorContains(t, err, "failed to delete user")
+	assert.Nil(t, user)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, username, email").WithArgs(9).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	_, err = uc.DeleteUser(context.Background(), 9, "token-1")
+	assert.ErrorIs(t, err, usecase.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
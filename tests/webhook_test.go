@@ -0,0 +1,249 @@
+package tests
+
+import (
+	"backend/internal/events"
+	"backend/internal/realtime"
+	"backend/internal/webhooks"
+	"backend/internal/webhooks/repository"
+	"backend/internal/webhooks/usecase"
+	"backend/pkg/safehttp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var claimedDeliveryColumns = []string{"delivery_id", "subscription_id", "client_id", "event_type", "payload", "status", "attempts", "next_attempt_at", "created_at", "url", "secret"}
+
+// TestWebhookSignature tests that the signature covers both the timestamp and the body
+func TestWebhookSignature(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	body := []byte(`{"type":"message.created"}`)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, want, webhooks.Sign("whsec_test", ts, body))
+	assert.Equal(t, "t=1700000000,v1="+want, webhooks.SignatureHeader("whsec_test", ts, body))
+	assert.NotEqual(t, want, webhooks.Sign("whsec_test", ts.Add(time.Second), body))
+}
+
+// TestWebhookBackoff tests exponential backoff with an upper bound
+func TestWebhookBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhooks.Backoff(1))
+	assert.Equal(t, time.Minute, webhooks.Backoff(2))
+	assert.Equal(t, 4*time.Minute, webhooks.Backoff(4))
+	assert.Equal(t, 6*time.Hour, webhooks.Backoff(20))
+}
+
+// TestWebhookDeliverDue_Success tests that a signed delivery is sent and marked delivered
+func TestWebhookDeliverDue_Success(t *testing.T) {
+	var gotSignature, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(webhooks.HeaderSignature)
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	payload := `{"type":"conversation.created","data":{"id":10}}`
+	mock.ExpectQuery("UPDATE webhook_deliveries d SET locked_until").
+		WillReturnRows(sqlmock.NewRows(claimedDeliveryColumns).
+			AddRow(5, 2, 1, "conversation.created", payload, webhooks.StatusPending, 0, time.Now(), time.Now(), server.URL, "whsec_test"))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE webhook_deliveries SET status").
+		WithArgs(webhooks.StatusDelivered, 1, sqlmock.AnyArg(), http.StatusNoContent, "", sqlmock.AnyArg(), 5, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO webhook_delivery_attempts").
+		WithArgs(5, 1, http.StatusNoContent, "", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	uc := usecase.NewWebhookUsecase(repository.NewWebhookRepository(db), server.Client())
+	delivered, err := uc.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, payload, gotBody)
+
+	var ts int64
+	var sig string
+	_, err = fmt.Sscanf(strings.Replace(gotSignature, ",v1=", " ", 1), "t=%d %s", &ts, &sig)
+	assert.NoError(t, err)
+	assert.Equal(t, webhooks.Sign("whsec_test", time.Unix(ts, 0), []byte(payload)), sig)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestWebhookDeliverDue_DeadLetter tests that the final failed attempt dead-letters the delivery
+func TestWebhookDeliverDue_DeadLetter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE webhook_deliveries d SET locked_until").
+		WillReturnRows(sqlmock.NewRows(claimedDeliveryColumns).
+			AddRow(6, 2, 1, "sla.breached", `{}`, webhooks.StatusRetrying, webhooks.MaxAttempts-1, time.Now(), time.Now(), server.URL, "whsec_test"))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE webhook_deliveries SET status").
+		WithArgs(webhooks.StatusDead, webhooks.MaxAttempts, sqlmock.AnyArg(), http.StatusInternalServerError, "unexpected status 500", nil, 6, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO webhook_delivery_attempts").
+		WithArgs(6, webhooks.MaxAttempts, http.StatusInternalServerError, "unexpected status 500", "boom", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	uc := usecase.NewWebhookUsecase(repository.NewWebhookRepository(db), server.Client())
+	delivered, err := uc.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestWebhookDeliverDue_LeaseLost tests that an attempt is discarded when another dispatcher took over the delivery
+func TestWebhookDeliverDue_LeaseLost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE webhook_deliveries d SET locked_until = .*, locked_by = \\$5").
+		WithArgs(20, sqlmock.AnyArg(), webhooks.StatusPending, webhooks.StatusRetrying, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(claimedDeliveryColumns).
+			AddRow(8, 2, 1, "message.created", `{}`, webhooks.StatusPending, 0, time.Now(), time.Now(), server.URL, "whsec_test"))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE webhook_deliveries SET status .* AND locked_by = \\$8 AND locked_until > NOW\\(\\)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	uc := usecase.NewWebhookUsecase(repository.NewWebhookRepository(db), server.Client())
+	delivered, err := uc.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestWebhookSubscription_RejectsInternalURL tests that subscriptions cannot target internal addresses
+func TestWebhookSubscription_RejectsInternalURL(t *testing.T) {
+	uc := usecase.NewWebhookUsecase(nil, nil)
+	for _, target := range []string{
+		"http://169.254.169.254/latest/meta-data/",
+		"http://localhost:8080/hook",
+		"http://127.0.0.1/hook",
+		"https://10.0.0.5/hook",
+		"http://[::1]/hook",
+		"http://[::ffff:192.168.1.1]/hook",
+		"ftp://example.com/hook",
+	} {
+		_, err := uc.CreateSubscription(webhooks.Subscription{ClientID: 1, URL: target, Events: []string{"message.created"}})
+		assert.ErrorIs(t, err, usecase.ErrInvalidSubscription, target)
+	}
+}
+
+// TestSafeHTTP_Allowed tests which resolved addresses the guarded client may dial
+func TestSafeHTTP_Allowed(t *testing.T) {
+	for addr, allowed := range map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.0.10":    false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		assert.Equal(t, allowed, safehttp.Allowed(netip.MustParseAddr(addr)), addr)
+	}
+	assert.Equal(t, "bad  body", safehttp.Snippet([]byte("bad\x00\nbody\xff"), 100))
+	assert.Equal(t, "abc", safehttp.Snippet([]byte("abcdef"), 3))
+}
+
+// TestWebhookDeliverDue_RejectsInternalDestination tests that the default client refuses to dial internal addresses
+func TestWebhookDeliverDue_RejectsInternalDestination(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	// URL tersimpan sebelum validasi atau nama domain yang di-resolve ke loopback tetap ditolak saat dial
+	mock.ExpectQuery("UPDATE webhook_deliveries d SET locked_until").
+		WillReturnRows(sqlmock.NewRows(claimedDeliveryColumns).
+			AddRow(7, 2, 1, "message.created", `{}`, webhooks.StatusPending, 0, time.Now(), time.Now(), server.URL, "whsec_test"))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE webhook_deliveries SET status").
+		WithArgs(webhooks.StatusRetrying, 1, sqlmock.AnyArg(), nil, sqlmock.AnyArg(), nil, 7, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO webhook_delivery_attempts").
+		WithArgs(7, 1, nil, sqlmock.AnyArg(), "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	uc := usecase.NewWebhookUsecase(repository.NewWebhookRepository(db), nil)
+	delivered, err := uc.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.False(t, hit)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestWebhookHandleEvent_UsesEventIDAsKey tests that outbox events are stored as deliveries keyed by the event ID
+func TestWebhookHandleEvent_UsesEventIDAsKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	e, err := events.New(1, realtime.Payload{Type: realtime.EventMessageCreated, Data: map[string]interface{}{"id": 9, "body": "Hi"}})
+	assert.NoError(t, err)
+	mock.ExpectExec("INSERT INTO webhook_deliveries").
+		WithArgs(1, realtime.EventMessageCreated, e.ID, sqlmock.AnyArg(), webhooks.StatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	uc := usecase.NewWebhookUsecase(repository.NewWebhookRepository(db), nil)
+	assert.NoError(t, uc.HandleEvent(context.Background(), e))
+	assert.JSONEq(t, `{"id":9,"body":"Hi"}`, string(e.Payload))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}